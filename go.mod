@@ -5,6 +5,8 @@ go 1.24.2
 // github.com/audiomage-dev/go-benchmarks v0.0.0
 require github.com/spf13/cobra v1.8.0
 
+require gopkg.in/yaml.v3 v3.0.1
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
@@ -25,4 +25,13 @@ func (elf *EruditeLossFunction) CalculateRegularizedLoss(baseLoss float64, weigh
 		regularization += weight * weight
 	}
 	return baseLoss + elf.Regularization*regularization
-}
\ No newline at end of file
+}
+// RegularizationGradient is the gradient of the penalty added by
+// CalculateRegularizedLoss, to be added to the gradient of the base loss
+func (elf *EruditeLossFunction) RegularizationGradient(weights []float64) []float64 {
+	gradient := make([]float64, len(weights))
+	for i, weight := range weights {
+		gradient[i] = 2 * elf.Regularization * weight
+	}
+	return gradient
+}
@@ -1,5 +1,10 @@
 package coordination
 
+import (
+	"math"
+	"sort"
+)
+
 type ResonanceCoupler struct {
 	CouplingMatrix [][]float64
 	Resonators map[string]Resonator
@@ -29,15 +34,77 @@ func (rc *ResonanceCoupler) AddResonator(id string, freq, amp, phase float64) {
 	}
 }
 
+// CoupleResonators pulls every resonator towards the others' phases for one
+// unit of time without advancing their natural frequencies.
 func (rc *ResonanceCoupler) CoupleResonators() {
-	for id1, res1 := range rc.Resonators {
-		for id2, res2 := range rc.Resonators {
+	for id, drift := range rc.couplingDrift() {
+		rc.applyCoupling(id, drift)
+	}
+}
+
+// Evolve advances every resonator by deltaTime under the Kuramoto model:
+// each phase rotates at its own angular frequency and is pulled towards the
+// others by sin(phase difference), weighted by calculateCoupling.
+func (rc *ResonanceCoupler) Evolve(deltaTime float64) {
+	for id, drift := range rc.couplingDrift() {
+		rc.applyCoupling(id, (rc.Resonators[id].Frequency+drift)*deltaTime)
+	}
+}
+
+// ShiftPhase adds deltaPhase to a resonator's phase, e.g. to apply noise.
+func (rc *ResonanceCoupler) ShiftPhase(id string, deltaPhase float64) {
+	if _, exists := rc.Resonators[id]; exists {
+		rc.applyCoupling(id, deltaPhase)
+	}
+}
+
+// OrderParameter returns the Kuramoto order parameter r in [0, 1] (1 when
+// all phases coincide) and the mean phase.
+func (rc *ResonanceCoupler) OrderParameter() (float64, float64) {
+	if len(rc.Resonators) == 0 {
+		return 0.0, 0.0
+	}
+
+	sumCos, sumSin := 0.0, 0.0
+	for _, id := range rc.sortedIDs() {
+		phase := rc.Resonators[id].Phase
+		sumCos += math.Cos(phase)
+		sumSin += math.Sin(phase)
+	}
+
+	n := float64(len(rc.Resonators))
+	return math.Hypot(sumCos, sumSin) / n, math.Atan2(sumSin, sumCos)
+}
+
+// couplingDrift computes every resonator's phase velocity due to coupling
+// from a snapshot of the current phases, visiting resonators in ID order so
+// results do not depend on map iteration.
+func (rc *ResonanceCoupler) couplingDrift() map[string]float64 {
+	ids := rc.sortedIDs()
+	drift := make(map[string]float64, len(ids))
+
+	for _, id1 := range ids {
+		res1 := rc.Resonators[id1]
+		sum := 0.0
+		for _, id2 := range ids {
 			if id1 != id2 {
-				coupling := rc.calculateCoupling(res1, res2)
-				rc.applyCoupling(id1, id2, coupling)
+				res2 := rc.Resonators[id2]
+				sum += rc.calculateCoupling(res1, res2) * math.Sin(res2.Phase-res1.Phase)
 			}
 		}
+		drift[id1] = sum / float64(len(ids))
 	}
+
+	return drift
+}
+
+func (rc *ResonanceCoupler) sortedIDs() []string {
+	ids := make([]string, 0, len(rc.Resonators))
+	for id := range rc.Resonators {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 func (rc *ResonanceCoupler) calculateCoupling(res1, res2 Resonator) float64 {
@@ -48,6 +115,11 @@ func (rc *ResonanceCoupler) calculateCoupling(res1, res2 Resonator) float64 {
 	return rc.CouplingStrength / (1.0 + freqDiff)
 }
 
-func (rc *ResonanceCoupler) applyCoupling(id1, id2 string, coupling float64) {
-	// Apply coupling effect between resonators
+func (rc *ResonanceCoupler) applyCoupling(id string, deltaPhase float64) {
+	res := rc.Resonators[id]
+	res.Phase = math.Mod(res.Phase+deltaPhase, 2*math.Pi)
+	if res.Phase < 0 {
+		res.Phase += 2 * math.Pi
+	}
+	rc.Resonators[id] = res
 }
@@ -1,6 +1,9 @@
 package entropy
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 type EntropyDistribution struct {
 	HierarchyLevels map[int]float64
@@ -20,11 +20,7 @@ func NewEntropyDynamics(maxEntropy, rate float64) *EntropyDynamics {
 func (ed *EntropyDynamics) EvolveEntropy(deltaTime float64) {
 	// Entropy evolution based on thermodynamic principles
 	deltaEntropy := ed.EvolutionRate * deltaTime * (ed.MaxEntropy - ed.CurrentEntropy)
-	ed.CurrentEntropy += deltaEntropy
-	
-	if ed.CurrentEntropy > ed.MaxEntropy {
-		ed.CurrentEntropy = ed.MaxEntropy
-	}
+	ed.CurrentEntropy = math.Min(ed.CurrentEntropy+deltaEntropy, ed.MaxEntropy)
 	
 	ed.EntropyHistory = append(ed.EntropyHistory, ed.CurrentEntropy)
 }
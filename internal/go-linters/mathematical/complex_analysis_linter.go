@@ -206,7 +206,7 @@ func (cal *ComplexAnalysisLinter) computeResidue(function ComplexFunction, singu
 	
 	for i := range circle {
 		angle := 2 * 3.14159 * float64(i) / float64(len(circle))
-		circle[i] = singularity + complex(radius*cmplx.Cos(complex(angle, 0)), radius*cmplx.Sin(complex(angle, 0)))
+		circle[i] = singularity + cmplx.Rect(radius, angle)
 	}
 	
 	integral := cal.computeContourIntegral(function, circle)
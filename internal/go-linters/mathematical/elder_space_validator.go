@@ -1,6 +1,11 @@
 package mathematical
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type ElderSpaceValidator struct {
 	Dimension   int
@@ -36,6 +41,30 @@ func (esv *ElderSpaceValidator) computeDotProduct(v1, v2 []float64) float64 {
 	return sum
 }
 
+// ValidateCompleteness checks that basis spans the space: it must have as
+// many linearly independent vectors, up to Tolerance, as the dimension.
 func (esv *ElderSpaceValidator) ValidateCompleteness(basis [][]float64) bool {
-	return len(basis) == esv.Dimension
+	if len(basis) != esv.Dimension {
+		return false
+	}
+	
+	matrix, err := tensor.FromRows(basis)
+	if err != nil || matrix.Dim(1) != esv.Dimension {
+		return false
+	}
+	
+	rank, err := linalg.Rank(matrix, esv.Tolerance)
+	return err == nil && rank == esv.Dimension
+}
+
+// ValidateMetric checks that metric is symmetric positive definite, as an
+// inner product on the space must be.
+func (esv *ElderSpaceValidator) ValidateMetric(metric [][]float64) bool {
+	matrix, err := tensor.FromRows(metric)
+	if err != nil || matrix.Dim(0) != esv.Dimension || matrix.Dim(1) != esv.Dimension {
+		return false
+	}
+	
+	_, err = linalg.Cholesky(matrix)
+	return err == nil
 }
@@ -1,7 +1,5 @@
 package mathematical
 
-import "math"
-
 type TopologyValidator struct {
 	Space      TopologicalSpace
 	Tolerance  float64
@@ -78,9 +76,6 @@ func (tv *TopologyValidator) checkHausdorff() bool {
 }
 
 func (tv *TopologyValidator) findSeparatingNeighborhoods(p1, p2 Point) bool {
-	distance := tv.Space.Metric(p1, p2)
-	radius := distance / 3.0
-	
 	for _, set1 := range tv.Space.OpenSets {
 		for _, set2 := range tv.Space.OpenSets {
 			if tv.pointInSet(p1, set1) && tv.pointInSet(p2, set2) {
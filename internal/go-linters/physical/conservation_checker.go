@@ -16,10 +16,41 @@ func NewConservationChecker() *ConservationChecker {
 	}
 }
 
+// CheckEnergyConservation reports whether energy changed by less than
+// EnergyTolerance in absolute terms. Use EnergyDrift to compare against a
+// relative tolerance.
 func (cc *ConservationChecker) CheckEnergyConservation(initialEnergy, finalEnergy float64) bool {
 	return math.Abs(initialEnergy-finalEnergy) < cc.EnergyTolerance
 }
 
+// EnergyDrift is the change in energy relative to the initial energy, or
+// the absolute change when the initial energy is zero.
+func (cc *ConservationChecker) EnergyDrift(initialEnergy, finalEnergy float64) float64 {
+	drift := math.Abs(finalEnergy - initialEnergy)
+	if initialEnergy != 0 {
+		drift /= math.Abs(initialEnergy)
+	}
+	return drift
+}
+
+// AngularMomentumDrift is the length of the change in angular momentum
+// relative to the length of the initial angular momentum, or the absolute
+// length when that is zero.
+func (cc *ConservationChecker) AngularMomentumDrift(initialMomentum, finalMomentum []float64) float64 {
+	change, initial := 0.0, 0.0
+	for i := range initialMomentum {
+		change += (finalMomentum[i] - initialMomentum[i]) * (finalMomentum[i] - initialMomentum[i])
+		initial += initialMomentum[i] * initialMomentum[i]
+	}
+	drift := math.Sqrt(change)
+	if initial != 0 {
+		drift /= math.Sqrt(initial)
+	}
+	return drift
+}
+
+// CheckMomentumConservation reports whether every component of the
+// momentum changed by at most MomentumTolerance in absolute terms.
 func (cc *ConservationChecker) CheckMomentumConservation(initialMomentum, finalMomentum []float64) bool {
 	for i := range initialMomentum {
 		if math.Abs(initialMomentum[i]-finalMomentum[i]) > cc.MomentumTolerance {
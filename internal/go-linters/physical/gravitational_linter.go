@@ -214,7 +214,7 @@ func (gl *GravitationalLinter) checkDifferentiabilityAtPoint(field Gravitational
 	derivative := (field.Potential(Vector3D{point.X + h, point.Y, point.Z}) - 
 		          field.Potential(Vector3D{point.X - h, point.Y, point.Z})) / (2 * h)
 	
-	return !math.IsInf(derivative) && !math.IsNaN(derivative)
+	return !math.IsInf(derivative, 0) && !math.IsNaN(derivative)
 }
 
 func (gl *GravitationalLinter) findPotentialMinimum(field GravitationalField) float64 {
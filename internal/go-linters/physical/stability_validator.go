@@ -1,6 +1,12 @@
 package physical
 
-import "math"
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type StabilityValidator struct {
 	Systems     map[string]PhysicalSystem
@@ -68,6 +74,10 @@ func (sv *StabilityValidator) ValidateStability(systemID string) StabilityResult
 		result.StabilityType = "marginally_stable"
 	}
 	
+	if system.Dynamics != nil && len(system.State) > 0 {
+		sv.checkLinearStability(system, &result)
+	}
+	
 	if !sv.checkBoundedness(system) {
 		result.Stable = false
 		result.Violations = append(result.Violations, "System trajectories are unbounded")
@@ -81,9 +91,51 @@ type StabilityResult struct {
 	Stable           bool
 	StabilityType    string
 	LyapunovExponent float64
+	Eigenvalues      []complex128
 	Violations       []string
 }
 
+// checkLinearStability linearizes the system's dynamics about its state: an
+// eigenvalue of the Jacobian with positive real part means perturbations
+// grow along its eigenvector.
+func (sv *StabilityValidator) checkLinearStability(system PhysicalSystem, result *StabilityResult) {
+	jacobian := sv.computeJacobian(system)
+	eigenvalues, err := linalg.Eigenvalues(jacobian)
+	if err != nil {
+		result.Violations = append(result.Violations, fmt.Sprintf("Linear stability undetermined: %v", err))
+		return
+	}
+	
+	result.Eigenvalues = eigenvalues.Values()
+	if dominant := real(result.Eigenvalues[0]); dominant > sv.Tolerance {
+		result.Stable = false
+		result.StabilityType = "unstable"
+		result.Violations = append(result.Violations, fmt.Sprintf("Jacobian eigenvalue %.6g has positive real part", result.Eigenvalues[0]))
+	}
+}
+
+func (sv *StabilityValidator) computeJacobian(system PhysicalSystem) *tensor.Tensor[float64] {
+	n := len(system.State)
+	jacobian := tensor.Zeros[float64](n, n)
+	state := make([]float64, n)
+	copy(state, system.State)
+	
+	for j := 0; j < n; j++ {
+		h := 1e-6 * math.Max(1, math.Abs(state[j]))
+		state[j] = system.State[j] + h
+		plus := system.Dynamics(state, system.Parameters)
+		state[j] = system.State[j] - h
+		minus := system.Dynamics(state, system.Parameters)
+		state[j] = system.State[j]
+		
+		for i := 0; i < n && i < len(plus) && i < len(minus); i++ {
+			jacobian.Set((plus[i]-minus[i])/(2*h), i, j)
+		}
+	}
+	
+	return jacobian
+}
+
 func (sv *StabilityValidator) calculateLyapunovExponent(system PhysicalSystem) float64 {
 	initialSeparation := 1e-8
 	timeSteps := 100
@@ -0,0 +1,98 @@
+package dataset
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// Augmenter perturbs a training sample. It is applied after preprocessing,
+// only to the training split, and draws all its randomness from rng so that
+// every epoch is reproducible. It may modify the sample it is given.
+type Augmenter func(sample training.TrainingSample, rng *random.Source) training.TrainingSample
+
+// AugmenterFactory builds an augmenter from the argument after the colon
+// in its name, which is empty when there is none.
+type AugmenterFactory func(argument string) (Augmenter, error)
+
+var augmenters = map[string]AugmenterFactory{
+	"jitter": jitter,
+	"scale":  scale,
+}
+
+// RegisterAugmenter makes an augmenter available to NewAugmenter and to
+// data.augmentations in the training config.
+func RegisterAugmenter(name string, factory AugmenterFactory) {
+	augmenters[strings.ToLower(name)] = factory
+}
+
+// Augmenters lists the registered augmenter names.
+func Augmenters() []string {
+	names := make([]string, 0, len(augmenters))
+	for name := range augmenters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAugmenter builds a registered augmenter from "name" or
+// "name:argument".
+func NewAugmenter(name string) (Augmenter, error) {
+	kind, argument, _ := strings.Cut(strings.ToLower(strings.TrimSpace(name)), ":")
+	factory, exists := augmenters[kind]
+	if !exists {
+		return nil, fmt.Errorf("unknown augmentation %q (have %s)", name, strings.Join(Augmenters(), ", "))
+	}
+	augmenter, err := factory(argument)
+	if err != nil {
+		return nil, fmt.Errorf("augmentation %q: %w", name, err)
+	}
+	return augmenter, nil
+}
+
+func amount(argument string, fallback float64) (float64, error) {
+	if argument == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseFloat(argument, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("argument must be a non-negative number, got %q", argument)
+	}
+	return value, nil
+}
+
+// jitter adds Gaussian noise with standard deviation sigma (default 0.01)
+// to every input.
+func jitter(argument string) (Augmenter, error) {
+	sigma, err := amount(argument, 0.01)
+	if err != nil {
+		return nil, err
+	}
+	return func(sample training.TrainingSample, rng *random.Source) training.TrainingSample {
+		for i := range sample.Input {
+			sample.Input[i] += sigma * rng.NormFloat64()
+		}
+		return sample
+	}, nil
+}
+
+// scale multiplies all inputs by one factor drawn uniformly from
+// [1-range, 1+range] (default range 0.1).
+func scale(argument string) (Augmenter, error) {
+	spread, err := amount(argument, 0.1)
+	if err != nil {
+		return nil, err
+	}
+	return func(sample training.TrainingSample, rng *random.Source) training.TrainingSample {
+		factor := rng.Uniform(1-spread, 1+spread)
+		for i := range sample.Input {
+			sample.Input[i] *= factor
+		}
+		return sample
+	}, nil
+}
@@ -0,0 +1,262 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// Salts that give the holdout split and each epoch's shuffle their own
+// random streams from the one seed.
+const (
+	holdoutSalt = 0x6A09E667F3BCC908
+	epochSalt   = 0xBB67AE8584CAA73B
+)
+
+var errStop = errors.New("stop")
+
+// Dataset is a data file that is read again on every pass rather than held
+// in memory, so it may be larger than memory. Samples are assigned to the
+// holdout split independently of one another by a hash of Seed and their
+// position, and the training split is shuffled through a buffer of
+// ShuffleBuffer samples seeded by Seed and the epoch. Every epoch is
+// therefore reproducible on its own, which keeps resumed runs identical to
+// uninterrupted ones.
+type Dataset struct {
+	Path              string
+	Format            string
+	Targets           int
+	Steps             []Step
+	Augmenters        []Augmenter
+	Shuffle           bool
+	ShuffleBuffer     int
+	Seed              int64
+	Holdout           float64
+	TrainingSamples   int
+	ValidationSamples int
+	fitted            bool
+}
+
+func NewDataset(path string, targets int, seed int64) *Dataset {
+	return &Dataset{
+		Path:          path,
+		Targets:       targets,
+		Steps:         make([]Step, 0),
+		Augmenters:    make([]Augmenter, 0),
+		Shuffle:       true,
+		ShuffleBuffer: 1024,
+		Seed:          seed,
+	}
+}
+
+func (d *Dataset) stream(salt, n uint64) *random.Source {
+	source := random.NewSource(d.Seed)
+	source.State ^= salt
+	source.State += n * 0xD1B54A32D192ED03
+	return source
+}
+
+func (d *Dataset) held(index int) bool {
+	return d.Holdout > 0 && d.stream(holdoutSalt, uint64(index)).Float64() < d.Holdout
+}
+
+// each calls fn with every raw sample in the file and its position.
+func (d *Dataset) each(fn func(index int, sample training.TrainingSample) error) error {
+	reader, err := Open(d.Path, d.Format, d.Targets)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	inputs, targets := -1, -1
+	for index := 0; ; index++ {
+		sample, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.Path, err)
+		}
+
+		if inputs < 0 {
+			inputs, targets = len(sample.Input), len(sample.Target)
+		}
+		if len(sample.Input) != inputs || len(sample.Target) != targets {
+			return fmt.Errorf("%s: sample %d has %d inputs and %d targets, the first had %d and %d",
+				d.Path, index, len(sample.Input), len(sample.Target), inputs, targets)
+		}
+
+		if err := fn(index, sample); err != nil {
+			return err
+		}
+	}
+}
+
+// Fit counts the samples in each split and fits the preprocessing steps on
+// the training split, reading the file once per step.
+func (d *Dataset) Fit() error {
+	passes := len(d.Steps)
+	if passes == 0 {
+		passes = 1
+	}
+
+	for pass := 0; pass < passes; pass++ {
+		trained, held := 0, 0
+		err := d.each(func(index int, sample training.TrainingSample) error {
+			if d.held(index) {
+				held++
+				return nil
+			}
+			trained++
+			if pass < len(d.Steps) {
+				for _, step := range d.Steps[:pass] {
+					sample = step.Apply(sample)
+				}
+				d.Steps[pass].Observe(sample)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		d.TrainingSamples, d.ValidationSamples = trained, held
+	}
+
+	if d.TrainingSamples == 0 {
+		return fmt.Errorf("%s: no training samples", d.Path)
+	}
+	d.fitted = true
+	return nil
+}
+
+func (d *Dataset) ensureFitted() error {
+	if d.fitted {
+		return nil
+	}
+	return d.Fit()
+}
+
+func (d *Dataset) preprocess(sample training.TrainingSample) training.TrainingSample {
+	for _, step := range d.Steps {
+		sample = step.Apply(sample)
+	}
+	return sample
+}
+
+// Shape is the number of inputs and targets of a sample after
+// preprocessing.
+func (d *Dataset) Shape() (int, int, error) {
+	if err := d.ensureFitted(); err != nil {
+		return 0, 0, err
+	}
+
+	var first training.TrainingSample
+	err := d.each(func(index int, sample training.TrainingSample) error {
+		first = d.preprocess(sample)
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return 0, 0, err
+	}
+	return len(first.Input), len(first.Target), nil
+}
+
+// Training is the part of the dataset not held out. It is shuffled and
+// augmented.
+func (d *Dataset) Training() *Split {
+	return &Split{dataset: d}
+}
+
+// Validation is the held-out part of the dataset, read in file order
+// without augmentation.
+func (d *Dataset) Validation() *Split {
+	return &Split{dataset: d, holdout: true}
+}
+
+// Split is one side of a Dataset's holdout split. It implements
+// training.SampleSource.
+type Split struct {
+	dataset *Dataset
+	holdout bool
+}
+
+// Batches streams the split in batches of size samples, the last of which
+// may be short. Each batch is a new slice.
+func (s *Split) Batches(epoch, size int, yield func(batch []training.TrainingSample) error) error {
+	d := s.dataset
+	if size <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", size)
+	}
+	if err := d.ensureFitted(); err != nil {
+		return err
+	}
+
+	rng := d.stream(epochSalt, uint64(epoch))
+	batch := make([]training.TrainingSample, 0, size)
+	emit := func(sample training.TrainingSample) error {
+		if !s.holdout {
+			for _, augment := range d.Augmenters {
+				sample = augment(sample, rng)
+			}
+		}
+		batch = append(batch, sample)
+		if len(batch) < size {
+			return nil
+		}
+		full := batch
+		batch = make([]training.TrainingSample, 0, size)
+		return yield(full)
+	}
+
+	shuffle := d.Shuffle && !s.holdout && d.ShuffleBuffer > 1
+	buffer := make([]training.TrainingSample, 0)
+	err := d.each(func(index int, sample training.TrainingSample) error {
+		if d.held(index) != s.holdout {
+			return nil
+		}
+		sample = d.preprocess(sample)
+		if !shuffle {
+			return emit(sample)
+		}
+
+		if len(buffer) < d.ShuffleBuffer {
+			buffer = append(buffer, sample)
+			return nil
+		}
+		j := rng.Intn(len(buffer))
+		out := buffer[j]
+		buffer[j] = sample
+		return emit(out)
+	})
+	if err != nil {
+		return err
+	}
+
+	rng.Shuffle(len(buffer), func(i, j int) {
+		buffer[i], buffer[j] = buffer[j], buffer[i]
+	})
+	for _, sample := range buffer {
+		if err := emit(sample); err != nil {
+			return err
+		}
+	}
+
+	if len(batch) > 0 {
+		return yield(batch)
+	}
+	return nil
+}
+
+// Load reads the whole split as it would be served in epoch, for consumers
+// that need the samples in memory.
+func (s *Split) Load(epoch int) ([]training.TrainingSample, error) {
+	samples := make([]training.TrainingSample, 0)
+	err := s.Batches(epoch, 1024, func(batch []training.TrainingSample) error {
+		samples = append(samples, batch...)
+		return nil
+	})
+	return samples, err
+}
@@ -0,0 +1,232 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// csvFile writes rows of numbers to a headerless CSV file.
+func csvFile(t *testing.T, rows [][]float64) string {
+	t.Helper()
+	var b bytes.Buffer
+	for _, row := range rows {
+		for i, value := range row {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%g", value)
+		}
+		b.WriteByte('\n')
+	}
+	return writeFile(t, "data.csv", b.Bytes())
+}
+
+func load(t *testing.T, split *Split, epoch int) []training.TrainingSample {
+	t.Helper()
+	samples, err := split.Load(epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func firstInputs(samples []training.TrainingSample) []float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.Input[0]
+	}
+	return values
+}
+
+func TestShuffleIsSeededPermutation(t *testing.T) {
+	const n = 200
+	rows := make([][]float64, n)
+	for i := range rows {
+		rows[i] = []float64{float64(i)}
+	}
+	path := csvFile(t, rows)
+	open := func(seed int64) *Dataset {
+		d := NewDataset(path, 0, seed)
+		d.ShuffleBuffer = 16
+		d.Holdout = 0.25
+		return d
+	}
+
+	d := open(7)
+	train := firstInputs(load(t, d.Training(), 3))
+	validation := firstInputs(load(t, d.Validation(), 3))
+
+	if again := firstInputs(load(t, open(7).Training(), 3)); !reflect.DeepEqual(again, train) {
+		t.Errorf("the same seed and epoch gave a different order")
+	}
+	if other := firstInputs(load(t, d.Training(), 4)); reflect.DeepEqual(other, train) {
+		t.Errorf("epochs 3 and 4 were served in the same order")
+	}
+	if other := firstInputs(load(t, open(8).Training(), 3)); reflect.DeepEqual(other, train) {
+		t.Errorf("seeds 7 and 8 gave the same training split and order")
+	}
+	if sort.Float64sAreSorted(train) {
+		t.Errorf("the training split was not shuffled")
+	}
+	if !sort.Float64sAreSorted(validation) {
+		t.Errorf("the validation split is not in file order: %v", validation)
+	}
+	if len(train) != d.TrainingSamples || len(validation) != d.ValidationSamples {
+		t.Errorf("served %d and %d samples, Fit counted %d and %d",
+			len(train), len(validation), d.TrainingSamples, d.ValidationSamples)
+	}
+
+	// Between them the splits hold every sample exactly once.
+	all := append(append([]float64{}, train...), validation...)
+	sort.Float64s(all)
+	for i, value := range all {
+		if value != float64(i) {
+			t.Fatalf("the splits are not a permutation of the file: sorted they start %v", all[:i+1])
+		}
+	}
+	if len(all) != n {
+		t.Fatalf("the splits hold %d samples, want %d", len(all), n)
+	}
+
+	// Batching does not change the order.
+	batched := make([]float64, 0, len(train))
+	err := d.Training().Batches(3, 7, func(batch []training.TrainingSample) error {
+		if len(batch) != 7 && len(batched)+len(batch) != len(train) {
+			t.Errorf("a batch before the last has %d samples", len(batch))
+		}
+		batched = append(batched, firstInputs(batch)...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(batched, train) {
+		t.Errorf("batches of 7 were served in a different order from Load")
+	}
+}
+
+// TestPreprocessingFitsOnTrainingSplit gives the held-out samples inputs
+// far outside the training range and a class never seen in training, so
+// statistics fitted on them would show.
+func TestPreprocessingFitsOnTrainingSplit(t *testing.T) {
+	const n = 100
+	d := NewDataset("", 1, 5)
+	d.Holdout = 0.3
+	rows := make([][]float64, n)
+	trained := make([][]float64, 0)
+	for i := range rows {
+		if d.held(i) {
+			rows[i] = []float64{1000 + float64(i), -float64(i), 9}
+			continue
+		}
+		rows[i] = []float64{float64(i), float64(i * i), float64(i % 3)}
+		trained = append(trained, rows[i])
+	}
+	path := csvFile(t, rows)
+
+	open := func(step string) *Dataset {
+		d := NewDataset(path, 1, 5)
+		d.Holdout = 0.3
+		d.Shuffle = false
+		s, err := NewStep(step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Steps = append(d.Steps, s)
+		return d
+	}
+
+	// Population mean, standard deviation and range of each training input.
+	mean, std := make([]float64, 2), make([]float64, 2)
+	low, high := []float64{math.Inf(1), math.Inf(1)}, []float64{math.Inf(-1), math.Inf(-1)}
+	for _, row := range trained {
+		for j := range mean {
+			mean[j] += row[j] / float64(len(trained))
+			low[j], high[j] = math.Min(low[j], row[j]), math.Max(high[j], row[j])
+		}
+	}
+	for _, row := range trained {
+		for j := range std {
+			std[j] += (row[j] - mean[j]) * (row[j] - mean[j]) / float64(len(trained))
+		}
+	}
+	for j := range std {
+		std[j] = math.Sqrt(std[j])
+	}
+
+	// check compares every sample of a split with want applied to its row.
+	check := func(t *testing.T, split *Split, rows [][]float64, want func(row []float64) training.TrainingSample) {
+		t.Helper()
+		got := load(t, split, 0)
+		if len(got) != len(rows) {
+			t.Fatalf("served %d samples, want %d", len(got), len(rows))
+		}
+		for i, sample := range got {
+			expected := want(rows[i])
+			for _, pair := range [][2][]float64{{sample.Input, expected.Input}, {sample.Target, expected.Target}} {
+				if len(pair[0]) != len(pair[1]) {
+					t.Fatalf("sample %d is %+v, want %+v", i, sample, expected)
+				}
+				for k := range pair[0] {
+					if math.Abs(pair[0][k]-pair[1][k]) > 1e-9*math.Max(1, math.Abs(pair[1][k])) {
+						t.Fatalf("sample %d is %+v, want %+v", i, sample, expected)
+					}
+				}
+			}
+		}
+	}
+	held := make([][]float64, 0)
+	for i, row := range rows {
+		if d.held(i) {
+			held = append(held, row)
+		}
+	}
+
+	t.Run("standardize", func(t *testing.T) {
+		want := func(row []float64) training.TrainingSample {
+			return training.TrainingSample{
+				Input:  []float64{(row[0] - mean[0]) / std[0], (row[1] - mean[1]) / std[1]},
+				Target: []float64{row[2]},
+			}
+		}
+		d := open("standardize")
+		check(t, d.Training(), trained, want)
+		check(t, d.Validation(), held, want)
+	})
+
+	t.Run("minmax", func(t *testing.T) {
+		want := func(row []float64) training.TrainingSample {
+			return training.TrainingSample{
+				Input:  []float64{(row[0] - low[0]) / (high[0] - low[0]), (row[1] - low[1]) / (high[1] - low[1])},
+				Target: []float64{row[2]},
+			}
+		}
+		d := open("minmax")
+		check(t, d.Training(), trained, want)
+		check(t, d.Validation(), held, want)
+	})
+
+	t.Run("onehot", func(t *testing.T) {
+		// Class 9 appears only in the held-out samples, so it has no
+		// indicator and encodes as all zeros.
+		want := func(row []float64) training.TrainingSample {
+			target := make([]float64, 3)
+			if row[2] < 3 {
+				target[int(row[2])] = 1
+			}
+			return training.TrainingSample{Input: row[:2], Target: target}
+		}
+		d := open("onehot")
+		check(t, d.Training(), trained, want)
+		check(t, d.Validation(), held, want)
+		if classes := d.Steps[0].(*OneHot).Classes; !reflect.DeepEqual(classes, []float64{0, 1, 2}) {
+			t.Errorf("fitted classes %v, want [0 1 2]", classes)
+		}
+	})
+}
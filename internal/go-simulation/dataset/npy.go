@@ -0,0 +1,183 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+const npyMagic = "\x93NUMPY"
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// npyDecoder streams the rows of a one- or two-dimensional NumPy array in C
+// order. A one-dimensional array is read as a single column.
+type npyDecoder struct {
+	reader  io.Reader
+	order   binary.ByteOrder
+	kind    byte
+	size    int
+	rows    int
+	columns int
+	row     int
+	targets int
+	buffer  []byte
+	values  []float64
+}
+
+func newNPYDecoder(r io.Reader, targets int) (*npyDecoder, error) {
+	preamble := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, preamble); err != nil {
+		return nil, err
+	}
+	if string(preamble[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("not a NumPy .npy file")
+	}
+
+	var headerLength int
+	switch major := preamble[len(npyMagic)]; major {
+	case 1:
+		var length uint16
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+		headerLength = int(length)
+	case 2, 3:
+		var length uint32
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+		headerLength = int(length)
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", major)
+	}
+
+	header := make([]byte, headerLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	d := &npyDecoder{reader: r, targets: targets}
+	if err := d.parseHeader(string(header)); err != nil {
+		return nil, err
+	}
+	d.buffer = make([]byte, d.columns*d.size)
+	d.values = make([]float64, d.columns)
+	return d, nil
+}
+
+func (d *npyDecoder) parseHeader(header string) error {
+	descr := npyDescr.FindStringSubmatch(header)
+	fortran := npyFortran.FindStringSubmatch(header)
+	shape := npyShape.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shape == nil {
+		return fmt.Errorf("malformed .npy header %q", strings.TrimSpace(header))
+	}
+	if fortran[1] == "True" {
+		return fmt.Errorf("Fortran-ordered arrays are not supported")
+	}
+
+	dtype := descr[1]
+	if len(dtype) < 3 {
+		return fmt.Errorf("unsupported dtype %q", dtype)
+	}
+	switch dtype[0] {
+	case '<', '|':
+		d.order = binary.LittleEndian
+	case '>':
+		d.order = binary.BigEndian
+	default:
+		return fmt.Errorf("unsupported dtype %q", dtype)
+	}
+	d.kind = dtype[1]
+	size, err := strconv.Atoi(dtype[2:])
+	if err != nil {
+		return fmt.Errorf("unsupported dtype %q", dtype)
+	}
+	d.size = size
+	switch {
+	case d.kind == 'f' && (size == 4 || size == 8):
+	case (d.kind == 'i' || d.kind == 'u') && (size == 1 || size == 2 || size == 4 || size == 8):
+	default:
+		return fmt.Errorf("unsupported dtype %q", dtype)
+	}
+
+	dimensions := make([]int, 0, 2)
+	for _, field := range strings.Split(shape[1], ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("malformed shape (%s)", shape[1])
+		}
+		dimensions = append(dimensions, n)
+	}
+	switch len(dimensions) {
+	case 1:
+		d.rows, d.columns = dimensions[0], 1
+	case 2:
+		d.rows, d.columns = dimensions[0], dimensions[1]
+	default:
+		return fmt.Errorf("expected a one- or two-dimensional array, got shape (%s)", shape[1])
+	}
+	return nil
+}
+
+func (d *npyDecoder) next() (training.TrainingSample, error) {
+	if d.row >= d.rows {
+		return training.TrainingSample{}, io.EOF
+	}
+	if _, err := io.ReadFull(d.reader, d.buffer); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return training.TrainingSample{}, fmt.Errorf("row %d: %w", d.row, err)
+	}
+
+	for i := range d.values {
+		d.values[i] = d.decode(d.buffer[i*d.size : (i+1)*d.size])
+	}
+	d.row++
+	return split(d.values, d.targets)
+}
+
+func (d *npyDecoder) decode(b []byte) float64 {
+	switch d.kind {
+	case 'f':
+		if d.size == 4 {
+			return float64(math.Float32frombits(d.order.Uint32(b)))
+		}
+		return math.Float64frombits(d.order.Uint64(b))
+	case 'i':
+		switch d.size {
+		case 1:
+			return float64(int8(b[0]))
+		case 2:
+			return float64(int16(d.order.Uint16(b)))
+		case 4:
+			return float64(int32(d.order.Uint32(b)))
+		}
+		return float64(int64(d.order.Uint64(b)))
+	default:
+		switch d.size {
+		case 1:
+			return float64(b[0])
+		case 2:
+			return float64(d.order.Uint16(b))
+		case 4:
+			return float64(d.order.Uint32(b))
+		}
+		return float64(d.order.Uint64(b))
+	}
+}
@@ -0,0 +1,210 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// Step is a preprocessing transform fitted on the training data: Observe
+// sees every training sample once, after the steps before it, and Apply
+// then transforms samples from any split. Apply may modify the sample it is
+// given.
+type Step interface {
+	Name() string
+	Observe(sample training.TrainingSample)
+	Apply(sample training.TrainingSample) training.TrainingSample
+}
+
+// NewStep builds a preprocessing step by name:
+//
+//	standardize   zero mean and unit variance for every input column
+//	minmax        rescale every input column to [0, 1]
+//	onehot        replace a single class-index target with a one-hot vector
+//	onehot:<col>  replace input column <col> with a one-hot vector
+func NewStep(name string) (Step, error) {
+	kind, argument, hasArgument := strings.Cut(strings.ToLower(strings.TrimSpace(name)), ":")
+	switch {
+	case kind == "standardize" && !hasArgument:
+		return &Standardize{}, nil
+	case kind == "minmax" && !hasArgument:
+		return &MinMax{}, nil
+	case kind == "onehot" && !hasArgument:
+		return &OneHot{Column: -1}, nil
+	case kind == "onehot":
+		column, err := strconv.Atoi(argument)
+		if err != nil || column < 0 {
+			return nil, fmt.Errorf("preprocessing step %q: column must be a non-negative integer", name)
+		}
+		return &OneHot{Column: column}, nil
+	}
+	return nil, fmt.Errorf("unknown preprocessing step %q", name)
+}
+
+// Standardize centres each input column on its training mean and divides
+// it by its training standard deviation. Constant columns are only centred.
+type Standardize struct {
+	Count int       `json:"count"`
+	Mean  []float64 `json:"mean"`
+	M2    []float64 `json:"m2"`
+}
+
+func (s *Standardize) Name() string {
+	return "standardize"
+}
+
+// Observe updates the running moments with Welford's algorithm.
+func (s *Standardize) Observe(sample training.TrainingSample) {
+	if s.Mean == nil {
+		s.Mean = make([]float64, len(sample.Input))
+		s.M2 = make([]float64, len(sample.Input))
+	}
+	s.Count++
+	for i, value := range sample.Input {
+		delta := value - s.Mean[i]
+		s.Mean[i] += delta / float64(s.Count)
+		s.M2[i] += delta * (value - s.Mean[i])
+	}
+}
+
+func (s *Standardize) Apply(sample training.TrainingSample) training.TrainingSample {
+	for i := range sample.Input {
+		if i >= len(s.Mean) {
+			break
+		}
+		sample.Input[i] -= s.Mean[i]
+		if std := math.Sqrt(s.M2[i] / float64(s.Count)); std > 0 {
+			sample.Input[i] /= std
+		}
+	}
+	return sample
+}
+
+// MinMax rescales each input column so that its training range maps onto
+// [0, 1]. Constant columns become zero.
+type MinMax struct {
+	Min []float64 `json:"min"`
+	Max []float64 `json:"max"`
+}
+
+func (m *MinMax) Name() string {
+	return "minmax"
+}
+
+func (m *MinMax) Observe(sample training.TrainingSample) {
+	if m.Min == nil {
+		m.Min = append([]float64{}, sample.Input...)
+		m.Max = append([]float64{}, sample.Input...)
+		return
+	}
+	for i, value := range sample.Input {
+		m.Min[i] = math.Min(m.Min[i], value)
+		m.Max[i] = math.Max(m.Max[i], value)
+	}
+}
+
+func (m *MinMax) Apply(sample training.TrainingSample) training.TrainingSample {
+	for i := range sample.Input {
+		if i >= len(m.Min) {
+			break
+		}
+		if span := m.Max[i] - m.Min[i]; span > 0 {
+			sample.Input[i] = (sample.Input[i] - m.Min[i]) / span
+		} else {
+			sample.Input[i] = 0
+		}
+	}
+	return sample
+}
+
+// OneHot expands a column of class labels into one indicator per class
+// seen in training, in ascending order of label. Column -1 is the target,
+// which must then be a single value; otherwise it is an input column.
+// Labels not seen in training encode as all zeros.
+type OneHot struct {
+	Column  int       `json:"column"`
+	Classes []float64 `json:"classes"`
+	index   map[float64]int
+}
+
+func (o *OneHot) Name() string {
+	if o.Column < 0 {
+		return "onehot"
+	}
+	return fmt.Sprintf("onehot:%d", o.Column)
+}
+
+func (o *OneHot) Observe(sample training.TrainingSample) {
+	label, ok := o.label(sample)
+	if !ok {
+		return
+	}
+	if o.index == nil {
+		o.reindex()
+	}
+	if _, seen := o.index[label]; seen {
+		return
+	}
+
+	position := sort.SearchFloat64s(o.Classes, label)
+	o.Classes = append(o.Classes, 0)
+	copy(o.Classes[position+1:], o.Classes[position:])
+	o.Classes[position] = label
+	o.reindex()
+}
+
+func (o *OneHot) reindex() {
+	o.index = make(map[float64]int, len(o.Classes))
+	for i, class := range o.Classes {
+		o.index[class] = i
+	}
+}
+
+func (o *OneHot) label(sample training.TrainingSample) (float64, bool) {
+	if o.Column < 0 {
+		return firstOf(sample.Target)
+	}
+	if o.Column < len(sample.Input) {
+		return sample.Input[o.Column], true
+	}
+	return 0, false
+}
+
+func firstOf(values []float64) (float64, bool) {
+	if len(values) != 1 {
+		return 0, false
+	}
+	return values[0], true
+}
+
+func (o *OneHot) encode(label float64) []float64 {
+	if o.index == nil {
+		o.reindex()
+	}
+	encoded := make([]float64, len(o.Classes))
+	if i, exists := o.index[label]; exists {
+		encoded[i] = 1
+	}
+	return encoded
+}
+
+func (o *OneHot) Apply(sample training.TrainingSample) training.TrainingSample {
+	label, ok := o.label(sample)
+	if !ok {
+		return sample
+	}
+	if o.Column < 0 {
+		sample.Target = o.encode(label)
+		return sample
+	}
+
+	input := make([]float64, 0, len(sample.Input)+len(o.Classes)-1)
+	input = append(input, sample.Input[:o.Column]...)
+	input = append(input, o.encode(label)...)
+	sample.Input = append(input, sample.Input[o.Column+1:]...)
+	return sample
+}
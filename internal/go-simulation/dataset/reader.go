@@ -0,0 +1,267 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// Supported file formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatNPY   = "npy"
+)
+
+type decoder interface {
+	next() (training.TrainingSample, error)
+}
+
+// Reader streams the samples of a data file one at a time. In CSV and NPY
+// files, and in JSONL files of plain arrays, each row holds the inputs
+// followed by Targets target columns.
+type Reader struct {
+	Format  string
+	Targets int
+	file    *os.File
+	gz      *gzip.Reader
+	dec     decoder
+}
+
+// FormatOf guesses the format of filename from its extension, ignoring a
+// trailing .gz.
+func FormatOf(filename string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, ".gz")))
+	switch ext {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".npy":
+		return FormatNPY, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s from its extension", filename)
+}
+
+// Open opens filename for reading. An empty format is taken from the
+// extension; gzip compression is detected from the content.
+func Open(filename, format string, targets int) (*Reader, error) {
+	if format == "" {
+		var err error
+		if format, err = FormatOf(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewReader(file, format, targets)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	r.file = file
+	return r, nil
+}
+
+func NewReader(input io.Reader, format string, targets int) (*Reader, error) {
+	if targets < 0 {
+		return nil, fmt.Errorf("target column count must not be negative, got %d", targets)
+	}
+	r := &Reader{Format: strings.ToLower(format), Targets: targets}
+
+	buffered := bufio.NewReaderSize(input, 64*1024)
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		r.gz = gz
+		buffered = bufio.NewReaderSize(gz, 64*1024)
+	}
+
+	var err error
+	switch r.Format {
+	case FormatCSV:
+		r.dec, err = newCSVDecoder(buffered, targets)
+	case FormatJSONL:
+		r.dec = newJSONLDecoder(buffered, targets)
+	case FormatNPY:
+		r.dec, err = newNPYDecoder(buffered, targets)
+	default:
+		return nil, fmt.Errorf("unknown data format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s header: %w", r.Format, err)
+	}
+	return r, nil
+}
+
+// Next returns the next sample, or io.EOF after the last one. Each sample
+// has its own slices.
+func (r *Reader) Next() (training.TrainingSample, error) {
+	return r.dec.next()
+}
+
+func (r *Reader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// split makes a sample from one row of inputs followed by targets.
+func split(row []float64, targets int) (training.TrainingSample, error) {
+	if targets > len(row) {
+		return training.TrainingSample{}, fmt.Errorf("row has %d columns, fewer than the %d targets", len(row), targets)
+	}
+	cut := len(row) - targets
+	return training.TrainingSample{
+		Input:  append([]float64{}, row[:cut]...),
+		Target: append([]float64{}, row[cut:]...),
+		Weight: 1,
+	}, nil
+}
+
+// csvDecoder reads rows of numbers. A first row that does not parse as
+// numbers is a header; a header column named "weight" holds sample weights
+// and is not an input.
+type csvDecoder struct {
+	reader  *csv.Reader
+	targets int
+	weight  int
+	pending []string
+	row     []float64
+}
+
+func newCSVDecoder(r io.Reader, targets int) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.TrimLeadingSpace = true
+
+	first, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(first)
+
+	d := &csvDecoder{reader: reader, targets: targets, weight: -1}
+	if !numeric(first) {
+		for i, column := range first {
+			if strings.EqualFold(column, "weight") {
+				d.weight = i
+			}
+		}
+		return d, nil
+	}
+	d.pending = append([]string{}, first...)
+	return d, nil
+}
+
+func numeric(record []string) bool {
+	for _, field := range record {
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *csvDecoder) next() (training.TrainingSample, error) {
+	record := d.pending
+	d.pending = nil
+	if record == nil {
+		var err error
+		if record, err = d.reader.Read(); err != nil {
+			return training.TrainingSample{}, err
+		}
+	}
+
+	d.row = d.row[:0]
+	weight := 1.0
+	for i, field := range record {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			line, _ := d.reader.FieldPos(i)
+			return training.TrainingSample{}, fmt.Errorf("line %d, column %d: %w", line, i+1, err)
+		}
+		if i == d.weight {
+			weight = value
+			continue
+		}
+		d.row = append(d.row, value)
+	}
+
+	sample, err := split(d.row, d.targets)
+	if err != nil {
+		line, _ := d.reader.FieldPos(0)
+		return sample, fmt.Errorf("line %d: %w", line, err)
+	}
+	sample.Weight = weight
+	return sample, nil
+}
+
+// jsonlDecoder reads one value per line: either an object with "input",
+// "target" and optional "weight", or a flat array of numbers.
+type jsonlDecoder struct {
+	decoder *json.Decoder
+	targets int
+	line    int
+}
+
+type jsonlSample struct {
+	Input  []float64 `json:"input"`
+	Target []float64 `json:"target"`
+	Weight *float64  `json:"weight"`
+}
+
+func newJSONLDecoder(r io.Reader, targets int) *jsonlDecoder {
+	return &jsonlDecoder{decoder: json.NewDecoder(r), targets: targets}
+}
+
+func (d *jsonlDecoder) next() (training.TrainingSample, error) {
+	var raw json.RawMessage
+	if err := d.decoder.Decode(&raw); err != nil {
+		return training.TrainingSample{}, err
+	}
+	d.line++
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var row []float64
+		if err := json.Unmarshal(trimmed, &row); err != nil {
+			return training.TrainingSample{}, fmt.Errorf("record %d: %w", d.line, err)
+		}
+		sample, err := split(row, d.targets)
+		if err != nil {
+			return sample, fmt.Errorf("record %d: %w", d.line, err)
+		}
+		return sample, nil
+	}
+
+	var record jsonlSample
+	if err := json.Unmarshal(trimmed, &record); err != nil {
+		return training.TrainingSample{}, fmt.Errorf("record %d: %w", d.line, err)
+	}
+	sample := training.TrainingSample{Input: record.Input, Target: record.Target, Weight: 1}
+	if record.Weight != nil {
+		sample.Weight = *record.Weight
+	}
+	return sample, nil
+}
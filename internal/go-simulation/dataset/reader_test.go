@@ -0,0 +1,271 @@
+package dataset
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+)
+
+// samples are three samples of two inputs and one target, with weights.
+var samples = []training.TrainingSample{
+	{Input: []float64{1.5, -2}, Target: []float64{1}, Weight: 1},
+	{Input: []float64{0, 3.25}, Target: []float64{0}, Weight: 0.5},
+	{Input: []float64{1e-3, 7}, Target: []float64{2}, Weight: 2},
+}
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readAll opens path with its format taken from the extension and reads
+// every sample.
+func readAll(t *testing.T, path string, targets int) []training.TrainingSample {
+	t.Helper()
+	reader, err := Open(path, "", targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	read := make([]training.TrainingSample, 0)
+	for {
+		sample, err := reader.Next()
+		if err == io.EOF {
+			return read
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		read = append(read, sample)
+	}
+}
+
+// unweighted is samples with every weight 1, for formats without weights.
+func unweighted() []training.TrainingSample {
+	plain := make([]training.TrainingSample, len(samples))
+	for i, sample := range samples {
+		plain[i] = sample
+		plain[i].Weight = 1
+	}
+	return plain
+}
+
+func encodeCSV(header bool) []byte {
+	var b bytes.Buffer
+	if header {
+		b.WriteString("x0, weight, x1, y\n")
+	}
+	for _, sample := range samples {
+		if header {
+			fmt.Fprintf(&b, "%g,%g,%g,%g\n", sample.Input[0], sample.Weight, sample.Input[1], sample.Target[0])
+		} else {
+			fmt.Fprintf(&b, "%g,%g,%g\n", sample.Input[0], sample.Input[1], sample.Target[0])
+		}
+	}
+	return b.Bytes()
+}
+
+func encodeJSONL(objects bool) []byte {
+	var b bytes.Buffer
+	for _, sample := range samples {
+		if objects {
+			fmt.Fprintf(&b, `{"input": [%g, %g], "target": [%g], "weight": %g}`+"\n",
+				sample.Input[0], sample.Input[1], sample.Target[0], sample.Weight)
+		} else {
+			fmt.Fprintf(&b, "[%g, %g, %g]\n", sample.Input[0], sample.Input[1], sample.Target[0])
+		}
+	}
+	return b.Bytes()
+}
+
+// npyValues encodes values as dtype, such as "<f8" or ">i2".
+func npyValues(dtype string, values []float64) []byte {
+	var order binary.AppendByteOrder = binary.LittleEndian
+	if dtype[0] == '>' {
+		order = binary.BigEndian
+	}
+	b := make([]byte, 0)
+	for _, v := range values {
+		switch dtype[1:] {
+		case "f8":
+			b = order.AppendUint64(b, math.Float64bits(v))
+		case "f4":
+			b = order.AppendUint32(b, math.Float32bits(float32(v)))
+		case "i2":
+			b = order.AppendUint16(b, uint16(int16(v)))
+		case "i4":
+			b = order.AppendUint32(b, uint32(int32(v)))
+		case "u1":
+			b = append(b, byte(v))
+		default:
+			panic("npyValues: unsupported dtype " + dtype)
+		}
+	}
+	return b
+}
+
+// npyFile lays out a .npy file of the given format version around header,
+// padded with spaces to a multiple of 64 bytes as NumPy does.
+func npyFile(version byte, header string, data []byte) []byte {
+	prefix := len(npyMagic) + 2 + 2
+	if version > 1 {
+		prefix += 2
+	}
+	header += strings.Repeat(" ", 63-(prefix+len(header))%64) + "\n"
+
+	b := append([]byte(npyMagic), version, 0)
+	if version > 1 {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(header)))
+	} else {
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(header)))
+	}
+	return append(append(b, header...), data...)
+}
+
+func npyHeader(dtype, shape string) string {
+	return fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", dtype, shape)
+}
+
+func encodeNPY() []byte {
+	values := make([]float64, 0)
+	for _, sample := range samples {
+		values = append(values, sample.Input...)
+		values = append(values, sample.Target...)
+	}
+	return npyFile(1, npyHeader("<f8", "(3, 3)"), npyValues("<f8", values))
+}
+
+func gzipped(content []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(content)
+	w.Close()
+	return b.Bytes()
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    []training.TrainingSample
+	}{
+		{"plain.csv", encodeCSV(false), unweighted()},
+		{"weighted.csv", encodeCSV(true), samples},
+		{"arrays.jsonl", encodeJSONL(false), unweighted()},
+		{"objects.ndjson", encodeJSONL(true), samples},
+		{"array.npy", encodeNPY(), unweighted()},
+		{"compressed.csv.gz", gzipped(encodeCSV(true)), samples},
+		{"compressed.npy.gz", gzipped(encodeNPY()), unweighted()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readAll(t, writeFile(t, tt.name, tt.content), 1)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNPYHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		version byte
+		dtype   string
+		shape   string
+		values  []float64
+		targets int
+		want    [][]float64
+	}{
+		{"little-endian matrix", 1, "<f8", "(2, 3)", []float64{1, 2.5, -3, 4, 5, 6e10}, 1,
+			[][]float64{{1, 2.5, -3}, {4, 5, 6e10}}},
+		{"big-endian matrix", 1, ">f8", "(2, 3)", []float64{1, 2.5, -3, 4, 5, 6e10}, 1,
+			[][]float64{{1, 2.5, -3}, {4, 5, 6e10}}},
+		{"big-endian vector", 1, ">f4", "(3,)", []float64{0.5, -1, 8}, 0,
+			[][]float64{{0.5}, {-1}, {8}}},
+		{"little-endian integers", 1, "<i2", "(2, 2)", []float64{-300, 7, 12, -1}, 1,
+			[][]float64{{-300, 7}, {12, -1}}},
+		{"big-endian integers", 1, ">i4", "(1, 2)", []float64{-70000, 3}, 1,
+			[][]float64{{-70000, 3}}},
+		{"bytes", 1, "|u1", "(2,)", []float64{255, 1}, 1,
+			[][]float64{{255}, {1}}},
+		{"version 2", 2, "<f8", "(1, 2)", []float64{9, 10}, 1,
+			[][]float64{{9, 10}}},
+		{"empty", 1, "<f8", "(0, 4)", nil, 1,
+			[][]float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := npyFile(tt.version, npyHeader(tt.dtype, tt.shape), npyValues(tt.dtype, tt.values))
+			read := readAll(t, writeFile(t, "array.npy", content), tt.targets)
+
+			got := make([][]float64, len(read))
+			for i, sample := range read {
+				got[i] = append(append([]float64{}, sample.Input...), sample.Target...)
+				if len(sample.Target) != tt.targets {
+					t.Errorf("row %d has %d targets, want %d", i, len(sample.Target), tt.targets)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNPYRejectsUnsupportedArrays(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"rank 3", npyFile(1, npyHeader("<f8", "(2, 1, 1)"), npyValues("<f8", []float64{1, 2}))},
+		{"rank 0", npyFile(1, npyHeader("<f8", "()"), npyValues("<f8", []float64{1}))},
+		{"Fortran order", npyFile(1, "{'descr': '<f8', 'fortran_order': True, 'shape': (1, 1), }", npyValues("<f8", []float64{1}))},
+		{"complex", npyFile(1, npyHeader("<c16", "(1,)"), make([]byte, 16))},
+		{"version 9", npyFile(9, npyHeader("<f8", "(1,)"), npyValues("<f8", []float64{1}))},
+		{"missing shape", npyFile(1, "{'descr': '<f8', 'fortran_order': False, }", nil)},
+		{"not npy", []byte("x,y\n1,2\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := Open(writeFile(t, "array.npy", tt.content), "", 0)
+			if err == nil {
+				reader.Close()
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		content := npyFile(1, npyHeader("<f8", "(2, 2)"), npyValues("<f8", []float64{1, 2, 3}))
+		reader, err := Open(writeFile(t, "array.npy", content), "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		if _, err := reader.Next(); err != nil {
+			t.Fatalf("first row: %v", err)
+		}
+		if _, err := reader.Next(); err == nil || err == io.EOF {
+			t.Fatalf("second row: got %v, want an unexpected EOF", err)
+		}
+	})
+}
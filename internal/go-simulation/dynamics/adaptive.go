@@ -0,0 +1,189 @@
+package dynamics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Dormand–Prince 5(4) coefficients. The 5th-order solution is propagated and
+// the embedded 4th-order solution is only used to estimate the local error.
+var (
+	dpC = [7]float64{0, 1.0 / 5, 3.0 / 10, 4.0 / 5, 8.0 / 9, 1, 1}
+	dpA = [7][6]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	dpB = [7]float64{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84, 0}
+	dpE = [7]float64{
+		71.0 / 57600, 0, -71.0 / 16695, 71.0 / 1920, -17253.0 / 339200, 22.0 / 525, -1.0 / 40,
+	}
+)
+
+// AdaptiveStepper advances an OrbitalDynamics with an embedded Dormand–Prince
+// 5(4) Runge–Kutta pair, rejecting steps whose estimated local error exceeds
+// the mixed tolerance AbsTol + RelTol*|y| and resizing the next step.
+type AdaptiveStepper struct {
+	RelTol        float64
+	AbsTol        float64
+	MinStep       float64
+	MaxStep       float64
+	Safety        float64
+	MinFactor     float64
+	MaxFactor     float64
+	MaxRejections int
+	Accepted      int
+	Rejected      int
+	Forced        int
+	LastError     float64
+}
+
+func NewAdaptiveStepper(relTol, absTol, minStep, maxStep float64) *AdaptiveStepper {
+	return &AdaptiveStepper{
+		RelTol:        relTol,
+		AbsTol:        absTol,
+		MinStep:       minStep,
+		MaxStep:       maxStep,
+		Safety:        0.9,
+		MinFactor:     0.2,
+		MaxFactor:     5.0,
+		MaxRejections: 50,
+	}
+}
+
+// Step tries to advance od by dt. It returns the step actually taken and the
+// step size suggested for the next call. Steps are retried with a smaller dt
+// until the error estimate is within tolerance; once dt reaches MinStep the
+// step is accepted regardless and counted in Forced.
+func (as *AdaptiveStepper) Step(od *OrbitalDynamics, dt float64) (float64, float64, error) {
+	if dt <= 0 {
+		return 0, 0, fmt.Errorf("adaptive step size must be positive, got %g", dt)
+	}
+
+	dt = as.clamp(dt)
+	x0 := od.positions()
+	v0 := od.velocities()
+
+	for attempt := 0; ; attempt++ {
+		x1, v1, a1, errorNorm := as.trial(od, x0, v0, dt)
+		as.LastError = errorNorm
+
+		atFloor := as.MinStep > 0 && dt <= as.MinStep
+		if errorNorm <= 1 || atFloor || attempt >= as.MaxRejections {
+			if errorNorm > 1 {
+				as.Forced++
+			}
+			as.Accepted++
+
+			od.eachBody(func(i int, body *CelestialBody) {
+				body.Position = x1[i]
+				body.Velocity = v1[i]
+			})
+			od.recordForces(a1)
+			od.Time += dt
+
+			if od.MergeCollisions {
+				od.resolveCollisions()
+			}
+
+			return dt, as.clamp(dt * as.factor(errorNorm)), nil
+		}
+
+		as.Rejected++
+		dt = as.clamp(dt * as.factor(errorNorm))
+	}
+}
+
+// trial performs one Dormand–Prince step of size dt from (x0, v0) without
+// modifying od and returns the 5th-order solution, the accelerations there
+// and its scaled RMS error. The last stage is evaluated at the solution
+// itself (first same as last), so its accelerations come for free.
+func (as *AdaptiveStepper) trial(od *OrbitalDynamics, x0, v0 []Vector3D, dt float64) ([]Vector3D, []Vector3D, []Vector3D, float64) {
+	n := len(x0)
+	var kx, kv [7][]Vector3D
+	var x1 []Vector3D
+
+	for stage := 0; stage < 7; stage++ {
+		x := make([]Vector3D, n)
+		v := make([]Vector3D, n)
+		od.Pool.For(n, func(start, end int) {
+			for i := start; i < end; i++ {
+				x[i] = x0[i]
+				v[i] = v0[i]
+				for j := 0; j < stage; j++ {
+					if dpA[stage][j] == 0 {
+						continue
+					}
+					x[i] = x[i].Add(kx[j][i].Scale(dt * dpA[stage][j]))
+					v[i] = v[i].Add(kv[j][i].Scale(dt * dpA[stage][j]))
+				}
+			}
+		})
+		kx[stage] = v
+		kv[stage] = od.Accelerations(x)
+		x1 = x
+	}
+
+	// The last row of dpA is dpB, so the last stage is the solution.
+	v1 := kx[6]
+	sum := od.Pool.Sum(n, func(i int) float64 {
+		errX := Vector3D{}
+		errV := Vector3D{}
+		for stage := 0; stage < 7; stage++ {
+			errX = errX.Add(kx[stage][i].Scale(dt * dpE[stage]))
+			errV = errV.Add(kv[stage][i].Scale(dt * dpE[stage]))
+		}
+		return as.scaledSquares(errX, x0[i], x1[i]) + as.scaledSquares(errV, v0[i], v1[i])
+	})
+
+	if n == 0 {
+		return x1, v1, kv[6], 0
+	}
+	return x1, v1, kv[6], math.Sqrt(sum / float64(6*n))
+}
+
+func (as *AdaptiveStepper) scaledSquares(err, before, after Vector3D) float64 {
+	components := [3][3]float64{
+		{err.X, before.X, after.X},
+		{err.Y, before.Y, after.Y},
+		{err.Z, before.Z, after.Z},
+	}
+
+	sum := 0.0
+	for _, c := range components {
+		scale := as.AbsTol + as.RelTol*math.Max(math.Abs(c[1]), math.Abs(c[2]))
+		if scale <= 0 {
+			scale = math.SmallestNonzeroFloat64
+		}
+		ratio := c[0] / scale
+		sum += ratio * ratio
+	}
+	return sum
+}
+
+// factor is the standard step-size controller for a 5th-order method.
+func (as *AdaptiveStepper) factor(errorNorm float64) float64 {
+	if math.IsNaN(errorNorm) {
+		return as.MinFactor
+	}
+	if errorNorm == 0 {
+		return as.MaxFactor
+	}
+
+	factor := as.Safety * math.Pow(errorNorm, -0.2)
+	return math.Max(as.MinFactor, math.Min(as.MaxFactor, factor))
+}
+
+func (as *AdaptiveStepper) clamp(dt float64) float64 {
+	if as.MaxStep > 0 && dt > as.MaxStep {
+		dt = as.MaxStep
+	}
+	if as.MinStep > 0 && dt < as.MinStep {
+		dt = as.MinStep
+	}
+	return dt
+}
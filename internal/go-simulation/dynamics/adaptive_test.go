@@ -0,0 +1,99 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+)
+
+// eccentricBinary is two equal masses with G = 1 and unit total mass,
+// starting at apoapsis one apart on an orbit of eccentricity e. It returns
+// the system and the orbital period.
+func eccentricBinary(e float64) (*OrbitalDynamics, float64) {
+	semiMajor := 1 / (1 + e)
+	speed := math.Sqrt(2 - 1/semiMajor)
+	od := NewOrbitalDynamics(0)
+	od.G = 1
+	od.AddNamedBody("a", 0.5, 0, Vector3D{X: -0.5}, Vector3D{Y: -speed / 2})
+	od.AddNamedBody("b", 0.5, 0, Vector3D{X: 0.5}, Vector3D{Y: speed / 2})
+	return od, 2 * math.Pi * math.Pow(semiMajor, 1.5)
+}
+
+func TestAdaptiveStepperCloseEncounter(t *testing.T) {
+	// At e = 0.95 the separation falls from 1 to about 0.026, so the
+	// acceleration at periapsis is some 1500 times that at apoapsis.
+	for _, tol := range []float64{1e-6, 1e-8} {
+		od, period := eccentricBinary(0.95)
+		start := od.positions()
+		energy := od.TotalEnergy()
+		stepper := NewAdaptiveStepper(tol, tol, 0, 0)
+
+		dt, smallest, largest := 0.05, math.Inf(1), 0.0
+		closeRejections := 0
+		for od.Time < period {
+			separation := od.Bodies[1].Position.Sub(od.Bodies[0].Position).Norm()
+			rejected := stepper.Rejected
+			taken, next, err := stepper.Step(od, math.Min(dt, period-od.Time))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stepper.Rejected > rejected && separation < 0.2 {
+				closeRejections++
+			}
+			if stepper.LastError > 1 {
+				t.Fatalf("tol %g: accepted a step with scaled error %g", tol, stepper.LastError)
+			}
+			smallest, largest = math.Min(smallest, taken), math.Max(largest, taken)
+			dt = next
+		}
+
+		if closeRejections == 0 {
+			t.Errorf("tol %g: no step was rejected during the close encounter", tol)
+		}
+		if stepper.Forced != 0 {
+			t.Errorf("tol %g: %d steps were forced", tol, stepper.Forced)
+		}
+		if smallest > largest/100 {
+			t.Errorf("tol %g: steps ranged only from %g to %g", tol, smallest, largest)
+		}
+
+		// Local errors within tol accumulate over the orbit's steps to a
+		// global error of a few dozen times tol.
+		distance := 0.0
+		for i, body := range od.Bodies {
+			distance = math.Max(distance, body.Position.Sub(start[i]).Norm())
+		}
+		if distance > 100*tol {
+			t.Errorf("tol %g: after one period the bodies are %g from where they started", tol, distance)
+		}
+		if drift := math.Abs((od.TotalEnergy() - energy) / energy); drift > 100*tol {
+			t.Errorf("tol %g: relative energy error %g", tol, drift)
+		}
+	}
+}
+
+func TestAdaptiveStepperReusesLastStage(t *testing.T) {
+	// The last stage is evaluated at the solution only because the last
+	// row of dpA is the 5th-order weights.
+	for j := 0; j < 6; j++ {
+		if dpA[6][j] != dpB[j] {
+			t.Fatalf("dpA[6][%d] = %g, dpB[%d] = %g", j, dpA[6][j], j, dpB[j])
+		}
+	}
+	if dpB[6] != 0 {
+		t.Fatalf("dpB[6] = %g, want 0", dpB[6])
+	}
+
+	od, _ := eccentricBinary(0.5)
+	stepper := NewAdaptiveStepper(1e-8, 1e-8, 0, 0)
+	for step := 0; step < 5; step++ {
+		if _, _, err := stepper.Step(od, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	accelerations := od.Accelerations(od.positions())
+	for i, body := range od.Bodies {
+		if want := accelerations[i].Scale(body.Mass); body.Force != want {
+			t.Errorf("body %s: recorded force %+v, want %+v", body.ID, body.Force, want)
+		}
+	}
+}
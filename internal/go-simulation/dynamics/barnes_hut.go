@@ -0,0 +1,234 @@
+package dynamics
+
+import (
+	"math"
+	"sync"
+)
+
+// maxTreeDepth bounds subdivision so that coincident bodies share a leaf
+// instead of splitting forever.
+const maxTreeDepth = 48
+
+// BarnesHut approximates gravity with an octree: a cell whose size divided
+// by its distance from a body is below Theta acts as a single point mass at
+// its centre of mass. Each evaluation costs O(N log N); a Theta of zero or
+// less would open every cell, so it is handed to DirectSolver, which gives
+// exactly the direct sum rather than the same terms in tree order. A
+// BarnesHut may be shared between goroutines: each evaluation builds its
+// tree in storage taken from a pool, so storage is reused between
+// evaluations without being shared by concurrent ones.
+type BarnesHut struct {
+	Theta float64
+	trees sync.Pool // of *octree
+}
+
+// octree is the tree for one evaluation. nodes[0] is the root.
+type octree struct {
+	nodes []octreeNode
+	next  []int
+}
+
+// octreeNode is a cubic cell. Leaves hold a chain of bodies linked through
+// octree.next; internal cells hold up to eight children.
+type octreeNode struct {
+	center   Vector3D
+	half     float64
+	mass     float64
+	moment   Vector3D
+	parent   int
+	children [8]int
+	body     int
+	leaf     bool
+}
+
+func NewBarnesHut(theta float64) *BarnesHut {
+	return &BarnesHut{Theta: theta}
+}
+
+func (bh *BarnesHut) Accelerations(od *OrbitalDynamics, positions []Vector3D) []Vector3D {
+	if bh.Theta <= 0 {
+		return DirectSolver{}.Accelerations(od, positions)
+	}
+	accelerations := make([]Vector3D, len(od.Bodies))
+	if len(positions) == 0 {
+		return accelerations
+	}
+
+	tree, _ := bh.trees.Get().(*octree)
+	if tree == nil {
+		tree = &octree{}
+	}
+	defer bh.trees.Put(tree)
+
+	// The tree is built serially; the walks only read it, so they are
+	// sharded across od.Pool.
+	tree.build(od, positions)
+	theta2 := bh.Theta * bh.Theta
+	od.Pool.For(len(accelerations), func(start, end int) {
+		stack := make([]int, 0, 64)
+		for i := start; i < end; i++ {
+			accelerations[i], stack = tree.acceleration(od, positions, i, theta2, stack)
+		}
+	})
+	return accelerations
+}
+
+// build inserts every body into a fresh tree, reusing storage from the
+// evaluation that last used t, then accumulates mass and centre of mass
+// bottom-up.
+func (t *octree) build(od *OrbitalDynamics, positions []Vector3D) {
+	low, high := positions[0], positions[0]
+	for _, p := range positions[1:] {
+		low = Vector3D{math.Min(low.X, p.X), math.Min(low.Y, p.Y), math.Min(low.Z, p.Z)}
+		high = Vector3D{math.Max(high.X, p.X), math.Max(high.Y, p.Y), math.Max(high.Z, p.Z)}
+	}
+	extent := high.Sub(low)
+	half := 0.5 * math.Max(extent.X, math.Max(extent.Y, extent.Z))
+	half = half*(1+1e-9) + 1e-12
+
+	t.nodes = t.nodes[:0]
+	t.newNode(low.Add(high).Scale(0.5), half, -1)
+	if cap(t.next) < len(positions) {
+		t.next = make([]int, len(positions))
+	}
+	t.next = t.next[:len(positions)]
+
+	for i := range positions {
+		t.insert(positions, i)
+	}
+
+	// Children are always allocated after their parent, so a reverse sweep
+	// finishes every child before the parent it contributes to.
+	for n := len(t.nodes) - 1; n >= 0; n-- {
+		node := &t.nodes[n]
+		if node.leaf {
+			for b := node.body; b >= 0; b = t.next[b] {
+				mass := od.Bodies[b].Mass
+				node.mass += mass
+				node.moment = node.moment.Add(positions[b].Scale(mass))
+			}
+		}
+		if node.parent >= 0 {
+			parent := &t.nodes[node.parent]
+			parent.mass += node.mass
+			parent.moment = parent.moment.Add(node.moment)
+		}
+	}
+}
+
+func (t *octree) newNode(center Vector3D, half float64, parent int) int {
+	t.nodes = append(t.nodes, octreeNode{
+		center:   center,
+		half:     half,
+		parent:   parent,
+		children: [8]int{-1, -1, -1, -1, -1, -1, -1, -1},
+		body:     -1,
+		leaf:     true,
+	})
+	return len(t.nodes) - 1
+}
+
+func (t *octree) insert(positions []Vector3D, b int) {
+	t.next[b] = -1
+	n := 0
+	for depth := 0; ; depth++ {
+		node := &t.nodes[n]
+		if node.leaf {
+			if node.body < 0 {
+				node.body = b
+				return
+			}
+			if depth >= maxTreeDepth {
+				t.next[b] = node.body
+				node.body = b
+				return
+			}
+			// Push the resident bodies down one level and keep descending.
+			resident := node.body
+			node.body = -1
+			node.leaf = false
+			for resident >= 0 {
+				following := t.next[resident]
+				child := t.child(n, positions[resident])
+				t.next[resident] = t.nodes[child].body
+				t.nodes[child].body = resident
+				resident = following
+			}
+		}
+		n = t.child(n, positions[b])
+	}
+}
+
+// child returns the octant of node n containing p, creating it if needed.
+func (t *octree) child(n int, p Vector3D) int {
+	node := t.nodes[n]
+	octant := 0
+	offset := Vector3D{-1, -1, -1}
+	if p.X >= node.center.X {
+		octant |= 1
+		offset.X = 1
+	}
+	if p.Y >= node.center.Y {
+		octant |= 2
+		offset.Y = 1
+	}
+	if p.Z >= node.center.Z {
+		octant |= 4
+		offset.Z = 1
+	}
+
+	if node.children[octant] < 0 {
+		half := node.half / 2
+		created := t.newNode(node.center.Add(offset.Scale(half)), half, n)
+		t.nodes[n].children[octant] = created
+	}
+	return t.nodes[n].children[octant]
+}
+
+// acceleration walks the tree for body i, opening cells whose size is not
+// below θ times their distance, where theta2 is θ². The stack is returned
+// so callers can reuse its storage.
+func (t *octree) acceleration(od *OrbitalDynamics, positions []Vector3D, i int, theta2 float64, stack []int) (Vector3D, []int) {
+	softening := od.Softening * od.Softening
+	position := positions[i]
+	acceleration := Vector3D{}
+
+	stack = append(stack[:0], 0)
+	for len(stack) > 0 {
+		node := &t.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if node.mass == 0 {
+			continue
+		}
+
+		if node.leaf {
+			for b := node.body; b >= 0; b = t.next[b] {
+				if b != i {
+					acceleration = acceleration.Add(pointAcceleration(od.G, od.Bodies[b].Mass, positions[b].Sub(position), softening))
+				}
+			}
+			continue
+		}
+
+		centerOfMass := node.moment.Scale(1 / node.mass)
+		delta := centerOfMass.Sub(position)
+		size := 2 * node.half
+		if size*size < theta2*delta.Dot(delta) && !node.contains(position) {
+			acceleration = acceleration.Add(pointAcceleration(od.G, node.mass, delta, softening))
+			continue
+		}
+
+		for _, c := range node.children {
+			if c >= 0 {
+				stack = append(stack, c)
+			}
+		}
+	}
+	return acceleration, stack
+}
+
+func (node *octreeNode) contains(p Vector3D) bool {
+	return math.Abs(p.X-node.center.X) <= node.half &&
+		math.Abs(p.Y-node.center.Y) <= node.half &&
+		math.Abs(p.Z-node.center.Z) <= node.half
+}
@@ -0,0 +1,110 @@
+package dynamics
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+// cluster places n bodies of random mass uniformly in a unit cube.
+func cluster(n int, seed int64) *OrbitalDynamics {
+	source := random.NewSource(seed)
+	od := NewOrbitalDynamics(0.01)
+	od.G = 1
+	od.Softening = 1e-3
+	for i := 0; i < n; i++ {
+		position := Vector3D{source.Float64(), source.Float64(), source.Float64()}
+		od.AddBody(source.Uniform(0.5, 1.5), position, Vector3D{})
+	}
+	return od
+}
+
+func TestBarnesHutAccuracy(t *testing.T) {
+	od := cluster(2000, 1)
+	// A monopole approximation's error grows roughly as theta²; the largest
+	// relative errors belong to bodies near the middle, whose pulls mostly
+	// cancel.
+	tests := []struct {
+		theta    float64
+		maxRMS   float64
+		maxWorst float64
+	}{
+		{0.3, 3e-3, 5e-2},
+		{0.5, 1.5e-2, 1.5e-1},
+		{0.7, 4e-2, 4e-1},
+		{1.0, 1e-1, 8e-1},
+	}
+
+	previous := 0.0
+	for _, tt := range tests {
+		worst, rms := ForceError(od, NewBarnesHut(tt.theta))
+		t.Logf("theta %.1f: max %.3g, rms %.3g", tt.theta, worst, rms)
+		if rms > tt.maxRMS || worst > tt.maxWorst {
+			t.Errorf("theta %.1f: max error %.3g, rms %.3g; want at most %.3g, %.3g", tt.theta, worst, rms, tt.maxWorst, tt.maxRMS)
+		}
+		if rms < previous {
+			t.Errorf("theta %.1f: rms error %.3g fell below %.3g at a smaller theta", tt.theta, rms, previous)
+		}
+		previous = rms
+	}
+}
+
+func TestBarnesHutZeroThetaIsDirect(t *testing.T) {
+	od := cluster(500, 2)
+	worst, rms := ForceError(od, NewBarnesHut(0))
+	if worst != 0 || rms != 0 {
+		t.Errorf("theta 0: max error %g, rms %g; want exactly 0", worst, rms)
+	}
+}
+
+func TestBarnesHutSharedBetweenGoroutines(t *testing.T) {
+	// Systems of different sizes, so that trees built into shared storage
+	// would corrupt one another.
+	systems := []*OrbitalDynamics{cluster(300, 4), cluster(700, 5), cluster(50, 6), cluster(1000, 7)}
+	want := make([][]Vector3D, len(systems))
+	for i, od := range systems {
+		want[i] = NewBarnesHut(DefaultOpeningAngle).Accelerations(od, od.positions())
+	}
+
+	solver := NewBarnesHut(DefaultOpeningAngle)
+	var wg sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for i, od := range systems {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got := solver.Accelerations(od, od.positions()); !reflect.DeepEqual(got, want[i]) {
+					t.Errorf("system %d: accelerations differ when the solver is shared", i)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func BenchmarkDirect(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("N=%d", n), func(b *testing.B) {
+			benchmarkSolver(b, cluster(n, 3), DirectSolver{})
+		})
+	}
+}
+
+func BenchmarkBarnesHut(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("N=%d", n), func(b *testing.B) {
+			benchmarkSolver(b, cluster(n, 3), NewBarnesHut(DefaultOpeningAngle))
+		})
+	}
+}
+
+func benchmarkSolver(b *testing.B, od *OrbitalDynamics, solver ForceSolver) {
+	positions := od.positions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		solver.Accelerations(od, positions)
+	}
+}
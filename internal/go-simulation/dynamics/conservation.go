@@ -0,0 +1,49 @@
+package dynamics
+
+import "math"
+
+func (od *OrbitalDynamics) KineticEnergy() float64 {
+	return od.Pool.Sum(len(od.Bodies), func(i int) float64 {
+		body := od.Bodies[i]
+		return 0.5 * body.Mass * body.Velocity.Dot(body.Velocity)
+	})
+}
+
+// PotentialEnergy is the softened gravitational potential energy, consistent
+// with the forces used by the integrators. Per-body partial sums are added in
+// body order, so the result does not depend on the worker count.
+func (od *OrbitalDynamics) PotentialEnergy() float64 {
+	epsilon2 := od.Softening * od.Softening
+	return od.Pool.Sum(len(od.Bodies), func(i int) float64 {
+		energy := 0.0
+		for j := i + 1; j < len(od.Bodies); j++ {
+			offset := od.Bodies[j].Position.Sub(od.Bodies[i].Position)
+			distance := math.Sqrt(offset.Dot(offset) + epsilon2)
+			if distance > 0 {
+				energy -= od.G * od.Bodies[i].Mass * od.Bodies[j].Mass / distance
+			}
+		}
+		return energy
+	})
+}
+
+func (od *OrbitalDynamics) TotalEnergy() float64 {
+	return od.KineticEnergy() + od.PotentialEnergy()
+}
+
+// AngularMomentum is the total angular momentum about the origin.
+func (od *OrbitalDynamics) AngularMomentum() Vector3D {
+	total := Vector3D{}
+	for _, body := range od.Bodies {
+		total = total.Add(body.Position.Cross(body.Velocity.Scale(body.Mass)))
+	}
+	return total
+}
+
+func (od *OrbitalDynamics) LinearMomentum() Vector3D {
+	total := Vector3D{}
+	for _, body := range od.Bodies {
+		total = total.Add(body.Velocity.Scale(body.Mass))
+	}
+	return total
+}
@@ -0,0 +1,103 @@
+package dynamics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Force solvers accepted by NewForceSolver. The names match EngineConfig.Type
+// in pkg/go-cli/config.
+const (
+	SolverDirect = "direct"
+	SolverTree   = "tree"
+)
+
+// DefaultOpeningAngle is the Barnes-Hut opening angle used when none is
+// configured. Smaller angles open more cells and are more accurate.
+const DefaultOpeningAngle = 0.5
+
+// ForceSolver computes the softened gravitational acceleration of every body
+// in od when the bodies are at the given positions.
+type ForceSolver interface {
+	Accelerations(od *OrbitalDynamics, positions []Vector3D) []Vector3D
+}
+
+// NewForceSolver returns the solver named by kind. The opening angle only
+// applies to the tree solver; zero selects DefaultOpeningAngle.
+func NewForceSolver(kind string, theta float64) (ForceSolver, error) {
+	switch kind {
+	case SolverDirect, "":
+		return DirectSolver{}, nil
+	case SolverTree:
+		if theta == 0 {
+			theta = DefaultOpeningAngle
+		}
+		if theta < 0 {
+			return nil, fmt.Errorf("opening angle must not be negative, got %g", theta)
+		}
+		return NewBarnesHut(theta), nil
+	default:
+		return nil, fmt.Errorf("unknown force solver: %s", kind)
+	}
+}
+
+// DirectSolver sums every pairwise interaction, O(N²) per evaluation. Bodies
+// are sharded across od.Pool and each sums its interactions in body order, so
+// results do not depend on the worker count.
+type DirectSolver struct{}
+
+func (DirectSolver) Accelerations(od *OrbitalDynamics, positions []Vector3D) []Vector3D {
+	accelerations := make([]Vector3D, len(od.Bodies))
+	softening := od.Softening * od.Softening
+
+	od.Pool.For(len(od.Bodies), func(start, end int) {
+		for i := start; i < end; i++ {
+			acceleration := Vector3D{}
+			for j := range od.Bodies {
+				if i == j {
+					continue
+				}
+				acceleration = acceleration.Add(pointAcceleration(od.G, od.Bodies[j].Mass, positions[j].Sub(positions[i]), softening))
+			}
+			accelerations[i] = acceleration
+		}
+	})
+
+	return accelerations
+}
+
+// pointAcceleration is the softened acceleration towards a point mass at
+// offset delta.
+func pointAcceleration(g, mass float64, delta Vector3D, softening float64) Vector3D {
+	distanceSquared := delta.Dot(delta) + softening
+	if distanceSquared == 0 {
+		return Vector3D{}
+	}
+	inverse := 1.0 / (distanceSquared * math.Sqrt(distanceSquared))
+	return delta.Scale(g * mass * inverse)
+}
+
+// ForceError compares solver against direct summation for the current body
+// positions. It returns the largest and the root-mean-square acceleration
+// error, each relative to the magnitude of the exact acceleration.
+func ForceError(od *OrbitalDynamics, solver ForceSolver) (float64, float64) {
+	positions := od.positions()
+	exact := DirectSolver{}.Accelerations(od, positions)
+	approximate := solver.Accelerations(od, positions)
+
+	maxError := 0.0
+	sumSquares := 0.0
+	for i := range exact {
+		magnitude := exact[i].Norm()
+		if magnitude == 0 {
+			continue
+		}
+		relative := approximate[i].Sub(exact[i]).Norm() / magnitude
+		maxError = math.Max(maxError, relative)
+		sumSquares += relative * relative
+	}
+	if len(exact) == 0 {
+		return 0.0, 0.0
+	}
+	return maxError, math.Sqrt(sumSquares / float64(len(exact)))
+}
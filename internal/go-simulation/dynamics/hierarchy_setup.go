@@ -0,0 +1,144 @@
+package dynamics
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+// Gravitational parameters (G*M) and radii used when laying out an
+// Elder/Mentor/Erudite system. Masses are derived from G so that orbital
+// periods are independent of the gravitational constant in use.
+const (
+	elderMu   = 1000.0
+	mentorMu  = 1.0
+	eruditeMu = 0.0001
+
+	elderRadius   = 1.0
+	mentorRadius  = 0.05
+	eruditeRadius = 0.005
+
+	elderRing      = 4.0
+	mentorOrbit    = 20.0
+	mentorSpacing  = 1.6
+	eruditeOrbit   = 0.2
+	eruditeSpacing = 1.4
+
+	// eruditeReach is the fraction of a mentor's Hill radius that its
+	// erudites may occupy.
+	eruditeReach = 0.35
+)
+
+// NewHierarchicalSystem lays out elders near the origin, mentors on circular
+// orbits around the elders, and erudites on circular orbits around their
+// mentors, assigned round-robin. Mentors and the erudites of each mentor are
+// placed on geometrically spaced radii so the default layout stays free of
+// close encounters. When a mentor has more erudites than such radii fit in
+// its Hill sphere, they fill a disk inside it instead. With a non-nil rng each mentor, and each mentor's set of
+// erudites, starts at a random orbital phase; otherwise phases are evenly
+// spaced.
+func NewHierarchicalSystem(elders, mentors, erudites int, g, timeStep float64, rng *random.Source) *OrbitalDynamics {
+	od := NewOrbitalDynamics(timeStep)
+	od.G = g
+
+	elderMass := elderMu / g
+	mentorMass := mentorMu / g
+	eruditeMass := eruditeMu / g
+
+	for i := 0; i < elders; i++ {
+		if elders == 1 {
+			od.AddNamedBody("elder-0", elderMass, elderRadius, Vector3D{}, Vector3D{})
+			break
+		}
+
+		angle := 2 * math.Pi * float64(i) / float64(elders)
+		speed := elderRingSpeed(elders)
+		position := Vector3D{X: elderRing * math.Cos(angle), Y: elderRing * math.Sin(angle)}
+		velocity := Vector3D{X: -speed * math.Sin(angle), Y: speed * math.Cos(angle)}
+		od.AddNamedBody(fmt.Sprintf("elder-%d", i), elderMass, elderRadius, position, velocity)
+	}
+
+	centralMu := elderMu * float64(elders)
+	mentorPositions := make([]Vector3D, mentors)
+	mentorVelocities := make([]Vector3D, mentors)
+
+	for i := 0; i < mentors; i++ {
+		angle := 2*math.Pi*float64(i)/float64(mentors) + randomPhase(rng)
+		radius := mentorOrbit * math.Pow(mentorSpacing, float64(i))
+		speed := math.Sqrt(centralMu / radius)
+		mentorPositions[i] = Vector3D{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)}
+		mentorVelocities[i] = Vector3D{X: -speed * math.Sin(angle), Y: speed * math.Cos(angle)}
+		od.AddNamedBody(fmt.Sprintf("mentor-%d", i), mentorMass, mentorRadius, mentorPositions[i], mentorVelocities[i])
+	}
+
+	if mentors == 0 {
+		return od
+	}
+
+	perMentor := make([]int, mentors)
+	for i := 0; i < erudites; i++ {
+		perMentor[i%mentors]++
+	}
+
+	seen := make([]int, mentors)
+	rotation := make([]float64, mentors)
+	for i := range rotation {
+		rotation[i] = randomPhase(rng)
+	}
+
+	for i := 0; i < erudites; i++ {
+		mentor := i % mentors
+		slot := seen[mentor]
+		seen[mentor]++
+
+		angle, radius := eruditeSlot(slot, perMentor[mentor], mentorOrbit*math.Pow(mentorSpacing, float64(mentor)), centralMu)
+		angle += rotation[mentor]
+		speed := math.Sqrt(mentorMu / radius)
+		offset := Vector3D{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)}
+		relative := Vector3D{X: -speed * math.Sin(angle), Y: speed * math.Cos(angle)}
+
+		od.AddNamedBody(
+			fmt.Sprintf("erudite-%d", i),
+			eruditeMass,
+			eruditeRadius,
+			mentorPositions[mentor].Add(offset),
+			mentorVelocities[mentor].Add(relative),
+		)
+	}
+
+	return od
+}
+
+// elderRingSpeed is the circular speed of n equal masses evenly spaced on a
+// ring of radius elderRing.
+func elderRingSpeed(n int) float64 {
+	sum := 0.0
+	for k := 1; k < n; k++ {
+		sum += 1 / math.Sin(math.Pi*float64(k)/float64(n))
+	}
+	return math.Sqrt(elderMu * sum / (4 * elderRing))
+}
+
+// eruditeSlot places the slot-th of n erudites around a mentor orbiting at
+// mentorRadius. Siblings sit on geometrically spaced orbits, like the mentors
+// themselves, because co-orbiting bodies drift together over long runs. Large
+// populations that would leave the Hill sphere are spread over a disk on a
+// sunflower spiral, which keeps neighbours evenly separated.
+func eruditeSlot(slot, n int, mentorRadius, centralMu float64) (float64, float64) {
+	reach := eruditeReach * mentorRadius * math.Cbrt(mentorMu/(3*centralMu))
+	if eruditeOrbit*math.Pow(eruditeSpacing, float64(n-1)) <= reach {
+		return 2 * math.Pi * float64(slot) / float64(n), eruditeOrbit * math.Pow(eruditeSpacing, float64(slot))
+	}
+
+	golden := math.Pi * (3 - math.Sqrt(5))
+	fraction := (float64(slot) + 0.5) / float64(n)
+	return golden * float64(slot), eruditeOrbit + (math.Max(reach, eruditeOrbit)-eruditeOrbit)*math.Sqrt(fraction)
+}
+
+func randomPhase(rng *random.Source) float64 {
+	if rng == nil {
+		return 0.0
+	}
+	return rng.Uniform(0, 2*math.Pi)
+}
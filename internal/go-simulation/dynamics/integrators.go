@@ -0,0 +1,309 @@
+package dynamics
+
+import (
+	"fmt"
+	"math"
+)
+
+// Integration methods accepted by OrbitalDynamics.Integrator. The names match
+// IntegrationConfig.Method in pkg/go-cli/config.
+const (
+	MethodEuler             = "euler"
+	MethodSemiImplicitEuler = "semi_implicit_euler"
+	MethodVerlet            = "verlet"
+	MethodLeapfrog          = "leapfrog"
+	MethodRK4               = "rk4"
+	MethodYoshida           = "yoshida"
+)
+
+// Methods lists every supported integration method.
+var Methods = []string{
+	MethodEuler,
+	MethodSemiImplicitEuler,
+	MethodVerlet,
+	MethodLeapfrog,
+	MethodRK4,
+	MethodYoshida,
+}
+
+// Collision records two bodies that came within the sum of their radii and
+// were merged into one.
+type Collision struct {
+	Time     float64
+	Survivor string
+	Absorbed string
+	Mass     float64
+}
+
+// Yoshida 4th-order symplectic coefficients.
+var (
+	yoshidaW1 = 1.0 / (2.0 - math.Cbrt(2.0))
+	yoshidaW0 = -math.Cbrt(2.0) / (2.0 - math.Cbrt(2.0))
+	yoshidaC  = [4]float64{yoshidaW1 / 2, (yoshidaW0 + yoshidaW1) / 2, (yoshidaW0 + yoshidaW1) / 2, yoshidaW1 / 2}
+	yoshidaD  = [3]float64{yoshidaW1, yoshidaW0, yoshidaW1}
+)
+
+func (v Vector3D) Add(other Vector3D) Vector3D {
+	return Vector3D{v.X + other.X, v.Y + other.Y, v.Z + other.Z}
+}
+
+func (v Vector3D) Sub(other Vector3D) Vector3D {
+	return Vector3D{v.X - other.X, v.Y - other.Y, v.Z - other.Z}
+}
+
+func (v Vector3D) Scale(scalar float64) Vector3D {
+	return Vector3D{v.X * scalar, v.Y * scalar, v.Z * scalar}
+}
+
+func (v Vector3D) Dot(other Vector3D) float64 {
+	return v.X*other.X + v.Y*other.Y + v.Z*other.Z
+}
+
+func (v Vector3D) Cross(other Vector3D) Vector3D {
+	return Vector3D{
+		X: v.Y*other.Z - v.Z*other.Y,
+		Y: v.Z*other.X - v.X*other.Z,
+		Z: v.X*other.Y - v.Y*other.X,
+	}
+}
+
+func (v Vector3D) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Step advances the system by TimeStep using the configured integrator.
+func (od *OrbitalDynamics) Step() error {
+	return od.StepBy(od.TimeStep)
+}
+
+// StepBy advances the system by dt using the configured integrator, then
+// merges any bodies that collided during the step.
+func (od *OrbitalDynamics) StepBy(dt float64) error {
+	switch od.Integrator {
+	case MethodEuler:
+		od.stepEuler(dt)
+	case MethodSemiImplicitEuler:
+		od.stepSemiImplicitEuler(dt)
+	case MethodVerlet, MethodLeapfrog, "":
+		od.stepVerlet(dt)
+	case MethodRK4:
+		od.stepRK4(dt)
+	case MethodYoshida:
+		od.stepYoshida(dt)
+	default:
+		return fmt.Errorf("unknown integration method: %s", od.Integrator)
+	}
+
+	od.Time += dt
+
+	if od.MergeCollisions {
+		od.resolveCollisions()
+	}
+
+	return nil
+}
+
+// Accelerations returns the softened gravitational acceleration of every body
+// evaluated at the given positions, using Forces when set and direct
+// summation otherwise.
+func (od *OrbitalDynamics) Accelerations(positions []Vector3D) []Vector3D {
+	if od.Forces != nil {
+		return od.Forces.Accelerations(od, positions)
+	}
+	return DirectSolver{}.Accelerations(od, positions)
+}
+
+func (od *OrbitalDynamics) positions() []Vector3D {
+	positions := make([]Vector3D, len(od.Bodies))
+	for i, body := range od.Bodies {
+		positions[i] = body.Position
+	}
+	return positions
+}
+
+func (od *OrbitalDynamics) velocities() []Vector3D {
+	velocities := make([]Vector3D, len(od.Bodies))
+	for i, body := range od.Bodies {
+		velocities[i] = body.Velocity
+	}
+	return velocities
+}
+
+// eachBody applies update to every body, sharded across od.Pool. Updates
+// must only touch the body they are given.
+func (od *OrbitalDynamics) eachBody(update func(i int, body *CelestialBody)) {
+	od.Pool.For(len(od.Bodies), func(start, end int) {
+		for i := start; i < end; i++ {
+			update(i, &od.Bodies[i])
+		}
+	})
+}
+
+func (od *OrbitalDynamics) recordForces(accelerations []Vector3D) {
+	od.eachBody(func(i int, body *CelestialBody) {
+		body.Force = accelerations[i].Scale(body.Mass)
+	})
+}
+
+func (od *OrbitalDynamics) stepEuler(dt float64) {
+	accelerations := od.Accelerations(od.positions())
+	od.recordForces(accelerations)
+
+	od.eachBody(func(i int, body *CelestialBody) {
+		body.Position = body.Position.Add(body.Velocity.Scale(dt))
+		body.Velocity = body.Velocity.Add(accelerations[i].Scale(dt))
+	})
+}
+
+func (od *OrbitalDynamics) stepSemiImplicitEuler(dt float64) {
+	accelerations := od.Accelerations(od.positions())
+	od.recordForces(accelerations)
+
+	od.eachBody(func(i int, body *CelestialBody) {
+		body.Velocity = body.Velocity.Add(accelerations[i].Scale(dt))
+		body.Position = body.Position.Add(body.Velocity.Scale(dt))
+	})
+}
+
+// stepVerlet is the kick-drift-kick form of velocity Verlet, which is the
+// same scheme as leapfrog with synchronised velocities.
+func (od *OrbitalDynamics) stepVerlet(dt float64) {
+	accelerations := od.Accelerations(od.positions())
+
+	od.eachBody(func(i int, body *CelestialBody) {
+		body.Velocity = body.Velocity.Add(accelerations[i].Scale(dt / 2))
+		body.Position = body.Position.Add(body.Velocity.Scale(dt))
+	})
+
+	accelerations = od.Accelerations(od.positions())
+	od.recordForces(accelerations)
+
+	od.eachBody(func(i int, body *CelestialBody) {
+		body.Velocity = body.Velocity.Add(accelerations[i].Scale(dt / 2))
+	})
+}
+
+func (od *OrbitalDynamics) stepRK4(dt float64) {
+	x0 := od.positions()
+	v0 := od.velocities()
+	n := len(od.Bodies)
+
+	offset := func(base, slope []Vector3D, h float64) []Vector3D {
+		result := make([]Vector3D, n)
+		od.Pool.For(n, func(start, end int) {
+			for i := start; i < end; i++ {
+				result[i] = base[i].Add(slope[i].Scale(h))
+			}
+		})
+		return result
+	}
+
+	k1x := v0
+	k1v := od.Accelerations(x0)
+
+	k2x := offset(v0, k1v, dt/2)
+	k2v := od.Accelerations(offset(x0, k1x, dt/2))
+
+	k3x := offset(v0, k2v, dt/2)
+	k3v := od.Accelerations(offset(x0, k2x, dt/2))
+
+	k4x := offset(v0, k3v, dt)
+	k4v := od.Accelerations(offset(x0, k3x, dt))
+
+	od.eachBody(func(i int, body *CelestialBody) {
+		dx := k1x[i].Add(k2x[i].Scale(2)).Add(k3x[i].Scale(2)).Add(k4x[i])
+		dv := k1v[i].Add(k2v[i].Scale(2)).Add(k3v[i].Scale(2)).Add(k4v[i])
+		body.Position = x0[i].Add(dx.Scale(dt / 6))
+		body.Velocity = v0[i].Add(dv.Scale(dt / 6))
+	})
+
+	od.recordForces(k1v)
+}
+
+// stepYoshida applies the 4th-order symplectic composition of Yoshida (1990):
+// four drifts interleaved with three kicks.
+func (od *OrbitalDynamics) stepYoshida(dt float64) {
+	for stage := 0; stage < 4; stage++ {
+		drift := yoshidaC[stage] * dt
+		od.eachBody(func(i int, body *CelestialBody) {
+			body.Position = body.Position.Add(body.Velocity.Scale(drift))
+		})
+
+		if stage == 3 {
+			break
+		}
+
+		accelerations := od.Accelerations(od.positions())
+		od.recordForces(accelerations)
+		kick := yoshidaD[stage] * dt
+		od.eachBody(func(i int, body *CelestialBody) {
+			body.Velocity = body.Velocity.Add(accelerations[i].Scale(kick))
+		})
+	}
+}
+
+// resolveCollisions merges every pair of bodies whose separation is smaller
+// than the sum of their radii. Mass and linear momentum are conserved and the
+// merged volume is the sum of the two volumes.
+func (od *OrbitalDynamics) resolveCollisions() {
+	if !od.anyContact() {
+		return
+	}
+
+	for i := 0; i < len(od.Bodies); i++ {
+		for j := i + 1; j < len(od.Bodies); j++ {
+			a := &od.Bodies[i]
+			b := od.Bodies[j]
+
+			reach := a.Radius + b.Radius
+			if reach <= 0 || a.Position.Sub(b.Position).Norm() >= reach {
+				continue
+			}
+
+			mass := a.Mass + b.Mass
+			if mass > 0 {
+				a.Position = a.Position.Scale(a.Mass).Add(b.Position.Scale(b.Mass)).Scale(1 / mass)
+				a.Velocity = a.Velocity.Scale(a.Mass).Add(b.Velocity.Scale(b.Mass)).Scale(1 / mass)
+			}
+			a.Radius = math.Cbrt(a.Radius*a.Radius*a.Radius + b.Radius*b.Radius*b.Radius)
+			a.Mass = mass
+			a.Force = a.Force.Add(b.Force)
+
+			od.Collisions = append(od.Collisions, Collision{
+				Time:     od.Time,
+				Survivor: a.ID,
+				Absorbed: b.ID,
+				Mass:     mass,
+			})
+
+			od.Bodies = append(od.Bodies[:j], od.Bodies[j+1:]...)
+			j = i
+		}
+	}
+}
+
+// anyContact reports whether any two bodies overlap. The check is sharded
+// across od.Pool; the merges themselves run serially because their outcome
+// depends on order.
+func (od *OrbitalDynamics) anyContact() bool {
+	contacts := make([]bool, len(od.Bodies))
+	od.Pool.For(len(od.Bodies), func(start, end int) {
+		for i := start; i < end; i++ {
+			a := od.Bodies[i]
+			for j := i + 1; j < len(od.Bodies); j++ {
+				reach := a.Radius + od.Bodies[j].Radius
+				if reach > 0 && a.Position.Sub(od.Bodies[j].Position).Norm() < reach {
+					contacts[i] = true
+					break
+				}
+			}
+		}
+	})
+
+	for _, contact := range contacts {
+		if contact {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+)
+
+// binary is two equal masses on a circular orbit about their centre of mass
+// with G = 1, unit separation and unit total mass, so the relative speed is
+// one and the period is 2π.
+func binary(integrator string, dt float64) *OrbitalDynamics {
+	od := NewOrbitalDynamics(dt)
+	od.G = 1
+	od.Integrator = integrator
+	od.AddNamedBody("a", 0.5, 0, Vector3D{X: -0.5}, Vector3D{Y: -0.5})
+	od.AddNamedBody("b", 0.5, 0, Vector3D{X: 0.5}, Vector3D{Y: 0.5})
+	return od
+}
+
+// quarterOrbit integrates the binary for a quarter period in steps steps.
+// It returns the largest distance of a body from its exact position and
+// the largest relative energy error along the way.
+func quarterOrbit(t *testing.T, integrator string, steps int) (float64, float64) {
+	t.Helper()
+	od := binary(integrator, math.Pi/2/float64(steps))
+	energy := od.TotalEnergy()
+	drift := 0.0
+	for step := 0; step < steps; step++ {
+		if err := od.Step(); err != nil {
+			t.Fatal(err)
+		}
+		drift = math.Max(drift, math.Abs((od.TotalEnergy()-energy)/energy))
+	}
+
+	// After a quarter turn a sits at (0, -½) and b at (0, ½).
+	exact := []Vector3D{{Y: -0.5}, {Y: 0.5}}
+	distance := 0.0
+	for i, body := range od.Bodies {
+		distance = math.Max(distance, body.Position.Sub(exact[i]).Norm())
+	}
+	return distance, drift
+}
+
+func TestIntegratorOrder(t *testing.T) {
+	// The bounds are a few times the errors measured at steps steps per
+	// quarter orbit. Halving the step must shrink the position error by
+	// 2^order.
+	tests := []struct {
+		method   string
+		order    float64
+		steps    int
+		maxError float64
+		maxDrift float64
+	}{
+		{MethodEuler, 1, 4000, 1e-3, 5e-3},
+		{MethodSemiImplicitEuler, 1, 4000, 5e-4, 2e-7},
+		{MethodVerlet, 2, 400, 5e-6, 1e-10},
+		{MethodLeapfrog, 2, 400, 5e-6, 1e-10},
+		{MethodRK4, 4, 100, 2e-9, 1e-10},
+		{MethodYoshida, 4, 100, 5e-8, 1e-13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			coarse, drift := quarterOrbit(t, tt.method, tt.steps)
+			fine, _ := quarterOrbit(t, tt.method, 2*tt.steps)
+
+			if coarse > tt.maxError {
+				t.Errorf("position error %g exceeds %g", coarse, tt.maxError)
+			}
+			if drift > tt.maxDrift {
+				t.Errorf("energy error %g exceeds %g", drift, tt.maxDrift)
+			}
+			if order := math.Log2(coarse / fine); math.Abs(order-tt.order) > 0.15 {
+				t.Errorf("observed order %.3f, want %g (errors %g and %g)", order, tt.order, coarse, fine)
+			}
+		})
+	}
+}
+
+// TestSymplecticEnergyBounded checks that the symplectic methods' energy
+// error oscillates without growing over many orbits, while Euler's and
+// RK4's accumulate.
+func TestSymplecticEnergyBounded(t *testing.T) {
+	const orbits, steps = 20, 2000
+
+	tests := []struct {
+		method     string
+		symplectic bool
+	}{
+		{MethodEuler, false},
+		{MethodSemiImplicitEuler, true},
+		{MethodVerlet, true},
+		{MethodRK4, false},
+		{MethodYoshida, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			// An eccentric orbit, so the energy error is not superconvergent.
+			// Its period is shorter than the circular one, so each window
+			// of steps still spans a whole orbit including periapsis.
+			od := binary(tt.method, 2*math.Pi/steps)
+			for i := range od.Bodies {
+				od.Bodies[i].Velocity = od.Bodies[i].Velocity.Scale(0.8)
+			}
+			energy := od.TotalEnergy()
+
+			// The largest error in the first and last orbits, and the
+			// error at the end of each.
+			first, last := 0.0, 0.0
+			firstEnd := 0.0
+			for orbit := 0; orbit < orbits; orbit++ {
+				for step := 0; step < steps; step++ {
+					if err := od.Step(); err != nil {
+						t.Fatal(err)
+					}
+					drift := math.Abs((od.TotalEnergy() - energy) / energy)
+					if orbit == 0 {
+						first = math.Max(first, drift)
+					}
+					if orbit == orbits-1 {
+						last = math.Max(last, drift)
+					}
+				}
+				if orbit == 0 {
+					firstEnd = math.Abs((od.TotalEnergy() - energy) / energy)
+				}
+			}
+			lastEnd := math.Abs((od.TotalEnergy() - energy) / energy)
+
+			if tt.symplectic && last > 1.5*first {
+				t.Errorf("energy error grew from %g in the first orbit to %g in the last", first, last)
+			}
+			if !tt.symplectic && lastEnd < 4*firstEnd {
+				t.Errorf("energy error only went from %g after one orbit to %g after %d; expected secular drift", firstEnd, lastEnd, orbits)
+			}
+		})
+	}
+}
+
+func TestSofteningKeepsCoincidentBodiesFinite(t *testing.T) {
+	for _, method := range Methods {
+		t.Run(method, func(t *testing.T) {
+			od := NewOrbitalDynamics(0.01)
+			od.G = 1
+			od.Integrator = method
+			od.Softening = 0.05
+			od.AddNamedBody("a", 1, 0, Vector3D{}, Vector3D{})
+			od.AddNamedBody("b", 1, 0, Vector3D{}, Vector3D{X: 0.1})
+			od.AddNamedBody("c", 1, 0, Vector3D{X: 1e-12}, Vector3D{})
+
+			// Softened pulls are bounded by G·m/ε² however close bodies are.
+			limit := od.G * 2 / (od.Softening * od.Softening)
+			for step := 0; step < 200; step++ {
+				if err := od.Step(); err != nil {
+					t.Fatal(err)
+				}
+				for _, body := range od.Bodies {
+					for _, v := range []Vector3D{body.Position, body.Velocity, body.Force} {
+						if !finite(v) {
+							t.Fatalf("step %d: body %s is not finite: %+v", step, body.ID, body)
+						}
+					}
+					if body.Force.Norm()/body.Mass > limit {
+						t.Fatalf("step %d: body %s accelerates at %g, beyond %g", step, body.ID, body.Force.Norm()/body.Mass, limit)
+					}
+				}
+			}
+			if energy := od.TotalEnergy(); math.IsNaN(energy) || math.IsInf(energy, 0) {
+				t.Fatalf("energy is %g", energy)
+			}
+		})
+	}
+}
+
+func finite(v Vector3D) bool {
+	for _, x := range []float64{v.X, v.Y, v.Z} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResolveCollisionsConservesMassAndMomentum(t *testing.T) {
+	od := NewOrbitalDynamics(0.01)
+	od.MergeCollisions = true
+	od.Time = 3
+	// a and b overlap, b and c overlap; d is far from everything.
+	od.AddNamedBody("a", 2, 0.5, Vector3D{}, Vector3D{X: 1})
+	od.AddNamedBody("b", 1, 0.5, Vector3D{X: 0.6}, Vector3D{Y: 3, Z: -1})
+	od.AddNamedBody("c", 3, 0.5, Vector3D{X: 1.2, Y: 0.2}, Vector3D{X: -2})
+	od.AddNamedBody("d", 4, 0.5, Vector3D{X: 10}, Vector3D{Z: 1})
+
+	mass := 0.0
+	for _, body := range od.Bodies {
+		mass += body.Mass
+	}
+	momentum := od.LinearMomentum()
+	centre := Vector3D{}
+	for _, body := range od.Bodies[:3] {
+		centre = centre.Add(body.Position.Scale(body.Mass))
+	}
+	centre = centre.Scale(1.0 / 6)
+
+	od.resolveCollisions()
+
+	if len(od.Bodies) != 2 {
+		t.Fatalf("expected a, b and c to merge, leaving 2 bodies, got %d", len(od.Bodies))
+	}
+	merged, far := od.Bodies[0], od.Bodies[1]
+	if merged.ID != "a" || far.ID != "d" {
+		t.Fatalf("survivors are %s and %s, want a and d", merged.ID, far.ID)
+	}
+
+	total := merged.Mass + far.Mass
+	if math.Abs(total-mass) > 1e-12 || merged.Mass != 6 {
+		t.Errorf("mass %g (merged %g), want %g (merged 6)", total, merged.Mass, mass)
+	}
+	if drift := od.LinearMomentum().Sub(momentum).Norm(); drift > 1e-12 {
+		t.Errorf("linear momentum changed by %g", drift)
+	}
+	if offset := merged.Position.Sub(centre).Norm(); offset > 1e-12 {
+		t.Errorf("merged body at %+v, want the centre of mass %+v", merged.Position, centre)
+	}
+	if want := math.Cbrt(3 * 0.125); math.Abs(merged.Radius-want) > 1e-12 {
+		t.Errorf("merged radius %g, want %g for the summed volume", merged.Radius, want)
+	}
+
+	if len(od.Collisions) != 2 {
+		t.Fatalf("recorded %d collisions, want 2", len(od.Collisions))
+	}
+	for _, collision := range od.Collisions {
+		if collision.Survivor != "a" || collision.Time != 3 {
+			t.Errorf("unexpected collision %+v", collision)
+		}
+	}
+}
+
+func TestStepMergesCollidingBodies(t *testing.T) {
+	for _, method := range Methods {
+		t.Run(method, func(t *testing.T) {
+			// Two bodies falling head-on into each other.
+			od := NewOrbitalDynamics(0.01)
+			od.G = 1
+			od.Integrator = method
+			od.MergeCollisions = true
+			od.AddNamedBody("a", 1, 0.1, Vector3D{X: -1}, Vector3D{X: 1})
+			od.AddNamedBody("b", 2, 0.1, Vector3D{X: 1}, Vector3D{X: -0.5, Y: 0.25})
+			momentum := od.LinearMomentum()
+
+			for step := 0; step < 200 && len(od.Bodies) > 1; step++ {
+				if err := od.Step(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(od.Bodies) != 1 {
+				t.Fatalf("bodies did not merge")
+			}
+			if od.Bodies[0].Mass != 3 {
+				t.Errorf("merged mass %g, want 3", od.Bodies[0].Mass)
+			}
+			if drift := od.LinearMomentum().Sub(momentum).Norm(); drift > 1e-9 {
+				t.Errorf("linear momentum changed by %g", drift)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package dynamics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/parallel"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+// evolve runs a hierarchy large enough to be sharded for steps steps and
+// returns its bodies.
+func evolve(t *testing.T, pool *parallel.Pool, integrator, solver string, adaptive bool) []CelestialBody {
+	t.Helper()
+	od := NewHierarchicalSystem(1, 6, 300, 1, 0.01, random.NewSource(3))
+	od.Integrator = integrator
+	od.MergeCollisions = true
+	od.Pool = pool
+	forces, err := NewForceSolver(solver, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	od.Forces = forces
+
+	stepper := NewAdaptiveStepper(1e-9, 1e-9, 1e-8, 0.05)
+	for step := 0; step < 15; step++ {
+		if adaptive {
+			_, _, err = stepper.Step(od, 0.01)
+		} else {
+			err = od.Step()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return od.Bodies
+}
+
+func TestParallelRunsMatchSerial(t *testing.T) {
+	cases := []struct {
+		integrator, solver string
+		adaptive           bool
+	}{
+		{MethodVerlet, SolverDirect, false},
+		{MethodRK4, SolverDirect, false},
+		{MethodYoshida, SolverTree, false},
+		{MethodVerlet, SolverDirect, true},
+	}
+	for _, tc := range cases {
+		name := fmt.Sprintf("%s/%s", tc.integrator, tc.solver)
+		if tc.adaptive {
+			name = "adaptive/" + tc.solver
+		}
+		t.Run(name, func(t *testing.T) {
+			serial := evolve(t, nil, tc.integrator, tc.solver, tc.adaptive)
+			for _, workers := range []int{1, 4, 7} {
+				bodies := evolve(t, parallel.NewPool(workers), tc.integrator, tc.solver, tc.adaptive)
+				if len(bodies) != len(serial) {
+					t.Fatalf("%d workers: %d bodies, serial run has %d", workers, len(bodies), len(serial))
+				}
+				for i, body := range bodies {
+					if body.ID != serial[i].ID || body.Position != serial[i].Position || body.Velocity != serial[i].Velocity {
+						t.Fatalf("%d workers: body %s at %v moving %v, serial run has %s at %v moving %v",
+							workers, body.ID, body.Position, body.Velocity, serial[i].ID, serial[i].Position, serial[i].Velocity)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-file/serialization"
+)
+
+// Checkpointer is implemented by systems whose state must survive a
+// checkpoint. Restore is called after Init, so it only has to overwrite the
+// state that Init derived from configuration.
+type Checkpointer interface {
+	Snapshot() (json.RawMessage, error)
+	Restore(data json.RawMessage) error
+}
+
+// Checkpoint is everything needed to continue a run exactly where it
+// stopped. Floats are written in shortest round-trip form, so a resumed run
+// is bit-for-bit identical to an uninterrupted one, including a finished
+// run extended to a later MaxTime.
+type Checkpoint struct {
+	Time      float64                    `json:"time"`
+	StepCount int                        `json:"step_count"`
+	TimeStep  float64                    `json:"time_step"`
+	Random    *random.Source             `json:"random,omitempty"`
+	Phases    map[string]float64         `json:"phases"`
+	Entropy   float64                    `json:"entropy"`
+	Scalars   map[string]float64         `json:"scalars"`
+	Systems   map[string]json.RawMessage `json:"systems"`
+	// Events is the bus's bounded History and EventCounts its counts by
+	// type, so a checkpoint's size does not grow with the run's length.
+	Events      []Event        `json:"events"`
+	EventCounts map[string]int `json:"event_counts"`
+}
+
+// Checkpoint captures the current state of the core and every system. It
+// shares nothing with the core, so it stays valid as the run continues.
+func (sc *SimulationCore) Checkpoint() (*Checkpoint, error) {
+	cp := &Checkpoint{
+		Time:        sc.CurrentTime,
+		StepCount:   sc.StepCount,
+		TimeStep:    sc.TimeStep,
+		Phases:      make(map[string]float64, len(sc.State.Phases)),
+		Entropy:     sc.State.Entropy,
+		Scalars:     make(map[string]float64, len(sc.State.Scalars)),
+		Systems:     make(map[string]json.RawMessage),
+		Events:      append([]Event{}, sc.Events.History...),
+		EventCounts: make(map[string]int, len(sc.Events.counts)),
+	}
+	for eventType, count := range sc.Events.counts {
+		cp.EventCounts[eventType] = count
+	}
+	for id, phase := range sc.State.Phases {
+		cp.Phases[id] = phase
+	}
+	for name, value := range sc.State.Scalars {
+		cp.Scalars[name] = value
+	}
+
+	if sc.State.Random != nil {
+		source := *sc.State.Random
+		cp.Random = &source
+	}
+
+	for _, system := range sc.Systems {
+		checkpointer, ok := system.(Checkpointer)
+		if !ok {
+			continue
+		}
+		data, err := checkpointer.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", system.Name(), err)
+		}
+		cp.Systems[system.Name()] = data
+	}
+
+	return cp, nil
+}
+
+func (sc *SimulationCore) SaveCheckpoint(filename string) error {
+	cp, err := sc.Checkpoint()
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(cp, filename)
+}
+
+func writeCheckpoint(cp *Checkpoint, filename string) error {
+	serializer := serialization.NewElderSerializer("json")
+	serializer.SetMetadata("systems", len(cp.Systems))
+	return serializer.SerializeToFile(cp, filename)
+}
+
+func LoadCheckpoint(filename string) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+	if err := serialization.NewElderSerializer("json").DeserializeFileInto(filename, cp); err != nil {
+		return nil, fmt.Errorf("load checkpoint %s: %w", filename, err)
+	}
+	return cp, nil
+}
+
+// restore applies cp to the core after the systems have been initialised.
+// The checkpoint must cover exactly the registered checkpointable systems.
+func (sc *SimulationCore) restore(cp *Checkpoint) error {
+	for _, system := range sc.Systems {
+		checkpointer, ok := system.(Checkpointer)
+		if !ok {
+			continue
+		}
+		data, exists := cp.Systems[system.Name()]
+		if !exists {
+			return fmt.Errorf("checkpoint has no state for system %q", system.Name())
+		}
+		if err := checkpointer.Restore(data); err != nil {
+			return fmt.Errorf("restore %s: %w", system.Name(), err)
+		}
+	}
+
+	for name := range cp.Systems {
+		if sc.System(name) == nil {
+			return fmt.Errorf("checkpoint has state for unregistered system %q", name)
+		}
+	}
+
+	sc.CurrentTime = cp.Time
+	sc.StepCount = cp.StepCount
+	sc.TimeStep = cp.TimeStep
+	sc.State.Time = cp.Time
+	sc.State.StepCount = cp.StepCount
+	sc.State.Entropy = cp.Entropy
+	if cp.Phases != nil {
+		sc.State.Phases = cp.Phases
+	}
+	if cp.Scalars != nil {
+		sc.State.Scalars = cp.Scalars
+	}
+	if cp.Random != nil {
+		source := *cp.Random
+		sc.State.Random = &source
+	}
+	if cp.Events != nil {
+		sc.Events.History = cp.Events
+	}
+	sc.Events.counts = make(map[string]int)
+	for eventType, count := range cp.EventCounts {
+		sc.Events.counts[eventType] = count
+	}
+	if cp.EventCounts == nil {
+		// Checkpoints from before counts were kept hold every event.
+		for _, event := range cp.Events {
+			sc.Events.counts[event.Type]++
+		}
+	}
+	return nil
+}
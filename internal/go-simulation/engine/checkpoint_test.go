@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
+
+// testCore builds an orbital run that records every step to a CSV
+// trajectory in dir and checkpoints every CheckpointEvery steps.
+func testCore(t *testing.T, dir string, maxTime float64, adaptive, resume bool) *SimulationCore {
+	t.Helper()
+	const timeStep = 0.01
+
+	od := dynamics.NewHierarchicalSystem(1, 2, 4, 1, timeStep, random.NewSource(7))
+	core := NewSimulationCore(timeStep, maxTime)
+	core.State.Random = random.NewSource(7)
+	core.CheckpointEvery = 7
+	core.CheckpointPath = filepath.Join(dir, "checkpoint.json")
+
+	orbital := NewOrbitalSystem(od)
+	if adaptive {
+		orbital.Stepper = dynamics.NewAdaptiveStepper(1e-8, 1e-8, 1e-8, 0.05)
+	}
+	recorder := NewTrajectorySystem(od, filepath.Join(dir, "trajectory.csv"), trajectory.FormatCSV,
+		[]string{trajectory.FieldPositions, trajectory.FieldVelocities, trajectory.FieldEnergy})
+	recorder.Resume = resume
+	for _, system := range []System{orbital, recorder} {
+		if err := core.AddSystem(system); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return core
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func resume(t *testing.T, dir string, maxTime float64, adaptive bool) {
+	t.Helper()
+	cp, err := LoadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testCore(t, dir, maxTime, adaptive, true).Resume(cp); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+}
+
+func TestResumeMatchesUninterruptedRun(t *testing.T) {
+	for _, adaptive := range []bool{false, true} {
+		name := "fixed"
+		if adaptive {
+			name = "adaptive"
+		}
+		t.Run(name, func(t *testing.T) {
+			reference := t.TempDir()
+			if err := testCore(t, reference, 2, adaptive, false).Start(); err != nil {
+				t.Fatal(err)
+			}
+			want := readFile(t, filepath.Join(reference, "trajectory.csv"))
+
+			t.Run("stopped", func(t *testing.T) {
+				dir := t.TempDir()
+				core := testCore(t, dir, 2, adaptive, false)
+				core.OnStep = func(core *SimulationCore) {
+					if core.StepCount == 53 {
+						core.Stop()
+					}
+				}
+				if err := core.Start(); err != nil {
+					t.Fatal(err)
+				}
+				resume(t, dir, 2, adaptive)
+				if got := readFile(t, filepath.Join(dir, "trajectory.csv")); !bytes.Equal(got, want) {
+					t.Error("trajectory of a stopped and resumed run differs from the uninterrupted run")
+				}
+			})
+
+			t.Run("extended", func(t *testing.T) {
+				dir := t.TempDir()
+				if err := testCore(t, dir, 1, adaptive, false).Start(); err != nil {
+					t.Fatal(err)
+				}
+				resume(t, dir, 2, adaptive)
+				if got := readFile(t, filepath.Join(dir, "trajectory.csv")); !bytes.Equal(got, want) {
+					t.Error("trajectory of a finished run extended by resuming differs from the uninterrupted run")
+				}
+			})
+		})
+	}
+}
+
+func TestResumeFinishedRunAtSameTime(t *testing.T) {
+	dir := t.TempDir()
+	if err := testCore(t, dir, 1, false, false).Start(); err != nil {
+		t.Fatal(err)
+	}
+	want := readFile(t, filepath.Join(dir, "trajectory.csv"))
+
+	resume(t, dir, 1, false)
+	if got := readFile(t, filepath.Join(dir, "trajectory.csv")); !bytes.Equal(got, want) {
+		t.Error("resuming a finished run without extending it changed its trajectory")
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import "sort"
+
+// Event types published by the built-in systems.
+const (
+	EventOrbitDestabilised = "orbit.destabilised"
+	EventBodiesMerged      = "bodies.merged"
+	EventResonanceLocked   = "resonance.locked"
+	EventResonanceUnlocked = "resonance.unlocked"
+
+	EventConservationViolated = "conservation.violated"
+)
+
+type Event struct {
+	Type   string
+	Time   float64
+	Source string
+	Data   map[string]float64
+}
+
+type EventHandler func(Event)
+
+// DefaultHistoryLimit is how many recorded events an EventBus keeps.
+const DefaultHistoryLimit = 1000
+
+// EventBus queues events published during a step and delivers them once the
+// step has finished, so every system observes the same state regardless of
+// registration order. Handlers run in subscription order; handlers for "*"
+// receive every event after the type-specific ones.
+//
+// With Record set, delivered events are counted by type and the most recent
+// HistoryLimit of them are kept in History, so long runs use bounded memory.
+type EventBus struct {
+	handlers     map[string][]EventHandler
+	pending      []Event
+	History      []Event
+	HistoryLimit int
+	Record       bool
+	counts       map[string]int
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers:     make(map[string][]EventHandler),
+		pending:      make([]Event, 0),
+		History:      make([]Event, 0),
+		HistoryLimit: DefaultHistoryLimit,
+		counts:       make(map[string]int),
+	}
+}
+
+func (eb *EventBus) Subscribe(eventType string, handler EventHandler) {
+	eb.handlers[eventType] = append(eb.handlers[eventType], handler)
+}
+
+func (eb *EventBus) Publish(event Event) {
+	eb.pending = append(eb.pending, event)
+}
+
+// Dispatch delivers queued events in publication order. Events published by
+// handlers are delivered in the same call.
+func (eb *EventBus) Dispatch() int {
+	delivered := 0
+	for len(eb.pending) > 0 {
+		event := eb.pending[0]
+		eb.pending = eb.pending[1:]
+
+		for _, handler := range eb.handlers[event.Type] {
+			handler(event)
+		}
+		for _, handler := range eb.handlers["*"] {
+			handler(event)
+		}
+		if eb.Record {
+			eb.record(event)
+		}
+		delivered++
+	}
+	return delivered
+}
+
+func (eb *EventBus) record(event Event) {
+	eb.counts[event.Type]++
+	eb.History = append(eb.History, event)
+	if eb.HistoryLimit > 0 && len(eb.History) > eb.HistoryLimit {
+		eb.History = eb.History[len(eb.History)-eb.HistoryLimit:]
+	}
+}
+
+// Count returns how many events of the given type have been recorded,
+// including those dropped from History.
+func (eb *EventBus) Count(eventType string) int {
+	return eb.counts[eventType]
+}
+
+// Types returns the distinct recorded event types in sorted order.
+func (eb *EventBus) Types() []string {
+	types := make([]string, 0, len(eb.counts))
+	for eventType := range eb.counts {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
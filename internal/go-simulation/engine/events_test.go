@@ -0,0 +1,58 @@
+package engine
+
+import "testing"
+
+func TestEventHistoryIsBounded(t *testing.T) {
+	bus := NewEventBus()
+	bus.Record = true
+	bus.HistoryLimit = 10
+	for i := 0; i < 25; i++ {
+		bus.Publish(Event{Type: EventBodiesMerged, Time: float64(i)})
+		if i%5 == 0 {
+			bus.Publish(Event{Type: EventResonanceLocked, Time: float64(i)})
+		}
+		bus.Dispatch()
+	}
+
+	if len(bus.History) != 10 {
+		t.Fatalf("history holds %d events, want 10", len(bus.History))
+	}
+	if last := bus.History[len(bus.History)-1]; last.Type != EventBodiesMerged || last.Time != 24 {
+		t.Errorf("last event = %+v, want the merge at t=24", last)
+	}
+	if got := bus.Count(EventBodiesMerged); got != 25 {
+		t.Errorf("merges counted = %d, want 25", got)
+	}
+	if got := bus.Count(EventResonanceLocked); got != 5 {
+		t.Errorf("locks counted = %d, want 5", got)
+	}
+	if types := bus.Types(); len(types) != 2 || types[0] != EventBodiesMerged || types[1] != EventResonanceLocked {
+		t.Errorf("types = %v", types)
+	}
+}
+
+func TestCheckpointKeepsEventCounts(t *testing.T) {
+	core := NewSimulationCore(0.1, 1)
+	core.Events.Record = true
+	core.Events.HistoryLimit = 3
+	for i := 0; i < 8; i++ {
+		core.Events.Publish(Event{Type: EventOrbitDestabilised, Time: float64(i)})
+	}
+	core.Events.Dispatch()
+
+	cp, err := core.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Events) != 3 {
+		t.Errorf("checkpoint holds %d events, want 3", len(cp.Events))
+	}
+
+	resumed := NewSimulationCore(0.1, 1)
+	if err := resumed.restore(cp); err != nil {
+		t.Fatal(err)
+	}
+	if got := resumed.Events.Count(EventOrbitDestabilised); got != 8 {
+		t.Errorf("restored count = %d, want 8", got)
+	}
+}
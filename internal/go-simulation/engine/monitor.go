@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-linters/physical"
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+)
+
+const (
+	ConservationMonitorName = "conservation"
+
+	ScalarEnergyDrift          = "conservation.energy_drift"
+	ScalarAngularMomentumDrift = "conservation.angular_momentum_drift"
+)
+
+// ConservationMonitor samples the total energy and angular momentum of the
+// orbital dynamics every Every steps and writes their drift, relative to the
+// values at the start of the run, to ScalarEnergyDrift and
+// ScalarAngularMomentumDrift. The scalars are removed on steps that are not
+// sampled, so they never hold a stale drift. Checker measures the drift,
+// and its EnergyTolerance and AngularTolerance bound the relative drift
+// rather than the absolute change. Violations counts the samples where
+// either is exceeded; the first one publishes EventConservationViolated and,
+// with Abort set, stops the run. Merging bodies dissipates energy, so the
+// reference values are reset, and violations reported afresh, after every
+// merge. Register it after the OrbitalSystem and before the
+// TrajectorySystem that records the drift.
+type ConservationMonitor struct {
+	Dynamics                *dynamics.OrbitalDynamics
+	Checker                 *physical.ConservationChecker
+	Every                   int
+	Abort                   bool
+	Samples                 int
+	Violations              int
+	MaxEnergyDrift          float64
+	MaxAngularMomentumDrift float64
+	energy                  float64
+	angularMomentum         dynamics.Vector3D
+	reported                bool
+	events                  *EventBus
+}
+
+// NewConservationMonitor checks both drifts against tolerance.
+func NewConservationMonitor(od *dynamics.OrbitalDynamics, every int, tolerance float64) *ConservationMonitor {
+	checker := physical.NewConservationChecker()
+	checker.EnergyTolerance = tolerance
+	checker.AngularTolerance = tolerance
+	return &ConservationMonitor{
+		Dynamics: od,
+		Checker:  checker,
+		Every:    every,
+	}
+}
+
+func (cm *ConservationMonitor) Name() string {
+	return ConservationMonitorName
+}
+
+func (cm *ConservationMonitor) Init(state *State, events *EventBus) error {
+	if cm.Dynamics == nil {
+		return fmt.Errorf("no orbital dynamics to monitor")
+	}
+
+	cm.events = events
+	cm.rebase()
+	events.Subscribe(EventBodiesMerged, func(Event) {
+		cm.rebase()
+	})
+	cm.publish(state, 0, 0)
+	return nil
+}
+
+func (cm *ConservationMonitor) Step(state *State, dt float64) error {
+	every := cm.Every
+	if every <= 0 {
+		every = 1
+	}
+	if (state.StepCount+1)%every != 0 {
+		delete(state.Scalars, ScalarEnergyDrift)
+		delete(state.Scalars, ScalarAngularMomentumDrift)
+		return nil
+	}
+	return cm.sample(state, state.Time+dt)
+}
+
+func (cm *ConservationMonitor) Finalize(state *State) error {
+	return nil
+}
+
+// Drift returns the current relative energy and angular momentum drift.
+func (cm *ConservationMonitor) Drift() (float64, float64) {
+	return cm.Checker.EnergyDrift(cm.energy, cm.Dynamics.TotalEnergy()),
+		cm.Checker.AngularMomentumDrift(components(cm.angularMomentum), components(cm.Dynamics.AngularMomentum()))
+}
+
+func (cm *ConservationMonitor) sample(state *State, time float64) error {
+	energyDrift, angularDrift := cm.Drift()
+	cm.Samples++
+	cm.MaxEnergyDrift = math.Max(cm.MaxEnergyDrift, energyDrift)
+	cm.MaxAngularMomentumDrift = math.Max(cm.MaxAngularMomentumDrift, angularDrift)
+	cm.publish(state, energyDrift, angularDrift)
+
+	if energyDrift <= cm.Checker.EnergyTolerance && angularDrift <= cm.Checker.AngularTolerance {
+		return nil
+	}
+
+	cm.Violations++
+	if !cm.reported {
+		cm.reported = true
+		cm.events.Publish(Event{
+			Type:   EventConservationViolated,
+			Time:   time,
+			Source: ConservationMonitorName,
+			Data: map[string]float64{
+				"energy_drift":               energyDrift,
+				"angular_momentum_drift":     angularDrift,
+				"energy_tolerance":           cm.Checker.EnergyTolerance,
+				"angular_momentum_tolerance": cm.Checker.AngularTolerance,
+			},
+		})
+	}
+
+	if cm.Abort {
+		return fmt.Errorf("drift exceeds tolerance: energy %.3e (tolerance %g), angular momentum %.3e (tolerance %g)",
+			energyDrift, cm.Checker.EnergyTolerance, angularDrift, cm.Checker.AngularTolerance)
+	}
+	return nil
+}
+
+func (cm *ConservationMonitor) rebase() {
+	cm.energy = cm.Dynamics.TotalEnergy()
+	cm.angularMomentum = cm.Dynamics.AngularMomentum()
+	cm.reported = false
+}
+
+func components(v dynamics.Vector3D) []float64 {
+	return []float64{v.X, v.Y, v.Z}
+}
+
+func (cm *ConservationMonitor) publish(state *State, energyDrift, angularDrift float64) {
+	state.Scalars[ScalarEnergyDrift] = energyDrift
+	state.Scalars[ScalarAngularMomentumDrift] = angularDrift
+}
+
+type conservationSnapshot struct {
+	Energy                  float64           `json:"energy"`
+	AngularMomentum         dynamics.Vector3D `json:"angular_momentum"`
+	Reported                bool              `json:"reported"`
+	Samples                 int               `json:"samples"`
+	Violations              int               `json:"violations"`
+	MaxEnergyDrift          float64           `json:"max_energy_drift"`
+	MaxAngularMomentumDrift float64           `json:"max_angular_momentum_drift"`
+}
+
+func (cm *ConservationMonitor) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(conservationSnapshot{
+		Energy:                  cm.energy,
+		AngularMomentum:         cm.angularMomentum,
+		Reported:                cm.reported,
+		Samples:                 cm.Samples,
+		Violations:              cm.Violations,
+		MaxEnergyDrift:          cm.MaxEnergyDrift,
+		MaxAngularMomentumDrift: cm.MaxAngularMomentumDrift,
+	})
+}
+
+func (cm *ConservationMonitor) Restore(data json.RawMessage) error {
+	var snapshot conservationSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	cm.energy = snapshot.Energy
+	cm.angularMomentum = snapshot.AngularMomentum
+	cm.reported = snapshot.Reported
+	cm.Samples = snapshot.Samples
+	cm.Violations = snapshot.Violations
+	cm.MaxEnergyDrift = snapshot.MaxEnergyDrift
+	cm.MaxAngularMomentumDrift = snapshot.MaxAngularMomentumDrift
+	return nil
+}
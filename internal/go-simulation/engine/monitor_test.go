@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
+
+// monitoredCore runs for 20 steps, sampling conservation every third step
+// and recording every step to a trajectory in format.
+func monitoredCore(t *testing.T, path, format string, tolerance float64) (*SimulationCore, *ConservationMonitor) {
+	t.Helper()
+	const timeStep = 0.01
+
+	od := dynamics.NewHierarchicalSystem(1, 2, 4, 1, timeStep, random.NewSource(7))
+	core := NewSimulationCore(timeStep, 20*timeStep)
+	core.Events.Record = true
+	monitor := NewConservationMonitor(od, 3, tolerance)
+	recorder := NewTrajectorySystem(od, path, format, []string{trajectory.FieldEnergy, trajectory.FieldDrift})
+	for _, system := range []System{NewOrbitalSystem(od), monitor, recorder} {
+		if err := core.AddSystem(system); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return core, monitor
+}
+
+func TestDriftIsRecordedOnlyOnSampledSteps(t *testing.T) {
+	for _, format := range []string{trajectory.FormatCSV, trajectory.FormatJSONL, trajectory.FormatBinary} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "trajectory."+format)
+			core, monitor := monitoredCore(t, path, format, 1)
+			if err := core.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := trajectory.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			sampled := 0
+			for step := 0; ; step++ {
+				frame, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// The start of the run is sampled as zero drift.
+				want := step%3 == 0
+				if got := !math.IsNaN(frame.Drift.Energy); got != want {
+					t.Errorf("step %d: drift %v recorded, want sampled = %t", step, frame.Drift, want)
+				}
+				if !math.IsNaN(frame.Drift.Energy) && step > 0 {
+					sampled++
+				}
+			}
+			if sampled != monitor.Samples {
+				t.Errorf("trajectory holds %d samples, monitor took %d", sampled, monitor.Samples)
+			}
+		})
+	}
+}
+
+func TestViolationsAreCheckedAgainstTolerance(t *testing.T) {
+	dir := t.TempDir()
+	core, monitor := monitoredCore(t, filepath.Join(dir, "trajectory.csv"), trajectory.FormatCSV, 0)
+	if err := core.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if monitor.Violations != monitor.Samples || core.Events.Count(EventConservationViolated) != 1 {
+		t.Errorf("zero tolerance gave %d violations in %d samples and %d events, want every sample and one event",
+			monitor.Violations, monitor.Samples, core.Events.Count(EventConservationViolated))
+	}
+
+	core, monitor = monitoredCore(t, filepath.Join(dir, "abort.csv"), trajectory.FormatCSV, 0)
+	monitor.Abort = true
+	if err := core.Start(); err == nil || core.StepCount != 2 {
+		t.Errorf("abort stopped at step %d with error %v, want step 2 with an error", core.StepCount, err)
+	}
+
+	core, monitor = monitoredCore(t, filepath.Join(dir, "loose.csv"), trajectory.FormatCSV, 1)
+	if err := core.Start(); err != nil || monitor.Violations != 0 {
+		t.Errorf("tolerance 1 gave %d violations and error %v", monitor.Violations, err)
+	}
+}
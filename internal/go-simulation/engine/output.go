@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
+
+const TrajectorySystemName = "trajectory"
+
+// TrajectorySystem streams a frame of the orbital state to a trajectory file
+// at the start and then every Frequency steps. It should be registered after
+// the systems whose state it records. Checkpoints record how much of the file
+// is complete; on resume everything after that point is discarded so the
+// file matches an uninterrupted run. The drift field records the sample a
+// ConservationMonitor took at the same step, and NaN on steps it did not
+// sample.
+type TrajectorySystem struct {
+	Path      string
+	Format    string
+	Fields    []string
+	Frequency int
+	Compress  bool
+	Resume    bool
+	Dynamics  *dynamics.OrbitalDynamics
+	writer    *trajectory.Writer
+	header    trajectory.Header
+	index     map[string]int
+	frame     *trajectory.Frame
+}
+
+func NewTrajectorySystem(od *dynamics.OrbitalDynamics, path, format string, fields []string) *TrajectorySystem {
+	return &TrajectorySystem{
+		Path:      path,
+		Format:    format,
+		Fields:    fields,
+		Frequency: 1,
+		Dynamics:  od,
+	}
+}
+
+func (ts *TrajectorySystem) Name() string {
+	return TrajectorySystemName
+}
+
+func (ts *TrajectorySystem) Init(state *State, events *EventBus) error {
+	bodies := make([]string, len(ts.Dynamics.Bodies))
+	ts.index = make(map[string]int, len(bodies))
+	for i, body := range ts.Dynamics.Bodies {
+		bodies[i] = body.ID
+		ts.index[body.ID] = i
+	}
+
+	header, err := trajectory.NewHeader(bodies, ts.Fields)
+	if err != nil {
+		return err
+	}
+	ts.header = header
+	ts.frame = header.NewFrame()
+
+	if ts.Resume {
+		// The writer is reopened at the checkpointed offset by Restore.
+		return nil
+	}
+
+	ts.writer, err = trajectory.Create(ts.Path, ts.Format, header, ts.Compress)
+	if err != nil {
+		return err
+	}
+	return ts.record(state, state.Time)
+}
+
+func (ts *TrajectorySystem) Step(state *State, dt float64) error {
+	if ts.writer == nil {
+		return fmt.Errorf("trajectory %s is not open", ts.Path)
+	}
+
+	// The core advances State.Time and StepCount once every system has
+	// stepped, so this step ends at state.Time+dt.
+	frequency := ts.Frequency
+	if frequency <= 0 {
+		frequency = 1
+	}
+	if (state.StepCount+1)%frequency != 0 {
+		return nil
+	}
+	return ts.record(state, state.Time+dt)
+}
+
+func (ts *TrajectorySystem) Finalize(state *State) error {
+	if ts.writer == nil {
+		return nil
+	}
+	err := ts.writer.Close()
+	ts.writer = nil
+	return err
+}
+
+func (ts *TrajectorySystem) record(state *State, time float64) error {
+	frame := ts.frame
+	frame.Time = time
+
+	for i := range frame.Positions {
+		frame.Positions[i] = [3]float64{math.NaN(), math.NaN(), math.NaN()}
+	}
+	for i := range frame.Velocities {
+		frame.Velocities[i] = [3]float64{math.NaN(), math.NaN(), math.NaN()}
+	}
+	for _, body := range ts.Dynamics.Bodies {
+		i, known := ts.index[body.ID]
+		if !known {
+			continue
+		}
+		if frame.Positions != nil {
+			frame.Positions[i] = [3]float64{body.Position.X, body.Position.Y, body.Position.Z}
+		}
+		if frame.Velocities != nil {
+			frame.Velocities[i] = [3]float64{body.Velocity.X, body.Velocity.Y, body.Velocity.Z}
+		}
+	}
+
+	if ts.header.Has(trajectory.FieldEnergy) {
+		frame.Energy = ts.Dynamics.TotalEnergy()
+	}
+	if ts.header.Has(trajectory.FieldAngularMomentum) {
+		l := ts.Dynamics.AngularMomentum()
+		frame.AngularMomentum = [3]float64{l.X, l.Y, l.Z}
+	}
+	if ts.header.Has(trajectory.FieldDrift) {
+		frame.Drift = trajectory.Drift{Energy: math.NaN(), AngularMomentum: math.NaN()}
+		if drift, sampled := state.Scalars[ScalarEnergyDrift]; sampled {
+			frame.Drift.Energy = drift
+		}
+		if drift, sampled := state.Scalars[ScalarAngularMomentumDrift]; sampled {
+			frame.Drift.AngularMomentum = drift
+		}
+	}
+
+	return ts.writer.WriteFrame(frame)
+}
+
+type trajectorySnapshot struct {
+	Offset int64 `json:"offset"`
+	Frames int   `json:"frames"`
+}
+
+func (ts *TrajectorySystem) Snapshot() (json.RawMessage, error) {
+	if ts.writer == nil {
+		return nil, fmt.Errorf("trajectory %s is not open", ts.Path)
+	}
+	offset, err := ts.writer.Sync()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(trajectorySnapshot{Offset: offset, Frames: ts.writer.Frames})
+}
+
+func (ts *TrajectorySystem) Restore(data json.RawMessage) error {
+	var snapshot trajectorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	if ts.writer != nil {
+		ts.writer.Close()
+	}
+	writer, err := trajectory.Append(ts.Path, ts.Format, ts.header, ts.Compress, snapshot.Offset)
+	if err != nil {
+		return err
+	}
+	writer.Frames = snapshot.Frames
+	ts.writer = writer
+	return nil
+}
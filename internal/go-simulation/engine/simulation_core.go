@@ -1,37 +1,220 @@
 package engine
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type SimulationCore struct {
 	TimeStep    float64
 	CurrentTime float64
 	MaxTime     float64
-	State       map[string]interface{}
+	State       *State
+	Systems     []System
+	Events      *EventBus
 	Running     bool
+	Realtime    bool
+	StepCount   int
+	OnStep      func(*SimulationCore)
+
+	// CheckpointEvery, when positive, writes a checkpoint to CheckpointPath
+	// every CheckpointEvery steps.
+	CheckpointEvery int
+	CheckpointPath  string
 }
 
 func NewSimulationCore(timeStep, maxTime float64) *SimulationCore {
 	return &SimulationCore{
 		TimeStep:    timeStep,
 		MaxTime:     maxTime,
-		State:       make(map[string]interface{}),
+		State:       NewState(),
+		Systems:     make([]System, 0),
+		Events:      NewEventBus(),
 		Running:     false,
 	}
 }
 
-func (sc *SimulationCore) Start() {
-	sc.Running = true
+// AddSystem registers a system. Systems run in registration order, so a
+// system that produces data others consume should be added first.
+func (sc *SimulationCore) AddSystem(system System) error {
+	for _, existing := range sc.Systems {
+		if existing.Name() == system.Name() {
+			return fmt.Errorf("system %q already registered", system.Name())
+		}
+	}
+	sc.Systems = append(sc.Systems, system)
+	return nil
+}
+
+// System returns the registered system with the given name.
+func (sc *SimulationCore) System(name string) System {
+	for _, system := range sc.Systems {
+		if system.Name() == name {
+			return system
+		}
+	}
+	return nil
+}
+
+// Start runs the simulation from t=0 until MaxTime or Stop. Adaptive
+// systems may shorten the step and choose the next one; otherwise TimeStep
+// is fixed. Events published during a step are dispatched once every system
+// has stepped. Steps are only paced against the wall clock in Realtime mode.
+func (sc *SimulationCore) Start() error {
 	sc.CurrentTime = 0.0
-	
+	sc.StepCount = 0
+	sc.State.Time = 0.0
+	sc.State.StepCount = 0
+	return sc.run(nil)
+}
+
+// Resume continues a run from a checkpoint. The systems must be registered
+// and configured as they were when the checkpoint was written.
+func (sc *SimulationCore) Resume(cp *Checkpoint) error {
+	return sc.run(cp)
+}
+
+func (sc *SimulationCore) run(cp *Checkpoint) (err error) {
+	if sc.TimeStep <= 0 {
+		return fmt.Errorf("time step must be positive, got %g", sc.TimeStep)
+	}
+
+	sc.Running = true
+	defer func() { sc.Running = false }()
+
+	for _, system := range sc.Systems {
+		if err := system.Init(sc.State, sc.Events); err != nil {
+			return fmt.Errorf("init %s: %w", system.Name(), err)
+		}
+	}
+	if cp != nil {
+		// Events raised by Init describe the checkpointed state, which
+		// were already delivered before the checkpoint was written.
+		sc.Events.pending = sc.Events.pending[:0]
+		if err := sc.restore(cp); err != nil {
+			return err
+		}
+	}
+	sc.Events.Dispatch()
+
+	defer func() {
+		if finalizeErr := sc.finalize(); err == nil {
+			err = finalizeErr
+		}
+	}()
+
+	// final is the checkpoint taken before the step that ends the run.
+	var final *Checkpoint
 	for sc.CurrentTime < sc.MaxTime && sc.Running {
-		sc.step()
-		sc.CurrentTime += sc.TimeStep
-		time.Sleep(time.Duration(sc.TimeStep*1000) * time.Millisecond)
+		// The final step absorbs round-off in the accumulated time so the
+		// run neither overshoots MaxTime nor ends with a sliver of a step.
+		dt := sc.TimeStep
+		remaining := sc.MaxTime - sc.CurrentTime
+		last := remaining <= dt*(1+1e-4)
+		if last {
+			dt = remaining
+			if sc.CheckpointEvery > 0 {
+				if final, err = sc.Checkpoint(); err != nil {
+					return fmt.Errorf("checkpoint at step %d: %w", sc.StepCount, err)
+				}
+			}
+		}
+
+		taken, err := sc.step(dt)
+		if err != nil {
+			return err
+		}
+
+		finished := last && taken == dt
+		sc.CurrentTime += taken
+		if finished {
+			sc.CurrentTime = sc.MaxTime
+		} else {
+			final = nil
+		}
+		sc.StepCount++
+		sc.State.Time = sc.CurrentTime
+		sc.State.StepCount = sc.StepCount
+
+		sc.Events.Dispatch()
+
+		if sc.OnStep != nil {
+			sc.OnStep(sc)
+		}
+
+		if !finished && sc.CheckpointEvery > 0 && sc.StepCount%sc.CheckpointEvery == 0 {
+			if err := sc.SaveCheckpoint(sc.CheckpointPath); err != nil {
+				return fmt.Errorf("checkpoint at step %d: %w", sc.StepCount, err)
+			}
+		}
+
+		if sc.Realtime {
+			time.Sleep(time.Duration(taken * float64(time.Second)))
+		}
+	}
+
+	// Always leave a checkpoint so a finished or stopped run can be
+	// extended. The step that lands on MaxTime is shortened and its time
+	// snapped, which a longer run would not do, so a finished run is
+	// checkpointed before that step and extending it takes the step again
+	// at full length.
+	if final != nil {
+		if err := writeCheckpoint(final, sc.CheckpointPath); err != nil {
+			return fmt.Errorf("checkpoint at step %d: %w", final.StepCount, err)
+		}
+	} else if sc.CheckpointEvery > 0 && sc.StepCount%sc.CheckpointEvery != 0 {
+		if err := sc.SaveCheckpoint(sc.CheckpointPath); err != nil {
+			return fmt.Errorf("checkpoint at step %d: %w", sc.StepCount, err)
+		}
 	}
+
+	return nil
 }
 
-func (sc *SimulationCore) step() {
-	// Simulation step implementation
+func (sc *SimulationCore) step(dt float64) (float64, error) {
+	taken := dt
+	stepped := ""
+
+	for _, system := range sc.Systems {
+		adaptive, ok := system.(AdaptiveSystem)
+		if !ok {
+			if err := system.Step(sc.State, taken); err != nil {
+				return 0, fmt.Errorf("%s: %w", system.Name(), err)
+			}
+			stepped = system.Name()
+			continue
+		}
+
+		actual, next, err := adaptive.StepAdaptive(sc.State, taken)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", system.Name(), err)
+		}
+		if actual < taken && stepped != "" {
+			return 0, fmt.Errorf("%s shortened the step to %g after %s advanced by %g", system.Name(), actual, stepped, taken)
+		}
+
+		// Keep the controller's suggestion even when dt was shortened to
+		// land on MaxTime, so a truncated final step does not shrink
+		// future steps.
+		if dt == sc.TimeStep || actual < taken {
+			sc.TimeStep = next
+		}
+		taken = actual
+		stepped = system.Name()
+	}
+
+	return taken, nil
+}
+
+func (sc *SimulationCore) finalize() error {
+	var first error
+	for _, system := range sc.Systems {
+		if err := system.Finalize(sc.State); err != nil && first == nil {
+			first = fmt.Errorf("finalize %s: %w", system.Name(), err)
+		}
+	}
+	sc.Events.Dispatch()
+	return first
 }
 
 func (sc *SimulationCore) Stop() {
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+// System is a component advanced by SimulationCore. Systems are initialised,
+// stepped and finalised in registration order and communicate through the
+// shared State and the core's EventBus.
+type System interface {
+	Name() string
+	Init(state *State, events *EventBus) error
+	Step(state *State, dt float64) error
+	Finalize(state *State) error
+}
+
+// AdaptiveSystem is a System that chooses its own step size. It may take a
+// shorter step than requested; systems registered after it then advance by
+// the step actually taken. next is the step size suggested for the next call.
+type AdaptiveSystem interface {
+	System
+	StepAdaptive(state *State, dt float64) (taken, next float64, err error)
+}
+
+// State is the data shared between systems. Bodies is owned by the orbital
+// system; Phases, Entropy and Scalars are written by the systems that own
+// the corresponding keys and read by everyone else. Stochastic systems must
+// draw from Random so that runs can be replayed and checkpointed.
+type State struct {
+	Time      float64
+	StepCount int
+	Bodies    []dynamics.CelestialBody
+	Phases    map[string]float64
+	Entropy   float64
+	Scalars   map[string]float64
+	Random    *random.Source
+}
+
+func NewState() *State {
+	return &State{
+		Bodies:  make([]dynamics.CelestialBody, 0),
+		Phases:  make(map[string]float64),
+		Scalars: make(map[string]float64),
+		Random:  random.NewSource(1),
+	}
+}
+
+// Body returns the body with the given ID.
+func (s *State) Body(id string) (dynamics.CelestialBody, bool) {
+	for _, body := range s.Bodies {
+		if body.ID == id {
+			return body, true
+		}
+	}
+	return dynamics.CelestialBody{}, false
+}
@@ -0,0 +1,580 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/ykashou/go-elder/internal/go-heliosystem/coordination"
+	"github.com/ykashou/go-elder/internal/go-heliosystem/entropy"
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/pkg/go-field/phase"
+)
+
+// Names of the built-in systems and the State.Scalars keys they write.
+const (
+	OrbitalSystemName   = "orbital"
+	PhaseSystemName     = "phase"
+	ResonanceSystemName = "resonance"
+	EntropySystemName   = "entropy"
+
+	ScalarPhaseCoherence = "phase.coherence"
+	ScalarResonanceOrder = "resonance.order"
+	ScalarSystemOrder    = "entropy.order"
+)
+
+// OrbitalSystem advances the N-body dynamics and owns State.Bodies. With a
+// Stepper it is adaptive and should be registered first. It publishes
+// EventOrbitDestabilised when a body stops being bound to its host, and
+// EventBodiesMerged for every collision.
+type OrbitalSystem struct {
+	Dynamics *dynamics.OrbitalDynamics
+	Stepper  *dynamics.AdaptiveStepper
+	events   *EventBus
+	bound    map[string]bool
+	merged   int
+}
+
+func NewOrbitalSystem(od *dynamics.OrbitalDynamics) *OrbitalSystem {
+	return &OrbitalSystem{
+		Dynamics: od,
+		bound:    make(map[string]bool),
+	}
+}
+
+func (orb *OrbitalSystem) Name() string {
+	return OrbitalSystemName
+}
+
+func (orb *OrbitalSystem) Init(state *State, events *EventBus) error {
+	if orb.Dynamics == nil {
+		return fmt.Errorf("no orbital dynamics configured")
+	}
+
+	orb.events = events
+	orb.merged = len(orb.Dynamics.Collisions)
+	orb.bound = orb.boundBodies()
+	state.Bodies = orb.Dynamics.Bodies
+	return nil
+}
+
+func (orb *OrbitalSystem) Step(state *State, dt float64) error {
+	if err := orb.Dynamics.StepBy(dt); err != nil {
+		return err
+	}
+	orb.publish(state)
+	return nil
+}
+
+func (orb *OrbitalSystem) StepAdaptive(state *State, dt float64) (float64, float64, error) {
+	if orb.Stepper == nil {
+		return dt, dt, orb.Step(state, dt)
+	}
+
+	taken, next, err := orb.Stepper.Step(orb.Dynamics, dt)
+	if err != nil {
+		return 0, 0, err
+	}
+	orb.publish(state)
+	return taken, next, nil
+}
+
+func (orb *OrbitalSystem) Finalize(state *State) error {
+	state.Bodies = orb.Dynamics.Bodies
+	return nil
+}
+
+func (orb *OrbitalSystem) publish(state *State) {
+	state.Bodies = orb.Dynamics.Bodies
+
+	for _, collision := range orb.Dynamics.Collisions[orb.merged:] {
+		orb.events.Publish(Event{
+			Type:   EventBodiesMerged,
+			Time:   collision.Time,
+			Source: collision.Survivor,
+			Data:   map[string]float64{"mass": collision.Mass},
+		})
+	}
+	orb.merged = len(orb.Dynamics.Collisions)
+
+	bound := orb.boundBodies()
+	for _, id := range sortedKeys(orb.bound) {
+		// Bodies missing from bound were merged and are reported as such.
+		if stillBound, exists := bound[id]; exists && orb.bound[id] && !stillBound {
+			body, _ := state.Body(id)
+			host, _ := orb.host(body)
+			orb.events.Publish(Event{
+				Type:   EventOrbitDestabilised,
+				Time:   orb.Dynamics.Time,
+				Source: id,
+				Data:   map[string]float64{"energy": orb.specificEnergy(host, body)},
+			})
+		}
+	}
+	orb.bound = bound
+}
+
+// boundBodies reports, for every body with a more massive neighbour, whether
+// its two-body orbital energy relative to its host is negative. The host is
+// the more massive body exerting the strongest pull, so erudites are judged
+// against their mentor rather than the elders.
+func (orb *OrbitalSystem) boundBodies() map[string]bool {
+	bound := make(map[string]bool)
+	for _, body := range orb.Dynamics.Bodies {
+		if host, ok := orb.host(body); ok && body.ID != "" {
+			bound[body.ID] = orb.specificEnergy(host, body) < 0
+		}
+	}
+	return bound
+}
+
+func (orb *OrbitalSystem) host(body dynamics.CelestialBody) (dynamics.CelestialBody, bool) {
+	return hostOf(orb.Dynamics.Bodies, body)
+}
+
+// hostOf is the more massive body in bodies exerting the strongest pull on
+// body.
+func hostOf(bodies []dynamics.CelestialBody, body dynamics.CelestialBody) (dynamics.CelestialBody, bool) {
+	host := dynamics.CelestialBody{}
+	strongest := 0.0
+	for _, other := range bodies {
+		if other.Mass <= body.Mass {
+			continue
+		}
+		offset := other.Position.Sub(body.Position)
+		pull := other.Mass / offset.Dot(offset)
+		if pull > strongest {
+			host, strongest = other, pull
+		}
+	}
+	return host, strongest > 0
+}
+
+func (orb *OrbitalSystem) specificEnergy(primary, body dynamics.CelestialBody) float64 {
+	r := body.Position.Sub(primary.Position).Norm()
+	v := body.Velocity.Sub(primary.Velocity).Norm()
+	if r == 0 {
+		return math.Inf(-1)
+	}
+	return 0.5*v*v - orb.Dynamics.G*(primary.Mass+body.Mass)/r
+}
+
+type orbitalSnapshot struct {
+	Time       float64                  `json:"time"`
+	Bodies     []dynamics.CelestialBody `json:"bodies"`
+	Collisions []dynamics.Collision     `json:"collisions"`
+	Accepted   int                      `json:"accepted,omitempty"`
+	Rejected   int                      `json:"rejected,omitempty"`
+	Forced     int                      `json:"forced,omitempty"`
+	LastError  float64                  `json:"last_error,omitempty"`
+}
+
+func (orb *OrbitalSystem) Snapshot() (json.RawMessage, error) {
+	snapshot := orbitalSnapshot{
+		Time:       orb.Dynamics.Time,
+		Bodies:     orb.Dynamics.Bodies,
+		Collisions: orb.Dynamics.Collisions,
+	}
+	if orb.Stepper != nil {
+		snapshot.Accepted = orb.Stepper.Accepted
+		snapshot.Rejected = orb.Stepper.Rejected
+		snapshot.Forced = orb.Stepper.Forced
+		snapshot.LastError = orb.Stepper.LastError
+	}
+	return json.Marshal(snapshot)
+}
+
+func (orb *OrbitalSystem) Restore(data json.RawMessage) error {
+	var snapshot orbitalSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	orb.Dynamics.Time = snapshot.Time
+	orb.Dynamics.Bodies = snapshot.Bodies
+	orb.Dynamics.Collisions = snapshot.Collisions
+	if orb.Dynamics.Collisions == nil {
+		orb.Dynamics.Collisions = make([]dynamics.Collision, 0)
+	}
+	if orb.Stepper != nil {
+		orb.Stepper.Accepted = snapshot.Accepted
+		orb.Stepper.Rejected = snapshot.Rejected
+		orb.Stepper.Forced = snapshot.Forced
+		orb.Stepper.LastError = snapshot.LastError
+	}
+
+	orb.merged = len(orb.Dynamics.Collisions)
+	orb.bound = orb.boundBodies()
+	return nil
+}
+
+// PhaseSystem evolves a set of phase fields and optionally couples them
+// through a CouplingMatrix. Coupling pulls each phase towards those of its
+// targets and leaves its amplitude unchanged. Field phases are written to
+// State.Phases as angles and the pairwise coherence to ScalarPhaseCoherence.
+type PhaseSystem struct {
+	Fields   *phase.PhaseFieldSystem
+	Coupling *phase.CouplingMatrix
+}
+
+func NewPhaseSystem(fields *phase.PhaseFieldSystem) *PhaseSystem {
+	return &PhaseSystem{Fields: fields}
+}
+
+// NewHierarchyPhaseSystem gives every named body in od a unit phase field
+// that turns at its initial angular velocity around its host, starting at
+// its orbital phase, and couples it to its host's field with the given
+// strength: erudites to their mentor and mentors to an elder.
+func NewHierarchyPhaseSystem(od *dynamics.OrbitalDynamics, strength float64) *PhaseSystem {
+	fields := phase.NewPhaseFieldSystem()
+	ids := make([]string, 0, len(od.Bodies))
+	hosts := make(map[string]string)
+
+	for _, body := range od.Bodies {
+		if body.ID == "" {
+			continue
+		}
+		ids = append(ids, body.ID)
+
+		host, ok := hostOf(od.Bodies, body)
+		if !ok {
+			fields.AddField(body.ID, 0, 1, 1)
+			continue
+		}
+		r := body.Position.Sub(host.Position)
+		v := body.Velocity.Sub(host.Velocity)
+		frequency := 0.0
+		if distanceSquared := r.Dot(r); distanceSquared > 0 {
+			frequency = r.Cross(v).Norm() / distanceSquared
+		}
+		fields.AddField(body.ID, frequency, 1, cmplx.Rect(1, math.Atan2(r.Y, r.X)))
+		hosts[body.ID] = host.ID
+	}
+
+	coupling := phase.NewCouplingMatrix(ids)
+	for _, id := range ids {
+		if host := hosts[id]; host != "" {
+			coupling.SetCoupling(id, host, strength)
+		}
+	}
+
+	return &PhaseSystem{Fields: fields, Coupling: coupling}
+}
+
+func (ps *PhaseSystem) Name() string {
+	return PhaseSystemName
+}
+
+func (ps *PhaseSystem) Init(state *State, events *EventBus) error {
+	if ps.Fields == nil {
+		return fmt.Errorf("no phase fields configured")
+	}
+	ps.publish(state)
+	return nil
+}
+
+func (ps *PhaseSystem) Step(state *State, dt float64) error {
+	ps.Fields.EvolveFields(dt)
+
+	if ps.Coupling != nil {
+		for id, coupled := range ps.Coupling.CalculateCoupledEvolution(ps.Fields.Fields, dt) {
+			field, exists := ps.Fields.Fields[id]
+			if !exists || coupled == 0 {
+				continue
+			}
+			field.Phase = coupled * complex(cmplx.Abs(field.Phase)/cmplx.Abs(coupled), 0)
+			ps.Fields.Fields[id] = field
+		}
+	}
+
+	ps.publish(state)
+	return nil
+}
+
+func (ps *PhaseSystem) Finalize(state *State) error {
+	return nil
+}
+
+func (ps *PhaseSystem) publish(state *State) {
+	for id, field := range ps.Fields.Fields {
+		state.Phases[id] = cmplx.Phase(field.Phase)
+	}
+	state.Scalars[ScalarPhaseCoherence] = ps.Fields.CalculateCoherence()
+}
+
+// phaseSnapshot stores complex phases as (real, imaginary) pairs, which
+// encoding/json cannot represent directly.
+type phaseSnapshot struct {
+	Time   float64               `json:"time"`
+	Phases map[string][2]float64 `json:"phases"`
+}
+
+func (ps *PhaseSystem) Snapshot() (json.RawMessage, error) {
+	snapshot := phaseSnapshot{
+		Time:   ps.Fields.Time,
+		Phases: make(map[string][2]float64),
+	}
+	for id, field := range ps.Fields.Fields {
+		snapshot.Phases[id] = [2]float64{real(field.Phase), imag(field.Phase)}
+	}
+	return json.Marshal(snapshot)
+}
+
+func (ps *PhaseSystem) Restore(data json.RawMessage) error {
+	var snapshot phaseSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	ps.Fields.Time = snapshot.Time
+	for id, value := range snapshot.Phases {
+		field, exists := ps.Fields.Fields[id]
+		if !exists {
+			return fmt.Errorf("phase field %q is not configured", id)
+		}
+		field.Phase = complex(value[0], value[1])
+		ps.Fields.Fields[id] = field
+	}
+	return nil
+}
+
+// ResonanceSystem couples the orbital phases of the listed bodies around a
+// central body with a Kuramoto ResonanceCoupler. Each step the resonators'
+// natural frequencies are refreshed from the bodies' current angular
+// velocities, and with Noise > 0 each phase also diffuses with strength
+// Noise, drawn from State.Random. It publishes EventResonanceLocked when the
+// order parameter rises to LockThreshold and EventResonanceUnlocked when it
+// falls below.
+type ResonanceSystem struct {
+	Coupler       *coordination.ResonanceCoupler
+	Center        string
+	Bodies        []string
+	LockThreshold float64
+	Noise         float64
+	Locked        bool
+	events        *EventBus
+}
+
+func NewResonanceSystem(strength float64, center string, bodies []string) *ResonanceSystem {
+	return &ResonanceSystem{
+		Coupler:       coordination.NewResonanceCoupler(strength),
+		Center:        center,
+		Bodies:        bodies,
+		LockThreshold: 0.95,
+	}
+}
+
+func (rs *ResonanceSystem) Name() string {
+	return ResonanceSystemName
+}
+
+func (rs *ResonanceSystem) Init(state *State, events *EventBus) error {
+	rs.events = events
+
+	for _, id := range rs.Bodies {
+		frequency, phase, ok := rs.orbit(state, id)
+		if !ok {
+			return fmt.Errorf("body %q or center %q not in state", id, rs.Center)
+		}
+		rs.Coupler.AddResonator(id, frequency, 1.0, phase)
+	}
+
+	order, _ := rs.Coupler.OrderParameter()
+	rs.Locked = len(rs.Bodies) > 1 && order >= rs.LockThreshold
+	rs.publish(state, order)
+	return nil
+}
+
+func (rs *ResonanceSystem) Step(state *State, dt float64) error {
+	for _, id := range rs.Bodies {
+		resonator, exists := rs.Coupler.Resonators[id]
+		if !exists {
+			continue
+		}
+		frequency, _, ok := rs.orbit(state, id)
+		if !ok {
+			// Merged into another body; it no longer resonates.
+			delete(rs.Coupler.Resonators, id)
+			continue
+		}
+		resonator.Frequency = frequency
+		rs.Coupler.Resonators[id] = resonator
+	}
+
+	rs.Coupler.Evolve(dt)
+
+	if rs.Noise > 0 {
+		// Bodies is in a fixed order, so draws are reproducible.
+		for _, id := range rs.Bodies {
+			if _, exists := rs.Coupler.Resonators[id]; exists {
+				rs.Coupler.ShiftPhase(id, rs.Noise*math.Sqrt(dt)*state.Random.NormFloat64())
+			}
+		}
+	}
+
+	order, mean := rs.Coupler.OrderParameter()
+	locked := len(rs.Coupler.Resonators) > 1 && order >= rs.LockThreshold
+	if locked != rs.Locked {
+		eventType := EventResonanceUnlocked
+		if locked {
+			eventType = EventResonanceLocked
+		}
+		rs.events.Publish(Event{
+			Type:   eventType,
+			Time:   state.Time + dt,
+			Source: rs.Name(),
+			Data:   map[string]float64{"order": order, "phase": mean},
+		})
+		rs.Locked = locked
+	}
+
+	rs.publish(state, order)
+	return nil
+}
+
+func (rs *ResonanceSystem) Finalize(state *State) error {
+	return nil
+}
+
+func (rs *ResonanceSystem) publish(state *State, order float64) {
+	for id, resonator := range rs.Coupler.Resonators {
+		state.Phases[rs.Name()+"/"+id] = resonator.Phase
+	}
+	state.Scalars[ScalarResonanceOrder] = order
+}
+
+// orbit returns the angular velocity and orbital phase of a body in the
+// plane of its motion around Center.
+func (rs *ResonanceSystem) orbit(state *State, id string) (float64, float64, bool) {
+	center, ok := state.Body(rs.Center)
+	if !ok {
+		return 0, 0, false
+	}
+	body, ok := state.Body(id)
+	if !ok {
+		return 0, 0, false
+	}
+
+	r := body.Position.Sub(center.Position)
+	v := body.Velocity.Sub(center.Velocity)
+	distanceSquared := r.Dot(r)
+	if distanceSquared == 0 {
+		return 0, 0, true
+	}
+	return r.Cross(v).Norm() / distanceSquared, math.Atan2(r.Y, r.X), true
+}
+
+type resonanceSnapshot struct {
+	Resonators map[string]coordination.Resonator `json:"resonators"`
+	Locked     bool                              `json:"locked"`
+}
+
+func (rs *ResonanceSystem) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(resonanceSnapshot{Resonators: rs.Coupler.Resonators, Locked: rs.Locked})
+}
+
+func (rs *ResonanceSystem) Restore(data json.RawMessage) error {
+	var snapshot resonanceSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	rs.Coupler.Resonators = snapshot.Resonators
+	if rs.Coupler.Resonators == nil {
+		rs.Coupler.Resonators = make(map[string]coordination.Resonator)
+	}
+	rs.Locked = snapshot.Locked
+	return nil
+}
+
+// EntropySystem relaxes an EntropyDynamics towards a ceiling of ln(N) for N
+// bodies, lowered in proportion to the resonance order parameter when a
+// ResonanceSystem is running: synchronised phases carry less entropy. It
+// writes State.Entropy and the resulting order to ScalarSystemOrder.
+type EntropySystem struct {
+	Dynamics *entropy.EntropyDynamics
+}
+
+func NewEntropySystem(rate float64) *EntropySystem {
+	return &EntropySystem{Dynamics: entropy.NewEntropyDynamics(0, rate)}
+}
+
+func (es *EntropySystem) Name() string {
+	return EntropySystemName
+}
+
+func (es *EntropySystem) Init(state *State, events *EventBus) error {
+	es.Dynamics.MaxEntropy = es.ceiling(state)
+	es.publish(state)
+	return nil
+}
+
+func (es *EntropySystem) Step(state *State, dt float64) error {
+	es.Dynamics.MaxEntropy = es.ceiling(state)
+	es.Dynamics.EvolveEntropy(dt)
+	es.publish(state)
+	return nil
+}
+
+func (es *EntropySystem) Finalize(state *State) error {
+	return nil
+}
+
+func (es *EntropySystem) ceiling(state *State) float64 {
+	if len(state.Bodies) < 2 {
+		return 0.0
+	}
+	ceiling := math.Log(float64(len(state.Bodies)))
+	if order, ok := state.Scalars[ScalarResonanceOrder]; ok {
+		ceiling *= 1 - order
+	}
+	return ceiling
+}
+
+func (es *EntropySystem) publish(state *State) {
+	state.Entropy = es.Dynamics.CurrentEntropy
+	state.Scalars[ScalarSystemOrder] = es.Dynamics.CalculateSystemOrder()
+}
+
+// entropySnapshot keeps only the tail of the history that
+// CalculateEntropyProduction and GetEntropyGradient look at.
+type entropySnapshot struct {
+	Current float64   `json:"current"`
+	Max     float64   `json:"max"`
+	History []float64 `json:"history"`
+}
+
+func (es *EntropySystem) Snapshot() (json.RawMessage, error) {
+	history := es.Dynamics.EntropyHistory
+	if len(history) > 3 {
+		history = history[len(history)-3:]
+	}
+	return json.Marshal(entropySnapshot{
+		Current: es.Dynamics.CurrentEntropy,
+		Max:     es.Dynamics.MaxEntropy,
+		History: history,
+	})
+}
+
+func (es *EntropySystem) Restore(data json.RawMessage) error {
+	var snapshot entropySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	es.Dynamics.CurrentEntropy = snapshot.Current
+	es.Dynamics.MaxEntropy = snapshot.Max
+	es.Dynamics.EntropyHistory = append(make([]float64, 0, len(snapshot.History)), snapshot.History...)
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/ykashou/go-elder/pkg/go-field/phase"
+)
+
+// phaseGap runs a field "a" turning at rate 1 and a field "b" turning at
+// rate 1.05, with a pulled towards b by strength, and returns how far a
+// trails b at the end.
+func phaseGap(t *testing.T, strength float64) float64 {
+	t.Helper()
+	fields := phase.NewPhaseFieldSystem()
+	fields.AddField("a", 1, 1, 1)
+	fields.AddField("b", 1.05, 1, 1)
+	system := NewPhaseSystem(fields)
+	system.Coupling = phase.NewCouplingMatrix([]string{"a", "b"})
+	system.Coupling.SetCoupling("a", "b", strength)
+
+	state := NewState()
+	if err := system.Init(state, NewEventBus()); err != nil {
+		t.Fatal(err)
+	}
+	for step := 0; step < 10000; step++ {
+		if err := system.Step(state, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cmplx.Phase(fields.Fields["b"].Phase / fields.Fields["a"].Phase)
+}
+
+func TestPhaseCouplingLocks(t *testing.T) {
+	// Uncoupled, b gains 0.05 rad per time unit: 5 rad over the run, which
+	// wraps to about -1.28.
+	if gap := phaseGap(t, 0); math.Abs(gap-math.Remainder(5, 2*math.Pi)) > 1e-6 {
+		t.Errorf("uncoupled gap = %g, want %g", gap, math.Remainder(5, 2*math.Pi))
+	}
+
+	// Coupled more strongly than the frequency difference, a locks to b
+	// with sin(gap) = 0.05 / 0.5.
+	if gap := phaseGap(t, 0.5); math.Abs(math.Sin(gap)-0.1) > 1e-2 {
+		t.Errorf("coupled gap = %g, want asin(0.1) = %g", gap, math.Asin(0.1))
+	}
+}
+
+func TestPhaseSystemKeepsAmplitude(t *testing.T) {
+	fields := phase.NewPhaseFieldSystem()
+	fields.AddField("a", 1, 2, 2)
+	fields.AddField("b", 3, 1, 1i)
+	system := NewPhaseSystem(fields)
+	system.Coupling = phase.NewCouplingMatrix([]string{"a", "b"})
+	system.Coupling.SetCoupling("a", "b", 5)
+
+	state := NewState()
+	for step := 0; step < 1000; step++ {
+		if err := system.Step(state, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if amplitude := cmplx.Abs(fields.Fields["a"].Phase); math.Abs(amplitude-2) > 1e-9 {
+		t.Errorf("amplitude of a = %g, want 2", amplitude)
+	}
+}
@@ -0,0 +1,118 @@
+package parallel
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minChunk is the smallest number of items handed to a worker; below it the
+// cost of starting a goroutine outweighs the work.
+const minChunk = 32
+
+// Pool shards index ranges across a fixed number of workers. Each index is
+// processed by exactly one worker, so loops whose iterations are independent
+// produce the same result for any worker count. A nil Pool runs serially.
+type Pool struct {
+	Workers int
+}
+
+// NewPool returns a pool with the given number of workers; zero or fewer
+// uses one worker per available CPU.
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Pool{Workers: workers}
+}
+
+// Workers resolves the engine settings into a worker count. Serial engines
+// get one worker; otherwise threads, or every CPU when threads is zero,
+// capped by maxProcessors when that is positive.
+func Workers(enabled bool, threads, maxProcessors int) int {
+	if !enabled {
+		return 1
+	}
+	workers := threads
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if maxProcessors > 0 && workers > maxProcessors {
+		workers = maxProcessors
+	}
+	return workers
+}
+
+// Size is the number of workers For may use.
+func (p *Pool) Size() int {
+	if p == nil || p.Workers < 1 {
+		return 1
+	}
+	return p.Workers
+}
+
+// For calls body on contiguous, non-overlapping ranges that cover [0, n) and
+// returns once every call has finished.
+func (p *Pool) For(n int, body func(start, end int)) {
+	chunks := p.Size()
+	if limit := (n + minChunk - 1) / minChunk; chunks > limit {
+		chunks = limit
+	}
+	if chunks <= 1 {
+		if n > 0 {
+			body(0, n)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for c := 0; c < chunks; c++ {
+		start, end := c*n/chunks, (c+1)*n/chunks
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body(start, end)
+		}()
+	}
+	wg.Wait()
+}
+
+// Sum evaluates term for every index in parallel and adds the results in
+// index order, so the total does not depend on the worker count.
+func (p *Pool) Sum(n int, term func(i int) float64) float64 {
+	terms := make([]float64, n)
+	p.For(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			terms[i] = term(i)
+		}
+	})
+
+	total := 0.0
+	for _, t := range terms {
+		total += t
+	}
+	return total
+}
+
+// Each calls body once for every index in [0, n), handing indices to the
+// workers one at a time. It suits a few long, uneven tasks, which For would
+// give to one worker in a single chunk.
+func (p *Pool) Each(n int, body func(i int)) {
+	workers := p.Size()
+	if workers > n {
+		workers = n
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				body(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package parallel
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+var pools = []*Pool{nil, NewPool(1), NewPool(3), NewPool(8)}
+
+func TestEveryIndexIsVisitedOnce(t *testing.T) {
+	for _, pool := range pools {
+		for _, n := range []int{0, 1, minChunk - 1, minChunk, minChunk + 1, 1000} {
+			t.Run(fmt.Sprintf("workers=%d/n=%d", pool.Size(), n), func(t *testing.T) {
+				forVisits := make([]int32, n)
+				pool.For(n, func(start, end int) {
+					for i := start; i < end; i++ {
+						atomic.AddInt32(&forVisits[i], 1)
+					}
+				})
+				eachVisits := make([]int32, n)
+				pool.Each(n, func(i int) {
+					atomic.AddInt32(&eachVisits[i], 1)
+				})
+
+				for i := 0; i < n; i++ {
+					if forVisits[i] != 1 || eachVisits[i] != 1 {
+						t.Fatalf("index %d visited %d times by For and %d by Each, want once", i, forVisits[i], eachVisits[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSumIsIndependentOfWorkers(t *testing.T) {
+	// Terms spanning many magnitudes make the total depend on the order in
+	// which they are added.
+	term := func(i int) float64 {
+		return math.Pow(-1.7, float64(i%40)) / float64(i+1)
+	}
+	want := 0.0
+	for i := 0; i < 5000; i++ {
+		want += term(i)
+	}
+
+	for _, pool := range pools {
+		if got := pool.Sum(5000, term); got != want {
+			t.Errorf("%d workers: sum %.17g, want %.17g", pool.Size(), got, want)
+		}
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	tests := []struct {
+		enabled                bool
+		threads, maxProcessors int
+		want                   int
+	}{
+		{false, 8, 0, 1},
+		{true, 8, 0, 8},
+		{true, 8, 4, 4},
+		{true, 2, 4, 2},
+	}
+	for _, tt := range tests {
+		if got := Workers(tt.enabled, tt.threads, tt.maxProcessors); got != tt.want {
+			t.Errorf("Workers(%t, %d, %d) = %d, want %d", tt.enabled, tt.threads, tt.maxProcessors, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package random
+
+import "math"
+
+// Source is a SplitMix64 generator. Its whole state is exported so it can be
+// checkpointed and restored exactly; two Sources with equal fields produce
+// identical streams on every platform.
+type Source struct {
+	State    uint64  `json:"state"`
+	Spare    float64 `json:"spare"`
+	HasSpare bool    `json:"has_spare"`
+}
+
+func NewSource(seed int64) *Source {
+	return &Source{State: uint64(seed)}
+}
+
+func (s *Source) Uint64() uint64 {
+	s.State += 0x9E3779B97F4A7C15
+	z := s.State
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Float64 returns a uniform value in [0, 1).
+func (s *Source) Float64() float64 {
+	return float64(s.Uint64()>>11) / (1 << 53)
+}
+
+// Uniform returns a uniform value in [low, high).
+func (s *Source) Uniform(low, high float64) float64 {
+	return low + (high-low)*s.Float64()
+}
+
+// NormFloat64 returns a standard normal value using the Marsaglia polar
+// method; the second value of each pair is kept in Spare.
+func (s *Source) NormFloat64() float64 {
+	if s.HasSpare {
+		s.HasSpare = false
+		return s.Spare
+	}
+
+	for {
+		u := 2*s.Float64() - 1
+		v := 2*s.Float64() - 1
+		q := u*u + v*v
+		if q > 0 && q < 1 {
+			scale := math.Sqrt(-2 * math.Log(q) / q)
+			s.Spare = v * scale
+			s.HasSpare = true
+			return u * scale
+		}
+	}
+}
+
+// Intn returns a uniform value in [0, n). It panics if n <= 0.
+func (s *Source) Intn(n int) int {
+	if n <= 0 {
+		panic("random: invalid argument to Intn")
+	}
+	return int(s.Uint64() % uint64(n))
+}
+
+// Perm returns a pseudo-random permutation of [0, n).
+func (s *Source) Perm(n int) []int {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	s.Shuffle(n, func(i, j int) {
+		perm[i], perm[j] = perm[j], perm[i]
+	})
+	return perm
+}
+
+// Shuffle performs a Fisher–Yates shuffle of n elements using swap.
+func (s *Source) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, s.Intn(i+1))
+	}
+}
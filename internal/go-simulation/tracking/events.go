@@ -0,0 +1,103 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// EventWriter writes scalar summaries in TensorBoard's event file format:
+// TFRecord framing around Event protocol buffers, encoded by hand so as not
+// to depend on TensorFlow.
+type EventWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewEventWriter creates the event file at path and writes the version
+// record TensorBoard expects first.
+func NewEventWriter(path string) (*EventWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ew := &EventWriter{file: file, writer: bufio.NewWriter(file)}
+	version := event(float64(time.Now().UnixNano())/1e9, 0)
+	version = appendBytes(version, 3, []byte("brain.Event:2"))
+	if err := ew.writeRecord(version); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return ew, nil
+}
+
+// WriteScalars writes one event holding every scalar, in tag order, and
+// flushes it.
+func (ew *EventWriter) WriteScalars(step int64, wallTime float64, scalars map[string]float64) error {
+	tags := make([]string, 0, len(scalars))
+	for tag := range scalars {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	summary := make([]byte, 0)
+	for _, tag := range tags {
+		value := appendBytes(nil, 1, []byte(tag))
+		value = appendFloat32(value, 2, float32(scalars[tag]))
+		summary = appendBytes(summary, 1, value)
+	}
+
+	return ew.writeRecord(appendBytes(event(wallTime, step), 5, summary))
+}
+
+func (ew *EventWriter) Close() error {
+	if err := ew.writer.Flush(); err != nil {
+		ew.file.Close()
+		return err
+	}
+	return ew.file.Close()
+}
+
+// writeRecord frames data as a TFRecord: its length and the length's
+// masked CRC, then the data and its masked CRC.
+func (ew *EventWriter) writeRecord(data []byte) error {
+	header := binary.LittleEndian.AppendUint64(nil, uint64(len(data)))
+	header = binary.LittleEndian.AppendUint32(header, maskedCRC(header))
+
+	ew.writer.Write(header)
+	ew.writer.Write(data)
+	ew.writer.Write(binary.LittleEndian.AppendUint32(nil, maskedCRC(data)))
+	return ew.writer.Flush()
+}
+
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, castagnoli)
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
+
+// event starts an Event message with its wall_time (field 1) and step
+// (field 2).
+func event(wallTime float64, step int64) []byte {
+	message := binary.AppendUvarint(nil, 1<<3|1)
+	message = binary.LittleEndian.AppendUint64(message, math.Float64bits(wallTime))
+	message = binary.AppendUvarint(message, 2<<3)
+	return binary.AppendUvarint(message, uint64(step))
+}
+
+func appendBytes(message []byte, field int, data []byte) []byte {
+	message = binary.AppendUvarint(message, uint64(field)<<3|2)
+	message = binary.AppendUvarint(message, uint64(len(data)))
+	return append(message, data...)
+}
+
+func appendFloat32(message []byte, field int, value float32) []byte {
+	message = binary.AppendUvarint(message, uint64(field)<<3|5)
+	return binary.LittleEndian.AppendUint32(message, math.Float32bits(value))
+}
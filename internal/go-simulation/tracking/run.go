@@ -0,0 +1,325 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ykashou/go-elder/pkg/go-file/serialization"
+)
+
+// Files in a run directory.
+const (
+	MetadataFile = "run.json"
+	ConfigFile   = "config.json"
+	MetricsFile  = "metrics.jsonl"
+	eventsPrefix = "events.out.tfevents."
+)
+
+// Run states.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Metadata describes a run. Summary holds the last value logged for every
+// metric, so runs can be listed without reading their metrics.
+type Metadata struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name,omitempty"`
+	Command   []string           `json:"command"`
+	Status    string             `json:"status"`
+	Error     string             `json:"error,omitempty"`
+	Started   time.Time          `json:"started"`
+	Finished  *time.Time         `json:"finished,omitempty"`
+	Host      string             `json:"host"`
+	GoVersion string             `json:"go_version"`
+	Steps     int                `json:"steps"`
+	Epochs    int                `json:"epochs"`
+	Summary   map[string]float64 `json:"summary"`
+}
+
+// Record is one line of metrics.jsonl: the scalars logged together at a
+// step. Epoch-level records repeat the step of the epoch's last batch.
+type Record struct {
+	Step     int                `json:"step"`
+	Epoch    int                `json:"epoch"`
+	WallTime float64            `json:"wall_time"`
+	Scalars  map[string]float64 `json:"scalars"`
+}
+
+// Run is a directory holding a run's metadata, the config it used, its
+// metrics as JSON lines and the same metrics as a TensorBoard event file.
+type Run struct {
+	Directory string
+	Metadata  Metadata
+	metrics   *os.File
+	writer    *bufio.Writer
+	events    *EventWriter
+}
+
+// NewRun creates a run directory under root named after the start time and
+// name, and records config as config.json.
+func NewRun(root, name string, config interface{}) (*Run, error) {
+	started := time.Now().UTC()
+	id := started.Format("20060102-150405")
+	if name = strings.Trim(unsafeName.ReplaceAllString(name, "-"), "-"); name != "" {
+		id += "-" + name
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	directory := filepath.Join(root, id)
+	for suffix := 2; ; suffix++ {
+		err := os.Mkdir(directory, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		directory = filepath.Join(root, fmt.Sprintf("%s-%d", id, suffix))
+	}
+
+	host, _ := os.Hostname()
+	r := &Run{
+		Directory: directory,
+		Metadata: Metadata{
+			ID:        filepath.Base(directory),
+			Name:      name,
+			Command:   os.Args,
+			Status:    StatusRunning,
+			Started:   started,
+			Host:      host,
+			GoVersion: runtime.Version(),
+			Summary:   make(map[string]float64),
+		},
+	}
+
+	document, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode run config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, ConfigFile), append(document, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	if err := r.saveMetadata(); err != nil {
+		return nil, err
+	}
+
+	if r.metrics, err = os.Create(filepath.Join(directory, MetricsFile)); err != nil {
+		return nil, err
+	}
+	r.writer = bufio.NewWriter(r.metrics)
+
+	events := fmt.Sprintf("%s%d.%s", eventsPrefix, started.Unix(), host)
+	if r.events, err = NewEventWriter(filepath.Join(directory, events)); err != nil {
+		r.metrics.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Run) saveMetadata() error {
+	return serialization.NewElderSerializer("json").SerializeToFile(r.Metadata, filepath.Join(r.Directory, MetadataFile))
+}
+
+// Log records scalars at step of epoch in both the JSONL and the event
+// file. Both are flushed, so a crashed run keeps everything logged. JSON
+// cannot represent NaN or infinity, so such values only reach the event
+// file.
+func (r *Run) Log(step, epoch int, scalars map[string]float64) error {
+	finite := make(map[string]float64, len(scalars))
+	for tag, value := range scalars {
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			finite[tag] = value
+		}
+	}
+
+	record := Record{
+		Step:     step,
+		Epoch:    epoch,
+		WallTime: float64(time.Now().UnixNano()) / 1e9,
+		Scalars:  finite,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode metrics at step %d: %w", step, err)
+	}
+	r.writer.Write(line)
+	r.writer.WriteByte('\n')
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := r.events.WriteScalars(int64(step), record.WallTime, scalars); err != nil {
+		return err
+	}
+
+	for tag, value := range finite {
+		r.Metadata.Summary[tag] = value
+	}
+	if step > r.Metadata.Steps {
+		r.Metadata.Steps = step
+	}
+	if epoch > r.Metadata.Epochs {
+		r.Metadata.Epochs = epoch
+	}
+	return nil
+}
+
+// Finish closes the run, marking it completed, or failed with cause when
+// cause is not nil.
+func (r *Run) Finish(cause error) error {
+	r.Metadata.Status = StatusCompleted
+	if cause != nil {
+		r.Metadata.Status = StatusFailed
+		r.Metadata.Error = cause.Error()
+	}
+	finished := time.Now().UTC()
+	r.Metadata.Finished = &finished
+
+	err := r.saveMetadata()
+	if closeErr := r.events.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := r.metrics.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// LoadMetadata reads the metadata of the run in directory.
+func LoadMetadata(directory string) (*Metadata, error) {
+	metadata := &Metadata{}
+	if err := serialization.NewElderSerializer("json").DeserializeFileInto(filepath.Join(directory, MetadataFile), metadata); err != nil {
+		return nil, fmt.Errorf("run %s: %w", directory, err)
+	}
+	return metadata, nil
+}
+
+// ListRuns returns the directories of the runs under root, oldest first.
+func ListRuns(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]string, 0)
+	for _, entry := range entries {
+		directory := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(directory, MetadataFile)); entry.IsDir() && err == nil {
+			runs = append(runs, directory)
+		}
+	}
+	// IDs begin with the start time, so name order is start order.
+	sort.Strings(runs)
+	return runs, nil
+}
+
+// FindRun resolves a run given as a directory, or under root as a run ID,
+// a unique prefix of one or the name it was given.
+func FindRun(root, run string) (string, error) {
+	if _, err := os.Stat(filepath.Join(run, MetadataFile)); err == nil {
+		return run, nil
+	}
+
+	runs, err := ListRuns(root)
+	if err != nil {
+		return "", err
+	}
+	matches := make([]string, 0)
+	for _, directory := range runs {
+		id := filepath.Base(directory)
+		if id == run {
+			return directory, nil
+		}
+		if strings.HasPrefix(id, run) || strings.HasSuffix(id, "-"+run) {
+			matches = append(matches, directory)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no run %q in %s", run, root)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("run %q is ambiguous: it matches %d runs in %s", run, len(matches), root)
+}
+
+// ReadMetrics reads every record logged by the run in directory.
+func ReadMetrics(directory string) ([]Record, error) {
+	file, err := os.Open(filepath.Join(directory, MetricsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]Record, 0)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		var record Record
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, fmt.Errorf("%s: record %d: %w", MetricsFile, len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ReadConfig reads the config of the run in directory flattened into
+// dotted paths, such as optimizer.learning_rate, with their values as text.
+func ReadConfig(directory string) (map[string]string, error) {
+	document, err := os.ReadFile(filepath.Join(directory, ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	var config interface{}
+	if err := json.Unmarshal(document, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+	}
+
+	flat := make(map[string]string)
+	flatten(config, "", flat)
+	return flat, nil
+}
+
+func flatten(value interface{}, path string, flat map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			flatten(child, key, flat)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(child, fmt.Sprintf("%s[%d]", path, i), flat)
+		}
+		if len(v) == 0 {
+			flat[path] = "[]"
+		}
+	case nil:
+		flat[path] = "null"
+	default:
+		flat[path] = fmt.Sprint(v)
+	}
+}
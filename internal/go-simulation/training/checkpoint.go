@@ -0,0 +1,243 @@
+package training
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-file/serialization"
+)
+
+const checkpointPrefix = "checkpoint-epoch-"
+
+// Checkpoint is everything needed to continue training exactly where it
+// stopped. Floats are written in shortest round-trip form, so a resumed run
+// is bit-for-bit identical to an uninterrupted one.
+type Checkpoint struct {
+	Epoch         int                       `json:"epoch"`
+	Step          int                       `json:"step"`
+	LearningRate  float64                   `json:"learning_rate"`
+	Model         *ElderModel               `json:"model"`
+	Optimizers    map[string]OptimizerState `json:"optimizers"`
+	Random        *random.Source            `json:"random,omitempty"`
+	Schedule      *LearningRateSchedule     `json:"schedule,omitempty"`
+	EarlyStopping *EarlyStopping            `json:"early_stopping,omitempty"`
+	Stability     *ParameterStability       `json:"stability,omitempty"`
+	History       []EpochMetrics            `json:"history"`
+	Stopped       bool                      `json:"stopped"`
+}
+
+// OptimizerState is one entry of OptimizationDynamics in a form that
+// survives JSON: Optimizer.State is split into its vector and scalar parts
+// so that decoding gives back []float64 rather than []interface{}.
+type OptimizerState struct {
+	Type          string               `json:"type"`
+	Parameters    map[string]float64   `json:"parameters"`
+	Vectors       map[string][]float64 `json:"vectors,omitempty"`
+	Scalars       map[string]float64   `json:"scalars,omitempty"`
+	LearningRate  float64              `json:"learning_rate"`
+	Momentum      float64              `json:"momentum"`
+	GradientNorm  float64              `json:"gradient_norm"`
+	UpdateHistory []float64            `json:"update_history,omitempty"`
+}
+
+// Checkpoint captures the loop after the last completed epoch.
+func (etl *ElderTrainingLoop) Checkpoint() (*Checkpoint, error) {
+	model := *etl.Model
+	model.Parameters = copyParameters(etl.Model.Parameters)
+
+	cp := &Checkpoint{
+		Epoch:         etl.CurrentEpoch,
+		Step:          etl.Step,
+		LearningRate:  etl.LearningRate,
+		Model:         &model,
+		Optimizers:    make(map[string]OptimizerState, len(etl.Dynamics.Optimizers)),
+		Schedule:      etl.Schedule,
+		EarlyStopping: etl.EarlyStopping,
+		Stability:     etl.Stability,
+		History:       etl.History,
+		Stopped:       etl.Stopped,
+	}
+
+	if etl.Random != nil {
+		source := *etl.Random
+		cp.Random = &source
+	}
+
+	for id, optimizer := range etl.Dynamics.Optimizers {
+		state := OptimizerState{
+			Type:          optimizer.Type,
+			Parameters:    optimizer.Parameters,
+			Vectors:       make(map[string][]float64),
+			Scalars:       make(map[string]float64),
+			LearningRate:  etl.Dynamics.LearningRates[id],
+			Momentum:      etl.Dynamics.Momentum[id],
+			GradientNorm:  etl.Dynamics.GradientNorms[id],
+			UpdateHistory: etl.Dynamics.UpdateHistory[id],
+		}
+		for name, value := range optimizer.State {
+			switch v := value.(type) {
+			case []float64:
+				state.Vectors[name] = append([]float64{}, v...)
+			case float64:
+				state.Scalars[name] = v
+			default:
+				return nil, fmt.Errorf("optimizer %s: cannot checkpoint state %q of type %T", id, name, value)
+			}
+		}
+		cp.Optimizers[id] = state
+	}
+
+	return cp, nil
+}
+
+// Restore puts the loop back into the state captured by cp. The model
+// architecture must match the one the loop was built with.
+func (etl *ElderTrainingLoop) Restore(cp *Checkpoint) error {
+	if cp.Model == nil {
+		return fmt.Errorf("checkpoint has no model")
+	}
+	if cp.Model.Inputs != etl.Model.Inputs || !reflect.DeepEqual(cp.Model.Layers, etl.Model.Layers) {
+		return fmt.Errorf("checkpoint model (%d inputs, layers %v) does not match the configured model (%d inputs, layers %v)",
+			cp.Model.Inputs, cp.Model.Layers, etl.Model.Inputs, etl.Model.Layers)
+	}
+
+	etl.CurrentEpoch = cp.Epoch
+	etl.Step = cp.Step
+	etl.LearningRate = cp.LearningRate
+	etl.Model = cp.Model
+	etl.History = cp.History
+	etl.Stopped = cp.Stopped
+	if cp.Random != nil {
+		source := *cp.Random
+		etl.Random = &source
+	}
+	if cp.Schedule != nil {
+		etl.Schedule = cp.Schedule
+	}
+	if cp.EarlyStopping != nil {
+		etl.EarlyStopping = cp.EarlyStopping
+	}
+	if cp.Stability != nil {
+		etl.Stability = cp.Stability
+	}
+
+	etl.Dynamics = NewOptimizationDynamics()
+	for id, state := range cp.Optimizers {
+		optimizer := Optimizer{
+			Type:       state.Type,
+			Parameters: state.Parameters,
+			State:      make(map[string]interface{}),
+		}
+		for name, vector := range state.Vectors {
+			optimizer.State[name] = vector
+		}
+		for name, scalar := range state.Scalars {
+			optimizer.State[name] = scalar
+		}
+
+		etl.Dynamics.Optimizers[id] = optimizer
+		etl.Dynamics.LearningRates[id] = state.LearningRate
+		etl.Dynamics.Momentum[id] = state.Momentum
+		etl.Dynamics.GradientNorms[id] = state.GradientNorm
+		etl.Dynamics.UpdateHistory[id] = append([]float64{}, state.UpdateHistory...)
+	}
+
+	return nil
+}
+
+// SaveCheckpoint writes a checkpoint for the current epoch into
+// CheckpointDirectory and then deletes all but the newest KeepCheckpoints.
+func (etl *ElderTrainingLoop) SaveCheckpoint() error {
+	cp, err := etl.Checkpoint()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(etl.CheckpointDirectory, 0755); err != nil {
+		return err
+	}
+	filename := filepath.Join(etl.CheckpointDirectory, fmt.Sprintf("%s%04d.json", checkpointPrefix, cp.Epoch))
+
+	serializer := serialization.NewElderSerializer("json")
+	serializer.SetMetadata("epoch", cp.Epoch)
+	if err := serializer.SerializeToFile(cp, filename); err != nil {
+		return err
+	}
+
+	return etl.rotateCheckpoints()
+}
+
+func (etl *ElderTrainingLoop) rotateCheckpoints() error {
+	if etl.KeepCheckpoints <= 0 {
+		return nil
+	}
+
+	checkpoints, err := ListCheckpoints(etl.CheckpointDirectory)
+	if err != nil {
+		return err
+	}
+	for len(checkpoints) > etl.KeepCheckpoints {
+		if err := os.Remove(checkpoints[0]); err != nil {
+			return err
+		}
+		checkpoints = checkpoints[1:]
+	}
+	return nil
+}
+
+// ListCheckpoints returns the training checkpoints in directory, oldest
+// epoch first.
+func ListCheckpoints(directory string) ([]string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	epochs := make(map[string]int)
+	checkpoints := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, checkpointPrefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		epoch, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, checkpointPrefix), ".json"))
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(directory, name)
+		epochs[path] = epoch
+		checkpoints = append(checkpoints, path)
+	}
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return epochs[checkpoints[i]] < epochs[checkpoints[j]]
+	})
+	return checkpoints, nil
+}
+
+// LoadCheckpoint reads a checkpoint file, or the newest checkpoint when
+// path is a directory.
+func LoadCheckpoint(path string) (*Checkpoint, string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		checkpoints, err := ListCheckpoints(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(checkpoints) == 0 {
+			return nil, "", fmt.Errorf("no training checkpoints in %s", path)
+		}
+		path = checkpoints[len(checkpoints)-1]
+	}
+
+	cp := &Checkpoint{}
+	if err := serialization.NewElderSerializer("json").DeserializeFileInto(path, cp); err != nil {
+		return nil, "", fmt.Errorf("load checkpoint %s: %w", path, err)
+	}
+	return cp, path, nil
+}
@@ -0,0 +1,100 @@
+package training
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+const testEpochs = 8
+
+// testLoop trains a small model with every piece of state a checkpoint has
+// to carry: Adam moments, a cosine schedule, early stopping, shuffling and
+// dropout drawing from the loop's random source, batch norm statistics and
+// parameter stability. Observed stability scalars are appended to curves.
+func testLoop(t *testing.T, dir string, curves *[]map[string]float64) *ElderTrainingLoop {
+	t.Helper()
+	rng := random.NewSource(11)
+	model, err := NewElderModel(4, []LayerSpec{
+		{Size: 8, Activation: "tanh", Dropout: 0.2, BatchNorm: true},
+		{Size: 2},
+	}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loop := NewElderTrainingLoop(testEpochs, 0.01, 8)
+	loop.Model = model
+	loop.OptimizerType = "adam"
+	loop.Shuffle = true
+	loop.Regularization = Regularization{L2: 1e-3}
+	loop.Schedule = NewLearningRateSchedule("cosine", 0.01)
+	loop.EarlyStopping = NewEarlyStopping(100)
+	loop.TrainingData, loop.ValidationData = SplitValidation(SyntheticRegression(80, 4, 2, rng), 0.2, rng)
+	loop.Random = rng
+	loop.CheckpointEvery = 3
+	loop.CheckpointDirectory = dir
+	loop.KeepCheckpoints = 2
+	loop.Stability = NewParameterStability()
+	loop.OnEpoch = func(loop *ElderTrainingLoop) {
+		*curves = append(*curves, loop.Stability.Observe(loop.Model.Parameters))
+	}
+	return loop
+}
+
+func TestResumeMatchesUninterruptedTraining(t *testing.T) {
+	var wantCurves []map[string]float64
+	reference := testLoop(t, t.TempDir(), &wantCurves)
+	if err := reference.Train(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	var curves []map[string]float64
+	interrupted := testLoop(t, dir, &curves)
+	if err := interrupted.TrainUntil(5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkpoints at epochs 3 and 5 are kept; the resumed run writes one at
+	// epoch 6 and its final one at 8.
+	cp, path, err := LoadCheckpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "checkpoint-epoch-0005.json" {
+		t.Errorf("newest checkpoint is %s, want epoch 5", path)
+	}
+
+	resumed := testLoop(t, dir, &curves)
+	if err := resumed.Restore(cp); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.Train(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resumed.Model.Parameters, reference.Model.Parameters) {
+		t.Error("resumed parameters differ from an uninterrupted run")
+	}
+	if !reflect.DeepEqual(resumed.History, reference.History) {
+		t.Errorf("resumed history %+v, want %+v", resumed.History, reference.History)
+	}
+	if !reflect.DeepEqual(curves, wantCurves) {
+		t.Errorf("resumed stability curves %v, want %v", curves, wantCurves)
+	}
+
+	checkpoints, err := ListCheckpoints(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, checkpoint := range checkpoints {
+		names = append(names, filepath.Base(checkpoint))
+	}
+	if want := []string{"checkpoint-epoch-0006.json", "checkpoint-epoch-0008.json"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("checkpoints kept: %v, want %v", names, want)
+	}
+}
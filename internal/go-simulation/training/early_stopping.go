@@ -0,0 +1,69 @@
+package training
+
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+// EarlyStopping ends training once the monitored loss has gone Patience
+// validations without improving, and remembers the parameters from the
+// best epoch so they can be restored.
+type EarlyStopping struct {
+	Patience       int                  `json:"patience"`
+	BestLoss       float64              `json:"best_loss"`
+	BestEpoch      int                  `json:"best_epoch"`
+	Wait           int                  `json:"wait"`
+	BestParameters map[string][]float64 `json:"best_parameters"`
+}
+
+func NewEarlyStopping(patience int) *EarlyStopping {
+	return &EarlyStopping{
+		Patience: patience,
+		// Not infinity, which JSON cannot represent in a checkpoint.
+		BestLoss: math.MaxFloat64,
+	}
+}
+
+// Observe records the loss at the end of epoch and reports whether training
+// should stop.
+func (es *EarlyStopping) Observe(epoch int, loss float64, parameters map[string][]float64) bool {
+	if loss < es.BestLoss {
+		es.BestLoss = loss
+		es.BestEpoch = epoch
+		es.Wait = 0
+		es.BestParameters = copyParameters(parameters)
+		return false
+	}
+
+	es.Wait++
+	return es.Wait >= es.Patience
+}
+
+// Restore puts the best parameters seen back into model.
+func (es *EarlyStopping) Restore(model *ElderModel) {
+	if es.BestParameters != nil {
+		model.Parameters = copyParameters(es.BestParameters)
+	}
+}
+
+func copyParameters(parameters map[string][]float64) map[string][]float64 {
+	copied := make(map[string][]float64, len(parameters))
+	for key, values := range parameters {
+		copied[key] = append([]float64{}, values...)
+	}
+	return copied
+}
+
+// SplitValidation shuffles samples with rng and holds back ratio of them
+// for validation. The input slice is not modified.
+func SplitValidation(samples []TrainingSample, ratio float64, rng *random.Source) ([]TrainingSample, []TrainingSample) {
+	shuffled := make([]TrainingSample, len(samples))
+	for i, j := range rng.Perm(len(samples)) {
+		shuffled[i] = samples[j]
+	}
+
+	held := int(math.Round(float64(len(samples)) * ratio))
+	cut := len(shuffled) - held
+	return shuffled[:cut], shuffled[cut:]
+}
@@ -0,0 +1,86 @@
+package training
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+)
+
+func TestEarlyStoppingKeepsBestParameters(t *testing.T) {
+	es := NewEarlyStopping(2)
+	parameters := map[string][]float64{"w": {1}}
+	losses := []float64{3, 1, 2, 1}
+
+	for epoch, loss := range losses {
+		parameters["w"][0] = float64(epoch)
+		stop := es.Observe(epoch, loss, parameters)
+		if want := epoch == len(losses)-1; stop != want {
+			t.Fatalf("epoch %d: stop %v, want %v", epoch, stop, want)
+		}
+	}
+	if es.BestEpoch != 1 || es.BestLoss != 1 {
+		t.Errorf("best epoch %d with loss %g, want epoch 1 with loss 1", es.BestEpoch, es.BestLoss)
+	}
+
+	model := &ElderModel{Parameters: parameters}
+	es.Restore(model)
+	if got := model.Parameters["w"][0]; got != 1 {
+		t.Fatalf("restored w = %g, want 1 from the best epoch", got)
+	}
+	model.Parameters["w"][0] = 7
+	if es.BestParameters["w"][0] != 1 {
+		t.Errorf("changing the restored model changed the saved parameters")
+	}
+}
+
+func TestTrainingRestoresBestParameters(t *testing.T) {
+	rng := random.NewSource(3)
+	model, err := NewElderModel(2, []LayerSpec{{Size: 8, Activation: "tanh"}, {Size: 1}}, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loop := NewElderTrainingLoop(30, 0.05, 16)
+	loop.Model = model
+	loop.EarlyStopping = NewEarlyStopping(3)
+	loop.TrainingData, loop.ValidationData = SplitValidation(SyntheticRegression(160, 2, 1, rng), 0.25, rng)
+
+	// Record the parameters at the end of every epoch, then wreck them from
+	// epoch 5 on so that the validation loss gets worse and the last
+	// parameters are not the best.
+	snapshots := make([]map[string][]float64, 0)
+	loop.OnEpoch = func(etl *ElderTrainingLoop) {
+		snapshots = append(snapshots, copyParameters(etl.Model.Parameters))
+		if etl.CurrentEpoch >= 5 {
+			for _, values := range etl.Model.Parameters {
+				for i := range values {
+					values[i] *= 3
+				}
+			}
+		}
+	}
+
+	if err := loop.Train(); err != nil {
+		t.Fatal(err)
+	}
+	if !loop.Stopped {
+		t.Fatalf("training ran all %d epochs without stopping", loop.CurrentEpoch)
+	}
+
+	best := 0
+	for i, metrics := range loop.History {
+		if metrics.ValidationLoss < loop.History[best].ValidationLoss {
+			best = i
+		}
+	}
+	if best == len(loop.History)-1 {
+		t.Fatalf("the last epoch was the best, so restoring cannot be told from keeping it")
+	}
+	if loop.EarlyStopping.BestEpoch != loop.History[best].Epoch {
+		t.Errorf("early stopping kept epoch %d, the lowest validation loss was at epoch %d",
+			loop.EarlyStopping.BestEpoch, loop.History[best].Epoch)
+	}
+	if !reflect.DeepEqual(loop.Model.Parameters, snapshots[best]) {
+		t.Errorf("the model does not hold the parameters of the best epoch %d", loop.History[best].Epoch)
+	}
+}
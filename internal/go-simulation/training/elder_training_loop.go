@@ -1,15 +1,70 @@
 package training
 
-import "time"
+import (
+	"fmt"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
 
 type ElderTrainingLoop struct {
-	MaxEpochs     int
-	LearningRate  float64
-	BatchSize     int
-	CurrentEpoch  int
-	TrainingData  []TrainingSample
-	ValidationData []TrainingSample
-	Model         *ElderModel
+	MaxEpochs           int
+	LearningRate        float64
+	Momentum            float64
+	OptimizerType       string
+	OptimizerParameters map[string]float64
+	BatchSize           int
+	CurrentEpoch        int
+	Step                int
+	ValidationFrequency int
+	TrainingData        []TrainingSample
+	ValidationData      []TrainingSample
+	Source              SampleSource
+	ValidationSource    SampleSource
+	Shuffle             bool
+	Random              *random.Source
+	Model               *ElderModel
+	Dynamics            *OptimizationDynamics
+	Schedule            *LearningRateSchedule
+	EarlyStopping       *EarlyStopping
+	Regularization      Regularization
+	History             []EpochMetrics
+	Stopped             bool
+	OnEpoch             func(etl *ElderTrainingLoop)
+	OnStep              func(etl *ElderTrainingLoop, loss float64)
+
+	// penalty is the regularization penalty of the last batch.
+	penalty float64
+
+	// CheckpointEvery, when positive, writes a checkpoint to
+	// CheckpointDirectory every CheckpointEvery epochs, keeping the newest
+	// KeepCheckpoints of them (all when zero).
+	CheckpointEvery     int
+	CheckpointDirectory string
+	KeepCheckpoints     int
+
+	// Stability, when set, is checkpointed with the loop. The loop does not
+	// observe it; that is left to OnEpoch.
+	Stability *ParameterStability
+}
+
+// EpochMetrics summarises one epoch. ValidationLoss and Accuracy are only
+// meaningful when Validated is set.
+type EpochMetrics struct {
+	Epoch          int     `json:"epoch"`
+	Loss           float64 `json:"loss"`
+	ValidationLoss float64 `json:"validation_loss"`
+	Accuracy       float64 `json:"accuracy"`
+	LearningRate   float64 `json:"learning_rate"`
+	Validated      bool    `json:"validated"`
+}
+
+// SampleSource streams samples that need not fit in memory. Batches calls
+// yield with consecutive batches of at most size samples; the order may
+// depend on epoch, but must be the same every time the same epoch is
+// requested so that resumed runs are reproducible.
+type SampleSource interface {
+	Batches(epoch, size int, yield func(batch []TrainingSample) error) error
 }
 
 type TrainingSample struct {
@@ -19,95 +74,358 @@ type TrainingSample struct {
 }
 
 type ElderModel struct {
-	Parameters map[string][]float64
-	Loss       float64
-	Accuracy   float64
+	Parameters     map[string][]float64 `json:"parameters"`
+	Layers         []Layer              `json:"layers"`
+	Inputs         int                  `json:"inputs"`
+	Loss           float64              `json:"loss"`
+	ValidationLoss float64              `json:"validation_loss"`
+	Accuracy       float64              `json:"accuracy"`
 }
 
 func NewElderTrainingLoop(epochs int, lr float64, batchSize int) *ElderTrainingLoop {
 	return &ElderTrainingLoop{
-		MaxEpochs:    epochs,
-		LearningRate: lr,
-		BatchSize:    batchSize,
-		Model:        &ElderModel{Parameters: make(map[string][]float64)},
+		MaxEpochs:           epochs,
+		LearningRate:        lr,
+		OptimizerType:       "sgd",
+		OptimizerParameters: make(map[string]float64),
+		BatchSize:           batchSize,
+		ValidationFrequency: 1,
+		Model:               &ElderModel{Parameters: make(map[string][]float64)},
+		Dynamics:            NewOptimizationDynamics(),
+		Random:              random.NewSource(1),
 	}
 }
 
-func (etl *ElderTrainingLoop) Train() {
-	for etl.CurrentEpoch < etl.MaxEpochs {
-		etl.trainEpoch()
-		etl.validateEpoch()
+// Train runs epochs until MaxEpochs or until early stopping triggers, after
+// which the best parameters seen are restored.
+func (etl *ElderTrainingLoop) Train() error {
+	return etl.TrainUntil(etl.MaxEpochs)
+}
+
+// TrainUntil runs epochs until epoch, so that training can be paused short of
+// MaxEpochs and resumed by a later call. Schedules still span MaxEpochs, and
+// the best parameters are only restored once training is over.
+func (etl *ElderTrainingLoop) TrainUntil(epoch int) error {
+	if epoch > etl.MaxEpochs {
+		epoch = etl.MaxEpochs
+	}
+
+	startEpoch := etl.CurrentEpoch
+	for etl.CurrentEpoch < epoch && !etl.Stopped {
+		etl.applySchedule()
+		if err := etl.trainEpoch(); err != nil {
+			return fmt.Errorf("epoch %d: %w", etl.CurrentEpoch+1, err)
+		}
+		validated, err := etl.validateEpoch()
+		if err != nil {
+			return fmt.Errorf("validate epoch %d: %w", etl.CurrentEpoch+1, err)
+		}
 		etl.CurrentEpoch++
-		
-		if etl.Model.Loss < 0.001 {
-			break
+		etl.recordEpoch(validated)
+
+		if etl.OnEpoch != nil {
+			etl.OnEpoch(etl)
+		}
+
+		if etl.CheckpointEvery > 0 && etl.CurrentEpoch%etl.CheckpointEvery == 0 {
+			if err := etl.SaveCheckpoint(); err != nil {
+				return fmt.Errorf("checkpoint at epoch %d: %w", etl.CurrentEpoch, err)
+			}
+		}
+	}
+
+	// Always leave a checkpoint of the final epoch, taken before the best
+	// weights are restored, so a finished or stopped run can be extended.
+	if etl.CheckpointEvery > 0 && etl.CurrentEpoch > startEpoch && etl.CurrentEpoch%etl.CheckpointEvery != 0 {
+		if err := etl.SaveCheckpoint(); err != nil {
+			return fmt.Errorf("checkpoint at epoch %d: %w", etl.CurrentEpoch, err)
 		}
 	}
+
+	if etl.EarlyStopping != nil && (etl.Stopped || etl.CurrentEpoch >= etl.MaxEpochs) {
+		etl.EarlyStopping.Restore(etl.Model)
+	}
+	return nil
 }
 
-func (etl *ElderTrainingLoop) trainEpoch() {
+func (etl *ElderTrainingLoop) applySchedule() {
+	if etl.Schedule == nil {
+		return
+	}
+
+	etl.LearningRate = etl.Schedule.Rate(etl.CurrentEpoch, etl.MaxEpochs)
+	for id := range etl.Dynamics.Optimizers {
+		etl.Dynamics.SetLearningRate(id, etl.LearningRate)
+	}
+}
+
+// recordEpoch appends the epoch's metrics and feeds the monitored loss,
+// validation loss when there is validation data and training loss
+// otherwise, to the schedule and early stopping.
+func (etl *ElderTrainingLoop) recordEpoch(validated bool) {
+	metrics := EpochMetrics{
+		Epoch:        etl.CurrentEpoch,
+		Loss:         etl.Model.Loss,
+		LearningRate: etl.LearningRate,
+		Validated:    validated,
+	}
+	if validated {
+		metrics.ValidationLoss = etl.Model.ValidationLoss
+		metrics.Accuracy = etl.Model.Accuracy
+	}
+	etl.History = append(etl.History, metrics)
+
+	monitored := etl.Model.Loss
+	if etl.hasValidation() {
+		if !validated {
+			return
+		}
+		monitored = etl.Model.ValidationLoss
+	}
+
+	if etl.Schedule != nil {
+		etl.Schedule.Observe(monitored)
+	}
+	if etl.EarlyStopping != nil && etl.EarlyStopping.Observe(etl.CurrentEpoch, monitored, etl.Model.Parameters) {
+		etl.Stopped = true
+	}
+}
+
+func (etl *ElderTrainingLoop) hasValidation() bool {
+	return len(etl.ValidationData) > 0 || etl.ValidationSource != nil
+}
+
+// trainEpoch trains on Source when one is set and on TrainingData
+// otherwise.
+func (etl *ElderTrainingLoop) trainEpoch() error {
 	totalLoss := 0.0
 	batchCount := 0
-	
-	for i := 0; i < len(etl.TrainingData); i += etl.BatchSize {
+
+	if etl.Source != nil {
+		err := etl.Source.Batches(etl.CurrentEpoch, etl.BatchSize, func(batch []TrainingSample) error {
+			batchLoss, err := etl.trainBatch(batch)
+			if err != nil {
+				return err
+			}
+			totalLoss += batchLoss
+			batchCount++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if batchCount > 0 {
+			etl.Model.Loss = totalLoss / float64(batchCount)
+		}
+		return nil
+	}
+
+	samples := etl.TrainingData
+	if etl.Shuffle && etl.Random != nil {
+		samples = make([]TrainingSample, len(etl.TrainingData))
+		for i, j := range etl.Random.Perm(len(samples)) {
+			samples[i] = etl.TrainingData[j]
+		}
+	}
+
+	for i := 0; i < len(samples); i += etl.BatchSize {
 		end := i + etl.BatchSize
-		if end > len(etl.TrainingData) {
-			end = len(etl.TrainingData)
+		if end > len(samples) {
+			end = len(samples)
+		}
+
+		batchLoss, err := etl.trainBatch(samples[i:end])
+		if err != nil {
+			return err
 		}
-		
-		batchLoss := etl.trainBatch(etl.TrainingData[i:end])
 		totalLoss += batchLoss
 		batchCount++
 	}
-	
-	etl.Model.Loss = totalLoss / float64(batchCount)
+
+	if batchCount > 0 {
+		etl.Model.Loss = totalLoss / float64(batchCount)
+	}
+	return nil
+}
+
+// trainBatch runs the whole batch through the model as one matrix in
+// training mode, differentiates the weighted loss plus the regularization
+// penalty and applies one optimizer step. It returns the loss without the
+// penalty, which is comparable with the validation loss.
+func (etl *ElderTrainingLoop) trainBatch(batch []TrainingSample) (float64, error) {
+	tape := autodiff.NewTape()
+	params := etl.Model.Track(tape)
+
+	inputs, targets, weights := etl.stack(batch)
+	prediction := etl.Model.TrainingGraph(inputs, params, etl.Random)
+	loss := etl.calculateLoss(prediction, targets, weights)
+
+	objective := loss
+	etl.penalty = 0
+	penalized := make([]*autodiff.Variable, len(etl.Model.Layers))
+	for i := range etl.Model.Layers {
+		penalized[i] = params[weightsKey(i)]
+	}
+	if penalty := etl.Regularization.Penalty(penalized...); penalty != nil {
+		objective = autodiff.Add(loss, penalty)
+		etl.penalty = penalty.Scalar()
+	}
+	if err := etl.backward(objective, params); err != nil {
+		return 0, err
+	}
+
+	etl.Step++
+	if etl.OnStep != nil {
+		etl.OnStep(etl, loss.Scalar())
+	}
+	return loss.Scalar(), nil
 }
 
-func (etl *ElderTrainingLoop) trainBatch(batch []TrainingSample) float64 {
-	batchLoss := 0.0
-	
+// stack lays a batch out as input and target matrices with one row per
+// sample, and a weight matrix shaped like the targets.
+func (etl *ElderTrainingLoop) stack(batch []TrainingSample) (*autodiff.Variable, *autodiff.Variable, *autodiff.Variable) {
+	inputWidth, targetWidth := len(batch[0].Input), len(batch[0].Target)
+	inputs := make([]float64, 0, len(batch)*inputWidth)
+	targets := make([]float64, 0, len(batch)*targetWidth)
+	weights := make([]float64, 0, len(batch)*targetWidth)
+
 	for _, sample := range batch {
-		prediction := etl.forward(sample.Input)
-		loss := etl.calculateLoss(prediction, sample.Target)
-		etl.backward(loss)
-		batchLoss += loss
+		inputs = append(inputs, sample.Input...)
+		targets = append(targets, sample.Target...)
+
+		// Unset weights count as one.
+		weight := sample.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		for range sample.Target {
+			weights = append(weights, weight)
+		}
 	}
-	
-	return batchLoss / float64(len(batch))
+
+	return autodiff.Constant(inputs, len(batch), inputWidth),
+		autodiff.Constant(targets, len(batch), targetWidth),
+		autodiff.Constant(weights, len(batch), targetWidth)
 }
 
-func (etl *ElderTrainingLoop) forward(input []float64) []float64 {
-	return input
+// calculateLoss is the weighted mean squared error over every output of
+// every sample.
+func (etl *ElderTrainingLoop) calculateLoss(pred, target, weights *autodiff.Variable) *autodiff.Variable {
+	squared := autodiff.Square(autodiff.Sub(pred, target))
+	return autodiff.Div(autodiff.Dot(squared, weights), autodiff.Sum(weights))
 }
 
-func (etl *ElderTrainingLoop) calculateLoss(pred, target []float64) float64 {
-	loss := 0.0
-	for i := range pred {
-		diff := pred[i] - target[i]
-		loss += diff * diff
+// backward differentiates loss and hands each parameter's gradient to its
+// optimizer in OptimizationDynamics.
+func (etl *ElderTrainingLoop) backward(loss *autodiff.Variable, params map[string]*autodiff.Variable) error {
+	if len(params) == 0 {
+		return loss.Err()
 	}
-	return loss / float64(len(pred))
+	if err := loss.Backward(); err != nil {
+		return err
+	}
+
+	for i := range etl.Model.Layers {
+		for _, key := range etl.Model.trainedKeys(i) {
+			if _, exists := etl.Dynamics.Optimizers[key]; !exists {
+				etl.Dynamics.AddOptimizer(key, etl.OptimizerType, etl.LearningRate, etl.Momentum)
+				for name, value := range etl.OptimizerParameters {
+					etl.Dynamics.SetHyperparameter(key, name, value)
+				}
+			}
+			etl.Model.Parameters[key] = etl.Dynamics.UpdateParameters(key, params[key].Grad.Values(), etl.Model.Parameters[key])
+		}
+	}
+	return nil
+}
+
+func (etl *ElderTrainingLoop) forward(input []float64) []float64 {
+	return etl.Model.Forward(input)
 }
 
-func (etl *ElderTrainingLoop) backward(loss float64) {
-	// Simplified backpropagation
+// validateEpoch scores the validation data every ValidationFrequency
+// epochs and reports whether it did.
+func (etl *ElderTrainingLoop) validateEpoch() (bool, error) {
+	if !etl.hasValidation() || etl.ValidationFrequency <= 0 || (etl.CurrentEpoch+1)%etl.ValidationFrequency != 0 {
+		return false, nil
+	}
+
+	if etl.ValidationSource != nil {
+		loss, accuracy, err := etl.EvaluateSource(etl.ValidationSource)
+		if err != nil {
+			return false, err
+		}
+		etl.Model.ValidationLoss, etl.Model.Accuracy = loss, accuracy
+		return true, nil
+	}
+
+	etl.Model.Accuracy = etl.Accuracy(etl.ValidationData)
+	etl.Model.ValidationLoss = etl.Evaluate(etl.ValidationData)
+	return true, nil
 }
 
-func (etl *ElderTrainingLoop) validateEpoch() {
+// Accuracy is the fraction of samples the model predicts within tolerance.
+func (etl *ElderTrainingLoop) Accuracy(samples []TrainingSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
 	correct := 0
-	total := len(etl.ValidationData)
-	
-	for _, sample := range etl.ValidationData {
+	for _, sample := range samples {
 		prediction := etl.forward(sample.Input)
-		if etl.isCorrectPrediction(prediction, sample.Target) {
+		if isCorrectPrediction(prediction, sample.Target) {
 			correct++
 		}
 	}
-	
-	etl.Model.Accuracy = float64(correct) / float64(total)
+	return float64(correct) / float64(len(samples))
+}
+
+// Evaluate is the mean squared error of the model over samples.
+func (etl *ElderTrainingLoop) Evaluate(samples []TrainingSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	inputs, targets, weights := etl.stack(samples)
+	prediction := etl.Model.Graph(inputs, etl.Model.constants())
+	return etl.calculateLoss(prediction, targets, weights).Scalar()
+}
+
+// EvaluateSource is the mean squared error and accuracy of the model over a
+// streamed source, read in the order of epoch zero.
+func (etl *ElderTrainingLoop) EvaluateSource(source SampleSource) (float64, float64, error) {
+	squared, weight := 0.0, 0.0
+	correct, total := 0, 0
+
+	err := source.Batches(0, etl.BatchSize, func(batch []TrainingSample) error {
+		inputs, targets, weights := etl.stack(batch)
+		prediction := etl.Model.Graph(inputs, etl.Model.constants())
+		batchWeight := autodiff.Sum(weights).Scalar()
+		squared += etl.calculateLoss(prediction, targets, weights).Scalar() * batchWeight
+		weight += batchWeight
+
+		rows, err := prediction.Value.Rows()
+		if err != nil {
+			return err
+		}
+		for i, sample := range batch {
+			if isCorrectPrediction(rows[i], sample.Target) {
+				correct++
+			}
+		}
+		total += len(batch)
+		return nil
+	})
+	if err != nil || total == 0 {
+		return 0, 0, err
+	}
+
+	return squared / weight, float64(correct) / float64(total), nil
 }
 
-func (etl *ElderTrainingLoop) isCorrectPrediction(pred, target []float64) bool {
+// isCorrectPrediction reports whether every output is within 0.1 of its
+// target.
+func isCorrectPrediction(pred, target []float64) bool {
 	threshold := 0.1
 	for i := range pred {
 		if (pred[i]-target[i])*(pred[i]-target[i]) > threshold*threshold {
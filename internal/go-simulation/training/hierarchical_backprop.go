@@ -1,115 +1,406 @@
 package training
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-loss/hierarchical"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+// Hierarchy levels, from the entities that see the input to the one that
+// produces the prediction. Lower levels learn faster.
+const (
+	LevelErudite = 0
+	LevelMentor  = 1
+	LevelElder   = 2
+)
+
+const readoutKey = "readout"
+
+// HierarchicalBackprop is a model in which every entity is a tanh layer. The
+// first layer's entities read the input; every later entity aggregates the
+// mean state of the entities below it assigned to it round-robin (entity j
+// of the lower layer reports to entity j mod n of the upper one). The mean
+// state of the top layer feeds a linear readout that makes the prediction.
+//
+// When the layers are exactly Erudite, Mentor and Elder, the hierarchical
+// losses are added to the task loss, so their gradients flow back through
+// all three levels, each updated with its own learning rate.
 type HierarchicalBackprop struct {
-	Layers        []HierarchyLayer
-	LearningRates map[int]float64
-	Gradients     map[string][]float64
+	Layers          []HierarchyLayer
+	LearningRates   map[int]float64
+	Gradients       map[string][]float64
+	Inputs          int
+	Outputs         int
+	Readout         []float64
+	ReadoutBias     []float64
+	CrossLevel      *hierarchical.CrossLevelLoss
+	ElderMentor     *hierarchical.ElderMentorLoss
+	MentorErudite   *hierarchical.MentorEruditeLoss
+	HierarchyWeight float64
+	Regularization  Regularization
+	ClipNorm        float64
+	Losses          HierarchyLosses
+	OnStep          func(hb *HierarchicalBackprop)
+
+	rng    *random.Source
+	tape   *autodiff.Tape
+	params map[string]*autodiff.Variable
+	states [][]*autodiff.Variable
+	output *autodiff.Variable
 }
 
 type HierarchyLayer struct {
 	Level       int
 	Entities    []string
+	Inputs      int
+	Size        int
 	Weights     map[string][]float64
+	Biases      map[string][]float64
 	Activations map[string][]float64
 }
 
-func NewHierarchicalBackprop() *HierarchicalBackprop {
+// HierarchyLosses are the components of the last loss passed back. Total is
+// Task plus HierarchyWeight times the sum of the hierarchical terms plus the
+// Regularization penalty on the weights;
+// CrossLevelTerms breaks CrossLevel down into its unweighted terms, and
+// GradientNorm is the norm of the whole gradient before clipping.
+type HierarchyLosses struct {
+	Task            float64            `json:"task"`
+	MentorErudite   float64            `json:"mentor_erudite"`
+	ElderMentor     float64            `json:"elder_mentor"`
+	CrossLevel      float64            `json:"cross_level"`
+	CrossLevelTerms map[string]float64 `json:"cross_level_terms,omitempty"`
+	Regularization  float64            `json:"regularization,omitempty"`
+	Total           float64            `json:"total"`
+	GradientNorm    float64            `json:"gradient_norm"`
+}
+
+func NewHierarchicalBackprop(inputs, outputs int, rng *random.Source) *HierarchicalBackprop {
 	return &HierarchicalBackprop{
-		Layers:        make([]HierarchyLayer, 0),
-		LearningRates: make(map[int]float64),
-		Gradients:     make(map[string][]float64),
+		Layers:          make([]HierarchyLayer, 0),
+		LearningRates:   make(map[int]float64),
+		Gradients:       make(map[string][]float64),
+		Inputs:          inputs,
+		Outputs:         outputs,
+		CrossLevel:      hierarchical.NewCrossLevelLoss(),
+		ElderMentor:     hierarchical.NewElderMentorLoss(3),
+		MentorErudite:   hierarchical.NewMentorEruditeLoss(),
+		HierarchyWeight: 0.01,
+		ClipNorm:        1.0,
+		rng:             rng,
 	}
 }
 
-func (hb *HierarchicalBackprop) AddLayer(level int, entities []string) {
+// AddLayer stacks a level of entities, each with a state of size elements,
+// on top of the existing layers and resizes the readout to match.
+func (hb *HierarchicalBackprop) AddLayer(level int, entities []string, size int) {
+	inputs := hb.Inputs
+	if len(hb.Layers) > 0 {
+		inputs = hb.Layers[len(hb.Layers)-1].Size
+	}
+
 	layer := HierarchyLayer{
 		Level:       level,
 		Entities:    entities,
+		Inputs:      inputs,
+		Size:        size,
 		Weights:     make(map[string][]float64),
+		Biases:      make(map[string][]float64),
 		Activations: make(map[string][]float64),
 	}
-	
+
 	for _, entity := range entities {
-		layer.Weights[entity] = make([]float64, 10)
-		layer.Activations[entity] = make([]float64, 10)
+		layer.Weights[entity] = hb.glorot(inputs, size)
+		layer.Biases[entity] = make([]float64, size)
+		layer.Activations[entity] = make([]float64, size)
 	}
-	
+
 	hb.Layers = append(hb.Layers, layer)
 	hb.LearningRates[level] = 0.01 / float64(level+1)
+
+	hb.Readout = hb.glorot(size, hb.Outputs)
+	hb.ReadoutBias = make([]float64, hb.Outputs)
+}
+
+func (hb *HierarchicalBackprop) glorot(inputs, outputs int) []float64 {
+	limit := math.Sqrt(6 / float64(inputs+outputs))
+	weights := make([]float64, inputs*outputs)
+	for i := range weights {
+		weights[i] = hb.rng.Uniform(-limit, limit)
+	}
+	return weights
 }
 
+// ForwardPass predicts the output for input, recording the pass so that a
+// following BackwardPass can differentiate it.
 func (hb *HierarchicalBackprop) ForwardPass(input []float64) []float64 {
-	current := input
-	
+	hb.tape = autodiff.NewTape()
+	hb.params = make(map[string]*autodiff.Variable)
+	hb.states = make([][]*autodiff.Variable, len(hb.Layers))
+
+	current := []*autodiff.Variable{autodiff.Constant(input)}
 	for i := range hb.Layers {
 		current = hb.processLayer(i, current)
+		hb.states[i] = current
 	}
-	
-	return current
+
+	readout := hb.track(readoutKey, hb.Readout, len(hb.Readout)/hb.Outputs, hb.Outputs)
+	bias := hb.track(readoutKey+".bias", hb.ReadoutBias, hb.Outputs)
+	hb.output = autodiff.Add(autodiff.MatMul(mean(current), readout), bias)
+	return hb.output.Value.Values()
 }
 
-func (hb *HierarchicalBackprop) processLayer(layerIndex int, input []float64) []float64 {
+// processLayer computes the state of every entity in a layer from the
+// states of the layer below.
+func (hb *HierarchicalBackprop) processLayer(layerIndex int, below []*autodiff.Variable) []*autodiff.Variable {
 	layer := &hb.Layers[layerIndex]
-	output := make([]float64, len(input))
-	
-	for _, entity := range layer.Entities {
-		weights := layer.Weights[entity]
-		activation := hb.computeActivation(input, weights)
-		layer.Activations[entity] = activation
-		
-		for i, val := range activation {
-			if i < len(output) {
-				output[i] += val / float64(len(layer.Entities))
+	states := make([]*autodiff.Variable, len(layer.Entities))
+
+	for i, entity := range layer.Entities {
+		reports := make([]*autodiff.Variable, 0, len(below)/len(layer.Entities)+1)
+		for j, state := range below {
+			if j%len(layer.Entities) == i {
+				reports = append(reports, state)
 			}
 		}
+		if len(reports) == 0 {
+			reports = below
+		}
+
+		weights := hb.track(entity, layer.Weights[entity], layer.Inputs, layer.Size)
+		bias := hb.track(entity+".bias", layer.Biases[entity], layer.Size)
+		states[i] = autodiff.Tanh(autodiff.Add(autodiff.MatMul(mean(reports), weights), bias))
+		layer.Activations[entity] = states[i].Value.Values()
 	}
-	
-	return output
+
+	return states
+}
+
+func (hb *HierarchicalBackprop) track(key string, values []float64, shape ...int) *autodiff.Variable {
+	variable := hb.tape.Variable(values, shape...)
+	hb.params[key] = variable
+	return variable
+}
+
+func mean(states []*autodiff.Variable) *autodiff.Variable {
+	sum := states[0]
+	for _, state := range states[1:] {
+		sum = autodiff.Add(sum, state)
+	}
+	if len(states) == 1 {
+		return sum
+	}
+	return autodiff.Scale(sum, 1/float64(len(states)))
 }
 
-func (hb *HierarchicalBackprop) computeActivation(input, weights []float64) []float64 {
-	activation := make([]float64, len(input))
-	for i := range input {
-		if i < len(weights) {
-			activation[i] = input[i] * weights[i]
-		} else {
-			activation[i] = input[i]
+// BackwardPass differentiates the loss of the last ForwardPass against
+// target and takes one gradient step per level. It returns the total loss;
+// the components are kept in Losses.
+func (hb *HierarchicalBackprop) BackwardPass(target []float64) (float64, error) {
+	if hb.output == nil {
+		return 0, fmt.Errorf("backward pass without a forward pass")
+	}
+
+	loss := hb.loss(target)
+	if err := loss.Backward(); err != nil {
+		return 0, err
+	}
+	scale := hb.clipScale()
+
+	for i := range hb.Layers {
+		layer := &hb.Layers[i]
+		learningRate := hb.LearningRates[layer.Level] * scale
+		for _, entity := range layer.Entities {
+			hb.descend(entity, layer.Weights[entity], learningRate)
+			hb.descend(entity+".bias", layer.Biases[entity], learningRate)
 		}
 	}
-	return activation
+
+	top := hb.Layers[len(hb.Layers)-1]
+	hb.descend(readoutKey, hb.Readout, hb.LearningRates[top.Level]*scale)
+	hb.descend(readoutKey+".bias", hb.ReadoutBias, hb.LearningRates[top.Level]*scale)
+
+	hb.output = nil
+	return hb.Losses.Total, nil
 }
 
-func (hb *HierarchicalBackprop) BackwardPass(loss []float64) {
-	currentError := loss
-	
-	for i := len(hb.Layers) - 1; i >= 0; i-- {
-		currentError = hb.backpropLayer(i, currentError)
+// clipScale records the gradient norm and shrinks the step when the
+// gradient over all parameters is longer than ClipNorm; the ratio terms of
+// the cross-level loss can otherwise produce steps large enough to saturate
+// every entity.
+func (hb *HierarchicalBackprop) clipScale() float64 {
+	total := 0.0
+	for _, variable := range hb.params {
+		for _, gradient := range variable.Grad.Values() {
+			total += gradient * gradient
+		}
 	}
+	norm := math.Sqrt(total)
+	hb.Losses.GradientNorm = norm
+
+	if hb.ClipNorm > 0 && norm > hb.ClipNorm {
+		return hb.ClipNorm / norm
+	}
+	return 1
 }
 
-func (hb *HierarchicalBackprop) backpropLayer(layerIndex int, error []float64) []float64 {
-	layer := &hb.Layers[layerIndex]
-	prevError := make([]float64, len(error))
-	learningRate := hb.LearningRates[layer.Level]
-	
-	for _, entity := range layer.Entities {
-		weights := layer.Weights[entity]
-		gradients := make([]float64, len(weights))
-		
-		for i := range gradients {
-			if i < len(error) {
-				gradients[i] = error[i] * learningRate
-				weights[i] -= gradients[i]
-				
-				if i < len(prevError) {
-					prevError[i] += gradients[i]
+func (hb *HierarchicalBackprop) descend(key string, values []float64, learningRate float64) {
+	gradients := hb.params[key].Grad.Values()
+	for i, gradient := range gradients {
+		values[i] -= learningRate * gradient
+	}
+	hb.Gradients[key] = gradients
+}
+
+// loss records the task loss and, for an Erudite-Mentor-Elder stack, the
+// hierarchical losses, filling in Losses.
+func (hb *HierarchicalBackprop) loss(target []float64) *autodiff.Variable {
+	targetVariable := autodiff.Constant(target)
+	task := autodiff.Mean(autodiff.Square(autodiff.Sub(hb.output, targetVariable)))
+	hb.Losses = HierarchyLosses{Task: task.Scalar()}
+
+	erudites, mentors, elders := hb.levelStates()
+	if erudites == nil || hb.HierarchyWeight == 0 {
+		return hb.regularize(task)
+	}
+	elder := elders[0]
+
+	terms := make([]*autodiff.Variable, 0, 3)
+	if hb.MentorErudite != nil {
+		perMentor := make([]*autodiff.Variable, len(mentors))
+		for i, mentor := range mentors {
+			assigned, targets := make([]*autodiff.Variable, 0), make([]*autodiff.Variable, 0)
+			for j, erudite := range erudites {
+				if j%len(mentors) == i {
+					assigned = append(assigned, erudite)
+					targets = append(targets, targetVariable)
 				}
 			}
+			perMentor[i] = hb.MentorErudite.Graph(mentor, assigned, targets)
+		}
+		mentorErudite := mean(perMentor)
+		hb.Losses.MentorErudite = mentorErudite.Scalar()
+		terms = append(terms, mentorErudite)
+	}
+	if hb.ElderMentor != nil {
+		elderMentor := hb.ElderMentor.Graph(elder, mentors)
+		hb.Losses.ElderMentor = elderMentor.Scalar()
+		terms = append(terms, elderMentor)
+	}
+	if hb.CrossLevel != nil {
+		crossLevel := hb.CrossLevel.Graph(elder, mentors, erudites)
+		hb.Losses.CrossLevel = crossLevel.Scalar()
+		hb.Losses.CrossLevelTerms = hb.CrossLevel.Components
+		terms = append(terms, crossLevel)
+	}
+
+	total := task
+	for _, term := range terms {
+		total = autodiff.Add(total, autodiff.Scale(term, hb.HierarchyWeight))
+	}
+	return hb.regularize(total)
+}
+
+// regularize adds the penalty on every weight matrix to total and records
+// the result as the total loss.
+func (hb *HierarchicalBackprop) regularize(total *autodiff.Variable) *autodiff.Variable {
+	weights := []*autodiff.Variable{hb.params[readoutKey]}
+	for _, layer := range hb.Layers {
+		for _, entity := range layer.Entities {
+			weights = append(weights, hb.params[entity])
+		}
+	}
+	if penalty := hb.Regularization.Penalty(weights...); penalty != nil {
+		hb.Losses.Regularization = penalty.Scalar()
+		total = autodiff.Add(total, penalty)
+	}
+	hb.Losses.Total = total.Scalar()
+	return total
+}
+
+// levelStates returns the states recorded for each level when the layers
+// are exactly Erudite, Mentor and a single Elder, and nils otherwise.
+func (hb *HierarchicalBackprop) levelStates() ([]*autodiff.Variable, []*autodiff.Variable, []*autodiff.Variable) {
+	if len(hb.Layers) != 3 ||
+		hb.Layers[0].Level != LevelErudite || hb.Layers[1].Level != LevelMentor || hb.Layers[2].Level != LevelElder ||
+		len(hb.Layers[2].Entities) != 1 {
+		return nil, nil, nil
+	}
+	return hb.states[0], hb.states[1], hb.states[2]
+}
+
+// TrainEpoch takes one gradient step per sample and returns the mean loss
+// components over the epoch.
+func (hb *HierarchicalBackprop) TrainEpoch(samples []TrainingSample) (HierarchyLosses, error) {
+	sum := HierarchyLosses{CrossLevelTerms: make(map[string]float64)}
+	for _, sample := range samples {
+		hb.ForwardPass(sample.Input)
+		if _, err := hb.BackwardPass(sample.Target); err != nil {
+			return sum, err
+		}
+		if hb.OnStep != nil {
+			hb.OnStep(hb)
+		}
+		sum.Task += hb.Losses.Task
+		sum.MentorErudite += hb.Losses.MentorErudite
+		sum.ElderMentor += hb.Losses.ElderMentor
+		sum.CrossLevel += hb.Losses.CrossLevel
+		sum.Regularization += hb.Losses.Regularization
+		sum.Total += hb.Losses.Total
+		sum.GradientNorm += hb.Losses.GradientNorm
+		for name, value := range hb.Losses.CrossLevelTerms {
+			sum.CrossLevelTerms[name] += value
+		}
+	}
+
+	if n := float64(len(samples)); n > 0 {
+		sum.Task /= n
+		sum.MentorErudite /= n
+		sum.ElderMentor /= n
+		sum.CrossLevel /= n
+		sum.Regularization /= n
+		sum.Total /= n
+		sum.GradientNorm /= n
+		for name := range sum.CrossLevelTerms {
+			sum.CrossLevelTerms[name] /= n
+		}
+	}
+	return sum, nil
+}
+
+// Evaluate is the mean task loss over samples, without updating.
+func (hb *HierarchicalBackprop) Evaluate(samples []TrainingSample) float64 {
+	total := 0.0
+	for _, sample := range samples {
+		prediction := hb.ForwardPass(sample.Input)
+		for i, value := range prediction {
+			diff := value - sample.Target[i]
+			total += diff * diff / float64(len(prediction))
+		}
+	}
+	hb.output = nil
+
+	if len(samples) == 0 {
+		return 0
+	}
+	return total / float64(len(samples))
+}
+
+// Accuracy is the fraction of samples predicted within tolerance.
+func (hb *HierarchicalBackprop) Accuracy(samples []TrainingSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	correct := 0
+	for _, sample := range samples {
+		if isCorrectPrediction(hb.ForwardPass(sample.Input), sample.Target) {
+			correct++
 		}
-		
-		layer.Weights[entity] = weights
-		hb.Gradients[entity] = gradients
 	}
-	
-	return prevError
+	hb.output = nil
+	return float64(correct) / float64(len(samples))
 }
@@ -0,0 +1,101 @@
+package training
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-loss/optimization"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+// StepScalars are the metrics of the optimizer step that just finished:
+// the batch loss, the regularization penalty when there is one and the norm
+// of each parameter's gradient as well as of the whole gradient. The
+// learning rate only changes between epochs and is in the epoch's Scalars.
+func (etl *ElderTrainingLoop) StepScalars(loss float64) map[string]float64 {
+	scalars := map[string]float64{"loss/batch": loss}
+	if etl.Regularization != (Regularization{}) {
+		scalars["loss/regularization"] = etl.penalty
+	}
+
+	total := 0.0
+	for id, norm := range etl.Dynamics.GradientNorms {
+		scalars["gradient_norm/"+id] = norm
+		total += norm * norm
+	}
+	scalars["gradient_norm/global"] = math.Sqrt(total)
+	return scalars
+}
+
+// Scalars are the epoch's metrics, with the validation metrics only when
+// the epoch was validated.
+func (em EpochMetrics) Scalars() map[string]float64 {
+	scalars := map[string]float64{
+		"loss/train":    em.Loss,
+		"learning_rate": em.LearningRate,
+	}
+	if em.Validated {
+		scalars["loss/validation"] = em.ValidationLoss
+		scalars["accuracy/validation"] = em.Accuracy
+	}
+	return scalars
+}
+
+// Scalars are the loss components and the cross-level terms.
+func (hl HierarchyLosses) Scalars() map[string]float64 {
+	scalars := map[string]float64{
+		"loss/task":           hl.Task,
+		"loss/mentor_erudite": hl.MentorErudite,
+		"loss/elder_mentor":   hl.ElderMentor,
+		"loss/cross_level":    hl.CrossLevel,
+		"loss/regularization": hl.Regularization,
+		"loss/total":          hl.Total,
+	}
+	for name, value := range hl.CrossLevelTerms {
+		scalars["cross_level/"+name] = value
+	}
+	return scalars
+}
+
+// ParameterStability watches the parameters as a trajectory through phase
+// space, with the change since the previous observation as the velocity,
+// and scores it with StabilityLoss. It only monitors; nothing is
+// differentiated. Its history is saved with training checkpoints when it is
+// the loop's Stability, so that a resumed run scores the same trajectory.
+type ParameterStability struct {
+	Loss     *optimization.StabilityLoss `json:"loss"`
+	Previous []float64                   `json:"previous"`
+}
+
+func NewParameterStability() *ParameterStability {
+	return &ParameterStability{Loss: optimization.NewStabilityLoss(0, math.MaxFloat64)}
+}
+
+// Observe adds the current parameters to the trajectory and returns the
+// stability terms and their total.
+func (ps *ParameterStability) Observe(parameters map[string][]float64) map[string]float64 {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	state := make([]float64, 0)
+	for _, key := range keys {
+		state = append(state, parameters[key]...)
+	}
+	velocity := make([]float64, len(state))
+	if len(ps.Previous) == len(state) {
+		for i := range state {
+			velocity[i] = state[i] - ps.Previous[i]
+		}
+	}
+	ps.Previous = state
+
+	total := ps.Loss.Graph(autodiff.Constant(state), autodiff.Constant(velocity)).Scalar()
+	scalars := map[string]float64{"stability/total": total}
+	for name, value := range ps.Loss.Components {
+		scalars["stability/"+name] = value
+	}
+	return scalars
+}
@@ -0,0 +1,38 @@
+package training
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParameterStabilitySurvivesCheckpoint(t *testing.T) {
+	parameters := func(step int) map[string][]float64 {
+		x := float64(step)
+		return map[string][]float64{
+			"layers.0.weights": {1 / (1 + x), 0.5 * x, -0.25 * x * x},
+			"layers.0.bias":    {0.1 * x},
+		}
+	}
+
+	original := NewParameterStability()
+	for step := 0; step < 12; step++ {
+		original.Observe(parameters(step))
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := &ParameterStability{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	for step := 12; step < 15; step++ {
+		want := original.Observe(parameters(step))
+		if got := restored.Observe(parameters(step)); !reflect.DeepEqual(got, want) {
+			t.Errorf("step %d: restored stability %v, want %v", step, got, want)
+		}
+	}
+}
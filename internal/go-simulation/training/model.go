@@ -0,0 +1,239 @@
+package training
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+// Layer is one dense layer of an ElderModel. The weights of layer i live in
+// ElderModel.Parameters under weightsKey(i), row-major Inputs×Size, and its
+// bias under biasKey(i). A layer with BatchNorm normalises its
+// pre-activations and keeps its scale and shift there too, along with the
+// running statistics used outside training, which are not trained. Dropout
+// is the fraction of its activations dropped in training.
+type Layer struct {
+	Type       string  `json:"type"`
+	Activation string  `json:"activation"`
+	Inputs     int     `json:"inputs"`
+	Size       int     `json:"size"`
+	Dropout    float64 `json:"dropout,omitempty"`
+	BatchNorm  bool    `json:"batch_norm,omitempty"`
+}
+
+// LayerSpec describes a layer before the model knows its input width.
+type LayerSpec struct {
+	Type       string
+	Size       int
+	Activation string
+	Dropout    float64
+	BatchNorm  bool
+}
+
+// NewElderModel builds a dense layer stack over inputs features. Weights
+// are drawn Glorot-uniform from rng and biases start at zero.
+func NewElderModel(inputs int, specs []LayerSpec, rng *random.Source) (*ElderModel, error) {
+	model := &ElderModel{Parameters: make(map[string][]float64), Inputs: inputs}
+
+	width := inputs
+	for i, spec := range specs {
+		if spec.Type != "" && spec.Type != "dense" {
+			return nil, fmt.Errorf("layer %d: unsupported type %q", i, spec.Type)
+		}
+		if !knownActivation(spec.Activation) {
+			return nil, fmt.Errorf("layer %d: unknown activation %q", i, spec.Activation)
+		}
+		if spec.Size <= 0 {
+			return nil, fmt.Errorf("layer %d: size must be positive, got %d", i, spec.Size)
+		}
+		if spec.Dropout < 0 || spec.Dropout >= 1 {
+			return nil, fmt.Errorf("layer %d: dropout must be in [0, 1), got %g", i, spec.Dropout)
+		}
+
+		layer := Layer{
+			Type:       "dense",
+			Activation: spec.Activation,
+			Inputs:     width,
+			Size:       spec.Size,
+			Dropout:    spec.Dropout,
+			BatchNorm:  spec.BatchNorm,
+		}
+		limit := math.Sqrt(6 / float64(width+spec.Size))
+		weights := make([]float64, width*spec.Size)
+		for j := range weights {
+			weights[j] = rng.Uniform(-limit, limit)
+		}
+		model.Parameters[weightsKey(i)] = weights
+		model.Parameters[biasKey(i)] = make([]float64, spec.Size)
+		if spec.BatchNorm {
+			model.Parameters[gammaKey(i)] = filled(spec.Size, 1)
+			model.Parameters[betaKey(i)] = make([]float64, spec.Size)
+			model.Parameters[runningMeanKey(i)] = make([]float64, spec.Size)
+			model.Parameters[runningVarianceKey(i)] = filled(spec.Size, 1)
+		}
+
+		model.Layers = append(model.Layers, layer)
+		width = spec.Size
+	}
+
+	return model, nil
+}
+
+func weightsKey(layer int) string {
+	return fmt.Sprintf("layers.%d.weights", layer)
+}
+
+func biasKey(layer int) string {
+	return fmt.Sprintf("layers.%d.bias", layer)
+}
+
+func gammaKey(layer int) string {
+	return fmt.Sprintf("layers.%d.gamma", layer)
+}
+
+func betaKey(layer int) string {
+	return fmt.Sprintf("layers.%d.beta", layer)
+}
+
+func runningMeanKey(layer int) string {
+	return fmt.Sprintf("layers.%d.running_mean", layer)
+}
+
+func runningVarianceKey(layer int) string {
+	return fmt.Sprintf("layers.%d.running_variance", layer)
+}
+
+// trainedKeys are the keys of the parameters of layer i that the optimizer
+// updates.
+func (m *ElderModel) trainedKeys(i int) []string {
+	if m.Layers[i].BatchNorm {
+		return []string{weightsKey(i), biasKey(i), gammaKey(i), betaKey(i)}
+	}
+	return []string{weightsKey(i), biasKey(i)}
+}
+
+func filled(n int, value float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// Outputs is the width of the model's prediction; a model without layers
+// passes its input through.
+func (m *ElderModel) Outputs() int {
+	if len(m.Layers) == 0 {
+		return m.Inputs
+	}
+	return m.Layers[len(m.Layers)-1].Size
+}
+
+// Forward predicts the output for a single input.
+func (m *ElderModel) Forward(input []float64) []float64 {
+	return m.Graph(autodiff.Constant(input), m.constants()).Value.Values()
+}
+
+// Graph records the model applied to inputs, a vector or a batch matrix with
+// one row per sample, for inference: nothing is dropped and batch norm uses
+// its running statistics. params holds a variable for every trained entry
+// of Parameters, usually tracked on the tape that will be differentiated.
+func (m *ElderModel) Graph(inputs *autodiff.Variable, params map[string]*autodiff.Variable) *autodiff.Variable {
+	return m.graph(inputs, params, false, nil)
+}
+
+// TrainingGraph records the model as Graph does, but in training mode:
+// dropout masks are drawn from rng and batch norm normalises with the
+// statistics of the batch, which it folds into the running statistics.
+func (m *ElderModel) TrainingGraph(inputs *autodiff.Variable, params map[string]*autodiff.Variable, rng *random.Source) *autodiff.Variable {
+	return m.graph(inputs, params, true, rng)
+}
+
+func (m *ElderModel) graph(inputs *autodiff.Variable, params map[string]*autodiff.Variable, training bool, rng *random.Source) *autodiff.Variable {
+	current := inputs
+	for i, layer := range m.Layers {
+		current = autodiff.Add(autodiff.MatMul(current, params[weightsKey(i)]), params[biasKey(i)])
+		if layer.BatchNorm {
+			current = m.normalize(i, current, params, training)
+		}
+		current = activate(layer.Activation, current)
+		if layer.Dropout > 0 && training {
+			current = dropout(current, layer.Dropout, rng)
+		}
+	}
+	return current
+}
+
+// Track records every trained parameter on tape and returns the variables
+// by key.
+func (m *ElderModel) Track(tape *autodiff.Tape) map[string]*autodiff.Variable {
+	params := make(map[string]*autodiff.Variable, len(m.Parameters))
+	for i, layer := range m.Layers {
+		params[weightsKey(i)] = tape.Variable(m.Parameters[weightsKey(i)], layer.Inputs, layer.Size)
+		for _, key := range m.trainedKeys(i)[1:] {
+			params[key] = tape.Variable(m.Parameters[key], layer.Size)
+		}
+	}
+	return params
+}
+
+func (m *ElderModel) constants() map[string]*autodiff.Variable {
+	params := make(map[string]*autodiff.Variable, len(m.Parameters))
+	for i, layer := range m.Layers {
+		params[weightsKey(i)] = autodiff.Constant(m.Parameters[weightsKey(i)], layer.Inputs, layer.Size)
+		for _, key := range m.trainedKeys(i)[1:] {
+			params[key] = autodiff.Constant(m.Parameters[key], layer.Size)
+		}
+	}
+	return params
+}
+
+func knownActivation(name string) bool {
+	switch name {
+	case "", "linear", "tanh", "relu", "sigmoid", "softmax":
+		return true
+	}
+	return false
+}
+
+func activate(name string, x *autodiff.Variable) *autodiff.Variable {
+	switch name {
+	case "tanh":
+		return autodiff.Tanh(x)
+	case "relu":
+		return autodiff.ReLU(x)
+	case "sigmoid":
+		return autodiff.Sigmoid(x)
+	case "softmax":
+		return autodiff.Softmax(x)
+	default:
+		return x
+	}
+}
+
+// SyntheticRegression draws n samples of a smooth nonlinear function of
+// inputs features in [-1, 1], one target per output, for exercising the
+// training loop without a dataset.
+func SyntheticRegression(n, inputs, outputs int, rng *random.Source) []TrainingSample {
+	samples := make([]TrainingSample, n)
+	for i := range samples {
+		input := make([]float64, inputs)
+		for j := range input {
+			input[j] = rng.Uniform(-1, 1)
+		}
+
+		target := make([]float64, outputs)
+		for k := range target {
+			phase := float64(k)
+			for j, x := range input {
+				phase += x * float64(j+1)
+			}
+			target[k] = 0.5*math.Sin(phase) + 0.3*input[0]*input[0]
+		}
+
+		samples[i] = TrainingSample{Input: input, Target: target, Weight: 1}
+	}
+	return samples
+}
@@ -3,11 +3,11 @@ package training
 import "math"
 
 type OptimizationDynamics struct {
-	Optimizers     map[string]Optimizer
-	LearningRates  map[string]float64
-	Momentum       map[string]float64
-	GradientNorms  map[string]float64
-	UpdateHistory  map[string][]float64
+	Optimizers    map[string]Optimizer
+	LearningRates map[string]float64
+	Momentum      map[string]float64
+	GradientNorms map[string]float64
+	UpdateHistory map[string][]float64
 }
 
 type Optimizer struct {
@@ -32,7 +32,7 @@ func (od *OptimizationDynamics) AddOptimizer(id, optimizerType string, lr, momen
 		Parameters: map[string]float64{"lr": lr, "momentum": momentum},
 		State:      make(map[string]interface{}),
 	}
-	
+
 	od.Optimizers[id] = optimizer
 	od.LearningRates[id] = lr
 	od.Momentum[id] = momentum
@@ -41,7 +41,7 @@ func (od *OptimizationDynamics) AddOptimizer(id, optimizerType string, lr, momen
 
 func (od *OptimizationDynamics) UpdateParameters(id string, gradients []float64, parameters []float64) []float64 {
 	optimizer := od.Optimizers[id]
-	
+
 	switch optimizer.Type {
 	case "sgd":
 		return od.sgdUpdate(id, gradients, parameters)
@@ -57,9 +57,9 @@ func (od *OptimizationDynamics) UpdateParameters(id string, gradients []float64,
 func (od *OptimizationDynamics) sgdUpdate(id string, gradients, parameters []float64) []float64 {
 	lr := od.LearningRates[id]
 	momentum := od.Momentum[id]
-	
+
 	updated := make([]float64, len(parameters))
-	
+
 	for i := range parameters {
 		if i < len(gradients) {
 			velocity := momentum*od.getPreviousUpdate(id, i) + lr*gradients[i]
@@ -69,66 +69,66 @@ func (od *OptimizationDynamics) sgdUpdate(id string, gradients, parameters []flo
 			updated[i] = parameters[i]
 		}
 	}
-	
+
 	od.GradientNorms[id] = od.calculateNorm(gradients)
 	return updated
 }
 
 func (od *OptimizationDynamics) adamUpdate(id string, gradients, parameters []float64) []float64 {
 	lr := od.LearningRates[id]
-	beta1 := 0.9
-	beta2 := 0.999
-	epsilon := 1e-8
-	
+	beta1 := od.hyperparameter(id, "beta1", 0.9)
+	beta2 := od.hyperparameter(id, "beta2", 0.999)
+	epsilon := od.hyperparameter(id, "epsilon", 1e-8)
+
 	optimizer := od.Optimizers[id]
 	if optimizer.State["m"] == nil {
 		optimizer.State["m"] = make([]float64, len(parameters))
 		optimizer.State["v"] = make([]float64, len(parameters))
 		optimizer.State["t"] = 0.0
 	}
-	
+
 	m := optimizer.State["m"].([]float64)
 	v := optimizer.State["v"].([]float64)
 	t := optimizer.State["t"].(float64) + 1
-	
+
 	updated := make([]float64, len(parameters))
-	
+
 	for i := range parameters {
 		if i < len(gradients) {
 			m[i] = beta1*m[i] + (1-beta1)*gradients[i]
 			v[i] = beta2*v[i] + (1-beta2)*gradients[i]*gradients[i]
-			
+
 			mHat := m[i] / (1 - math.Pow(beta1, t))
 			vHat := v[i] / (1 - math.Pow(beta2, t))
-			
+
 			updated[i] = parameters[i] - lr*mHat/(math.Sqrt(vHat)+epsilon)
 		} else {
 			updated[i] = parameters[i]
 		}
 	}
-	
+
 	optimizer.State["m"] = m
 	optimizer.State["v"] = v
 	optimizer.State["t"] = t
 	od.Optimizers[id] = optimizer
-	
+
 	od.GradientNorms[id] = od.calculateNorm(gradients)
 	return updated
 }
 
 func (od *OptimizationDynamics) rmspropUpdate(id string, gradients, parameters []float64) []float64 {
 	lr := od.LearningRates[id]
-	decay := 0.9
-	epsilon := 1e-8
-	
+	decay := od.hyperparameter(id, "decay", 0.9)
+	epsilon := od.hyperparameter(id, "epsilon", 1e-8)
+
 	optimizer := od.Optimizers[id]
 	if optimizer.State["s"] == nil {
 		optimizer.State["s"] = make([]float64, len(parameters))
 	}
-	
+
 	s := optimizer.State["s"].([]float64)
 	updated := make([]float64, len(parameters))
-	
+
 	for i := range parameters {
 		if i < len(gradients) {
 			s[i] = decay*s[i] + (1-decay)*gradients[i]*gradients[i]
@@ -137,14 +137,27 @@ func (od *OptimizationDynamics) rmspropUpdate(id string, gradients, parameters [
 			updated[i] = parameters[i]
 		}
 	}
-	
+
 	optimizer.State["s"] = s
 	od.Optimizers[id] = optimizer
-	
+
 	od.GradientNorms[id] = od.calculateNorm(gradients)
 	return updated
 }
 
+// SetHyperparameter overrides one of the optimizer's constants, such as
+// adam's beta1, beta2 and epsilon or rmsprop's decay.
+func (od *OptimizationDynamics) SetHyperparameter(id, name string, value float64) {
+	od.Optimizers[id].Parameters[name] = value
+}
+
+func (od *OptimizationDynamics) hyperparameter(id, name string, fallback float64) float64 {
+	if value, exists := od.Optimizers[id].Parameters[name]; exists {
+		return value
+	}
+	return fallback
+}
+
 func (od *OptimizationDynamics) calculateNorm(gradients []float64) float64 {
 	norm := 0.0
 	for _, grad := range gradients {
@@ -160,25 +173,32 @@ func (od *OptimizationDynamics) getPreviousUpdate(id string, index int) float64
 	return 0.0
 }
 
+// recordUpdate keeps the last step of every element for momentum.
 func (od *OptimizationDynamics) recordUpdate(id string, index int, update float64) {
-	if od.UpdateHistory[id] == nil {
-		od.UpdateHistory[id] = make([]float64, index+1)
-	}
-	
-	if index < len(od.UpdateHistory[id]) {
-		od.UpdateHistory[id][index] = update
+	history := od.UpdateHistory[id]
+	if index >= len(history) {
+		history = append(history, make([]float64, index+1-len(history))...)
+		od.UpdateHistory[id] = history
 	}
+	history[index] = update
+}
+
+// SetLearningRate changes the rate of one optimizer, as a schedule does
+// between epochs.
+func (od *OptimizationDynamics) SetLearningRate(id string, lr float64) {
+	od.LearningRates[id] = lr
+	od.Optimizers[id].Parameters["lr"] = lr
 }
 
 func (od *OptimizationDynamics) AdaptLearningRate(id string, performance float64) {
 	currentLR := od.LearningRates[id]
-	
+
 	if performance < 0.1 {
 		od.LearningRates[id] = currentLR * 1.1
 	} else if performance > 0.9 {
 		od.LearningRates[id] = currentLR * 0.9
 	}
-	
+
 	optimizer := od.Optimizers[id]
 	optimizer.Parameters["lr"] = od.LearningRates[id]
 	od.Optimizers[id] = optimizer
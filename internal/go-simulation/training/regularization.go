@@ -0,0 +1,90 @@
+package training
+
+import (
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+// Batch norm running statistics move this far towards each batch's, and
+// the variance is padded by batchNormEpsilon before dividing by its root.
+const (
+	batchNormMomentum = 0.1
+	batchNormEpsilon  = 1e-5
+)
+
+// Regularization penalises large weights: L1 times the sum of their
+// absolute values plus L2 times the sum of their squares. Biases and batch
+// norm parameters are not penalised.
+type Regularization struct {
+	L1 float64 `json:"l1"`
+	L2 float64 `json:"l2"`
+}
+
+// Penalty records the penalty on weights on their tape, so that it is
+// differentiated with the loss it is added to. It is nil when both
+// coefficients are zero.
+func (r Regularization) Penalty(weights ...*autodiff.Variable) *autodiff.Variable {
+	var total *autodiff.Variable
+	add := func(term *autodiff.Variable) {
+		if total == nil {
+			total = term
+		} else {
+			total = autodiff.Add(total, term)
+		}
+	}
+
+	for _, w := range weights {
+		if r.L1 > 0 {
+			add(autodiff.Scale(autodiff.Sum(autodiff.Abs(w)), r.L1))
+		}
+		if r.L2 > 0 {
+			add(autodiff.Scale(autodiff.Sum(autodiff.Square(w)), r.L2))
+		}
+	}
+	return total
+}
+
+// dropout zeroes each activation with probability rate and scales the rest
+// by 1/(1-rate), so that the expected activation is unchanged and nothing
+// needs rescaling at inference.
+func dropout(x *autodiff.Variable, rate float64, rng *random.Source) *autodiff.Variable {
+	mask := make([]float64, x.Len())
+	for i := range mask {
+		if rng.Float64() >= rate {
+			mask[i] = 1 / (1 - rate)
+		}
+	}
+	return autodiff.Mul(x, autodiff.Constant(mask, x.Shape()...))
+}
+
+// normalize applies batch norm to the pre-activations x of layer i. In
+// training the batch is normalised by its own mean and variance, which are
+// folded into the running statistics; otherwise the running statistics are
+// used. Either way the result is scaled by gamma and shifted by beta.
+func (m *ElderModel) normalize(i int, x *autodiff.Variable, params map[string]*autodiff.Variable, training bool) *autodiff.Variable {
+	size := m.Layers[i].Size
+	mean := autodiff.Constant(m.Parameters[runningMeanKey(i)], size)
+	variance := autodiff.Constant(m.Parameters[runningVarianceKey(i)], size)
+	centered := autodiff.Sub(x, mean)
+
+	if training {
+		rows := x.Len() / size
+		average := autodiff.Constant(filled(rows, 1/float64(rows)), rows)
+		mean = autodiff.MatMul(average, x)
+		centered = autodiff.Sub(x, mean)
+		variance = autodiff.MatMul(average, autodiff.Square(centered))
+
+		batchMean, batchVariance := mean.Value.Values(), variance.Value.Values()
+		runningMean := make([]float64, size)
+		runningVariance := make([]float64, size)
+		for j := range runningMean {
+			runningMean[j] = (1-batchNormMomentum)*m.Parameters[runningMeanKey(i)][j] + batchNormMomentum*batchMean[j]
+			runningVariance[j] = (1-batchNormMomentum)*m.Parameters[runningVarianceKey(i)][j] + batchNormMomentum*batchVariance[j]
+		}
+		m.Parameters[runningMeanKey(i)] = runningMean
+		m.Parameters[runningVarianceKey(i)] = runningVariance
+	}
+
+	normalized := autodiff.Div(centered, autodiff.Sqrt(autodiff.Shift(variance, batchNormEpsilon)))
+	return autodiff.Add(autodiff.Mul(normalized, params[gammaKey(i)]), params[betaKey(i)])
+}
@@ -0,0 +1,177 @@
+package training
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+func TestPenaltyGradient(t *testing.T) {
+	// No weight is zero, where the L1 term has no derivative.
+	weights := []float64{0.5, -1.25, 2, -0.1, 0.75, -3}
+	bias := []float64{0.3, -0.7}
+
+	for _, r := range []Regularization{{L1: 0.3}, {L2: 0.7}, {L1: 0.3, L2: 0.7}} {
+		penalty := func(tape *autodiff.Tape, x []float64) (*autodiff.Variable, *autodiff.Variable, *autodiff.Variable) {
+			w := tape.Variable(x[:6], 2, 3)
+			b := tape.Variable(x[6:])
+			return r.Penalty(w, b), w, b
+		}
+		x := append(append([]float64{}, weights...), bias...)
+
+		total, w, b := penalty(autodiff.NewTape(), x)
+		if err := total.Backward(); err != nil {
+			t.Fatal(err)
+		}
+		analytic := append(w.Grad.Values(), b.Grad.Values()...)
+
+		want, exact := 0.0, make([]float64, len(x))
+		for i, value := range x {
+			want += r.L1*math.Abs(value) + r.L2*value*value
+			exact[i] = r.L1*math.Copysign(1, value) + 2*r.L2*value
+		}
+		if math.Abs(total.Scalar()-want) > 1e-12 {
+			t.Errorf("%+v: penalty %g, want %g", r, total.Scalar(), want)
+		}
+
+		numeric := autodiff.NumericalGradient(func(x []float64) float64 {
+			total, _, _ := penalty(autodiff.NewTape(), x)
+			return total.Scalar()
+		}, x, 1e-6)
+		if worst := autodiff.GradientError(analytic, numeric); worst > 1e-8 {
+			t.Errorf("%+v: gradient differs from finite differences by %g: %v, want %v", r, worst, analytic, numeric)
+		}
+		if worst := autodiff.GradientError(analytic, exact); worst > 1e-12 {
+			t.Errorf("%+v: gradient %v, want %v", r, analytic, exact)
+		}
+	}
+
+	if penalty := (Regularization{}).Penalty(autodiff.NewTape().Variable(weights)); penalty != nil {
+		t.Errorf("zero coefficients gave a penalty of %g", penalty.Scalar())
+	}
+}
+
+func TestDropoutKeepsExpectedActivation(t *testing.T) {
+	const n = 100000
+	ones := make([]float64, n)
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	for _, rate := range []float64{0.1, 0.5, 0.9} {
+		tape := autodiff.NewTape()
+		x := tape.Variable(ones)
+		out := dropout(x, rate, random.NewSource(13))
+		if err := autodiff.Sum(out).Backward(); err != nil {
+			t.Fatal(err)
+		}
+
+		sum, dropped := 0.0, 0
+		for i, value := range out.Value.Values() {
+			sum += value
+			switch value {
+			case 0:
+				dropped++
+			case 1 / (1 - rate):
+			default:
+				t.Fatalf("rate %g: activation %d is %g, want 0 or %g", rate, i, value, 1/(1-rate))
+			}
+		}
+
+		// Each kept activation is 1/(1-rate) with probability 1-rate, so
+		// the mean has standard deviation sqrt(rate/(1-rate)/n): at most
+		// 0.01 here. Allow four of them.
+		sigma := math.Sqrt(rate / (1 - rate) / n)
+		if mean := sum / n; math.Abs(mean-1) > 4*sigma {
+			t.Errorf("rate %g: mean activation %g, want 1 ± %g", rate, mean, 4*sigma)
+		}
+		if fraction := float64(dropped) / n; math.Abs(fraction-rate) > 0.01 {
+			t.Errorf("rate %g: dropped %g of the activations", rate, fraction)
+		}
+		// The gradient flows through the kept activations, scaled alike.
+		if worst := autodiff.GradientError(x.Grad.Values(), out.Value.Values()); worst != 0 {
+			t.Errorf("rate %g: gradient is not the mask", rate)
+		}
+
+		again := dropout(autodiff.Constant(ones), rate, random.NewSource(13))
+		if autodiff.GradientError(again.Value.Values(), out.Value.Values()) != 0 {
+			t.Errorf("rate %g: the same seed drew a different mask", rate)
+		}
+	}
+}
+
+func TestBatchNormStatistics(t *testing.T) {
+	model, err := NewElderModel(2, []LayerSpec{{Size: 2, Activation: "linear", BatchNorm: true}}, random.NewSource(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pre-activations are the inputs swapped, plus the bias.
+	model.Parameters[weightsKey(0)] = []float64{0, 1, 1, 0}
+	model.Parameters[biasKey(0)] = []float64{1, -1}
+	model.Parameters[gammaKey(0)] = []float64{2, 0.5}
+	model.Parameters[betaKey(0)] = []float64{3, -3}
+	model.Parameters[runningMeanKey(0)] = []float64{0.5, 4}
+	model.Parameters[runningVarianceKey(0)] = []float64{2, 9}
+
+	batch := []float64{
+		1, 10,
+		2, 20,
+		3, 30,
+		6, 60,
+	}
+	// Columns of pre-activations x·W + b.
+	columns := [][]float64{{11, 21, 31, 61}, {0, 1, 2, 5}}
+	gamma, beta := []float64{2, 0.5}, []float64{3, -3}
+
+	normalize := func(x, mean, variance float64, j int) float64 {
+		return gamma[j]*(x-mean)/math.Sqrt(variance+batchNormEpsilon) + beta[j]
+	}
+	check := func(t *testing.T, out *autodiff.Variable, mean, variance []float64) {
+		t.Helper()
+		values := out.Value.Values()
+		for row := 0; row < 4; row++ {
+			for j := 0; j < 2; j++ {
+				want := normalize(columns[j][row], mean[j], variance[j], j)
+				if got := values[row*2+j]; math.Abs(got-want) > 1e-9 {
+					t.Errorf("output [%d][%d] = %g, want %g", row, j, got, want)
+				}
+			}
+		}
+	}
+
+	// Inference uses the running statistics and leaves them alone.
+	check(t, model.Graph(autodiff.Constant(batch, 4, 2), model.constants()), []float64{0.5, 4}, []float64{2, 9})
+	if model.Parameters[runningMeanKey(0)][0] != 0.5 || model.Parameters[runningVarianceKey(0)][1] != 9 {
+		t.Fatalf("inference changed the running statistics")
+	}
+
+	// Training uses the batch's own population statistics and folds them
+	// into the running ones.
+	batchMean, batchVariance := make([]float64, 2), make([]float64, 2)
+	for j, column := range columns {
+		for _, x := range column {
+			batchMean[j] += x / 4
+		}
+		for _, x := range column {
+			batchVariance[j] += (x - batchMean[j]) * (x - batchMean[j]) / 4
+		}
+	}
+	out := model.TrainingGraph(autodiff.Constant(batch, 4, 2), model.constants(), random.NewSource(1))
+	check(t, out, batchMean, batchVariance)
+
+	wantMean := []float64{0.9*0.5 + 0.1*batchMean[0], 0.9*4 + 0.1*batchMean[1]}
+	wantVariance := []float64{0.9*2 + 0.1*batchVariance[0], 0.9*9 + 0.1*batchVariance[1]}
+	for j := range wantMean {
+		if got := model.Parameters[runningMeanKey(0)][j]; math.Abs(got-wantMean[j]) > 1e-12 {
+			t.Errorf("running mean %d = %g, want %g", j, got, wantMean[j])
+		}
+		if got := model.Parameters[runningVarianceKey(0)][j]; math.Abs(got-wantVariance[j]) > 1e-12 {
+			t.Errorf("running variance %d = %g, want %g", j, got, wantVariance[j])
+		}
+	}
+
+	// After training, inference follows the updated running statistics.
+	check(t, model.Graph(autodiff.Constant(batch, 4, 2), model.constants()), wantMean, wantVariance)
+}
@@ -0,0 +1,88 @@
+package training
+
+import "math"
+
+// Learning-rate schedules.
+const (
+	ScheduleConstant = "constant"
+	ScheduleStep     = "step"
+	ScheduleCosine   = "cosine"
+	SchedulePlateau  = "plateau"
+)
+
+// LearningRateSchedule sets the learning rate for each epoch. Any schedule
+// can be preceded by WarmupEpochs of linear warmup from zero. The plateau
+// schedule multiplies its rate by Factor whenever the monitored loss has not
+// improved for Patience epochs; its progress is exported so that it can be
+// checkpointed.
+type LearningRateSchedule struct {
+	Type         string  `json:"type"`
+	BaseRate     float64 `json:"base_rate"`
+	MinRate      float64 `json:"min_rate"`
+	StepSize     int     `json:"step_size"`
+	Gamma        float64 `json:"gamma"`
+	WarmupEpochs int     `json:"warmup_epochs"`
+	Patience     int     `json:"patience"`
+	Factor       float64 `json:"factor"`
+	PlateauRate  float64 `json:"plateau_rate"`
+	PlateauBest  float64 `json:"plateau_best"`
+	PlateauWait  int     `json:"plateau_wait"`
+}
+
+func NewLearningRateSchedule(scheduleType string, baseRate float64) *LearningRateSchedule {
+	return &LearningRateSchedule{
+		Type:        scheduleType,
+		BaseRate:    baseRate,
+		StepSize:    30,
+		Gamma:       0.1,
+		Patience:    5,
+		Factor:      0.5,
+		PlateauRate: baseRate,
+		PlateauBest: math.MaxFloat64,
+	}
+}
+
+// Rate is the learning rate for epoch, counted from zero, of a run lasting
+// epochs.
+func (lrs *LearningRateSchedule) Rate(epoch, epochs int) float64 {
+	if epoch < lrs.WarmupEpochs {
+		return lrs.BaseRate * float64(epoch+1) / float64(lrs.WarmupEpochs)
+	}
+	elapsed := epoch - lrs.WarmupEpochs
+
+	switch lrs.Type {
+	case ScheduleStep:
+		return math.Max(lrs.MinRate, lrs.BaseRate*math.Pow(lrs.Gamma, float64(elapsed/lrs.StepSize)))
+	case ScheduleCosine:
+		span := epochs - lrs.WarmupEpochs
+		if span <= 1 {
+			return lrs.BaseRate
+		}
+		progress := float64(elapsed) / float64(span-1)
+		return lrs.MinRate + (lrs.BaseRate-lrs.MinRate)*(1+math.Cos(math.Pi*progress))/2
+	case SchedulePlateau:
+		return lrs.PlateauRate
+	default:
+		return lrs.BaseRate
+	}
+}
+
+// Observe feeds the epoch's monitored loss to the plateau schedule; other
+// schedules ignore it.
+func (lrs *LearningRateSchedule) Observe(loss float64) {
+	if lrs.Type != SchedulePlateau {
+		return
+	}
+
+	if loss < lrs.PlateauBest {
+		lrs.PlateauBest = loss
+		lrs.PlateauWait = 0
+		return
+	}
+
+	lrs.PlateauWait++
+	if lrs.PlateauWait >= lrs.Patience {
+		lrs.PlateauRate = math.Max(lrs.MinRate, lrs.PlateauRate*lrs.Factor)
+		lrs.PlateauWait = 0
+	}
+}
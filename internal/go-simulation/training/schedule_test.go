@@ -0,0 +1,113 @@
+package training
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-12*math.Max(1, math.Abs(b))
+}
+
+func TestWarmupRampsToBaseRate(t *testing.T) {
+	for _, scheduleType := range []string{ScheduleConstant, ScheduleStep, ScheduleCosine, SchedulePlateau} {
+		t.Run(scheduleType, func(t *testing.T) {
+			lrs := NewLearningRateSchedule(scheduleType, 0.1)
+			lrs.WarmupEpochs = 4
+			lrs.MinRate = 0.01
+
+			want := []float64{0.025, 0.05, 0.075, 0.1}
+			for epoch, rate := range want {
+				if got := lrs.Rate(epoch, 20); !near(got, rate) {
+					t.Errorf("warmup epoch %d: rate %g, want %g", epoch, got, rate)
+				}
+			}
+			// Warmup hands over to the schedule at its base rate.
+			if got := lrs.Rate(lrs.WarmupEpochs, 20); !near(got, lrs.BaseRate) {
+				t.Errorf("first epoch after warmup: rate %g, want %g", got, lrs.BaseRate)
+			}
+		})
+	}
+}
+
+func TestStepScheduleDecaysAtBoundaries(t *testing.T) {
+	lrs := NewLearningRateSchedule(ScheduleStep, 1)
+	lrs.StepSize = 3
+	lrs.Gamma = 0.5
+	lrs.MinRate = 0.1
+	lrs.WarmupEpochs = 2
+
+	// Steps are counted from the end of warmup; 1/16 is clamped to MinRate.
+	want := map[int]float64{
+		2: 1, 3: 1, 4: 1,
+		5: 0.5, 7: 0.5,
+		8: 0.25, 10: 0.25,
+		11: 0.125, 13: 0.125,
+		14: 0.1, 40: 0.1,
+	}
+	for epoch, rate := range want {
+		if got := lrs.Rate(epoch, 50); !near(got, rate) {
+			t.Errorf("epoch %d: rate %g, want %g", epoch, got, rate)
+		}
+	}
+}
+
+func TestCosineScheduleSpansBaseToMinRate(t *testing.T) {
+	const epochs = 12
+	lrs := NewLearningRateSchedule(ScheduleCosine, 1)
+	lrs.MinRate = 0.1
+	lrs.WarmupEpochs = 2
+
+	if got := lrs.Rate(lrs.WarmupEpochs, epochs); !near(got, 1) {
+		t.Errorf("first epoch after warmup: rate %g, want the base rate 1", got)
+	}
+	if got := lrs.Rate(epochs-1, epochs); !near(got, 0.1) {
+		t.Errorf("last epoch: rate %g, want the minimum rate 0.1", got)
+	}
+	for epoch := lrs.WarmupEpochs + 1; epoch < epochs; epoch++ {
+		if lrs.Rate(epoch, epochs) > lrs.Rate(epoch-1, epochs) {
+			t.Errorf("rate rose from %g to %g at epoch %d", lrs.Rate(epoch-1, epochs), lrs.Rate(epoch, epochs), epoch)
+		}
+	}
+	// A single epoch after warmup has nowhere to decay to.
+	if got := lrs.Rate(2, 3); got != 1 {
+		t.Errorf("single epoch after warmup: rate %g, want 1", got)
+	}
+}
+
+func TestPlateauReducesAfterPatience(t *testing.T) {
+	lrs := NewLearningRateSchedule(SchedulePlateau, 1)
+	lrs.Patience = 3
+	lrs.Factor = 0.5
+	lrs.MinRate = 0.2
+
+	// Each loss and the rate expected after observing it. A loss equal to
+	// the best is not an improvement.
+	steps := []struct {
+		loss, rate float64
+	}{
+		{1, 1}, {0.9, 1},
+		{0.95, 1}, {0.9, 1}, {0.91, 0.5},
+		{0.8, 0.5}, {0.85, 0.5}, {0.85, 0.5},
+		{0.7, 0.5}, {0.75, 0.5}, {0.75, 0.5}, {0.75, 0.25},
+		{0.75, 0.25}, {0.75, 0.25}, {0.75, 0.2},
+		{0.75, 0.2}, {0.75, 0.2}, {0.75, 0.2},
+	}
+	for i, step := range steps {
+		lrs.Observe(step.loss)
+		if got := lrs.Rate(i+1, 100); !near(got, step.rate) {
+			t.Fatalf("after loss %d (%g): rate %g, want %g", i, step.loss, got, step.rate)
+		}
+	}
+}
+
+func TestOnlyPlateauObservesLoss(t *testing.T) {
+	lrs := NewLearningRateSchedule(ScheduleConstant, 1)
+	lrs.Patience = 1
+	for i := 0; i < 5; i++ {
+		lrs.Observe(1)
+	}
+	if lrs.PlateauWait != 0 || lrs.PlateauRate != 1 || lrs.Rate(5, 10) != 1 {
+		t.Errorf("constant schedule reacted to the loss: %+v", lrs)
+	}
+}
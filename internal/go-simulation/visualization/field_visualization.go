@@ -1,12 +1,17 @@
 package visualization
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/parallel"
+)
 
 type FieldVisualizer struct {
 	GravitationalFields []GravField
 	GridResolution      int
 	BoundingBox         BoundingBox
 	FieldLines          []FieldLine
+	Pool                *parallel.Pool
 }
 
 type GravField struct {
@@ -47,24 +52,30 @@ func (fv *FieldVisualizer) AddGravitationalField(pos Point3D, strength float64,
 	fv.GravitationalFields = append(fv.GravitationalFields, field)
 }
 
+// GenerateFieldLines traces one line from every grid point. Lines are traced
+// across fv.Pool and kept in grid order.
 func (fv *FieldVisualizer) GenerateFieldLines() {
 	fv.FieldLines = make([]FieldLine, 0)
 	
 	stepX := (fv.BoundingBox.MaxX - fv.BoundingBox.MinX) / float64(fv.GridResolution)
 	stepY := (fv.BoundingBox.MaxY - fv.BoundingBox.MinY) / float64(fv.GridResolution)
 	
-	for i := 0; i < fv.GridResolution; i++ {
-		for j := 0; j < fv.GridResolution; j++ {
+	lines := make([]FieldLine, fv.GridResolution*fv.GridResolution)
+	fv.Pool.For(len(lines), func(start, end int) {
+		for k := start; k < end; k++ {
+			i, j := k/fv.GridResolution, k%fv.GridResolution
 			startPoint := Point3D{
 				X: fv.BoundingBox.MinX + float64(i)*stepX,
 				Y: fv.BoundingBox.MinY + float64(j)*stepY,
 				Z: 0,
 			}
-			
-			fieldLine := fv.traceFieldLine(startPoint)
-			if len(fieldLine.Points) > 1 {
-				fv.FieldLines = append(fv.FieldLines, fieldLine)
-			}
+			lines[k] = fv.traceFieldLine(startPoint)
+		}
+	})
+	
+	for _, fieldLine := range lines {
+		if len(fieldLine.Points) > 1 {
+			fv.FieldLines = append(fv.FieldLines, fieldLine)
 		}
 	}
 }
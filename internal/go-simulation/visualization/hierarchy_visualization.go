@@ -1,5 +1,7 @@
 package visualization
 
+import "math"
+
 type HierarchyVisualizer struct {
 	Entities     map[string]HierarchyEntity
 	Connections  []Connection
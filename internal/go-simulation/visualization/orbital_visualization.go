@@ -1,12 +1,19 @@
 package visualization
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"strings"
+)
 
 type OrbitalVisualizer struct {
 	Bodies     []CelestialBody
 	Trajectories map[string][]Point3D
 	TimeStep   float64
 	Scale      float64
+	MaxPoints  int
+	strides    map[string]int
+	skipped    map[string]int
 }
 
 type CelestialBody struct {
@@ -27,6 +34,9 @@ func NewOrbitalVisualizer(timeStep, scale float64) *OrbitalVisualizer {
 		Trajectories: make(map[string][]Point3D),
 		TimeStep:     timeStep,
 		Scale:        scale,
+		MaxPoints:    1000,
+		strides:      make(map[string]int),
+		skipped:      make(map[string]int),
 	}
 }
 
@@ -108,3 +118,79 @@ func (ov *OrbitalVisualizer) calculateDistance(p1, p2 Point3D) float64 {
 	dz := p1.Z - p2.Z
 	return math.Sqrt(dx*dx + dy*dy + dz*dz)
 }
+
+// RecordPosition appends a sampled position to a body's trajectory. Once a
+// trajectory holds MaxPoints points every other point is dropped and only
+// every second subsequent sample is kept, so arbitrarily long runs are
+// covered end to end in bounded memory.
+func (ov *OrbitalVisualizer) RecordPosition(id string, position Point3D) {
+	stride := ov.strides[id]
+	if stride == 0 {
+		stride = 1
+		ov.strides[id] = stride
+	}
+
+	ov.skipped[id]++
+	if ov.skipped[id] < stride {
+		return
+	}
+	ov.skipped[id] = 0
+
+	points := append(ov.Trajectories[id], position)
+	if ov.MaxPoints > 1 && len(points) >= ov.MaxPoints {
+		kept := points[:0]
+		for i := 0; i < len(points); i += 2 {
+			kept = append(kept, points[i])
+		}
+		points = kept
+		ov.strides[id] = stride * 2
+	}
+	ov.Trajectories[id] = points
+}
+
+// RenderSVG draws every trajectory projected onto the XY plane, scaled to
+// fit a size x size canvas.
+func (ov *OrbitalVisualizer) RenderSVG(size int) string {
+	extent := 0.0
+	for _, points := range ov.Trajectories {
+		for _, p := range points {
+			extent = math.Max(extent, math.Max(math.Abs(p.X), math.Abs(p.Y)))
+		}
+	}
+	if extent == 0 {
+		extent = 1
+	}
+	scale := 0.48 * float64(size) / extent
+	centre := float64(size) / 2
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size, size, size, size)
+	fmt.Fprintf(&b, "<rect width=\"%d\" height=\"%d\" fill=\"black\"/>\n", size, size)
+
+	for _, body := range ov.Bodies {
+		points := ov.Trajectories[body.ID]
+		if len(points) == 0 {
+			continue
+		}
+
+		color := body.Color
+		if color == "" {
+			color = "white"
+		}
+
+		b.WriteString("<polyline fill=\"none\" stroke-width=\"1\" stroke=\"" + color + "\" points=\"")
+		for i, p := range points {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(&b, "%.1f,%.1f", centre+p.X*scale, centre-p.Y*scale)
+		}
+		b.WriteString("\"><title>" + body.ID + "</title></polyline>\n")
+
+		last := points[len(points)-1]
+		fmt.Fprintf(&b, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"3\" fill=\"%s\"/>\n", centre+last.X*scale, centre-last.Y*scale, color)
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
@@ -1,6 +1,6 @@
 package visualization
 
-import "math"
+import "fmt"
 
 type PhaseSpacePlotter struct {
 	Trajectories map[string]PhaseTrajectory
@@ -3,10 +3,11 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ykashou/go-elder/pkg/go-cli/commands"
 )
 
 var rootCmd = &cobra.Command{
@@ -17,74 +18,297 @@ hierarchical artificial intelligence with Elder, Mentor, and Erudite entities.
 
 The system implements gravitational field dynamics, heliomorphic functions,
 and multi-level knowledge transfer across domains.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Go-Elder Hierarchical AI System")
 		fmt.Println("Use 'go-elder --help' to see available commands")
 	},
 }
 
-var simulateCmd = &cobra.Command{
-	Use:   "simulate",
-	Short: "Run Elder Theory simulation",
-	Long:  "Execute orbital dynamics simulation with Elder, Mentor, and Erudite entities",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting Elder Theory simulation...")
-		runSimulation()
-	},
+func newSimulateCmd() *cobra.Command {
+	sc := commands.NewSimulateCommand()
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Run Elder Theory simulation",
+		Long:  "Execute orbital dynamics simulation with Elder, Mentor, and Erudite entities",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sc.Overrides = configOverrides(cmd, map[string]string{
+				"duration":      "simulation.max_duration",
+				"time-step":     "simulation.integration.time_step",
+				"method":        "simulation.integration.method",
+				"adaptive":      "simulation.integration.adaptive",
+				"solver":        "simulation.engine.type",
+				"theta":         "simulation.engine.opening_angle",
+				"parallel":      "simulation.engine.parallel",
+				"threads":       "simulation.engine.threads",
+				"monitor":       "simulation.monitor.enabled",
+				"monitor-every": "simulation.monitor.every",
+				"on-drift":      "simulation.monitor.action",
+				"seed":          "simulation.seed",
+				"format":        "simulation.output.format",
+				"compress":      "simulation.output.compress",
+			})
+			return sc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&sc.ConfigFile, "config", "c", "", "JSON or YAML ElderConfig file")
+	flags.Float64Var(&sc.Duration, "duration", sc.Duration, "simulated duration in time units")
+	flags.Float64Var(&sc.TimeStep, "time-step", sc.TimeStep, "integration time step")
+	flags.StringVarP(&sc.OutputFile, "output", "o", sc.OutputFile, "file to write simulation results to")
+	flags.BoolVar(&sc.Visualize, "visualize", sc.Visualize, "generate visualization data while simulating")
+	flags.BoolVar(&sc.Interactive, "interactive", sc.Interactive, "run the simulation interactively")
+	flags.BoolVar(&sc.Realtime, "realtime", sc.Realtime, "pace steps against the wall clock instead of running as fast as possible")
+	flags.String("method", "", "integration method: euler, semi_implicit_euler, verlet, leapfrog, rk4 or yoshida")
+	flags.Bool("adaptive", false, "use adaptive Dormand-Prince time stepping")
+	flags.String("solver", "", "force solver: direct or tree (Barnes-Hut)")
+	flags.Float64("theta", 0.5, "Barnes-Hut opening angle for the tree solver")
+	flags.Bool("parallel", false, "shard force evaluation and integration across worker threads")
+	flags.Int("threads", 0, "worker threads when --parallel is set (default: all CPUs)")
+	flags.Bool("monitor", false, "sample energy and angular momentum drift during the run")
+	flags.Int("monitor-every", 100, "steps between conservation samples")
+	flags.String("on-drift", "warn", "what to do when drift exceeds system.tolerance: warn or abort")
+	flags.Int64("seed", 1, "seed for the initial layout and stochastic systems")
+	flags.String("format", "", "trajectory format: csv, jsonl or binary (default from the output file extension)")
+	flags.Bool("compress", false, "gzip the trajectory output")
+	flags.StringVar(&sc.ResumeFile, "resume", "", "continue from a checkpoint written by an earlier run")
+	return cmd
 }
 
-var trainCmd = &cobra.Command{
-	Use:   "train",
-	Short: "Train hierarchical models",
-	Long:  "Train Elder, Mentor, and Erudite entities using hierarchical learning algorithms",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting hierarchical training...")
-		runTraining()
-	},
+func newTrainCmd() *cobra.Command {
+	tc := commands.NewTrainCommand()
+	cmd := &cobra.Command{
+		Use:   "train",
+		Short: "Train hierarchical models",
+		Long:  "Train Elder, Mentor, and Erudite entities using hierarchical learning algorithms",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tc.Overrides = configOverrides(cmd, map[string]string{
+				"epochs":         "epochs",
+				"learning-rate":  "optimizer.learning_rate",
+				"batch-size":     "data.batch_size",
+				"optimizer":      "optimizer.type",
+				"seed":           "seed",
+				"checkpoints":    "checkpoints.enabled",
+				"checkpoint-dir": "checkpoints.directory",
+				"track":          "tracking.enabled",
+				"run-name":       "tracking.name",
+				"runs-dir":       "tracking.directory",
+			})
+			return tc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&tc.ConfigFile, "config", "c", "", "JSON or YAML TrainingConfig file")
+	flags.StringVar(&tc.ModelPath, "model", tc.ModelPath, "path to read or write the model")
+	flags.StringVar(&tc.DataPath, "data", tc.DataPath, "CSV, JSONL or .npy training data; the last data.targets columns are targets")
+	flags.IntVar(&tc.Epochs, "epochs", tc.Epochs, "number of training epochs")
+	flags.Float64Var(&tc.LearningRate, "learning-rate", tc.LearningRate, "optimizer learning rate")
+	flags.IntVar(&tc.BatchSize, "batch-size", tc.BatchSize, "mini-batch size")
+	flags.String("optimizer", "", "optimizer: sgd, adam or rmsprop")
+	flags.Int64("seed", 1, "seed for weight initialisation, data shuffling and synthetic data")
+	flags.Bool("checkpoints", false, "write training checkpoints every checkpoints.frequency epochs")
+	flags.String("checkpoint-dir", "checkpoints", "directory for training checkpoints")
+	flags.StringVar(&tc.ResumeFile, "resume", "", "continue from a training checkpoint, or the newest one in a directory")
+	flags.Bool("track", true, "log metrics, metadata and the config to a run directory")
+	flags.String("run-name", "", "name appended to the run directory")
+	flags.String("runs-dir", "runs", "directory holding run directories")
+	return cmd
 }
 
-var analyzeCmd = &cobra.Command{
-	Use:   "analyze",
-	Short: "Analyze system performance",
-	Long:  "Analyze Elder Theory system performance and generate reports",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting system analysis...")
-		runAnalysis()
-	},
+func newTuneCmd() *cobra.Command {
+	tc := commands.NewTuneCommand()
+	cmd := &cobra.Command{
+		Use:   "tune",
+		Short: "Search training hyperparameters",
+		Long:  "Train trials over the search space of a TuneConfig by grid, random, successive-halving or Hyperband search and rank them in a leaderboard",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tc.Overrides = configOverrides(cmd, map[string]string{
+				"method":     "method",
+				"trials":     "trials",
+				"parallel":   "parallel",
+				"seed":       "seed",
+				"objective":  "objective",
+				"min-epochs": "min_epochs",
+				"eta":        "eta",
+				"dir":        "directory",
+				"epochs":     "training.epochs",
+				"track":      "training.tracking.enabled",
+			})
+			return tc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&tc.ConfigFile, "config", "c", "", "JSON or YAML TuneConfig file declaring the search space and the training settings")
+	flags.StringVar(&tc.DataPath, "data", tc.DataPath, "CSV, JSONL or .npy training data; the last training.data.targets columns are targets")
+	flags.String("method", "random", "search method: grid, random, halving or hyperband")
+	flags.Int("trials", 16, "configurations drawn by random search or started by successive halving")
+	flags.Int("parallel", 0, "trials trained at once; 0 uses every CPU")
+	flags.Int64("seed", 1, "seed for sampling configurations")
+	flags.String("objective", "validation_loss", "metric to rank trials by: validation_loss, validation_accuracy or training_loss")
+	flags.Int("min-epochs", 1, "epochs the first successive-halving rung trains for")
+	flags.Int("eta", 3, "successive halving keeps 1/eta of the trials at each rung")
+	flags.String("dir", "tune", "directory holding tune runs")
+	flags.Int("epochs", 100, "epochs each trial trains for at most")
+	flags.Bool("track", true, "log every trial's metrics to a run directory")
+	return cmd
 }
 
-func init() {
-	rootCmd.AddCommand(simulateCmd)
-	rootCmd.AddCommand(trainCmd)
-	rootCmd.AddCommand(analyzeCmd)
+func newRunsCmd() *cobra.Command {
+	rc := commands.NewRunsCommand()
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Inspect tracked training runs",
+		Long:  "List training runs and compare their configs and metrics",
+	}
+	cmd.PersistentFlags().StringVar(&rc.Directory, "dir", rc.Directory, "directory holding run directories")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List training runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rc.List()
+		},
+	}
+
+	compare := &cobra.Command{
+		Use:   "compare RUN RUN...",
+		Short: "Compare the configs and metrics of training runs",
+		Long:  "Compare training runs given as run directories or unique prefixes of run IDs",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rc.Compare(args)
+		},
+	}
+	flags := compare.Flags()
+	flags.StringSliceVar(&rc.Metrics, "metrics", rc.Metrics, "metric tag prefixes to show")
+	flags.BoolVar(&rc.All, "all", rc.All, "show settings that are the same in every run")
+
+	cmd.AddCommand(list, compare)
+	return cmd
+}
+
+func newAnalyzeCmd() *cobra.Command {
+	ac := commands.NewAnalyzeCommand()
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze system performance",
+		Long:  "Analyze Elder Theory system performance and generate reports",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ac.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&ac.ConfigFile, "config", "c", "", "JSON or YAML AnalysisConfig file")
+	flags.StringVarP(&ac.InputFile, "input", "i", ac.InputFile, "simulation results to analyze")
+	flags.StringVarP(&ac.OutputFile, "output", "o", ac.OutputFile, "file to write the analysis report to")
+	flags.StringVar(&ac.AnalysisType, "type", ac.AnalysisType, "analysis type: stability, convergence, performance or comprehensive")
+	flags.BoolVar(&ac.Detailed, "detailed", ac.Detailed, "include detailed metrics in the report")
+	return cmd
+}
+
+func newTransferCmd() *cobra.Command {
+	tc := commands.NewTransferCommand()
+	cmd := &cobra.Command{
+		Use:   "transfer",
+		Short: "Transfer knowledge across domains",
+		Long:  "Transfer knowledge between Mentor domains using isomorphic, hierarchical or resonance methods",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tc.Overrides = configOverrides(cmd, map[string]string{
+				"source": "source.name",
+				"target": "target.name",
+				"method": "method.type",
+			})
+			return tc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&tc.ConfigFile, "config", "c", "", "JSON or YAML TransferConfig file")
+	flags.StringVar(&tc.SourceDomain, "source", tc.SourceDomain, "source domain")
+	flags.StringVar(&tc.TargetDomain, "target", tc.TargetDomain, "target domain")
+	flags.StringVar(&tc.Method, "method", tc.Method, "transfer method: isomorphic, hierarchical or resonance")
+	flags.BoolVar(&tc.Validate, "validate", tc.Validate, "validate the transfer after it completes")
+	return cmd
 }
 
-func runSimulation() {
-	fmt.Println("Initializing Elder entities...")
-	fmt.Println("Setting up gravitational fields...")
-	fmt.Println("Starting orbital dynamics...")
-	fmt.Println("Simulation completed successfully!")
+func newValidateCmd() *cobra.Command {
+	vc := commands.NewValidateCommand()
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate Elder Theory properties",
+		Long:  "Run mathematical, physical, hierarchical and performance validators against the system",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return vc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&vc.Target, "target", vc.Target, "validation target")
+	flags.StringSliceVar(&vc.Validators, "validators", vc.Validators, "validators to run")
+	flags.BoolVar(&vc.Strict, "strict", vc.Strict, "fail on the first validator that does not pass")
+	flags.StringVarP(&vc.OutputFile, "output", "o", vc.OutputFile, "file to write the validation report to")
+	return cmd
 }
 
-func runTraining() {
-	fmt.Println("Initializing hierarchical training...")
-	fmt.Println("Training Elder entities...")
-	fmt.Println("Training Mentor entities across domains...")
-	fmt.Println("Training Erudite entities for specific tasks...")
-	fmt.Println("Training completed successfully!")
+func newVisualizeCmd() *cobra.Command {
+	vc := commands.NewVisualizeCommand()
+	cmd := &cobra.Command{
+		Use:   "visualize",
+		Short: "Visualize simulation results",
+		Long:  "Generate hierarchy, field, dynamics, phase and orbit visualizations from simulation results",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return vc.Execute()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&vc.InputFile, "input", "i", vc.InputFile, "simulation results to visualize")
+	flags.StringVarP(&vc.OutputFile, "output", "o", vc.OutputFile, "file to write the visualization to")
+	flags.StringVar(&vc.OutputFormat, "format", vc.OutputFormat, "output format: html, svg or json")
+	flags.BoolVar(&vc.Interactive, "interactive", vc.Interactive, "generate an interactive visualization")
+	flags.StringSliceVar(&vc.Components, "components", vc.Components, "components to visualize")
+	return cmd
 }
 
-func runAnalysis() {
-	fmt.Println("Analyzing system performance...")
-	fmt.Println("Checking conservation laws...")
-	fmt.Println("Validating mathematical properties...")
-	fmt.Println("Generating performance report...")
-	fmt.Println("Analysis completed successfully!")
+// configOverrides maps the flags the user set explicitly onto config field
+// paths so that they take precedence over config files and the environment.
+func configOverrides(cmd *cobra.Command, paths map[string]string) map[string]string {
+	overrides := make(map[string]string)
+	for name, path := range paths {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			overrides[path] = flag.Value.String()
+		}
+	}
+	return overrides
+}
+
+func init() {
+	rootCmd.AddCommand(newSimulateCmd())
+	rootCmd.AddCommand(newTrainCmd())
+	rootCmd.AddCommand(newTuneCmd())
+	rootCmd.AddCommand(newRunsCmd())
+	rootCmd.AddCommand(newAnalyzeCmd())
+	rootCmd.AddCommand(newTransferCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newVisualizeCmd())
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error executing command: %v", err)
+		fmt.Fprintf(os.Stderr, "Error executing command: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
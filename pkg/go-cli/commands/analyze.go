@@ -1,12 +1,35 @@
 package commands
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
 
 type AnalyzeCommand struct {
 	InputFile   string
 	OutputFile  string
 	AnalysisType string
 	Detailed    bool
+	ConfigFile  string
+	Overrides   map[string]string
+	Config      *config.AnalysisConfig
+	Summary     *trajectory.Summary
+	Sections    []ReportSection
+	readTime    time.Duration
+	inputBytes  int64
+}
+
+// ReportSection is one titled table of the analysis report.
+type ReportSection struct {
+	Title string     `json:"title"`
+	Rows  [][]string `json:"rows"`
 }
 
 func NewAnalyzeCommand() *AnalyzeCommand {
@@ -15,39 +38,163 @@ func NewAnalyzeCommand() *AnalyzeCommand {
 		OutputFile:   "analysis_report.html",
 		AnalysisType: "comprehensive",
 		Detailed:     false,
+		Overrides:    make(map[string]string),
 	}
 }
 
 func (ac *AnalyzeCommand) Execute() error {
+	cfg := config.DefaultAnalysisConfig()
+	if err := config.NewLoader().Load(cfg, ac.ConfigFile, ac.Overrides); err != nil {
+		return err
+	}
+	ac.Config = cfg
+
 	fmt.Printf("Starting Elder Theory analysis...\n")
 	fmt.Printf("Input file: %s\n", ac.InputFile)
 	fmt.Printf("Analysis type: %s\n", ac.AnalysisType)
-	
-	switch ac.AnalysisType {
-	case "stability":
-		ac.analyzeStability()
-	case "convergence":
-		ac.analyzeConvergence()
-	case "performance":
-		ac.analyzePerformance()
-	case "comprehensive":
-		ac.analyzeStability()
-		ac.analyzeConvergence()
-		ac.analyzePerformance()
-	}
-	
+
+	analyses := map[string][]func(){
+		"stability":     {ac.analyzeStability},
+		"convergence":   {ac.analyzeConvergence},
+		"performance":   {ac.analyzePerformance},
+		"comprehensive": {ac.analyzeStability, ac.analyzeConvergence, ac.analyzePerformance},
+	}
+	selected, known := analyses[ac.AnalysisType]
+	if !known {
+		return fmt.Errorf("unknown analysis type: %s", ac.AnalysisType)
+	}
+
+	if err := ac.readTrajectory(); err != nil {
+		return err
+	}
+	for _, analyze := range selected {
+		analyze()
+	}
+
+	if err := ac.writeReport(); err != nil {
+		return err
+	}
 	fmt.Printf("Analysis completed. Report saved to %s\n", ac.OutputFile)
 	return nil
 }
 
+func (ac *AnalyzeCommand) readTrajectory() error {
+	started := time.Now()
+
+	reader, err := trajectory.Open(ac.InputFile)
+	if err != nil {
+		return fmt.Errorf("open simulation results: %w", err)
+	}
+	defer reader.Close()
+
+	summary, err := trajectory.Summarize(reader)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", ac.InputFile, err)
+	}
+	if summary.Frames == 0 {
+		return fmt.Errorf("%s contains no frames", ac.InputFile)
+	}
+
+	ac.Summary = summary
+	ac.readTime = time.Since(started)
+	if info, err := os.Stat(ac.InputFile); err == nil {
+		ac.inputBytes = info.Size()
+	}
+
+	fmt.Printf("Read %d %s frames covering t=%g..%g\n", summary.Frames, reader.Format, summary.StartTime, summary.EndTime)
+	return nil
+}
+
 func (ac *AnalyzeCommand) analyzeStability() {
 	fmt.Println("Analyzing system stability...")
+
+	s := ac.Summary
+	verdict := "stable"
+	if s.MaxEnergyDrift > ac.Config.Stability.Tolerance {
+		verdict = "energy drift exceeds tolerance"
+	}
+	if s.Survivors < s.Bodies {
+		verdict += fmt.Sprintf(", %d bodies merged", s.Bodies-s.Survivors)
+	}
+
+	ac.addSection("Stability",
+		[]string{"Verdict", verdict},
+		[]string{"Max relative energy drift", fmt.Sprintf("%.3e", s.MaxEnergyDrift)},
+		[]string{"Tolerance", fmt.Sprintf("%.3e", ac.Config.Stability.Tolerance)},
+		[]string{"Max angular momentum drift", fmt.Sprintf("%.3e", s.MaxAngularMomentumDrift)},
+		[]string{"Bodies (start / end)", fmt.Sprintf("%d / %d", s.Bodies, s.Survivors)},
+		[]string{"Max distance from origin", fmt.Sprintf("%.4g", s.MaxRadius)},
+	)
 }
 
 func (ac *AnalyzeCommand) analyzeConvergence() {
 	fmt.Println("Analyzing convergence properties...")
+
+	s := ac.Summary
+	ac.addSection("Convergence",
+		[]string{"Initial energy", fmt.Sprintf("%.10g", s.InitialEnergy)},
+		[]string{"Final energy", fmt.Sprintf("%.10g", s.FinalEnergy)},
+		[]string{"Energy drift rate (per time unit)", fmt.Sprintf("%.3e", s.EnergyDriftRate())},
+	)
 }
 
 func (ac *AnalyzeCommand) analyzePerformance() {
 	fmt.Println("Analyzing performance metrics...")
+
+	s := ac.Summary
+	interval := 0.0
+	if s.Frames > 1 {
+		interval = (s.EndTime - s.StartTime) / float64(s.Frames-1)
+	}
+	ac.addSection("Performance",
+		[]string{"Frames", fmt.Sprintf("%d", s.Frames)},
+		[]string{"Mean sampling interval", fmt.Sprintf("%.4g", interval)},
+		[]string{"Input size (bytes)", fmt.Sprintf("%d", ac.inputBytes)},
+		[]string{"Read time", ac.readTime.String()},
+	)
+}
+
+func (ac *AnalyzeCommand) addSection(title string, rows ...[]string) {
+	ac.Sections = append(ac.Sections, ReportSection{Title: title, Rows: rows})
+	if ac.Detailed {
+		for _, row := range rows {
+			fmt.Printf("  %s: %s\n", row[0], row[1])
+		}
+	}
+}
+
+// writeReport writes the sections as HTML, JSON or plain text according to
+// output.format.
+func (ac *AnalyzeCommand) writeReport() error {
+	var b strings.Builder
+
+	switch ac.Config.Output.Format {
+	case "json":
+		encoded, err := json.MarshalIndent(ac.Sections, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.Write(encoded)
+		b.WriteString("\n")
+	case "html":
+		b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Elder analysis</title></head><body>\n")
+		fmt.Fprintf(&b, "<h1>Analysis of %s</h1>\n", html.EscapeString(ac.InputFile))
+		for _, section := range ac.Sections {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n<table>\n", html.EscapeString(section.Title))
+			for _, row := range section.Rows {
+				fmt.Fprintf(&b, "<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", html.EscapeString(row[0]), html.EscapeString(row[1]))
+			}
+			b.WriteString("</table>\n")
+		}
+		b.WriteString("</body></html>\n")
+	default:
+		for _, section := range ac.Sections {
+			fmt.Fprintf(&b, "%s\n", section.Title)
+			for _, row := range section.Rows {
+				fmt.Fprintf(&b, "  %s: %s\n", row[0], row[1])
+			}
+		}
+	}
+
+	return os.WriteFile(ac.OutputFile, []byte(b.String()), 0644)
 }
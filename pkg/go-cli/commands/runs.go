@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/tracking"
+)
+
+type RunsCommand struct {
+	Directory string
+	Metrics   []string
+	All       bool
+}
+
+func NewRunsCommand() *RunsCommand {
+	return &RunsCommand{
+		Directory: "runs",
+		Metrics:   []string{"loss/", "accuracy/", "learning_rate", "stability/total"},
+	}
+}
+
+// List prints one line per run, oldest first, with its final training and
+// validation loss.
+func (rc *RunsCommand) List() error {
+	runs, err := tracking.ListRuns(rc.Directory)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No runs in %s\n", rc.Directory)
+		return nil
+	}
+
+	listed := make([]*tracking.Metadata, len(runs))
+	for i, directory := range runs {
+		if listed[i], err = tracking.LoadMetadata(directory); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].Started.Before(listed[j].Started)
+	})
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSTATUS\tSTARTED\tDURATION\tEPOCHS\tSTEPS\tLOSS\tVAL LOSS")
+	for _, metadata := range listed {
+
+		duration := "-"
+		if metadata.Finished != nil {
+			duration = metadata.Finished.Sub(metadata.Started).Round(time.Second).String()
+		}
+		loss := summaryValue(metadata.Summary, "loss/train", "loss/task")
+		validation := summaryValue(metadata.Summary, "loss/validation")
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			metadata.ID, metadata.Status, metadata.Started.Local().Format("2006-01-02 15:04"), duration,
+			metadata.Epochs, metadata.Steps, loss, validation)
+	}
+	return table.Flush()
+}
+
+func summaryValue(summary map[string]float64, tags ...string) string {
+	for _, tag := range tags {
+		if value, exists := summary[tag]; exists {
+			return fmt.Sprintf("%.6g", value)
+		}
+	}
+	return "-"
+}
+
+// Compare prints the config settings in which the runs differ, or all of
+// them with All, and for every metric matching Metrics its final value and
+// its best value with the epoch it was reached.
+func (rc *RunsCommand) Compare(names []string) error {
+	configs := make([]map[string]string, len(names))
+	records := make([][]tracking.Record, len(names))
+	header := "SETTING"
+	for i, name := range names {
+		directory, err := tracking.FindRun(rc.Directory, name)
+		if err != nil {
+			return err
+		}
+		if configs[i], err = tracking.ReadConfig(directory); err != nil {
+			return fmt.Errorf("run %s: %w", name, err)
+		}
+		if records[i], err = tracking.ReadMetrics(directory); err != nil {
+			return fmt.Errorf("run %s: %w", name, err)
+		}
+		header += "\t" + filepath.Base(directory)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, header)
+	keys := make(map[string]bool)
+	for _, config := range configs {
+		for key := range config {
+			keys[key] = true
+		}
+	}
+	for _, key := range sortedKeys(keys) {
+		row := make([]string, len(configs))
+		differs := false
+		for i, config := range configs {
+			value, exists := config[key]
+			if !exists {
+				value = "-"
+			}
+			row[i] = value
+			differs = differs || value != row[0]
+		}
+		if differs || rc.All {
+			fmt.Fprintf(table, "%s\t%s\n", key, strings.Join(row, "\t"))
+		}
+	}
+
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, strings.Replace(header, "SETTING", "METRIC (final, best@epoch)", 1))
+	tags := make(map[string]bool)
+	summaries := make([]map[string]metricSummary, len(records))
+	for i, runRecords := range records {
+		summaries[i] = summarize(runRecords)
+		for tag := range summaries[i] {
+			tags[tag] = true
+		}
+	}
+	for _, tag := range sortedKeys(tags) {
+		if !rc.selected(tag) {
+			continue
+		}
+		row := make([]string, len(summaries))
+		for i, summary := range summaries {
+			row[i] = "-"
+			if metric, exists := summary[tag]; exists {
+				row[i] = fmt.Sprintf("%.6g, %.6g@%d", metric.Final, metric.Best, metric.BestEpoch)
+			}
+		}
+		fmt.Fprintf(table, "%s\t%s\n", tag, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+func (rc *RunsCommand) selected(tag string) bool {
+	for _, prefix := range rc.Metrics {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return len(rc.Metrics) == 0
+}
+
+type metricSummary struct {
+	Final     float64
+	Best      float64
+	BestEpoch int
+}
+
+// summarize finds each metric's last value and its best, the highest for
+// accuracies and the lowest for everything else.
+func summarize(records []tracking.Record) map[string]metricSummary {
+	summaries := make(map[string]metricSummary)
+	for _, record := range records {
+		for tag, value := range record.Scalars {
+			summary, seen := summaries[tag]
+			higherIsBetter := strings.HasPrefix(tag, "accuracy/")
+			if !seen || (higherIsBetter && value > summary.Best) || (!higherIsBetter && value < summary.Best) {
+				summary.Best, summary.BestEpoch = value, record.Epoch
+			}
+			summary.Final = value
+			summaries[tag] = summary
+		}
+	}
+	return summaries
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
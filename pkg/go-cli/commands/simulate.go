@@ -1,6 +1,17 @@
 package commands
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/dynamics"
+	"github.com/ykashou/go-elder/internal/go-simulation/engine"
+	"github.com/ykashou/go-elder/internal/go-simulation/parallel"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
 
 type SimulateCommand struct {
 	Duration    float64
@@ -8,41 +19,198 @@ type SimulateCommand struct {
 	OutputFile  string
 	Visualize   bool
 	Interactive bool
+	Realtime    bool
+	ResumeFile  string
+	ConfigFile  string
+	Overrides   map[string]string
+	Config      *config.ElderConfig
 }
 
 func NewSimulateCommand() *SimulateCommand {
+	defaults := config.DefaultElderConfig()
 	return &SimulateCommand{
-		Duration:    100.0,
-		TimeStep:    0.1,
+		Duration:    defaults.Simulation.MaxDuration,
+		TimeStep:    defaults.Simulation.Integration.TimeStep,
 		OutputFile:  "simulation_results.json",
 		Visualize:   false,
 		Interactive: false,
+		Overrides:   make(map[string]string),
 	}
 }
 
 func (sc *SimulateCommand) Execute() error {
+	if err := sc.loadConfig(); err != nil {
+		return err
+	}
+	
+	if sc.TimeStep <= 0 {
+		return fmt.Errorf("time step must be positive, got %g", sc.TimeStep)
+	}
+	if sc.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %g", sc.Duration)
+	}
+	
 	fmt.Printf("Starting Elder Theory simulation...\n")
 	fmt.Printf("Duration: %.2f time units\n", sc.Duration)
 	fmt.Printf("Time step: %.4f\n", sc.TimeStep)
 	
-	steps := int(sc.Duration / sc.TimeStep)
+	fmt.Printf("Integrator: %s (adaptive: %t)\n", sc.Config.Simulation.Integration.Method, sc.Config.Simulation.Integration.Adaptive)
+	fmt.Printf("Force solver: %s\n", sc.Config.Simulation.Engine.Type)
 	
-	for step := 0; step < steps; step++ {
-		currentTime := float64(step) * sc.TimeStep
-		sc.simulateStep(currentTime)
-		
-		if step%100 == 0 {
-			progress := float64(step) / float64(steps) * 100
+	core, err := sc.buildCore()
+	if err != nil {
+		return err
+	}
+	
+	nextReport := 0.0
+	core.OnStep = func(core *engine.SimulationCore) {
+		if core.CurrentTime >= nextReport {
+			progress := core.CurrentTime / core.MaxTime * 100
 			fmt.Printf("Progress: %.1f%%\n", progress)
+			nextReport += core.MaxTime / 10
+		}
+	}
+	
+	if sc.ResumeFile != "" {
+		checkpoint, err := engine.LoadCheckpoint(sc.ResumeFile)
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Resuming from %s at t=%.4f (step %d)\n", sc.ResumeFile, checkpoint.Time, checkpoint.StepCount)
+		nextReport = math.Ceil(checkpoint.Time/(sc.Duration/10)) * sc.Duration / 10
+		err = core.Resume(checkpoint)
+	} else {
+		err = core.Start()
+	}
+	if err != nil {
+		return fmt.Errorf("simulation failed at t=%g: %w", core.CurrentTime, err)
 	}
 	
-	fmt.Printf("Simulation completed. Results saved to %s\n", sc.OutputFile)
+	fmt.Printf("Simulation completed after %d steps at t=%.4f\n", core.StepCount, core.CurrentTime)
+	recorder := core.System(engine.TrajectorySystemName).(*engine.TrajectorySystem)
+	fmt.Printf("Results saved to %s (%s)\n", recorder.Path, recorder.Format)
+	orbital := core.System(engine.OrbitalSystemName).(*engine.OrbitalSystem)
+	if orbital.Stepper != nil {
+		fmt.Printf("Adaptive steps: %d accepted, %d rejected\n", orbital.Stepper.Accepted, orbital.Stepper.Rejected)
+	}
+	if collisions := len(orbital.Dynamics.Collisions); collisions > 0 {
+		fmt.Printf("Collisions merged: %d\n", collisions)
+	}
+	if monitor, ok := core.System(engine.ConservationMonitorName).(*engine.ConservationMonitor); ok {
+		fmt.Printf("Conservation: %d samples, max energy drift %.3e, max angular momentum drift %.3e, %d violations\n",
+			monitor.Samples, monitor.MaxEnergyDrift, monitor.MaxAngularMomentumDrift, monitor.Violations)
+	}
+	if order, ok := core.State.Scalars[engine.ScalarResonanceOrder]; ok {
+		fmt.Printf("Resonance order: %.4f, entropy: %.4f\n", order, core.State.Entropy)
+	}
+	if coherence, ok := core.State.Scalars[engine.ScalarPhaseCoherence]; ok {
+		fmt.Printf("Phase coherence: %.4f\n", coherence)
+	}
+	for _, eventType := range core.Events.Types() {
+		fmt.Printf("Events %s: %d\n", eventType, core.Events.Count(eventType))
+	}
 	return nil
 }
 
-func (sc *SimulateCommand) simulateStep(time float64) {
-	// Placeholder for actual simulation step
+func (sc *SimulateCommand) buildCore() (*engine.SimulationCore, error) {
+	cfg := sc.Config
+	
+	od := dynamics.NewHierarchicalSystem(
+		cfg.Entities.ElderCount,
+		cfg.Entities.MentorCount,
+		cfg.Entities.EruditeCount,
+		cfg.System.GravitationalG,
+		sc.TimeStep,
+		random.NewSource(cfg.Simulation.Seed),
+	)
+	od.Integrator = cfg.Simulation.Integration.Method
+	od.MergeCollisions = true
+	forces, err := dynamics.NewForceSolver(cfg.Simulation.Engine.Type, cfg.Simulation.Engine.OpeningAngle)
+	if err != nil {
+		return nil, err
+	}
+	od.Forces = forces
+	od.Pool = parallel.NewPool(parallel.Workers(cfg.Simulation.Engine.Parallel, cfg.Simulation.Engine.Threads, cfg.System.MaxProcessors))
+	
+	core := engine.NewSimulationCore(sc.TimeStep, sc.Duration)
+	core.Realtime = sc.Realtime
+	core.Events.Record = true
+	core.State.Random = random.NewSource(cfg.Simulation.Seed)
+	if cfg.Simulation.CheckpointFreq > 0 {
+		core.CheckpointEvery = cfg.Simulation.CheckpointFreq
+		core.CheckpointPath = filepath.Join(cfg.Simulation.Output.Directory, "simulation_checkpoint.json")
+	}
+	
+	orbital := engine.NewOrbitalSystem(od)
+	if cfg.Simulation.Integration.Adaptive {
+		maxStep := cfg.Simulation.OutputInterval
+		if maxStep <= 0 {
+			maxStep = sc.TimeStep * 100
+		}
+		tolerance := cfg.System.Tolerance
+		orbital.Stepper = dynamics.NewAdaptiveStepper(tolerance, tolerance, math.Min(sc.TimeStep*1e-6, maxStep), maxStep)
+	}
+	systems := []engine.System{orbital}
+	
+	if cfg.Simulation.Physics.Resonance && cfg.Entities.MentorCount > 0 {
+		mentors := make([]string, cfg.Entities.MentorCount)
+		for i := range mentors {
+			mentors[i] = fmt.Sprintf("mentor-%d", i)
+		}
+		resonance := engine.NewResonanceSystem(cfg.Simulation.Physics.Coupling, "elder-0", mentors)
+		resonance.Noise = cfg.Simulation.Physics.PhaseNoise
+		systems = append(systems, resonance, engine.NewEntropySystem(cfg.Fields.DecayRate))
+	}
+	if cfg.Simulation.Physics.Resonance {
+		systems = append(systems, engine.NewHierarchyPhaseSystem(od, cfg.Simulation.Physics.Coupling))
+	}
+	
+	output := cfg.Simulation.Output
+	fields := output.Fields
+	if monitor := cfg.Simulation.Monitor; monitor.Enabled {
+		conservation := engine.NewConservationMonitor(od, monitor.Every, cfg.System.Tolerance)
+		conservation.Abort = monitor.Action == "abort"
+		systems = append(systems, conservation)
+		fields = append(append([]string{}, fields...), trajectory.FieldDrift)
+		core.Events.Subscribe(engine.EventConservationViolated, func(event engine.Event) {
+			fmt.Printf("Warning: conservation drift exceeds tolerance at t=%.4f (energy %.3e of %g, angular momentum %.3e of %g)\n",
+				event.Time, event.Data["energy_drift"], event.Data["energy_tolerance"],
+				event.Data["angular_momentum_drift"], event.Data["angular_momentum_tolerance"])
+		})
+	}
+	
+	path, format, compress := sc.outputTarget()
+	recorder := engine.NewTrajectorySystem(od, path, format, fields)
+	recorder.Frequency = output.Frequency
+	recorder.Compress = compress
+	recorder.Resume = sc.ResumeFile != ""
+	systems = append(systems, recorder)
+	
+	for _, system := range systems {
+		if err := core.AddSystem(system); err != nil {
+			return nil, err
+		}
+	}
+	return core, nil
+}
+
+// outputTarget resolves where and how trajectories are written. A relative
+// OutputFile is placed in the configured output directory; an extension
+// that names a format (.csv, .jsonl, .bin, optionally with .gz) takes
+// precedence over output.format, and .gz or output.compress enables gzip.
+func (sc *SimulateCommand) outputTarget() (string, string, bool) {
+	output := sc.Config.Simulation.Output
+	
+	path := sc.OutputFile
+	if !filepath.IsAbs(path) && output.Directory != "" {
+		path = filepath.Join(output.Directory, path)
+	}
+	
+	format, compressed := trajectory.DetectFormat(path)
+	if format == "" {
+		format = output.Format
+	}
+	return path, format, compressed || output.Compress
 }
 
 func (sc *SimulateCommand) SetParameters(duration, timeStep float64, outputFile string) {
@@ -50,3 +218,15 @@ func (sc *SimulateCommand) SetParameters(duration, timeStep float64, outputFile
 	sc.TimeStep = timeStep
 	sc.OutputFile = outputFile
 }
+
+func (sc *SimulateCommand) loadConfig() error {
+	cfg := config.DefaultElderConfig()
+	if err := config.NewLoader().Load(cfg, sc.ConfigFile, sc.Overrides); err != nil {
+		return err
+	}
+	
+	sc.Config = cfg
+	sc.Duration = cfg.Simulation.MaxDuration
+	sc.TimeStep = cfg.Simulation.Integration.TimeStep
+	return nil
+}
@@ -1,6 +1,23 @@
 package commands
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/dataset"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/internal/go-simulation/tracking"
+	"github.com/ykashou/go-elder/internal/go-simulation/training"
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+)
+
+// Shape of the synthetic regression set used when no data is given.
+const (
+	syntheticFeatures = 2
+	syntheticSamples  = 640
+)
 
 type TrainCommand struct {
 	ModelPath    string
@@ -8,29 +25,581 @@ type TrainCommand struct {
 	Epochs       int
 	LearningRate float64
 	BatchSize    int
+	ResumeFile   string
+	ConfigFile   string
+	Overrides    map[string]string
+	Config       *config.TrainingConfig
+	Output       io.Writer
 }
 
 func NewTrainCommand() *TrainCommand {
+	defaults := config.DefaultTrainingConfig()
 	return &TrainCommand{
-		Epochs:       100,
-		LearningRate: 0.001,
-		BatchSize:    32,
+		Epochs:       defaults.Epochs,
+		LearningRate: defaults.Optimizer.LearningRate,
+		BatchSize:    defaults.Data.BatchSize,
+		Overrides:    make(map[string]string),
+		Output:       os.Stdout,
+	}
+}
+
+func (tc *TrainCommand) Execute() (err error) {
+	if err := tc.loadConfig(); err != nil {
+		return err
+	}
+	
+	if tc.Epochs <= 0 {
+		return fmt.Errorf("epochs must be positive, got %d", tc.Epochs)
+	}
+	if tc.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", tc.BatchSize)
+	}
+	
+	fmt.Fprintf(tc.Output, "Starting training with %d epochs...\n", tc.Epochs)
+	fmt.Fprintf(tc.Output, "Learning rate: %f\n", tc.LearningRate)
+	fmt.Fprintf(tc.Output, "Batch size: %d\n", tc.BatchSize)
+	
+	t, err := tc.build()
+	if err != nil {
+		return err
+	}
+	
+	run, err := tc.startRun()
+	if err != nil {
+		return err
+	}
+	defer func() { err = finishRun(run, err) }()
+	t.attach(run)
+	
+	initialLoss, err := t.trainingLoss()
+	if err != nil {
+		return err
+	}
+	if tc.ResumeFile != "" {
+		if err := t.resume(tc.ResumeFile); err != nil {
+			return err
+		}
+	}
+	if err := t.advance(tc.Epochs); err != nil {
+		return err
+	}
+	
+	result, err := t.result()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tc.Output, "Training loss: %.6f -> %.6f\n", initialLoss, result.TrainingLoss)
+	if result.Validated {
+		fmt.Fprintf(tc.Output, "Validation loss: %.6f\n", result.ValidationLoss)
+	}
+	fmt.Fprintln(tc.Output, "Training completed successfully!")
+	return nil
+}
+
+// trainer is a model together with the data it trains on, driven the same
+// way whatever the architecture.
+type trainer interface {
+	// attach logs metrics to run from now on; run may be nil.
+	attach(run *tracking.Run)
+	// resume continues from the checkpoint at path.
+	resume(path string) error
+	// advance trains until epochs epochs have been completed in all.
+	advance(epochs int) error
+	trainingLoss() (float64, error)
+	// result scores the model as it now stands.
+	result() (trainResult, error)
+}
+
+// trainResult is how a model scored after Epochs epochs. The validation
+// metrics are only set when Validated.
+type trainResult struct {
+	Epochs             int     `json:"epochs"`
+	TrainingLoss       float64 `json:"training_loss"`
+	ValidationLoss     float64 `json:"validation_loss"`
+	ValidationAccuracy float64 `json:"validation_accuracy"`
+	Validated          bool    `json:"validated"`
+}
+
+// build assembles the trainer for the configured architecture.
+func (tc *TrainCommand) build() (trainer, error) {
+	if strings.EqualFold(tc.Config.Model.Architecture, "hierarchical") {
+		return tc.buildHierarchy()
+	}
+	fmt.Fprintf(tc.Output, "Optimizer: %s\n", tc.Config.Optimizer.Type)
+	
+	loop, err := tc.buildLoop()
+	if err != nil {
+		return nil, err
+	}
+	return newLoopTrainer(tc, loop), nil
+}
+
+// startRun creates the run directory that metrics are logged to, unless
+// tracking is disabled, in which case the run is nil.
+func (tc *TrainCommand) startRun() (*tracking.Run, error) {
+	settings := tc.Config.Tracking
+	if !settings.Enabled {
+		return nil, nil
+	}
+	
+	run, err := tracking.NewRun(settings.Directory, settings.Name, tc.Config)
+	if err != nil {
+		return nil, fmt.Errorf("start run: %w", err)
+	}
+	fmt.Fprintf(tc.Output, "Run: %s\n", run.Directory)
+	return run, nil
+}
+
+// finishRun records how training ended in the run's metadata and returns
+// the training error, or failing that any error from closing the run.
+func finishRun(run *tracking.Run, err error) error {
+	if run == nil {
+		return err
+	}
+	if finishErr := run.Finish(err); err == nil {
+		return finishErr
 	}
+	return err
 }
 
-func (tc *TrainCommand) Execute() error {
-	fmt.Printf("Starting training with %d epochs...\n", tc.Epochs)
-	fmt.Printf("Learning rate: %f\n", tc.LearningRate)
-	fmt.Printf("Batch size: %d\n", tc.BatchSize)
+// loopTrainer trains an ElderModel with the ElderTrainingLoop.
+type loopTrainer struct {
+	loop   *training.ElderTrainingLoop
+	output io.Writer
+	run    *tracking.Run
+	logErr error
+}
+
+// newLoopTrainer observes parameter stability through the loop's Stability,
+// so that its history is checkpointed and the stability curves of a resumed
+// run continue those of the original.
+func newLoopTrainer(tc *TrainCommand, loop *training.ElderTrainingLoop) *loopTrainer {
+	lt := &loopTrainer{
+		loop:   loop,
+		output: tc.Output,
+	}
+	loop.Stability = training.NewParameterStability()
 	
-	// Training implementation would go here
+	logEvery := tc.Config.Tracking.StepFrequency
+	loop.OnStep = func(loop *training.ElderTrainingLoop, loss float64) {
+		if lt.run != nil && lt.logErr == nil && logEvery > 0 && loop.Step%logEvery == 0 {
+			lt.logErr = lt.run.Log(loop.Step, loop.CurrentEpoch+1, loop.StepScalars(loss))
+		}
+	}
+	loop.OnEpoch = func(loop *training.ElderTrainingLoop) {
+		reportEpoch(lt.output, loop)
+		if lt.run != nil && lt.logErr == nil {
+			scalars := loop.History[len(loop.History)-1].Scalars()
+			for tag, value := range loop.Stability.Observe(loop.Model.Parameters) {
+				scalars[tag] = value
+			}
+			lt.logErr = lt.run.Log(loop.Step, loop.CurrentEpoch, scalars)
+		}
+	}
+	return lt
+}
+
+func (lt *loopTrainer) attach(run *tracking.Run) {
+	lt.run = run
+}
+
+func (lt *loopTrainer) resume(path string) error {
+	checkpoint, path, err := training.LoadCheckpoint(path)
+	if err != nil {
+		return err
+	}
+	if err := lt.loop.Restore(checkpoint); err != nil {
+		return fmt.Errorf("resume from %s: %w", path, err)
+	}
+	fmt.Fprintf(lt.output, "Resuming from %s after epoch %d\n", path, checkpoint.Epoch)
+	return nil
+}
+
+func (lt *loopTrainer) advance(epochs int) error {
+	loop := lt.loop
+	if err := loop.TrainUntil(epochs); err != nil {
+		return err
+	}
+	if lt.logErr != nil {
+		return fmt.Errorf("log metrics: %w", lt.logErr)
+	}
+	if !loop.Stopped && loop.CurrentEpoch < loop.MaxEpochs {
+		return nil
+	}
 	
-	fmt.Println("Training completed successfully!")
+	if loop.Stopped {
+		fmt.Fprintf(lt.output, "Early stopping after epoch %d\n", loop.CurrentEpoch)
+	}
+	if loop.EarlyStopping != nil && loop.EarlyStopping.BestParameters != nil {
+		fmt.Fprintf(lt.output, "Restored best weights from epoch %d (monitored loss %.6f)\n",
+			loop.EarlyStopping.BestEpoch, loop.EarlyStopping.BestLoss)
+	}
 	return nil
 }
 
+func (lt *loopTrainer) trainingLoss() (float64, error) {
+	return evaluate(lt.loop, lt.loop.TrainingData, lt.loop.Source)
+}
+
+func (lt *loopTrainer) result() (trainResult, error) {
+	loop := lt.loop
+	result := trainResult{Epochs: loop.CurrentEpoch}
+	
+	var err error
+	if result.TrainingLoss, err = lt.trainingLoss(); err != nil {
+		return result, err
+	}
+	switch {
+	case loop.ValidationSource != nil:
+		result.Validated = true
+		result.ValidationLoss, result.ValidationAccuracy, err = loop.EvaluateSource(loop.ValidationSource)
+	case len(loop.ValidationData) > 0:
+		result.Validated = true
+		result.ValidationLoss = loop.Evaluate(loop.ValidationData)
+		result.ValidationAccuracy = loop.Accuracy(loop.ValidationData)
+	}
+	return result, err
+}
+
+// evaluate scores the model on source when it is set and on samples
+// otherwise.
+func evaluate(loop *training.ElderTrainingLoop, samples []training.TrainingSample, source training.SampleSource) (float64, error) {
+	if source == nil {
+		return loop.Evaluate(samples), nil
+	}
+	loss, _, err := loop.EvaluateSource(source)
+	return loss, err
+}
+
+func reportEpoch(output io.Writer, loop *training.ElderTrainingLoop) {
+	metrics := loop.History[len(loop.History)-1]
+	line := fmt.Sprintf("Epoch %d/%d: loss %.6f", metrics.Epoch, loop.MaxEpochs, metrics.Loss)
+	if metrics.Validated {
+		line += fmt.Sprintf(", val_loss %.6f, val_accuracy %.1f%%", metrics.ValidationLoss, metrics.Accuracy*100)
+	}
+	fmt.Fprintf(output, "%s, lr %.3g\n", line, metrics.LearningRate)
+}
+
+// buildLoop assembles the model described by the config and the data it
+// trains on.
+func (tc *TrainCommand) buildLoop() (*training.ElderTrainingLoop, error) {
+	inputs, targets := syntheticFeatures, 0
+	var data *dataset.Dataset
+	if tc.DataPath != "" {
+		var err error
+		if data, err = tc.openDataset(); err != nil {
+			return nil, err
+		}
+		if inputs, targets, err = data.Shape(); err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Fprintf(tc.Output, "Data: synthetic regression, %d features\n", syntheticFeatures)
+	}
+	
+	// Dropout and batch norm apply to the hidden layers, not the output.
+	regularization := tc.Config.Model.Regularization
+	specs := make([]training.LayerSpec, len(tc.Config.Model.Layers))
+	for i, layer := range tc.Config.Model.Layers {
+		specs[i] = training.LayerSpec{
+			Type:       strings.ToLower(layer.Type),
+			Size:       layer.Size,
+			Activation: strings.ToLower(layer.Activation),
+		}
+		if i < len(specs)-1 {
+			specs[i].Dropout = regularization.Dropout
+			specs[i].BatchNorm = regularization.BatchNorm
+		}
+	}
+	
+	rng := random.NewSource(tc.Config.Seed)
+	model, err := training.NewElderModel(inputs, specs, rng)
+	if err != nil {
+		return nil, fmt.Errorf("model: %w", err)
+	}
+	if data != nil && targets != model.Outputs() {
+		return nil, fmt.Errorf("model has %d outputs but %s has %d targets per sample", model.Outputs(), tc.DataPath, targets)
+	}
+	
+	loop := training.NewElderTrainingLoop(tc.Epochs, tc.LearningRate, tc.BatchSize)
+	loop.Model = model
+	loop.OptimizerType = strings.ToLower(tc.Config.Optimizer.Type)
+	loop.Momentum = tc.Config.Optimizer.Momentum
+	loop.OptimizerParameters["beta1"] = tc.Config.Optimizer.Beta1
+	loop.OptimizerParameters["beta2"] = tc.Config.Optimizer.Beta2
+	loop.OptimizerParameters["epsilon"] = tc.Config.Optimizer.Epsilon
+	loop.Shuffle = tc.Config.Data.Shuffle
+	loop.Regularization = training.Regularization{L1: regularization.L1, L2: regularization.L2}
+	if regularization != (config.RegularizationConfig{}) {
+		fmt.Fprintf(tc.Output, "Regularization: l1 %g, l2 %g, dropout %g, batch norm %t\n",
+			regularization.L1, regularization.L2, regularization.Dropout, regularization.BatchNorm)
+	}
+	
+	schedule := tc.Config.Optimizer.Schedule
+	loop.Schedule = training.NewLearningRateSchedule(strings.ToLower(schedule.Type), tc.LearningRate)
+	loop.Schedule.StepSize = schedule.StepSize
+	loop.Schedule.Gamma = schedule.Gamma
+	loop.Schedule.WarmupEpochs = schedule.WarmupEpochs
+	loop.Schedule.MinRate = schedule.MinLearningRate
+	loop.Schedule.Patience = schedule.Patience
+	loop.Schedule.Factor = schedule.Factor
+	
+	validation := tc.Config.Validation
+	loop.ValidationFrequency = validation.Frequency
+	if validation.EarlyStopping {
+		loop.EarlyStopping = training.NewEarlyStopping(validation.Patience)
+	}
+	
+	if data != nil {
+		loop.Source = data.Training()
+		if data.ValidationSamples > 0 {
+			loop.ValidationSource = data.Validation()
+		}
+		fmt.Fprintf(tc.Output, "Samples: %d training, %d validation\n", data.TrainingSamples, data.ValidationSamples)
+	} else {
+		samples := training.SyntheticRegression(syntheticSamples, syntheticFeatures, model.Outputs(), rng)
+		loop.TrainingData, loop.ValidationData = training.SplitValidation(samples, validation.SplitRatio, rng)
+		fmt.Fprintf(tc.Output, "Samples: %d training, %d validation\n", len(loop.TrainingData), len(loop.ValidationData))
+	}
+	
+	// The loop carries on with the same stream, so that a resumed run
+	// shuffles exactly as an uninterrupted one.
+	loop.Random = rng
+	
+	if checkpoints := tc.Config.Checkpoints; checkpoints.Enabled && checkpoints.Frequency > 0 {
+		loop.CheckpointEvery = checkpoints.Frequency
+		loop.CheckpointDirectory = checkpoints.Directory
+		loop.KeepCheckpoints = checkpoints.KeepLast
+		fmt.Fprintf(tc.Output, "Checkpoints: every %d epochs to %s (keeping %d)\n", checkpoints.Frequency, checkpoints.Directory, checkpoints.KeepLast)
+	}
+	return loop, nil
+}
+
+// openDataset prepares the file at DataPath for streaming with the
+// preprocessing, augmentation and shuffling settings from data, holding
+// back validation.split_ratio of it for validation.
+func (tc *TrainCommand) openDataset() (*dataset.Dataset, error) {
+	settings := tc.Config.Data
+	data := dataset.NewDataset(tc.DataPath, settings.Targets, tc.Config.Seed)
+	data.Format = strings.ToLower(settings.Format)
+	data.Shuffle = settings.Shuffle
+	data.ShuffleBuffer = settings.ShuffleBuffer
+	data.Holdout = tc.Config.Validation.SplitRatio
+	
+	for _, name := range settings.Preprocessing {
+		step, err := dataset.NewStep(name)
+		if err != nil {
+			return nil, err
+		}
+		data.Steps = append(data.Steps, step)
+	}
+	if settings.Augment {
+		for _, name := range settings.Augmentations {
+			augmenter, err := dataset.NewAugmenter(name)
+			if err != nil {
+				return nil, err
+			}
+			data.Augmenters = append(data.Augmenters, augmenter)
+		}
+	}
+	
+	if err := data.Fit(); err != nil {
+		return nil, err
+	}
+	inputs, targets, err := data.Shape()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(tc.Output, "Data: %s, %d inputs, %d targets\n", tc.DataPath, inputs, targets)
+	if len(data.Steps) > 0 {
+		fmt.Fprintf(tc.Output, "Preprocessing: %s\n", strings.Join(settings.Preprocessing, ", "))
+	}
+	if len(data.Augmenters) > 0 {
+		fmt.Fprintf(tc.Output, "Augmentation: %s\n", strings.Join(settings.Augmentations, ", "))
+	}
+	return data, nil
+}
+
+// buildHierarchy assembles an Erudite-Mentor-Elder hierarchy trained with
+// per-level gradient descent. Its shape comes from model.parameters:
+// erudites, mentors, erudite_size, mentor_size, elder_size, hierarchy_weight,
+// learning_rate (the Erudite rate; each level above learns more slowly) and
+// the Elder-Mentor loss weights coordination_weight, alignment_weight,
+// efficiency_weight and stability_weight. Of the regularizers only the L1
+// and L2 penalties apply; entities have no dropout or batch norm.
+func (tc *TrainCommand) buildHierarchy() (*hierarchyTrainer, error) {
+	regularization := tc.Config.Model.Regularization
+	if regularization.Dropout > 0 || regularization.BatchNorm {
+		return nil, fmt.Errorf("the hierarchical architecture supports only l1 and l2 regularization")
+	}
+	
+	parameter := func(name string, fallback float64) float64 {
+		if value, exists := tc.Config.Model.Parameters[name]; exists {
+			return value
+		}
+		return fallback
+	}
+	entities := func(prefix string, count int) []string {
+		names := make([]string, count)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return names
+	}
+	
+	inputs, outputs := syntheticFeatures, 1
+	var data *dataset.Dataset
+	if tc.DataPath != "" {
+		var err error
+		if data, err = tc.openDataset(); err != nil {
+			return nil, err
+		}
+		if inputs, outputs, err = data.Shape(); err != nil {
+			return nil, err
+		}
+	}
+	
+	rng := random.NewSource(tc.Config.Seed)
+	hb := training.NewHierarchicalBackprop(inputs, outputs, rng)
+	hb.AddLayer(training.LevelErudite, entities("erudite", int(parameter("erudites", 4))), int(parameter("erudite_size", 8)))
+	hb.AddLayer(training.LevelMentor, entities("mentor", int(parameter("mentors", 2))), int(parameter("mentor_size", 6)))
+	hb.AddLayer(training.LevelElder, []string{"elder"}, int(parameter("elder_size", 4)))
+	hb.HierarchyWeight = parameter("hierarchy_weight", hb.HierarchyWeight)
+	if rate, exists := tc.Config.Model.Parameters["learning_rate"]; exists {
+		for level := range hb.LearningRates {
+			hb.LearningRates[level] = rate / float64(level+1)
+		}
+	}
+	hb.ElderMentor.CoordinationWeight = parameter("coordination_weight", hb.ElderMentor.CoordinationWeight)
+	hb.ElderMentor.AlignmentWeight = parameter("alignment_weight", hb.ElderMentor.AlignmentWeight)
+	hb.ElderMentor.EfficiencyWeight = parameter("efficiency_weight", hb.ElderMentor.EfficiencyWeight)
+	hb.ElderMentor.StabilityWeight = parameter("stability_weight", hb.ElderMentor.StabilityWeight)
+	hb.Regularization = training.Regularization{L1: regularization.L1, L2: regularization.L2}
+	
+	ht := &hierarchyTrainer{hb: hb, data: data, output: tc.Output, epochs: tc.Epochs, logEvery: tc.Config.Tracking.StepFrequency}
+	// A dataset is loaded into memory one epoch at a time, so that each
+	// epoch gets its own shuffle and augmentation.
+	if data != nil {
+		var err error
+		if ht.trainSet, err = data.Training().Load(0); err != nil {
+			return nil, err
+		}
+		if ht.validSet, err = data.Validation().Load(0); err != nil {
+			return nil, err
+		}
+	} else {
+		samples := training.SyntheticRegression(syntheticSamples, syntheticFeatures, 1, rng)
+		ht.trainSet, ht.validSet = training.SplitValidation(samples, tc.Config.Validation.SplitRatio, rng)
+	}
+	fmt.Fprintf(tc.Output, "Hierarchy: %d erudites, %d mentors, 1 elder\n", len(hb.Layers[0].Entities), len(hb.Layers[1].Entities))
+	fmt.Fprintf(tc.Output, "Samples: %d training, %d validation\n", len(ht.trainSet), len(ht.validSet))
+	
+	hb.OnStep = func(hb *training.HierarchicalBackprop) {
+		ht.step++
+		if ht.run != nil && ht.logErr == nil && ht.logEvery > 0 && ht.step%ht.logEvery == 0 {
+			ht.logErr = ht.run.Log(ht.step, ht.epoch, map[string]float64{
+				"loss/batch":           hb.Losses.Total,
+				"gradient_norm/global": hb.Losses.GradientNorm,
+			})
+		}
+	}
+	return ht, nil
+}
+
+// hierarchyTrainer trains a HierarchicalBackprop one epoch at a time.
+type hierarchyTrainer struct {
+	hb       *training.HierarchicalBackprop
+	data     *dataset.Dataset
+	trainSet []training.TrainingSample
+	validSet []training.TrainingSample
+	output   io.Writer
+	run      *tracking.Run
+	epochs   int
+	epoch    int
+	step     int
+	logEvery int
+	logErr   error
+}
+
+func (ht *hierarchyTrainer) attach(run *tracking.Run) {
+	ht.run = run
+}
+
+func (ht *hierarchyTrainer) resume(path string) error {
+	return fmt.Errorf("resuming is not supported by the hierarchical architecture")
+}
+
+func (ht *hierarchyTrainer) advance(epochs int) error {
+	hb := ht.hb
+	for ht.epoch < epochs {
+		ht.epoch++
+		epoch := ht.epoch
+		if ht.data != nil && epoch > 1 {
+			var err error
+			if ht.trainSet, err = ht.data.Training().Load(epoch - 1); err != nil {
+				return err
+			}
+		}
+		losses, err := hb.TrainEpoch(ht.trainSet)
+		if err != nil {
+			return fmt.Errorf("epoch %d: %w", epoch, err)
+		}
+		fmt.Fprintf(ht.output, "Epoch %d/%d: task %.6f, mentor-erudite %.4f, elder-mentor %.4f, cross-level %.4f, total %.6f\n",
+			epoch, ht.epochs, losses.Task, losses.MentorErudite, losses.ElderMentor, losses.CrossLevel, losses.Total)
+		
+		if ht.logErr != nil {
+			return fmt.Errorf("log metrics: %w", ht.logErr)
+		}
+		if ht.run != nil {
+			scalars := losses.Scalars()
+			scalars["learning_rate/erudite"] = hb.LearningRates[training.LevelErudite]
+			scalars["learning_rate/mentor"] = hb.LearningRates[training.LevelMentor]
+			scalars["learning_rate/elder"] = hb.LearningRates[training.LevelElder]
+			if len(ht.validSet) > 0 {
+				scalars["loss/validation"] = hb.Evaluate(ht.validSet)
+			}
+			if ht.logErr = ht.run.Log(ht.step, epoch, scalars); ht.logErr != nil {
+				return fmt.Errorf("log metrics: %w", ht.logErr)
+			}
+		}
+	}
+	return nil
+}
+
+func (ht *hierarchyTrainer) trainingLoss() (float64, error) {
+	return ht.hb.Evaluate(ht.trainSet), nil
+}
+
+func (ht *hierarchyTrainer) result() (trainResult, error) {
+	result := trainResult{Epochs: ht.epoch, TrainingLoss: ht.hb.Evaluate(ht.trainSet)}
+	if len(ht.validSet) > 0 {
+		result.Validated = true
+		result.ValidationLoss = ht.hb.Evaluate(ht.validSet)
+		result.ValidationAccuracy = ht.hb.Accuracy(ht.validSet)
+	}
+	return result, nil
+}
+
 func (tc *TrainCommand) SetConfig(modelPath, dataPath string, epochs int) {
 	tc.ModelPath = modelPath
 	tc.DataPath = dataPath
 	tc.Epochs = epochs
 }
+
+func (tc *TrainCommand) loadConfig() error {
+	cfg := config.DefaultTrainingConfig()
+	if err := config.NewLoader().Load(cfg, tc.ConfigFile, tc.Overrides); err != nil {
+		return err
+	}
+	
+	tc.useConfig(cfg)
+	return nil
+}
+
+// useConfig takes the settings from cfg, which must already be valid.
+func (tc *TrainCommand) useConfig(cfg *config.TrainingConfig) {
+	tc.Config = cfg
+	tc.Epochs = cfg.Epochs
+	tc.LearningRate = cfg.Optimizer.LearningRate
+	tc.BatchSize = cfg.Data.BatchSize
+}
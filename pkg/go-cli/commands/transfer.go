@@ -1,12 +1,19 @@
 package commands
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+)
 
 type TransferCommand struct {
 	SourceDomain string
 	TargetDomain string
 	Method       string
 	Validate     bool
+	ConfigFile   string
+	Overrides    map[string]string
+	Config       *config.TransferConfig
 }
 
 func NewTransferCommand() *TransferCommand {
@@ -15,17 +22,24 @@ func NewTransferCommand() *TransferCommand {
 		TargetDomain: "vision",
 		Method:       "isomorphic",
 		Validate:     true,
+		Overrides:    make(map[string]string),
 	}
 }
 
 func (tc *TransferCommand) Execute() error {
+	if err := tc.loadConfig(); err != nil {
+		return err
+	}
+	
 	fmt.Printf("Starting knowledge transfer...\n")
 	fmt.Printf("Source domain: %s\n", tc.SourceDomain)
 	fmt.Printf("Target domain: %s\n", tc.TargetDomain)
 	fmt.Printf("Transfer method: %s\n", tc.Method)
 	
 	tc.initializeTransfer()
-	tc.performTransfer()
+	if err := tc.performTransfer(); err != nil {
+		return err
+	}
 	
 	if tc.Validate {
 		tc.validateTransfer()
@@ -39,7 +53,7 @@ func (tc *TransferCommand) initializeTransfer() {
 	fmt.Printf("Initializing transfer from %s to %s...\n", tc.SourceDomain, tc.TargetDomain)
 }
 
-func (tc *TransferCommand) performTransfer() {
+func (tc *TransferCommand) performTransfer() error {
 	switch tc.Method {
 	case "isomorphic":
 		tc.performIsomorphicTransfer()
@@ -47,7 +61,10 @@ func (tc *TransferCommand) performTransfer() {
 		tc.performHierarchicalTransfer()
 	case "resonance":
 		tc.performResonanceTransfer()
+	default:
+		return fmt.Errorf("unknown transfer method: %s", tc.Method)
 	}
+	return nil
 }
 
 func (tc *TransferCommand) performIsomorphicTransfer() {
@@ -65,3 +82,16 @@ func (tc *TransferCommand) performResonanceTransfer() {
 func (tc *TransferCommand) validateTransfer() {
 	fmt.Println("Validating knowledge transfer...")
 }
+
+func (tc *TransferCommand) loadConfig() error {
+	cfg := config.DefaultTransferConfig()
+	if err := config.NewLoader().Load(cfg, tc.ConfigFile, tc.Overrides); err != nil {
+		return err
+	}
+	
+	tc.Config = cfg
+	tc.SourceDomain = cfg.Source.Name
+	tc.TargetDomain = cfg.Target.Name
+	tc.Method = cfg.Method.Type
+	return nil
+}
@@ -0,0 +1,437 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/parallel"
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/internal/go-simulation/tracking"
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+)
+
+// LeaderboardFile is written to the tune run directory when the search ends.
+const LeaderboardFile = "leaderboard.json"
+
+type TuneCommand struct {
+	ConfigFile string
+	DataPath   string
+	Overrides  map[string]string
+	Config     *config.TuneConfig
+	Output     io.Writer
+
+	paths []string
+	trial int
+}
+
+func NewTuneCommand() *TuneCommand {
+	return &TuneCommand{
+		Overrides: make(map[string]string),
+		Output:    os.Stdout,
+	}
+}
+
+// tuneTrial is one configuration tried by the search, trained in memory so
+// that successive halving can carry on where the previous rung stopped.
+type tuneTrial struct {
+	Rank       int                    `json:"rank"`
+	Name       string                 `json:"name"`
+	Bracket    int                    `json:"bracket"`
+	Parameters map[string]string      `json:"parameters"`
+	Budget     int                    `json:"budget"`
+	Objective  float64                `json:"objective"`
+	Result     trainResult            `json:"result"`
+	Run        string                 `json:"run,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Config     *config.TrainingConfig `json:"config"`
+
+	command *TrainCommand
+	trainer trainer
+	run     *tracking.Run
+	done    bool
+}
+
+type leaderboard struct {
+	Method    string       `json:"method"`
+	Objective string       `json:"objective"`
+	Trials    []*tuneTrial `json:"trials"`
+}
+
+// Execute runs the search, recording it as a run under Directory with each
+// trial's own run in its trials directory, and writes the leaderboard.
+func (tc *TuneCommand) Execute() (err error) {
+	cfg := config.DefaultTuneConfig()
+	if err := config.NewLoader().Load(cfg, tc.ConfigFile, tc.Overrides); err != nil {
+		return err
+	}
+	tc.Config = cfg
+	tc.paths = make([]string, 0, len(cfg.Space))
+	for path := range cfg.Space {
+		tc.paths = append(tc.paths, path)
+	}
+	sort.Strings(tc.paths)
+
+	run, err := tracking.NewRun(cfg.Directory, "", cfg)
+	if err != nil {
+		return fmt.Errorf("start tune run: %w", err)
+	}
+	defer func() { err = finishRun(run, err) }()
+	fmt.Fprintf(tc.Output, "Tuning %s over %s by %s search\n", cfg.Objective, strings.Join(tc.paths, ", "), cfg.Method)
+	fmt.Fprintf(tc.Output, "Run: %s\n", run.Directory)
+
+	var trials []*tuneTrial
+	switch method := strings.ToLower(cfg.Method); method {
+	case "grid", "random":
+		if method == "grid" {
+			trials = tc.grid(run.Directory)
+		} else {
+			trials = tc.sample(cfg.Trials, 0, random.NewSource(cfg.Seed), run.Directory)
+		}
+		tc.advance(trials, cfg.Training.Epochs)
+	case "halving":
+		trials = tc.sample(cfg.Trials, 0, random.NewSource(cfg.Seed), run.Directory)
+		tc.halve(trials, cfg.MinEpochs)
+	case "hyperband":
+		trials = tc.hyperband(run.Directory)
+	}
+
+	tc.rank(trials)
+	board := leaderboard{Method: cfg.Method, Objective: cfg.Objective, Trials: trials}
+	document, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode leaderboard: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(run.Directory, LeaderboardFile), append(document, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := tc.report(trials); err != nil {
+		return err
+	}
+	fmt.Fprintf(tc.Output, "Leaderboard: %s\n", filepath.Join(run.Directory, LeaderboardFile))
+
+	if trials[0].Error != "" {
+		return fmt.Errorf("every trial failed; %s: %s", trials[0].Name, trials[0].Error)
+	}
+	return nil
+}
+
+// hyperband runs successive halving brackets from the most exploratory,
+// many trials starting on MinEpochs, down to a few trials trained on the
+// full budget from the start. Every bracket gets about the same number of
+// epochs in all.
+func (tc *TuneCommand) hyperband(directory string) []*tuneTrial {
+	cfg := tc.Config
+	eta := float64(cfg.Eta)
+	budget := float64(cfg.Training.Epochs)
+	brackets := int(math.Floor(math.Log(budget/float64(cfg.MinEpochs))/math.Log(eta) + 1e-9))
+
+	rng := random.NewSource(cfg.Seed)
+	all := make([]*tuneTrial, 0)
+	for s := brackets; s >= 0; s-- {
+		n := int(math.Ceil(float64(brackets+1) / float64(s+1) * math.Pow(eta, float64(s))))
+		epochs := int(math.Round(budget * math.Pow(eta, -float64(s))))
+		if epochs < cfg.MinEpochs {
+			epochs = cfg.MinEpochs
+		}
+		fmt.Fprintf(tc.Output, "Bracket %d: %d trials from %d epochs\n", brackets-s+1, n, epochs)
+
+		trials := tc.sample(n, brackets-s+1, rng, directory)
+		tc.halve(trials, epochs)
+		all = append(all, trials...)
+	}
+	return all
+}
+
+// halve trains trials for epochs, keeps the best 1/Eta of them and trains
+// the survivors Eta times as long, until they reach the full budget.
+func (tc *TuneCommand) halve(trials []*tuneTrial, epochs int) {
+	for {
+		tc.advance(trials, epochs)
+		if epochs >= tc.Config.Training.Epochs {
+			return
+		}
+
+		tc.rank(trials)
+		keep := len(trials) / tc.Config.Eta
+		if keep < 1 {
+			keep = 1
+		}
+		for _, trial := range trials[keep:] {
+			trial.finish(nil)
+		}
+		trials = trials[:keep]
+
+		if epochs *= tc.Config.Eta; epochs > tc.Config.Training.Epochs {
+			epochs = tc.Config.Training.Epochs
+		}
+	}
+}
+
+// advance trains every unfinished trial, in parallel, until it has
+// completed epochs epochs, and reports them in order. Trials that reach the
+// full budget are finished.
+func (tc *TuneCommand) advance(trials []*tuneTrial, epochs int) {
+	active := make([]*tuneTrial, 0, len(trials))
+	for _, trial := range trials {
+		if !trial.done {
+			active = append(active, trial)
+		}
+	}
+	parallel.NewPool(tc.Config.Parallel).Each(len(active), func(i int) {
+		trial := active[i]
+		err := trial.advance(epochs, tc.Config.Objective)
+		if err != nil || epochs >= tc.Config.Training.Epochs {
+			trial.finish(err)
+		}
+	})
+
+	for _, trial := range active {
+		if trial.Error != "" {
+			fmt.Fprintf(tc.Output, "  %s failed: %s\n", trial.Name, trial.Error)
+			continue
+		}
+		fmt.Fprintf(tc.Output, "  %s after %d epochs: %s %.6g (%s)\n",
+			trial.Name, trial.Result.Epochs, tc.Config.Objective, trial.Objective, trial.describe(tc.paths))
+	}
+}
+
+// grid returns a trial for every combination of the values in the space.
+func (tc *TuneCommand) grid(directory string) []*tuneTrial {
+	combinations := []map[string]string{{}}
+	for _, path := range tc.paths {
+		values := gridValues(tc.Config.Space[path])
+		expanded := make([]map[string]string, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, value := range values {
+				parameters := map[string]string{path: value}
+				for key, existing := range combination {
+					parameters[key] = existing
+				}
+				expanded = append(expanded, parameters)
+			}
+		}
+		combinations = expanded
+	}
+
+	trials := make([]*tuneTrial, len(combinations))
+	for i, parameters := range combinations {
+		trials[i] = tc.newTrial(parameters, 0, directory)
+	}
+	return trials
+}
+
+func gridValues(space config.ParameterSpace) []string {
+	if len(space.Values) > 0 {
+		values := make([]string, len(space.Values))
+		for i, value := range space.Values {
+			values[i] = fmt.Sprint(value)
+		}
+		return values
+	}
+
+	values := make([]string, 0, space.Steps)
+	for step := 0; step < space.Steps; step++ {
+		fraction := 0.0
+		if space.Steps > 1 {
+			fraction = float64(step) / float64(space.Steps-1)
+		}
+		value := space.Format(interpolate(space, fraction))
+		if len(values) == 0 || values[len(values)-1] != value {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// sample draws n trials at random from the space.
+func (tc *TuneCommand) sample(n, bracket int, rng *random.Source, directory string) []*tuneTrial {
+	trials := make([]*tuneTrial, n)
+	for i := range trials {
+		parameters := make(map[string]string, len(tc.paths))
+		for _, path := range tc.paths {
+			space := tc.Config.Space[path]
+			switch {
+			case len(space.Values) > 0:
+				parameters[path] = fmt.Sprint(space.Values[rng.Intn(len(space.Values))])
+			case space.Integer && !strings.EqualFold(space.Scale, "log"):
+				// Widen the range by half a step either side so that
+				// rounding gives the end points their fair share.
+				parameters[path] = space.Format(rng.Uniform(space.Min-0.5, space.Max+0.5))
+			default:
+				parameters[path] = space.Format(interpolate(space, rng.Float64()))
+			}
+		}
+		trials[i] = tc.newTrial(parameters, bracket, directory)
+	}
+	return trials
+}
+
+// interpolate maps fraction in [0, 1] onto the space's range.
+func interpolate(space config.ParameterSpace, fraction float64) float64 {
+	if strings.EqualFold(space.Scale, "log") {
+		return math.Exp(math.Log(space.Min) + fraction*(math.Log(space.Max)-math.Log(space.Min)))
+	}
+	return space.Min + fraction*(space.Max-space.Min)
+}
+
+// newTrial derives a trial's config from the training settings. Trials
+// share the training seed, so they differ only in their parameters; they
+// log to the trials directory and never checkpoint, as their checkpoints
+// would overwrite one another.
+func (tc *TuneCommand) newTrial(parameters map[string]string, bracket int, directory string) *tuneTrial {
+	tc.trial++
+	trial := &tuneTrial{
+		Name:       fmt.Sprintf("trial-%03d", tc.trial),
+		Bracket:    bracket,
+		Parameters: parameters,
+	}
+
+	// A JSON round trip copies the maps and slices as well.
+	cfg := &config.TrainingConfig{}
+	document, err := json.Marshal(tc.Config.Training)
+	if err == nil {
+		err = json.Unmarshal(document, cfg)
+	}
+	for _, path := range tc.paths {
+		if err != nil {
+			break
+		}
+		if err = config.Set(cfg, path, parameters[path]); err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err == nil {
+		err = cfg.Validate()
+	}
+	cfg.Checkpoints.Enabled = false
+	cfg.Tracking.Directory = filepath.Join(directory, "trials")
+	cfg.Tracking.Name = trial.Name
+	trial.Config = cfg
+
+	if err != nil {
+		trial.Error = err.Error()
+		trial.done = true
+		return trial
+	}
+
+	trial.command = &TrainCommand{DataPath: tc.DataPath, Output: io.Discard}
+	trial.command.useConfig(cfg)
+	return trial
+}
+
+// advance trains the trial until it has completed epochs epochs, building
+// it first if need be, and scores it.
+func (tt *tuneTrial) advance(epochs int, objective string) error {
+	if tt.trainer == nil {
+		var err error
+		if tt.trainer, err = tt.command.build(); err != nil {
+			return err
+		}
+		if tt.run, err = tt.command.startRun(); err != nil {
+			return err
+		}
+		if tt.run != nil {
+			tt.Run = tt.run.Directory
+		}
+		tt.trainer.attach(tt.run)
+	}
+
+	tt.Budget = epochs
+	if err := tt.trainer.advance(epochs); err != nil {
+		return err
+	}
+	result, err := tt.trainer.result()
+	if err != nil {
+		return err
+	}
+	tt.Result = result
+
+	switch strings.ToLower(objective) {
+	case "training_loss":
+		tt.Objective = result.TrainingLoss
+	case "validation_accuracy":
+		tt.Objective = result.ValidationAccuracy
+	default:
+		tt.Objective = result.ValidationLoss
+	}
+	if strings.HasPrefix(strings.ToLower(objective), "validation") && !result.Validated {
+		return fmt.Errorf("%s needs validation data, but validation.split_ratio is 0", objective)
+	}
+	if math.IsNaN(tt.Objective) || math.IsInf(tt.Objective, 0) {
+		return fmt.Errorf("%s diverged to %g", objective, tt.Objective)
+	}
+	return nil
+}
+
+// finish closes the trial's run, recording err as the reason it failed if
+// it is not nil, and releases its model.
+func (tt *tuneTrial) finish(err error) {
+	if tt.done {
+		return
+	}
+	tt.done = true
+	if err = finishRun(tt.run, err); err != nil {
+		tt.Error = err.Error()
+	}
+	tt.trainer = nil
+}
+
+func (tt *tuneTrial) describe(paths []string) string {
+	settings := make([]string, len(paths))
+	for i, path := range paths {
+		settings[i] = path + "=" + tt.Parameters[path]
+	}
+	return strings.Join(settings, ", ")
+}
+
+// rank orders trials best first: those that trained on the largest budget,
+// by objective, then the trials pruned earlier and last the failed ones.
+func (tc *TuneCommand) rank(trials []*tuneTrial) {
+	maximize := strings.EqualFold(tc.Config.Objective, "validation_accuracy")
+	sort.SliceStable(trials, func(i, j int) bool {
+		a, b := trials[i], trials[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if a.Budget != b.Budget {
+			return a.Budget > b.Budget
+		}
+		if maximize {
+			return a.Objective > b.Objective
+		}
+		return a.Objective < b.Objective
+	})
+	for i, trial := range trials {
+		trial.Rank = i + 1
+	}
+}
+
+// report prints the leaderboard with a column for each searched setting.
+func (tc *TuneCommand) report(trials []*tuneTrial) error {
+	table := tabwriter.NewWriter(tc.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "RANK\tTRIAL\tEPOCHS\tLOSS\tVAL LOSS\tVAL ACCURACY\t%s\n", strings.Join(tc.paths, "\t"))
+	for _, trial := range trials {
+		loss, validation, accuracy := "failed", "-", "-"
+		if trial.Error == "" {
+			loss = fmt.Sprintf("%.6g", trial.Result.TrainingLoss)
+			if trial.Result.Validated {
+				validation = fmt.Sprintf("%.6g", trial.Result.ValidationLoss)
+				accuracy = fmt.Sprintf("%.1f%%", trial.Result.ValidationAccuracy*100)
+			}
+		}
+		values := make([]string, len(tc.paths))
+		for i, path := range tc.paths {
+			values[i] = trial.Parameters[path]
+		}
+		fmt.Fprintf(table, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			trial.Rank, trial.Name, trial.Result.Epochs, loss, validation, accuracy, strings.Join(values, "\t"))
+	}
+	return table.Flush()
+}
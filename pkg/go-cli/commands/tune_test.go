@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/random"
+	"github.com/ykashou/go-elder/pkg/go-cli/config"
+)
+
+// tuneCommand is a TuneCommand over space whose trials are never trained.
+func tuneCommand(space map[string]config.ParameterSpace) *TuneCommand {
+	tc := NewTuneCommand()
+	tc.Output = io.Discard
+	tc.Config = config.DefaultTuneConfig()
+	tc.Config.Space = space
+	for path := range space {
+		tc.paths = append(tc.paths, path)
+	}
+	sort.Strings(tc.paths)
+	return tc
+}
+
+func TestGridExpansion(t *testing.T) {
+	tc := tuneCommand(map[string]config.ParameterSpace{
+		"optimizer.type":          {Values: []interface{}{"sgd", "adam", "rmsprop"}},
+		"optimizer.learning_rate": {Min: 1e-4, Max: 1e-2, Scale: "log", Steps: 3},
+		"optimizer.momentum":      {Min: 0, Max: 0.9, Steps: 4},
+		// Five steps over [1, 2] round to 1, 1, 2, 2, 2, which are
+		// tried once each.
+		"data.batch_size": {Min: 1, Max: 2, Steps: 5, Integer: true},
+	})
+
+	trials := tc.grid(t.TempDir())
+	if want := 3 * 3 * 4 * 2; len(trials) != want {
+		t.Fatalf("grid has %d trials, want %d", len(trials), want)
+	}
+
+	seen := make(map[string]bool)
+	for _, trial := range trials {
+		if trial.Error != "" {
+			t.Fatalf("%s: %s", trial.Name, trial.Error)
+		}
+		key := trial.describe(tc.paths)
+		if seen[key] {
+			t.Errorf("%s is tried twice", key)
+		}
+		seen[key] = true
+	}
+
+	want := map[string][]string{
+		"optimizer.learning_rate": {"0.0001", "0.001", "0.01"},
+		"optimizer.momentum":      {"0", "0.3", "0.6", "0.9"},
+		"data.batch_size":         {"1", "2"},
+	}
+	for path, values := range want {
+		if got := gridValues(tc.Config.Space[path]); !reflect.DeepEqual(got, values) {
+			t.Errorf("%s: grid values %v, want %v", path, got, values)
+		}
+	}
+}
+
+func TestRandomSearchIsSeeded(t *testing.T) {
+	space := map[string]config.ParameterSpace{
+		"optimizer.type":          {Values: []interface{}{"sgd", "adam", "rmsprop"}},
+		"optimizer.learning_rate": {Min: 1e-4, Max: 1e-2, Scale: "log"},
+		"optimizer.momentum":      {Min: 0.5, Max: 0.9},
+		"data.batch_size":         {Min: 8, Max: 12, Integer: true},
+	}
+	draw := func(seed int64) [][]string {
+		tc := tuneCommand(space)
+		trials := tc.sample(50, 0, random.NewSource(seed), t.TempDir())
+		draws := make([][]string, len(trials))
+		for i, trial := range trials {
+			if trial.Error != "" {
+				t.Fatalf("%s: %s", trial.Name, trial.Error)
+			}
+			draws[i] = make([]string, len(tc.paths))
+			for j, path := range tc.paths {
+				draws[i][j] = trial.Parameters[path]
+			}
+		}
+		return draws
+	}
+
+	first := draw(4)
+	if again := draw(4); !reflect.DeepEqual(again, first) {
+		t.Errorf("seed 4 drew different trials the second time")
+	}
+	if other := draw(5); reflect.DeepEqual(other, first) {
+		t.Errorf("seeds 4 and 5 drew the same trials")
+	}
+
+	// The columns are the paths in sorted order.
+	sizes := make(map[string]bool)
+	for _, trial := range first {
+		size, err := strconv.Atoi(trial[0])
+		if err != nil || size < 8 || size > 12 {
+			t.Errorf("batch size %q outside [8, 12]", trial[0])
+		}
+		sizes[trial[0]] = true
+		if rate, _ := strconv.ParseFloat(trial[1], 64); rate < 1e-4 || rate > 1e-2 {
+			t.Errorf("learning rate %q outside [1e-4, 1e-2]", trial[1])
+		}
+		if momentum, _ := strconv.ParseFloat(trial[2], 64); momentum < 0.5 || momentum > 0.9 {
+			t.Errorf("momentum %q outside [0.5, 0.9]", trial[2])
+		}
+		if trial[3] != "sgd" && trial[3] != "adam" && trial[3] != "rmsprop" {
+			t.Errorf("optimizer %q is not one of the values", trial[3])
+		}
+	}
+	if len(sizes) != 5 {
+		t.Errorf("50 draws gave batch sizes %v, want all of 8 to 12", sizes)
+	}
+}
+
+// runTune runs a search over the learning rate on the synthetic data with
+// the given settings and returns its leaderboard.
+func runTune(t *testing.T, method string, trials, eta, minEpochs, epochs int) leaderboard {
+	t.Helper()
+	dir := t.TempDir()
+	document, err := json.Marshal(map[string]interface{}{
+		"method":     method,
+		"trials":     trials,
+		"eta":        eta,
+		"min_epochs": minEpochs,
+		"seed":       3,
+		"directory":  dir,
+		"space": map[string]interface{}{
+			"optimizer.learning_rate": map[string]interface{}{"min": 1e-3, "max": 1e-1, "scale": "log"},
+		},
+		"training": map[string]interface{}{
+			"epochs": epochs,
+			"model": map[string]interface{}{
+				"layers": []map[string]interface{}{
+					{"type": "dense", "size": 4, "activation": "tanh"},
+					{"type": "dense", "size": 1, "activation": "linear"},
+				},
+			},
+			"validation": map[string]interface{}{"early_stopping": false},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "tune.json")
+	if err := os.WriteFile(file, document, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTuneCommand()
+	tc.ConfigFile = file
+	tc.Output = io.Discard
+	if err := tc.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := filepath.Glob(filepath.Join(dir, "*", LeaderboardFile))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("found leaderboards %v: %v", runs, err)
+	}
+	content, err := os.ReadFile(runs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var board leaderboard
+	if err := json.Unmarshal(content, &board); err != nil {
+		t.Fatal(err)
+	}
+	return board
+}
+
+// budgets counts the trials of each bracket by the epochs they reached.
+func budgets(t *testing.T, board leaderboard) map[int]map[int]int {
+	t.Helper()
+	counts := make(map[int]map[int]int)
+	for _, trial := range board.Trials {
+		if trial.Error != "" {
+			t.Fatalf("%s: %s", trial.Name, trial.Error)
+		}
+		if trial.Result.Epochs != trial.Budget {
+			t.Errorf("%s trained %d epochs on a budget of %d", trial.Name, trial.Result.Epochs, trial.Budget)
+		}
+		if counts[trial.Bracket] == nil {
+			counts[trial.Bracket] = make(map[int]int)
+		}
+		counts[trial.Bracket][trial.Budget]++
+	}
+	return counts
+}
+
+func TestSuccessiveHalvingRungs(t *testing.T) {
+	tests := []struct {
+		name                        string
+		method                      string
+		trials, eta, minEpochs, max int
+		want                        map[int]map[int]int
+	}{
+		{
+			// 10 trials on 1 epoch, the best 3 on 3 and the best one on 9.
+			name: "halving", method: "halving", trials: 10, eta: 3, minEpochs: 1, max: 9,
+			want: map[int]map[int]int{0: {1: 7, 3: 2, 9: 1}},
+		},
+		{
+			// The last rung is cut to the full budget: 9 on 2, 3 on 6,
+			// then 1 on 10 rather than 18.
+			name: "halving capped", method: "halving", trials: 9, eta: 3, minEpochs: 2, max: 10,
+			want: map[int]map[int]int{0: {2: 6, 6: 2, 10: 1}},
+		},
+		{
+			// Three brackets: 9 trials from 1 epoch, 5 from 3 and 3 from 9.
+			name: "hyperband eta 3", method: "hyperband", eta: 3, minEpochs: 1, max: 9, trials: 1,
+			want: map[int]map[int]int{
+				1: {1: 6, 3: 2, 9: 1},
+				2: {3: 4, 9: 1},
+				3: {9: 3},
+			},
+		},
+		{
+			// Three brackets: 4 trials from 2 epochs, 3 from 4 and 3 from 8.
+			name: "hyperband eta 2", method: "hyperband", eta: 2, minEpochs: 2, max: 8, trials: 1,
+			want: map[int]map[int]int{
+				1: {2: 2, 4: 1, 8: 1},
+				2: {4: 2, 8: 1},
+				3: {8: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := runTune(t, tt.method, tt.trials, tt.eta, tt.minEpochs, tt.max)
+			if got := budgets(t, board); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trials per bracket and budget %v, want %v", got, tt.want)
+			}
+			for i, trial := range board.Trials {
+				if trial.Rank != i+1 {
+					t.Errorf("trial %d of the leaderboard has rank %d", i, trial.Rank)
+				}
+			}
+		})
+	}
+}
+
+func TestRankOrdering(t *testing.T) {
+	trials := func() []*tuneTrial {
+		return []*tuneTrial{
+			{Name: "pruned", Budget: 3, Objective: 0.1},
+			{Name: "failed", Budget: 9, Objective: 0, Error: "diverged"},
+			{Name: "low", Budget: 9, Objective: 0.2},
+			{Name: "high", Budget: 9, Objective: 0.8},
+			{Name: "pruned early", Budget: 1, Objective: 0.9},
+			{Name: "middle", Budget: 9, Objective: 0.5},
+		}
+	}
+
+	tests := []struct {
+		objective string
+		want      []string
+	}{
+		{"validation_loss", []string{"low", "middle", "high", "pruned", "pruned early", "failed"}},
+		{"training_loss", []string{"low", "middle", "high", "pruned", "pruned early", "failed"}},
+		{"validation_accuracy", []string{"high", "middle", "low", "pruned", "pruned early", "failed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.objective, func(t *testing.T) {
+			tc := tuneCommand(nil)
+			tc.Config.Objective = tt.objective
+			ranked := trials()
+			tc.rank(ranked)
+
+			names := make([]string, len(ranked))
+			for i, trial := range ranked {
+				names[i] = trial.Name
+				if trial.Rank != i+1 {
+					t.Errorf("%s is at %d with rank %d", trial.Name, i+1, trial.Rank)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ranked %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
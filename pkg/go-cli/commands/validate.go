@@ -35,13 +35,13 @@ func (vc *ValidateCommand) Execute() error {
 		}
 	}
 	
-	if allPassed {
-		fmt.Println("All validations passed!")
-	} else {
-		fmt.Println("Some validations failed. Check report for details.")
+	fmt.Printf("Validation report saved to %s\n", vc.OutputFile)
+	
+	if !allPassed {
+		return fmt.Errorf("some validations failed, check %s for details", vc.OutputFile)
 	}
 	
-	fmt.Printf("Validation report saved to %s\n", vc.OutputFile)
+	fmt.Println("All validations passed!")
 	return nil
 }
 
@@ -1,17 +1,29 @@
 package commands
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ykashou/go-elder/internal/go-simulation/visualization"
+	"github.com/ykashou/go-elder/pkg/go-file/trajectory"
+)
 
 type VisualizeCommand struct {
 	InputFile    string
+	OutputFile   string
 	OutputFormat string
 	Interactive  bool
 	Components   []string
+	orbits       *visualization.OrbitalVisualizer
 }
 
 func NewVisualizeCommand() *VisualizeCommand {
 	return &VisualizeCommand{
 		InputFile:    "simulation_results.json",
+		OutputFile:   "visualization.html",
 		OutputFormat: "html",
 		Interactive:  true,
 		Components:   []string{"hierarchy", "fields", "dynamics"},
@@ -23,34 +35,47 @@ func (vc *VisualizeCommand) Execute() error {
 	fmt.Printf("Input file: %s\n", vc.InputFile)
 	fmt.Printf("Output format: %s\n", vc.OutputFormat)
 	fmt.Printf("Components: %v\n", vc.Components)
-	
+
+	if vc.OutputFormat != "html" && vc.OutputFormat != "svg" && vc.OutputFormat != "json" {
+		return fmt.Errorf("unknown output format: %s", vc.OutputFormat)
+	}
+
 	for _, component := range vc.Components {
-		vc.visualizeComponent(component)
+		if err := vc.visualizeComponent(component); err != nil {
+			return err
+		}
 	}
-	
+
 	if vc.Interactive {
 		vc.generateInteractiveVisualization()
 	}
-	
+
+	if err := vc.writeOutput(); err != nil {
+		return err
+	}
+
 	fmt.Println("Visualization completed!")
 	return nil
 }
 
-func (vc *VisualizeCommand) visualizeComponent(component string) {
+func (vc *VisualizeCommand) visualizeComponent(component string) error {
 	fmt.Printf("Visualizing %s...\n", component)
-	
+
 	switch component {
 	case "hierarchy":
 		vc.visualizeHierarchy()
 	case "fields":
 		vc.visualizeFields()
 	case "dynamics":
-		vc.visualizeDynamics()
+		return vc.visualizeDynamics()
 	case "phase":
 		vc.visualizePhase()
 	case "orbits":
-		vc.visualizeOrbits()
+		return vc.visualizeOrbits()
+	default:
+		return fmt.Errorf("unknown visualization component: %s", component)
 	}
+	return nil
 }
 
 func (vc *VisualizeCommand) visualizeHierarchy() {
@@ -61,18 +86,105 @@ func (vc *VisualizeCommand) visualizeFields() {
 	fmt.Println("Generating gravitational field visualization...")
 }
 
-func (vc *VisualizeCommand) visualizeDynamics() {
+func (vc *VisualizeCommand) visualizeDynamics() error {
 	fmt.Println("Generating orbital dynamics visualization...")
+	return vc.loadOrbits()
 }
 
 func (vc *VisualizeCommand) visualizePhase() {
 	fmt.Println("Generating phase space visualization...")
 }
 
-func (vc *VisualizeCommand) visualizeOrbits() {
+func (vc *VisualizeCommand) visualizeOrbits() error {
 	fmt.Println("Generating orbital trajectory visualization...")
+	return vc.loadOrbits()
 }
 
 func (vc *VisualizeCommand) generateInteractiveVisualization() {
 	fmt.Println("Generating interactive visualization interface...")
 }
+
+// loadOrbits streams the trajectory file once into an OrbitalVisualizer,
+// which downsamples long runs to a bounded number of points per body.
+func (vc *VisualizeCommand) loadOrbits() error {
+	if vc.orbits != nil {
+		return nil
+	}
+
+	reader, err := trajectory.Open(vc.InputFile)
+	if err != nil {
+		return fmt.Errorf("open simulation results: %w", err)
+	}
+	defer reader.Close()
+
+	if !reader.Header.Has(trajectory.FieldPositions) {
+		return fmt.Errorf("%s does not record positions", vc.InputFile)
+	}
+
+	orbits := visualization.NewOrbitalVisualizer(0, 1)
+	for _, id := range reader.Header.Bodies {
+		orbits.AddBody(id, visualization.Point3D{}, visualization.Point3D{}, 0, bodyColor(id))
+	}
+
+	frames := 0
+	for {
+		frame, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", vc.InputFile, err)
+		}
+
+		for i, id := range reader.Header.Bodies {
+			if frame.Present(i) {
+				p := frame.Positions[i]
+				orbits.RecordPosition(id, visualization.Point3D{X: p[0], Y: p[1], Z: p[2]})
+			}
+		}
+		frames++
+	}
+
+	fmt.Printf("Loaded %d frames for %d bodies\n", frames, len(reader.Header.Bodies))
+	vc.orbits = orbits
+	return nil
+}
+
+func (vc *VisualizeCommand) writeOutput() error {
+	if vc.orbits == nil {
+		return nil
+	}
+
+	var content string
+	switch vc.OutputFormat {
+	case "json":
+		encoded, err := json.MarshalIndent(vc.orbits.GenerateVisualizationData(), "", "  ")
+		if err != nil {
+			return err
+		}
+		content = string(encoded) + "\n"
+	case "svg":
+		content = vc.orbits.RenderSVG(800)
+	default:
+		content = "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Elder visualization</title></head><body>\n" +
+			vc.orbits.RenderSVG(800) + "</body></html>\n"
+	}
+
+	if err := os.WriteFile(vc.OutputFile, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Visualization saved to %s\n", vc.OutputFile)
+	return nil
+}
+
+func bodyColor(id string) string {
+	switch {
+	case strings.HasPrefix(id, "elder"):
+		return "gold"
+	case strings.HasPrefix(id, "mentor"):
+		return "deepskyblue"
+	case strings.HasPrefix(id, "erudite"):
+		return "lightgreen"
+	}
+	return "white"
+}
@@ -39,3 +39,29 @@ type AnalysisOutputConfig struct {
 	Interactive bool     `json:"interactive"`
 	Export      []string `json:"export"`
 }
+
+func DefaultAnalysisConfig() *AnalysisConfig {
+	return &AnalysisConfig{
+		Targets: []string{"system"},
+		Metrics: MetricsConfig{
+			Convergence: true,
+			Energy:      true,
+			Entropy:     true,
+		},
+		Stability: StabilityConfig{
+			LyapunovExponent: true,
+			PhaseAnalysis:    true,
+			Tolerance:        1e-6,
+			TimeWindow:       100.0,
+		},
+		Performance: PerformanceConfig{
+			Memory: true,
+			CPU:    true,
+		},
+		Output: AnalysisOutputConfig{
+			Format:  "html",
+			Details: "summary",
+			Plots:   true,
+		},
+	}
+}
@@ -28,12 +28,6 @@ type FieldsConfig struct {
 	DecayRate        float64 `json:"decay_rate"`
 }
 
-type SimulationConfig struct {
-	MaxDuration     float64 `json:"max_duration"`
-	OutputInterval  float64 `json:"output_interval"`
-	CheckpointFreq  int     `json:"checkpoint_frequency"`
-}
-
 type LoggingConfig struct {
 	Level      string `json:"level"`
 	OutputFile string `json:"output_file"`
@@ -59,11 +53,7 @@ func DefaultElderConfig() *ElderConfig {
 			FieldRange:      100.0,
 			DecayRate:       0.01,
 		},
-		Simulation: SimulationConfig{
-			MaxDuration:    1000.0,
-			OutputInterval: 1.0,
-			CheckpointFreq: 100,
-		},
+		Simulation: *DefaultSimulationConfig(),
 		Logging: LoggingConfig{
 			Level:      "INFO",
 			OutputFile: "elder.log",
@@ -0,0 +1,463 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError reports a problem with a single configuration field, identified
+// by its dotted JSON path (for example "optimizer.learning_rate").
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (fe FieldError) Error() string {
+	if fe.Path == "" {
+		return fe.Message
+	}
+	return fmt.Sprintf("%s: %s", fe.Path, fe.Message)
+}
+
+// FieldErrors collects every problem found while loading or validating a
+// configuration so that they can be reported together.
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	messages := make([]string, len(fe))
+	for i, err := range fe {
+		messages[i] = err.Error()
+	}
+	return "invalid configuration:\n  " + strings.Join(messages, "\n  ")
+}
+
+func (fe FieldErrors) orNil() error {
+	if len(fe) == 0 {
+		return nil
+	}
+	return fe
+}
+
+// Validator is implemented by every configuration type in this package.
+type Validator interface {
+	Validate() error
+}
+
+// Loader fills a configuration struct in layers: the defaults already present
+// in the target, then a JSON or YAML file, then environment variables named
+// after the field path (GO_ELDER_OPTIMIZER_LEARNING_RATE), then explicit
+// overrides such as command-line flags.
+type Loader struct {
+	EnvPrefix string
+	LookupEnv func(string) (string, bool)
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		EnvPrefix: "GO_ELDER",
+		LookupEnv: os.LookupEnv,
+	}
+}
+
+// Load applies every layer to target, which must be a pointer to a struct
+// pre-populated with defaults, and validates the result.
+func (l *Loader) Load(target interface{}, path string, overrides map[string]string) error {
+	if err := l.checkTarget(target); err != nil {
+		return err
+	}
+
+	if path != "" {
+		if err := l.LoadFile(target, path); err != nil {
+			return err
+		}
+	}
+
+	if err := l.ApplyEnv(target); err != nil {
+		return err
+	}
+
+	if err := l.ApplyOverrides(target, overrides); err != nil {
+		return err
+	}
+
+	if validator, ok := target.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// LoadFile merges a JSON (.json) or YAML (.yaml, .yml) file into target.
+// Keys that do not correspond to a field are reported with their path.
+func (l *Loader) LoadFile(target interface{}, path string) error {
+	if err := l.checkTarget(target); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var document interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+		document, err = normalizeYAML(document, "")
+		if err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+
+	if document == nil {
+		return nil
+	}
+
+	var problems FieldErrors
+	checkKeys(document, reflect.TypeOf(target).Elem(), "", &problems)
+	if len(problems) > 0 {
+		return problems
+	}
+
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(normalized, target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return FieldErrors{{
+				Path:    typeErr.Field,
+				Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+			}}
+		}
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ApplyEnv overrides every scalar field for which an environment variable
+// named EnvPrefix_<FIELD_PATH> is set.
+func (l *Loader) ApplyEnv(target interface{}) error {
+	if err := l.checkTarget(target); err != nil {
+		return err
+	}
+
+	var problems FieldErrors
+	for _, path := range FieldPaths(target) {
+		value, ok := l.LookupEnv(l.EnvName(path))
+		if !ok {
+			continue
+		}
+		if err := Set(target, path, value); err != nil {
+			problems = append(problems, FieldError{
+				Path:    path,
+				Message: fmt.Sprintf("from %s: %v", l.EnvName(path), err),
+			})
+		}
+	}
+
+	return problems.orNil()
+}
+
+// ApplyOverrides sets each field path in overrides to its string value.
+// Paths are applied in sorted order so the result does not depend on map
+// iteration.
+func (l *Loader) ApplyOverrides(target interface{}, overrides map[string]string) error {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var problems FieldErrors
+	for _, path := range paths {
+		if err := Set(target, path, overrides[path]); err != nil {
+			problems = append(problems, FieldError{Path: path, Message: err.Error()})
+		}
+	}
+
+	return problems.orNil()
+}
+
+func (l *Loader) EnvName(path string) string {
+	name := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	if l.EnvPrefix == "" {
+		return name
+	}
+	return l.EnvPrefix + "_" + name
+}
+
+func (l *Loader) checkTarget(target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config target must be a non-nil pointer to a struct, got %T", target)
+	}
+	return nil
+}
+
+// FieldPaths lists the dotted paths of every field that can be set from a
+// single string value: numbers, booleans, strings and string slices.
+func FieldPaths(target interface{}) []string {
+	paths := make([]string, 0)
+	collectPaths(reflect.TypeOf(target).Elem(), "", &paths)
+	return paths
+}
+
+func collectPaths(t reflect.Type, prefix string, paths *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		path := joinPath(prefix, name)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			collectPaths(field.Type, path, paths)
+		case isScalar(field.Type):
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// Set parses value into the field at path, a dotted sequence of JSON names.
+// The last name may instead be a key of a map of scalars, such as
+// model.parameters.learning_rate.
+func Set(target interface{}, path string, value string) error {
+	current := reflect.ValueOf(target).Elem()
+
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		if current.Kind() == reflect.Map && i == len(names)-1 {
+			return setMapEntry(current, name, value)
+		}
+		if current.Kind() != reflect.Struct {
+			return fmt.Errorf("unknown key")
+		}
+
+		field, ok := fieldByJSONName(current, name)
+		if !ok {
+			return fmt.Errorf("unknown key")
+		}
+		current = field
+	}
+
+	if !isScalar(current.Type()) {
+		return fmt.Errorf("cannot be set from a single value")
+	}
+
+	return setScalar(current, value)
+}
+
+func setMapEntry(m reflect.Value, key string, value string) error {
+	if m.Type().Key().Kind() != reflect.String || !isScalar(m.Type().Elem()) {
+		return fmt.Errorf("cannot be set from a single value")
+	}
+
+	entry := reflect.New(m.Type().Elem()).Elem()
+	if err := setScalar(entry, value); err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), entry)
+	return nil
+}
+
+func setScalar(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected a boolean, got %q", value)
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", value)
+		}
+		field.SetInt(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		field.SetFloat(parsed)
+	case reflect.Slice:
+		parts := make([]string, 0)
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		field.Set(reflect.ValueOf(parts))
+	}
+	return nil
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+func fieldByJSONName(value reflect.Value, name string) (reflect.Value, bool) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// checkKeys walks a decoded document alongside the struct type it will be
+// decoded into and records every key that has no matching field.
+func checkKeys(document interface{}, t reflect.Type, path string, problems *FieldErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := document.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, ok := structFieldByJSONName(t, key)
+			if !ok {
+				*problems = append(*problems, FieldError{Path: joinPath(path, key), Message: "unknown key"})
+				continue
+			}
+			checkKeys(object[key], field.Type, joinPath(path, key), problems)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := document.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case reflect.Map:
+		object, ok := document.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, item := range object {
+			checkKeys(item, t.Elem(), joinPath(path, key), problems)
+		}
+	}
+}
+
+func structFieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// normalizeYAML converts the generic values produced by the YAML decoder into
+// the shapes encoding/json understands.
+func normalizeYAML(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			normalized, err := normalizeYAML(item, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			v[key] = normalized
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, FieldError{Path: path, Message: fmt.Sprintf("non-string key %v", key)}
+			}
+			normalized, err := normalizeYAML(item, joinPath(path, name))
+			if err != nil {
+				return nil, err
+			}
+			converted[name] = normalized
+		}
+		return converted, nil
+	case []interface{}:
+		for i, item := range v {
+			normalized, err := normalizeYAML(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = normalized
+		}
+		return v, nil
+	}
+	return value, nil
+}
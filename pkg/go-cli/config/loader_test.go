@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fieldErrors asserts that err is a FieldErrors and returns it.
+func fieldErrors(t *testing.T, err error) FieldErrors {
+	t.Helper()
+	var problems FieldErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected FieldErrors, got %T: %v", err, err)
+	}
+	return problems
+}
+
+func paths(problems FieldErrors) []string {
+	result := make([]string, len(problems))
+	for i, problem := range problems {
+		result[i] = problem.Path
+	}
+	return result
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testLoader(env map[string]string) *Loader {
+	loader := NewLoader()
+	loader.LookupEnv = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	return loader
+}
+
+func TestDefaultsValidate(t *testing.T) {
+	validators := map[string]Validator{
+		"elder":      DefaultElderConfig(),
+		"simulation": DefaultSimulationConfig(),
+		"training":   DefaultTrainingConfig(),
+		"transfer":   DefaultTransferConfig(),
+		"analysis":   DefaultAnalysisConfig(),
+	}
+	for name, validator := range validators {
+		if err := validator.Validate(); err != nil {
+			t.Errorf("default %s config: %v", name, err)
+		}
+	}
+}
+
+func TestValidateReportsPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ElderConfig)
+		path   string
+	}{
+		{"system", func(c *ElderConfig) { c.System.TimeStep = 0 }, "system.time_step"},
+		{"fields", func(c *ElderConfig) { c.Fields.DecayRate = 2 }, "fields.decay_rate"},
+		{"logging", func(c *ElderConfig) { c.Logging.Level = "LOUD" }, "logging.level"},
+		{"nested simulation", func(c *ElderConfig) { c.Simulation.Physics.Coupling = -1 }, "simulation.physics.coupling_strength"},
+		{"nested integration", func(c *ElderConfig) { c.Simulation.Integration.Method = "magic" }, "simulation.integration.method"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultElderConfig()
+			tt.modify(cfg)
+
+			problems := fieldErrors(t, cfg.Validate())
+			if len(problems) != 1 || problems[0].Path != tt.path {
+				t.Fatalf("expected one error at %s, got %v", tt.path, paths(problems))
+			}
+			if !strings.Contains(problems.Error(), tt.path+": ") {
+				t.Errorf("message does not name the path: %q", problems.Error())
+			}
+		})
+	}
+}
+
+func TestValidateCollectsEveryProblem(t *testing.T) {
+	cfg := DefaultElderConfig()
+	cfg.System.Tolerance = -1
+	cfg.Entities.ElderCount = 0
+	cfg.Simulation.MaxDuration = 0
+
+	problems := fieldErrors(t, cfg.Validate())
+	want := []string{"system.tolerance", "entities.elder_count", "simulation.max_duration"}
+	if strings.Join(paths(problems), ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, paths(problems))
+	}
+}
+
+func TestTrainingValidateReportsPath(t *testing.T) {
+	cfg := DefaultTrainingConfig()
+	cfg.Epochs = 0
+	cfg.Optimizer.LearningRate = -0.1
+
+	problems := fieldErrors(t, cfg.Validate())
+	want := []string{"epochs", "optimizer.learning_rate"}
+	if strings.Join(paths(problems), ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, paths(problems))
+	}
+}
+
+func TestLoadFileUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"json", "elder.json", `{"system": {"time_stpe": 0.1}, "simulation": {"physics": {"coupling": 1}}}`},
+		{"yaml", "elder.yaml", "system:\n  time_stpe: 0.1\nsimulation:\n  physics:\n    coupling: 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file, tt.content)
+			err := testLoader(nil).LoadFile(DefaultElderConfig(), path)
+
+			problems := fieldErrors(t, err)
+			want := []string{"simulation.physics.coupling", "system.time_stpe"}
+			if strings.Join(paths(problems), ",") != strings.Join(want, ",") {
+				t.Fatalf("expected %v, got %v", want, paths(problems))
+			}
+			for _, problem := range problems {
+				if problem.Message != "unknown key" {
+					t.Errorf("%s: unexpected message %q", problem.Path, problem.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFileTypeError(t *testing.T) {
+	path := writeConfig(t, "elder.json", `{"simulation": {"integration": {"time_step": "fast"}}}`)
+	err := testLoader(nil).LoadFile(DefaultElderConfig(), path)
+
+	problems := fieldErrors(t, err)
+	if len(problems) != 1 || problems[0].Path != "simulation.integration.time_step" {
+		t.Fatalf("expected a type error at simulation.integration.time_step, got %v", err)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeConfig(t, "elder.json", `{"system": {"time_step": 0.5}, "simulation": {"seed": 7}}`)
+	env := map[string]string{
+		"GO_ELDER_SYSTEM_TIME_STEP":       "0.25",
+		"GO_ELDER_SIMULATION_ENGINE_TYPE": "tree",
+	}
+	overrides := map[string]string{"system.time_step": "0.125"}
+
+	cfg := DefaultElderConfig()
+	if err := testLoader(env).Load(cfg, path, overrides); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.TimeStep != 0.125 {
+		t.Errorf("override should win over env and file, got time_step %g", cfg.System.TimeStep)
+	}
+	if cfg.Simulation.Engine.Type != "tree" {
+		t.Errorf("env was not applied, got engine type %q", cfg.Simulation.Engine.Type)
+	}
+	if cfg.Simulation.Seed != 7 {
+		t.Errorf("file was not applied, got seed %d", cfg.Simulation.Seed)
+	}
+}
+
+func TestLoadEnvErrorReportsPath(t *testing.T) {
+	env := map[string]string{"GO_ELDER_SIMULATION_INTEGRATION_MAX_STEPS": "many"}
+	err := testLoader(env).Load(DefaultElderConfig(), "", nil)
+
+	problems := fieldErrors(t, err)
+	if len(problems) != 1 || problems[0].Path != "simulation.integration.max_steps" {
+		t.Fatalf("expected an error at simulation.integration.max_steps, got %v", err)
+	}
+	if !strings.Contains(problems[0].Message, "GO_ELDER_SIMULATION_INTEGRATION_MAX_STEPS") {
+		t.Errorf("message does not name the variable: %q", problems[0].Message)
+	}
+}
+
+func TestLoadOverrideErrorsReportPath(t *testing.T) {
+	overrides := map[string]string{
+		"system.max_processors": "lots",
+		"system.no_such_field":  "1",
+	}
+	err := testLoader(nil).Load(DefaultElderConfig(), "", overrides)
+
+	problems := fieldErrors(t, err)
+	want := []string{"system.max_processors", "system.no_such_field"}
+	if strings.Join(paths(problems), ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, paths(problems))
+	}
+}
+
+func TestLoadValidatesResult(t *testing.T) {
+	overrides := map[string]string{"simulation.physics.coupling_strength": "-2"}
+	err := testLoader(nil).Load(DefaultElderConfig(), "", overrides)
+
+	problems := fieldErrors(t, err)
+	if len(problems) != 1 || problems[0].Path != "simulation.physics.coupling_strength" {
+		t.Fatalf("expected an error at simulation.physics.coupling_strength, got %v", err)
+	}
+}
@@ -1,18 +1,26 @@
 package config
 
 type SimulationConfig struct {
-	Engine      EngineConfig      `json:"engine"`
-	Physics     PhysicsConfig     `json:"physics"`
-	Integration IntegrationConfig `json:"integration"`
-	Output      OutputConfig      `json:"output"`
+	MaxDuration    float64           `json:"max_duration"`
+	OutputInterval float64           `json:"output_interval"`
+	CheckpointFreq int               `json:"checkpoint_frequency"`
+	Seed           int64             `json:"seed"`
+	Engine         EngineConfig      `json:"engine"`
+	Physics        PhysicsConfig     `json:"physics"`
+	Integration    IntegrationConfig `json:"integration"`
+	Output         OutputConfig      `json:"output"`
+	Monitor        MonitorConfig     `json:"monitor"`
 }
 
 type EngineConfig struct {
-	Type        string  `json:"type"`
-	Precision   string  `json:"precision"`
-	Parallel    bool    `json:"parallel"`
-	Threads     int     `json:"threads"`
-	MemoryLimit int64   `json:"memory_limit"`
+	Type        string `json:"type"`
+	Precision   string `json:"precision"`
+	Parallel    bool   `json:"parallel"`
+	Threads     int    `json:"threads"`
+	MemoryLimit int64  `json:"memory_limit"`
+	// OpeningAngle is the Barnes-Hut accuracy parameter used when Type is
+	// "tree".
+	OpeningAngle float64 `json:"opening_angle"`
 }
 
 type PhysicsConfig struct {
@@ -21,6 +29,8 @@ type PhysicsConfig struct {
 	Resonance       bool    `json:"resonance"`
 	Damping         float64 `json:"damping"`
 	StabilityCheck  bool    `json:"stability_check"`
+	Coupling        float64 `json:"coupling_strength"`
+	PhaseNoise      float64 `json:"phase_noise"`
 }
 
 type IntegrationConfig struct {
@@ -30,10 +40,57 @@ type IntegrationConfig struct {
 	Adaptive bool    `json:"adaptive"`
 }
 
+// MonitorConfig controls the conservation monitor, which samples energy and
+// angular momentum drift every Every steps and compares it against
+// system.tolerance. Action is "warn" or "abort".
+type MonitorConfig struct {
+	Enabled bool   `json:"enabled"`
+	Every   int    `json:"every"`
+	Action  string `json:"action"`
+}
+
 type OutputConfig struct {
-	Format     string   `json:"format"`
-	Fields     []string `json:"fields"`
-	Frequency  int      `json:"frequency"`
-	Compress   bool     `json:"compress"`
-	Directory  string   `json:"directory"`
+	Format    string   `json:"format"`
+	Fields    []string `json:"fields"`
+	Frequency int      `json:"frequency"`
+	Compress  bool     `json:"compress"`
+	Directory string   `json:"directory"`
+}
+
+func DefaultSimulationConfig() *SimulationConfig {
+	return &SimulationConfig{
+		MaxDuration:    1000.0,
+		OutputInterval: 1.0,
+		CheckpointFreq: 100,
+		Seed:           1,
+		Engine: EngineConfig{
+			Type:         "direct",
+			Precision:    "float64",
+			Parallel:     false,
+			Threads:      1,
+			OpeningAngle: 0.5,
+		},
+		Physics: PhysicsConfig{
+			Gravity:        true,
+			Resonance:      true,
+			StabilityCheck: true,
+			Coupling:       0.1,
+			PhaseNoise:     0.01,
+		},
+		Integration: IntegrationConfig{
+			Method:   "verlet",
+			TimeStep: 0.01,
+			Adaptive: false,
+		},
+		Output: OutputConfig{
+			Format:    "jsonl",
+			Fields:    []string{"time", "positions", "velocities", "energy", "angular_momentum"},
+			Frequency: 1,
+			Directory: ".",
+		},
+		Monitor: MonitorConfig{
+			Every:  100,
+			Action: "warn",
+		},
+	}
 }
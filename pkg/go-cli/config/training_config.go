@@ -1,17 +1,20 @@
 package config
 
 type TrainingConfig struct {
-	Model       ModelConfig       `json:"model"`
-	Optimizer   OptimizerConfig   `json:"optimizer"`
-	Data        DataConfig        `json:"data"`
-	Validation  ValidationConfig  `json:"validation"`
-	Checkpoints CheckpointConfig  `json:"checkpoints"`
+	Epochs      int              `json:"epochs"`
+	Seed        int64            `json:"seed"`
+	Model       ModelConfig      `json:"model"`
+	Optimizer   OptimizerConfig  `json:"optimizer"`
+	Data        DataConfig       `json:"data"`
+	Validation  ValidationConfig `json:"validation"`
+	Checkpoints CheckpointConfig `json:"checkpoints"`
+	Tracking    TrackingConfig   `json:"tracking"`
 }
 
 type ModelConfig struct {
-	Architecture string             `json:"architecture"`
-	Layers       []LayerConfig      `json:"layers"`
-	Parameters   map[string]float64 `json:"parameters"`
+	Architecture   string               `json:"architecture"`
+	Layers         []LayerConfig        `json:"layers"`
+	Parameters     map[string]float64   `json:"parameters"`
 	Regularization RegularizationConfig `json:"regularization"`
 }
 
@@ -23,33 +26,57 @@ type LayerConfig struct {
 }
 
 type RegularizationConfig struct {
-	L1         float64 `json:"l1"`
-	L2         float64 `json:"l2"`
-	Dropout    float64 `json:"dropout"`
-	BatchNorm  bool    `json:"batch_norm"`
+	L1        float64 `json:"l1"`
+	L2        float64 `json:"l2"`
+	Dropout   float64 `json:"dropout"`
+	BatchNorm bool    `json:"batch_norm"`
 }
 
 type OptimizerConfig struct {
-	Type         string  `json:"type"`
-	LearningRate float64 `json:"learning_rate"`
-	Momentum     float64 `json:"momentum"`
-	Beta1        float64 `json:"beta1"`
-	Beta2        float64 `json:"beta2"`
-	Epsilon      float64 `json:"epsilon"`
+	Type         string         `json:"type"`
+	LearningRate float64        `json:"learning_rate"`
+	Momentum     float64        `json:"momentum"`
+	Beta1        float64        `json:"beta1"`
+	Beta2        float64        `json:"beta2"`
+	Epsilon      float64        `json:"epsilon"`
+	Schedule     ScheduleConfig `json:"schedule"`
 }
 
+// ScheduleConfig selects how the learning rate changes between epochs:
+// constant, step (multiply by gamma every step_size epochs), cosine
+// (anneal to min_learning_rate) or plateau (multiply by factor after
+// patience epochs without improvement). Any of them may start with
+// warmup_epochs of linear warmup.
+type ScheduleConfig struct {
+	Type            string  `json:"type"`
+	StepSize        int     `json:"step_size"`
+	Gamma           float64 `json:"gamma"`
+	WarmupEpochs    int     `json:"warmup_epochs"`
+	MinLearningRate float64 `json:"min_learning_rate"`
+	Patience        int     `json:"patience"`
+	Factor          float64 `json:"factor"`
+}
+
+// DataConfig describes how a data file is read. Format is csv, jsonl or
+// npy, or empty to go by the file extension; in csv, npy and array-per-line
+// jsonl files the last Targets columns of each row are the targets.
+// Augmentations are applied to training samples only when Augment is set.
 type DataConfig struct {
-	BatchSize    int      `json:"batch_size"`
-	Shuffle      bool     `json:"shuffle"`
-	Augment      bool     `json:"augment"`
+	BatchSize     int      `json:"batch_size"`
+	Shuffle       bool     `json:"shuffle"`
+	ShuffleBuffer int      `json:"shuffle_buffer"`
+	Augment       bool     `json:"augment"`
+	Augmentations []string `json:"augmentations"`
 	Preprocessing []string `json:"preprocessing"`
+	Format        string   `json:"format"`
+	Targets       int      `json:"targets"`
 }
 
 type ValidationConfig struct {
-	SplitRatio float64 `json:"split_ratio"`
-	Frequency  int     `json:"frequency"`
-	EarlyStopping bool `json:"early_stopping"`
-	Patience   int     `json:"patience"`
+	SplitRatio    float64 `json:"split_ratio"`
+	Frequency     int     `json:"frequency"`
+	EarlyStopping bool    `json:"early_stopping"`
+	Patience      int     `json:"patience"`
 }
 
 type CheckpointConfig struct {
@@ -58,3 +85,68 @@ type CheckpointConfig struct {
 	Directory string `json:"directory"`
 	KeepLast  int    `json:"keep_last"`
 }
+
+// TrackingConfig controls the run directory each training run writes under
+// Directory. Step metrics are logged every StepFrequency optimizer steps;
+// epoch metrics are logged every epoch.
+type TrackingConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Directory     string `json:"directory"`
+	Name          string `json:"name"`
+	StepFrequency int    `json:"step_frequency"`
+}
+
+func DefaultTrainingConfig() *TrainingConfig {
+	return &TrainingConfig{
+		Epochs: 100,
+		Seed:   1,
+		Model: ModelConfig{
+			Architecture: "elder",
+			Layers: []LayerConfig{
+				{Type: "dense", Size: 32, Activation: "tanh"},
+				{Type: "dense", Size: 1, Activation: "linear"},
+			},
+			Parameters: make(map[string]float64),
+		},
+		Optimizer: OptimizerConfig{
+			Type:         "adam",
+			LearningRate: 0.001,
+			Momentum:     0.9,
+			Beta1:        0.9,
+			Beta2:        0.999,
+			Epsilon:      1e-8,
+			Schedule: ScheduleConfig{
+				Type:     "constant",
+				StepSize: 30,
+				Gamma:    0.1,
+				Patience: 5,
+				Factor:   0.5,
+			},
+		},
+		Data: DataConfig{
+			BatchSize:     32,
+			Shuffle:       true,
+			ShuffleBuffer: 1024,
+			Augmentations: []string{"jitter"},
+			Preprocessing: []string{},
+			Targets:       1,
+		},
+		Validation: ValidationConfig{
+			SplitRatio:    0.2,
+			Frequency:     1,
+			EarlyStopping: true,
+			Patience:      10,
+		},
+		Checkpoints: CheckpointConfig{
+			Enabled:   false,
+			Frequency: 10,
+			Directory: "checkpoints",
+			KeepLast:  3,
+		},
+		Tracking: TrackingConfig{
+			Enabled:       true,
+			Directory:     "runs",
+			StepFrequency: 1,
+		},
+	}
+}
@@ -31,3 +31,25 @@ type MappingConfig struct {
 	Weights        map[string]float64 `json:"weights"`
 	Bidirectional  bool               `json:"bidirectional"`
 }
+
+func DefaultTransferConfig() *TransferConfig {
+	return &TransferConfig{
+		Source: DomainConfig{Name: "audio", Type: "audio"},
+		Target: DomainConfig{Name: "vision", Type: "vision"},
+		Method: MethodConfig{
+			Type:          "isomorphic",
+			Isomorphic:    true,
+			Confidence:    0.8,
+			MaxIterations: 100,
+		},
+		Mapping: MappingConfig{
+			AutoDetect:     true,
+			ManualMappings: make(map[string]string),
+			Weights:        make(map[string]float64),
+		},
+		Validation: ValidationConfig{
+			SplitRatio: 0.2,
+			Frequency:  1,
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"math"
+	"strconv"
+)
+
+// TuneConfig describes a hyperparameter search over Training. Space maps the
+// dotted path of each setting searched, such as optimizer.learning_rate or
+// model.parameters.alignment_weight, to the values it may take.
+//
+// Grid search tries every combination and random search draws Trials of
+// them. Halving starts Trials configurations on MinEpochs epochs, then
+// repeatedly keeps the best 1/Eta of them and trains those Eta times as
+// long, up to Training.Epochs. Hyperband runs several halving brackets,
+// from many trials on short budgets to a few on the full one.
+type TuneConfig struct {
+	Method    string                    `json:"method"`
+	Trials    int                       `json:"trials"`
+	Parallel  int                       `json:"parallel"`
+	Seed      int64                     `json:"seed"`
+	Objective string                    `json:"objective"`
+	MinEpochs int                       `json:"min_epochs"`
+	Eta       int                       `json:"eta"`
+	Directory string                    `json:"directory"`
+	Space     map[string]ParameterSpace `json:"space"`
+	Training  TrainingConfig            `json:"training"`
+}
+
+// ParameterSpace is either a list of Values or a range from Min to Max,
+// sampled uniformly on a linear or log Scale and split into Steps points by
+// grid search. Integer ranges are rounded to whole numbers.
+type ParameterSpace struct {
+	Values  []interface{} `json:"values"`
+	Min     float64       `json:"min"`
+	Max     float64       `json:"max"`
+	Scale   string        `json:"scale"`
+	Steps   int           `json:"steps"`
+	Integer bool          `json:"integer"`
+}
+
+// Format renders a value from the range as Set expects it, to six
+// significant digits.
+func (ps ParameterSpace) Format(value float64) string {
+	if ps.Integer {
+		return strconv.FormatInt(int64(math.Round(value)), 10)
+	}
+	return strconv.FormatFloat(value, 'g', 6, 64)
+}
+
+func DefaultTuneConfig() *TuneConfig {
+	return &TuneConfig{
+		Method:    "random",
+		Trials:    16,
+		Seed:      1,
+		Objective: "validation_loss",
+		MinEpochs: 1,
+		Eta:       3,
+		Directory: "tune",
+		Space:     make(map[string]ParameterSpace),
+		Training:  *DefaultTrainingConfig(),
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// rangeChecker accumulates out-of-range values under a common path prefix.
+type rangeChecker struct {
+	prefix   string
+	problems FieldErrors
+}
+
+func (rc *rangeChecker) at(path string) string {
+	return joinPath(rc.prefix, path)
+}
+
+func (rc *rangeChecker) fail(path, format string, args ...interface{}) {
+	rc.problems = append(rc.problems, FieldError{Path: rc.at(path), Message: fmt.Sprintf(format, args...)})
+}
+
+func (rc *rangeChecker) positive(path string, value float64) {
+	if !(value > 0) {
+		rc.fail(path, "must be positive, got %g", value)
+	}
+}
+
+func (rc *rangeChecker) nonNegative(path string, value float64) {
+	if !(value >= 0) {
+		rc.fail(path, "must not be negative, got %g", value)
+	}
+}
+
+func (rc *rangeChecker) between(path string, value, low, high float64) {
+	if !(value >= low && value <= high) {
+		rc.fail(path, "must be between %g and %g, got %g", low, high, value)
+	}
+}
+
+func (rc *rangeChecker) below(path string, value, low, high float64) {
+	if !(value >= low && value < high) {
+		rc.fail(path, "must be in [%g, %g), got %g", low, high, value)
+	}
+}
+
+func (rc *rangeChecker) oneOf(path, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return
+		}
+	}
+	rc.fail(path, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (ec *ElderConfig) Validate() error {
+	rc := &rangeChecker{}
+	ec.check(rc)
+	return rc.problems.orNil()
+}
+
+func (ec *ElderConfig) check(rc *rangeChecker) {
+	rc.positive("system.max_memory", float64(ec.System.MaxMemory))
+	rc.positive("system.max_processors", float64(ec.System.MaxProcessors))
+	rc.positive("system.gravitational_g", ec.System.GravitationalG)
+	rc.positive("system.time_step", ec.System.TimeStep)
+	rc.positive("system.tolerance", ec.System.Tolerance)
+
+	rc.positive("entities.elder_count", float64(ec.Entities.ElderCount))
+	rc.nonNegative("entities.mentor_count", float64(ec.Entities.MentorCount))
+	rc.nonNegative("entities.erudite_count", float64(ec.Entities.EruditeCount))
+
+	rc.nonNegative("fields.max_field_strength", ec.Fields.MaxFieldStrength)
+	rc.positive("fields.field_range", ec.Fields.FieldRange)
+	rc.between("fields.decay_rate", ec.Fields.DecayRate, 0, 1)
+
+	rc.oneOf("logging.level", ec.Logging.Level, "DEBUG", "INFO", "WARN", "ERROR")
+
+	sub := &rangeChecker{prefix: rc.at("simulation")}
+	ec.Simulation.check(sub)
+	rc.problems = append(rc.problems, sub.problems...)
+}
+
+func (sc *SimulationConfig) Validate() error {
+	rc := &rangeChecker{}
+	sc.check(rc)
+	return rc.problems.orNil()
+}
+
+func (sc *SimulationConfig) check(rc *rangeChecker) {
+	rc.positive("max_duration", sc.MaxDuration)
+	rc.nonNegative("output_interval", sc.OutputInterval)
+	rc.nonNegative("checkpoint_frequency", float64(sc.CheckpointFreq))
+
+	rc.oneOf("engine.type", sc.Engine.Type, "direct", "tree")
+	rc.nonNegative("engine.opening_angle", sc.Engine.OpeningAngle)
+	rc.oneOf("engine.precision", sc.Engine.Precision, "float64", "float32")
+	rc.nonNegative("engine.threads", float64(sc.Engine.Threads))
+	rc.nonNegative("engine.memory_limit", float64(sc.Engine.MemoryLimit))
+
+	rc.between("physics.damping", sc.Physics.Damping, 0, 1)
+	rc.nonNegative("physics.coupling_strength", sc.Physics.Coupling)
+	rc.nonNegative("physics.phase_noise", sc.Physics.PhaseNoise)
+
+	rc.oneOf("integration.method", sc.Integration.Method,
+		"euler", "semi_implicit_euler", "verlet", "leapfrog", "rk4", "yoshida")
+	rc.positive("integration.time_step", sc.Integration.TimeStep)
+	rc.nonNegative("integration.max_steps", float64(sc.Integration.MaxSteps))
+
+	rc.oneOf("output.format", sc.Output.Format, "csv", "jsonl", "binary")
+	for i, field := range sc.Output.Fields {
+		rc.oneOf(fmt.Sprintf("output.fields[%d]", i), field,
+			"time", "positions", "velocities", "energy", "angular_momentum", "drift")
+	}
+	rc.nonNegative("output.frequency", float64(sc.Output.Frequency))
+
+	rc.nonNegative("monitor.every", float64(sc.Monitor.Every))
+	rc.oneOf("monitor.action", sc.Monitor.Action, "warn", "abort")
+}
+
+func (tc *TrainingConfig) Validate() error {
+	rc := &rangeChecker{}
+	tc.check(rc)
+	return rc.problems.orNil()
+}
+
+func (tc *TrainingConfig) check(rc *rangeChecker) {
+	rc.positive("epochs", float64(tc.Epochs))
+	rc.oneOf("model.architecture", tc.Model.Architecture, "elder", "hierarchical")
+
+	for i, layer := range tc.Model.Layers {
+		rc.oneOf(fmt.Sprintf("model.layers[%d].type", i), layer.Type, "dense")
+		rc.positive(fmt.Sprintf("model.layers[%d].size", i), float64(layer.Size))
+		if layer.Activation != "" {
+			rc.oneOf(fmt.Sprintf("model.layers[%d].activation", i), layer.Activation,
+				"linear", "tanh", "relu", "sigmoid", "softmax")
+		}
+	}
+	rc.nonNegative("model.regularization.l1", tc.Model.Regularization.L1)
+	rc.nonNegative("model.regularization.l2", tc.Model.Regularization.L2)
+	rc.below("model.regularization.dropout", tc.Model.Regularization.Dropout, 0, 1)
+
+	rc.oneOf("optimizer.type", tc.Optimizer.Type, "sgd", "adam", "rmsprop")
+	rc.positive("optimizer.learning_rate", tc.Optimizer.LearningRate)
+	rc.below("optimizer.momentum", tc.Optimizer.Momentum, 0, 1)
+	rc.below("optimizer.beta1", tc.Optimizer.Beta1, 0, 1)
+	rc.below("optimizer.beta2", tc.Optimizer.Beta2, 0, 1)
+	rc.positive("optimizer.epsilon", tc.Optimizer.Epsilon)
+	rc.oneOf("optimizer.schedule.type", tc.Optimizer.Schedule.Type, "constant", "step", "cosine", "plateau")
+	rc.positive("optimizer.schedule.step_size", float64(tc.Optimizer.Schedule.StepSize))
+	rc.between("optimizer.schedule.gamma", tc.Optimizer.Schedule.Gamma, 0, 1)
+	rc.nonNegative("optimizer.schedule.warmup_epochs", float64(tc.Optimizer.Schedule.WarmupEpochs))
+	rc.nonNegative("optimizer.schedule.min_learning_rate", tc.Optimizer.Schedule.MinLearningRate)
+	rc.positive("optimizer.schedule.patience", float64(tc.Optimizer.Schedule.Patience))
+	rc.between("optimizer.schedule.factor", tc.Optimizer.Schedule.Factor, 0, 1)
+
+	rc.positive("data.batch_size", float64(tc.Data.BatchSize))
+	rc.positive("data.shuffle_buffer", float64(tc.Data.ShuffleBuffer))
+	rc.nonNegative("data.targets", float64(tc.Data.Targets))
+	if tc.Data.Format != "" {
+		rc.oneOf("data.format", tc.Data.Format, "csv", "jsonl", "npy")
+	}
+	for i, step := range tc.Data.Preprocessing {
+		name, _, _ := strings.Cut(step, ":")
+		rc.oneOf(fmt.Sprintf("data.preprocessing[%d]", i), name, "standardize", "minmax", "onehot")
+	}
+
+	rc.below("validation.split_ratio", tc.Validation.SplitRatio, 0, 1)
+	rc.nonNegative("validation.frequency", float64(tc.Validation.Frequency))
+	rc.nonNegative("validation.patience", float64(tc.Validation.Patience))
+
+	rc.nonNegative("checkpoints.frequency", float64(tc.Checkpoints.Frequency))
+	rc.nonNegative("checkpoints.keep_last", float64(tc.Checkpoints.KeepLast))
+
+	rc.nonNegative("tracking.step_frequency", float64(tc.Tracking.StepFrequency))
+}
+
+func (tc *TransferConfig) Validate() error {
+	rc := &rangeChecker{}
+
+	rc.nonNegative("source.dimensions", float64(tc.Source.Dimensions))
+	rc.nonNegative("target.dimensions", float64(tc.Target.Dimensions))
+	rc.oneOf("method.type", tc.Method.Type, "isomorphic", "hierarchical", "resonance")
+	rc.between("method.confidence", tc.Method.Confidence, 0, 1)
+	rc.nonNegative("method.max_iterations", float64(tc.Method.MaxIterations))
+	rc.below("validation.split_ratio", tc.Validation.SplitRatio, 0, 1)
+
+	return rc.problems.orNil()
+}
+
+func (ac *AnalysisConfig) Validate() error {
+	rc := &rangeChecker{}
+
+	rc.nonNegative("stability.tolerance", ac.Stability.Tolerance)
+	rc.nonNegative("stability.time_window", ac.Stability.TimeWindow)
+
+	return rc.problems.orNil()
+}
+
+func (tc *TuneConfig) Validate() error {
+	rc := &rangeChecker{}
+
+	rc.oneOf("method", tc.Method, "grid", "random", "halving", "hyperband")
+	rc.positive("trials", float64(tc.Trials))
+	rc.nonNegative("parallel", float64(tc.Parallel))
+	rc.oneOf("objective", tc.Objective, "validation_loss", "validation_accuracy", "training_loss")
+	rc.positive("min_epochs", float64(tc.MinEpochs))
+	if tc.MinEpochs > tc.Training.Epochs {
+		rc.fail("min_epochs", "must not exceed training.epochs (%d), got %d", tc.Training.Epochs, tc.MinEpochs)
+	}
+	if tc.Eta < 2 {
+		rc.fail("eta", "must be at least 2, got %d", tc.Eta)
+	}
+
+	if len(tc.Space) == 0 {
+		rc.fail("space", "must declare at least one setting to search")
+	}
+	paths := make([]string, 0, len(tc.Space))
+	for path := range tc.Space {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		space := tc.Space[path]
+		at := joinPath("space", path)
+		if len(space.Values) > 0 {
+			for i, value := range space.Values {
+				if err := Set(DefaultTrainingConfig(), path, fmt.Sprint(value)); err != nil {
+					rc.fail(fmt.Sprintf("%s.values[%d]", at, i), "%v", err)
+				}
+			}
+			continue
+		}
+
+		if err := Set(DefaultTrainingConfig(), path, space.Format(space.Min)); err != nil {
+			rc.fail(at, "%v", err)
+		}
+		if space.Max < space.Min {
+			rc.fail(joinPath(at, "max"), "must not be below min (%g), got %g", space.Min, space.Max)
+		}
+		if space.Scale != "" {
+			rc.oneOf(joinPath(at, "scale"), space.Scale, "linear", "log")
+		}
+		if strings.EqualFold(space.Scale, "log") {
+			rc.positive(joinPath(at, "min"), space.Min)
+		}
+		if strings.EqualFold(tc.Method, "grid") {
+			rc.positive(joinPath(at, "steps"), float64(space.Steps))
+		}
+	}
+
+	sub := &rangeChecker{prefix: rc.at("training")}
+	tc.Training.check(sub)
+	rc.problems = append(rc.problems, sub.problems...)
+	return rc.problems.orNil()
+}
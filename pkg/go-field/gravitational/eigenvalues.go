@@ -1,7 +1,12 @@
 // Package gravitational implements gravitational eigenvalue computation
 package gravitational
 
-import "math/cmplx"
+import (
+	"math/cmplx"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 // EigenvalueCalculator computes gravitational field eigenvalues
 type EigenvalueCalculator struct {
@@ -26,6 +31,20 @@ func (ec *EigenvalueCalculator) ComputeSpectrum(fields []*Field) []complex128 {
 	return spectrum
 }
 
+// ComputeMatrixSpectrum calculates the eigenvalues of a square coupling matrix
+func (ec *EigenvalueCalculator) ComputeMatrixSpectrum(matrix [][]float64) ([]complex128, error) {
+	a, err := tensor.FromRows(matrix)
+	if err != nil {
+		return nil, err
+	}
+	
+	spectrum, err := linalg.Eigenvalues(a)
+	if err != nil {
+		return nil, err
+	}
+	return spectrum.Values(), nil
+}
+
 // FindDominantEigenvalue identifies the dominant eigenvalue
 func (ec *EigenvalueCalculator) FindDominantEigenvalue(spectrum []complex128) complex128 {
 	if len(spectrum) == 0 {
@@ -1,6 +1,12 @@
 package phase
 
-import "math/cmplx"
+import (
+	"math/cmplx"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/sparse"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type PhaseCoupling struct {
 	SourceField string
@@ -9,9 +15,13 @@ type PhaseCoupling struct {
 	Type        string
 }
 
+// CouplingMatrix couples each of Fields to targets with a strength. Change
+// couplings through SetCoupling, which keeps the sparse form in step.
 type CouplingMatrix struct {
 	Couplings map[string]map[string]float64
 	Fields    []string
+	ids       []string
+	matrix    *sparse.CSR[complex128]
 }
 
 func NewCouplingMatrix(fields []string) *CouplingMatrix {
@@ -33,23 +43,74 @@ func (cm *CouplingMatrix) SetCoupling(source, target string, strength float64) {
 		cm.Couplings[source] = make(map[string]float64)
 	}
 	cm.Couplings[source][target] = strength
+	cm.matrix = nil
 }
 
-func (cm *CouplingMatrix) CalculateCoupledEvolution(fields map[string]PhaseField, deltaTime float64) map[string]complex128 {
-	newPhases := make(map[string]complex128)
+// Sparse is the coupling strengths as a sparse matrix whose entry (i, j)
+// couples field ids[i] to ids[j]. The ids are Fields in order, followed by
+// any other coupled targets in sorted order; sources outside Fields are
+// left out. The entries are real but stored as complex so the matrix can
+// multiply phases directly. It is built once and reused until SetCoupling
+// changes a strength, and must not be modified.
+func (cm *CouplingMatrix) Sparse() ([]string, *sparse.CSR[complex128]) {
+	if cm.matrix != nil {
+		return cm.ids, cm.matrix
+	}
 	
+	ids := make([]string, len(cm.Fields))
+	copy(ids, cm.Fields)
+	position := make(map[string]int)
+	for i, id := range ids {
+		position[id] = i
+	}
+	
+	others := make(map[string]bool)
 	for _, fieldID := range cm.Fields {
-		field := fields[fieldID]
-		coupledPhase := field.Phase
-		
-		for targetID, strength := range cm.Couplings[fieldID] {
-			if targetField, exists := fields[targetID]; exists {
-				coupling := complex(strength*deltaTime, 0) * targetField.Phase
-				coupledPhase += coupling
+		for targetID := range cm.Couplings[fieldID] {
+			if _, known := position[targetID]; !known {
+				others[targetID] = true
 			}
 		}
-		
-		newPhases[fieldID] = coupledPhase
+	}
+	extra := make([]string, 0, len(others))
+	for id := range others {
+		extra = append(extra, id)
+	}
+	sort.Strings(extra)
+	for _, id := range extra {
+		position[id] = len(ids)
+		ids = append(ids, id)
+	}
+	
+	matrix, _ := sparse.NewCOO[complex128](len(cm.Fields), len(ids))
+	for i, fieldID := range cm.Fields {
+		for targetID, strength := range cm.Couplings[fieldID] {
+			matrix.Add(i, position[targetID], complex(strength, 0))
+		}
+	}
+	cm.ids, cm.matrix = ids, matrix.ToCSR()
+	return cm.ids, cm.matrix
+}
+
+// CalculateCoupledEvolution advances each field's phase by deltaTime times
+// the sum of its coupled targets' phases weighted by coupling strength.
+// Fields missing from fields count as zero phase.
+func (cm *CouplingMatrix) CalculateCoupledEvolution(fields map[string]PhaseField, deltaTime float64) map[string]complex128 {
+	ids, matrix := cm.Sparse()
+	
+	phases := make([]complex128, len(ids))
+	for i, id := range ids {
+		phases[i] = fields[id].Phase
+	}
+	
+	newPhases := make(map[string]complex128)
+	coupled, err := matrix.MulVec(tensor.Vector(phases))
+	if err != nil {
+		return newPhases
+	}
+	
+	for i, value := range coupled.Values() {
+		newPhases[cm.Fields[i]] = phases[i] + complex(deltaTime, 0)*value
 	}
 	
 	return newPhases
@@ -0,0 +1,47 @@
+package phase
+
+import (
+	"math/cmplx"
+	"testing"
+)
+
+func TestCoupledEvolution(t *testing.T) {
+	cm := NewCouplingMatrix([]string{"a", "b"})
+	cm.SetCoupling("a", "b", 0.5)
+	cm.SetCoupling("b", "z", 2)
+	cm.SetCoupling("q", "a", 9)
+	fields := map[string]PhaseField{"a": {Phase: 1i}, "b": {Phase: 2}, "z": {Phase: 1 + 1i}}
+
+	got := cm.CalculateCoupledEvolution(fields, 0.1)
+	want := map[string]complex128{
+		"a": 1i + 0.1*0.5*2,
+		"b": 2 + 0.1*2*(1+1i),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("evolved %d fields, want %d", len(got), len(want))
+	}
+	for id, phase := range want {
+		if cmplx.Abs(got[id]-phase) > 1e-15 {
+			t.Errorf("phase of %s = %v, want %v", id, got[id], phase)
+		}
+	}
+}
+
+func TestSparseIsCachedUntilSetCoupling(t *testing.T) {
+	cm := NewCouplingMatrix([]string{"a", "b"})
+	cm.SetCoupling("a", "b", 1)
+
+	_, first := cm.Sparse()
+	if _, again := cm.Sparse(); again != first {
+		t.Error("Sparse rebuilt the matrix without a change")
+	}
+
+	cm.SetCoupling("b", "a", 3)
+	_, changed := cm.Sparse()
+	if changed == first {
+		t.Fatal("SetCoupling did not invalidate the sparse matrix")
+	}
+	if value, _ := changed.At(1, 0); value != 3 {
+		t.Errorf("entry (b, a) = %v, want 3", value)
+	}
+}
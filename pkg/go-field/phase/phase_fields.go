@@ -1,6 +1,10 @@
 package phase
 
-import "math"
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
 
 type PhaseField struct {
 	ID         string
@@ -15,6 +19,7 @@ type PhaseFieldSystem struct {
 	Fields      map[string]PhaseField
 	Couplings   map[string][]string
 	GlobalPhase complex128
+	Time        float64
 }
 
 func NewPhaseFieldSystem() *PhaseFieldSystem {
@@ -39,10 +44,13 @@ func (pfs *PhaseFieldSystem) AddField(id string, freq, amp float64, initialPhase
 	pfs.Fields[id] = field
 }
 
+// EvolveFields turns each phase by its frequency times deltaTime from where
+// it is now, so changes made between steps, such as coupling, carry over.
 func (pfs *PhaseFieldSystem) EvolveFields(deltaTime float64) {
+	pfs.Time += deltaTime
+	
 	for id, field := range pfs.Fields {
-		newPhase := field.Evolution(deltaTime)
-		field.Phase = newPhase
+		field.Phase *= cmplx.Rect(1, field.Frequency*deltaTime)
 		pfs.Fields[id] = field
 	}
 	
@@ -53,8 +61,8 @@ func (pfs *PhaseFieldSystem) updateGlobalPhase() {
 	totalPhase := complex(0, 0)
 	count := 0
 	
-	for _, field := range pfs.Fields {
-		totalPhase += field.Phase
+	for _, id := range pfs.fieldIDs() {
+		totalPhase += pfs.Fields[id].Phase
 		count++
 	}
 	
@@ -71,9 +79,10 @@ func (pfs *PhaseFieldSystem) CalculateCoherence() float64 {
 	totalCoherence := 0.0
 	pairs := 0
 	
-	fieldList := make([]PhaseField, 0)
-	for _, field := range pfs.Fields {
-		fieldList = append(fieldList, field)
+	// Sum in ID order so the result does not depend on map iteration.
+	fieldList := make([]PhaseField, 0, len(pfs.Fields))
+	for _, id := range pfs.fieldIDs() {
+		fieldList = append(fieldList, pfs.Fields[id])
 	}
 	
 	for i := 0; i < len(fieldList); i++ {
@@ -87,6 +96,15 @@ func (pfs *PhaseFieldSystem) CalculateCoherence() float64 {
 	return totalCoherence / float64(pairs)
 }
 
+func (pfs *PhaseFieldSystem) fieldIDs() []string {
+	ids := make([]string, 0, len(pfs.Fields))
+	for id := range pfs.Fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (pfs *PhaseFieldSystem) calculatePairCoherence(field1, field2 PhaseField) float64 {
 	phaseDiff := field1.Phase - field2.Phase
 	return math.Abs(real(phaseDiff))
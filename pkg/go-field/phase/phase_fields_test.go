@@ -0,0 +1,26 @@
+package phase
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCalculateCoherenceIsReproducible(t *testing.T) {
+	// Map iteration order changes between copies of the map, so rebuilding
+	// the system exercises many orders.
+	var want float64
+	for trial := 0; trial < 50; trial++ {
+		pfs := NewPhaseFieldSystem()
+		for i := 0; i < 40; i++ {
+			pfs.AddField(fmt.Sprintf("field-%d", i), float64(i), 1, complex(0.1*float64(i*i%7), 0.3))
+		}
+		pfs.EvolveFields(0.37)
+
+		coherence := pfs.CalculateCoherence()
+		if trial == 0 {
+			want = coherence
+		} else if coherence != want {
+			t.Fatalf("trial %d: coherence %v differs from %v", trial, coherence, want)
+		}
+	}
+}
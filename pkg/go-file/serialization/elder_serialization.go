@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 )
 
 type ElderSerializer struct {
@@ -100,14 +101,33 @@ func (es *ElderSerializer) deserializeBinary(reader io.Reader) (interface{}, err
 	return serializable.Data, nil
 }
 
+// SerializeToFile writes data to a temporary file in the same directory and
+// renames it over filename, so readers never observe a partial file.
 func (es *ElderSerializer) SerializeToFile(data interface{}, filename string) error {
-	file, err := os.Create(filename)
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	defer os.Remove(file.Name())
+	
+	// CreateTemp uses 0600; match the permissions os.Create would give.
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		return err
+	}
+	if err := es.Serialize(data, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
 	
-	return es.Serialize(data, file)
+	return os.Rename(file.Name(), filename)
 }
 
 func (es *ElderSerializer) DeserializeFromFile(filename string) (interface{}, error) {
@@ -120,6 +140,39 @@ func (es *ElderSerializer) DeserializeFromFile(filename string) (interface{}, er
 	return es.Deserialize(file)
 }
 
+// DeserializeInto decodes the payload of a serialized document into target,
+// which must be a pointer, and restores the document's metadata.
+func (es *ElderSerializer) DeserializeInto(reader io.Reader, target interface{}) error {
+	var envelope struct {
+		Type     string                 `json:"type"`
+		Version  string                 `json:"version"`
+		Metadata map[string]interface{} `json:"metadata"`
+		Data     json.RawMessage        `json:"data"`
+	}
+	
+	if err := json.NewDecoder(reader).Decode(&envelope); err != nil {
+		return err
+	}
+	if len(envelope.Data) == 0 {
+		return fmt.Errorf("serialized %s document has no data", envelope.Type)
+	}
+	
+	for key, value := range envelope.Metadata {
+		es.Metadata[key] = value
+	}
+	return json.Unmarshal(envelope.Data, target)
+}
+
+func (es *ElderSerializer) DeserializeFileInto(filename string, target interface{}) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	return es.DeserializeInto(file, target)
+}
+
 func (es *ElderSerializer) SetMetadata(key string, value interface{}) {
 	es.Metadata[key] = value
 }
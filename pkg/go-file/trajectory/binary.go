@@ -0,0 +1,149 @@
+package trajectory
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Binary layout, all integers and floats little-endian:
+//
+//	magic       8 bytes  "ELDRTRAJ"
+//	header      uint32 length, then the Header as JSON
+//	chunks      uint32 record count n, then n*Header.Width() float64 values
+//
+// A chunk ends after the record that takes it past binaryChunkBytes, so
+// memory stays bounded however many bodies a record holds; a record larger
+// than that is a chunk of its own. Sync ends the current chunk early so
+// every chunk boundary is a valid end of file.
+const (
+	binaryMagic      = "ELDRTRAJ"
+	binaryChunkBytes = 1 << 20
+)
+
+type binaryEncoder struct {
+	header  Header
+	w       io.Writer
+	pending []byte
+	records uint32
+	values  []float64
+}
+
+func newBinaryEncoder(w io.Writer, header Header) *binaryEncoder {
+	return &binaryEncoder{
+		header:  header,
+		w:       w,
+		pending: make([]byte, 4, 4+8*header.Width()),
+		values:  make([]float64, 0, header.Width()),
+	}
+}
+
+func (e *binaryEncoder) writeHeader() error {
+	encoded, err := json.Marshal(e.header)
+	if err != nil {
+		return err
+	}
+
+	prefix := make([]byte, len(binaryMagic)+4)
+	copy(prefix, binaryMagic)
+	binary.LittleEndian.PutUint32(prefix[len(binaryMagic):], uint32(len(encoded)))
+	if _, err := e.w.Write(prefix); err != nil {
+		return err
+	}
+	_, err = e.w.Write(encoded)
+	return err
+}
+
+func (e *binaryEncoder) writeFrame(frame *Frame) error {
+	e.values = e.header.Values(frame, e.values)
+	for _, value := range e.values {
+		e.pending = binary.LittleEndian.AppendUint64(e.pending, math.Float64bits(value))
+	}
+	e.records++
+
+	if len(e.pending) >= binaryChunkBytes {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *binaryEncoder) flush() error {
+	if e.records == 0 {
+		return nil
+	}
+
+	binary.LittleEndian.PutUint32(e.pending[:4], e.records)
+	if _, err := e.w.Write(e.pending); err != nil {
+		return err
+	}
+	e.pending = e.pending[:4]
+	e.records = 0
+	return nil
+}
+
+type binaryDecoder struct {
+	hdr       Header
+	r         io.Reader
+	remaining uint32
+	record    []byte
+	values    []float64
+}
+
+func newBinaryDecoder(r io.Reader) (*binaryDecoder, error) {
+	prefix := make([]byte, len(binaryMagic)+4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, err
+	}
+	if string(prefix[:len(binaryMagic)]) != binaryMagic {
+		return nil, fmt.Errorf("missing %s magic", binaryMagic)
+	}
+
+	encoded := make([]byte, binary.LittleEndian.Uint32(prefix[len(binaryMagic):]))
+	if _, err := io.ReadFull(r, encoded); err != nil {
+		return nil, err
+	}
+
+	var header Header
+	if err := json.Unmarshal(encoded, &header); err != nil {
+		return nil, err
+	}
+
+	return &binaryDecoder{
+		hdr:    header,
+		r:      r,
+		record: make([]byte, 8*header.Width()),
+		values: make([]float64, header.Width()),
+	}, nil
+}
+
+func (d *binaryDecoder) header() Header {
+	return d.hdr
+}
+
+func (d *binaryDecoder) next(frame *Frame) error {
+	for d.remaining == 0 {
+		var count [4]byte
+		if _, err := io.ReadFull(d.r, count[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return fmt.Errorf("truncated chunk header: %w", err)
+			}
+			return err
+		}
+		d.remaining = binary.LittleEndian.Uint32(count[:])
+	}
+
+	if _, err := io.ReadFull(d.r, d.record); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("truncated record: %w", err)
+	}
+	d.remaining--
+
+	for i := range d.values {
+		d.values[i] = math.Float64frombits(binary.LittleEndian.Uint64(d.record[8*i:]))
+	}
+	return d.hdr.SetValues(frame, d.values)
+}
@@ -0,0 +1,145 @@
+package trajectory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvEncoder struct {
+	header Header
+	writer *csv.Writer
+	values []float64
+	record []string
+}
+
+func newCSVEncoder(w io.Writer, header Header) *csvEncoder {
+	return &csvEncoder{
+		header: header,
+		writer: csv.NewWriter(w),
+		values: make([]float64, 0, header.Width()),
+		record: make([]string, header.Width()),
+	}
+}
+
+func (e *csvEncoder) writeHeader() error {
+	return e.writer.Write(e.header.Columns())
+}
+
+func (e *csvEncoder) writeFrame(frame *Frame) error {
+	e.values = e.header.Values(frame, e.values)
+	for i, value := range e.values {
+		e.record[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type csvDecoder struct {
+	hdr    Header
+	reader *csv.Reader
+	values []float64
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	header, err := parseColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	reader.FieldsPerRecord = len(columns)
+	return &csvDecoder{hdr: header, reader: reader, values: make([]float64, len(columns))}, nil
+}
+
+func (d *csvDecoder) header() Header {
+	return d.hdr
+}
+
+func (d *csvDecoder) next(frame *Frame) error {
+	record, err := d.reader.Read()
+	if err != nil {
+		return err
+	}
+
+	for i, field := range record {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			line, _ := d.reader.FieldPos(i)
+			return fmt.Errorf("line %d, column %d: %w", line, i+1, err)
+		}
+		d.values[i] = value
+	}
+	return d.hdr.SetValues(frame, d.values)
+}
+
+// parseColumns rebuilds a Header from the column names written by Columns.
+func parseColumns(columns []string) (Header, error) {
+	fields := make([]string, 0)
+	bodies := make([]string, 0)
+	bodyField := ""
+
+	addField := func(field string) {
+		if len(fields) == 0 || fields[len(fields)-1] != field {
+			fields = append(fields, field)
+		}
+	}
+	addBody := func(field, body string) {
+		if bodyField == "" {
+			bodyField = field
+		}
+		if field == bodyField {
+			bodies = append(bodies, body)
+		}
+	}
+
+	for _, column := range columns {
+		if column == FieldTime || column == FieldEnergy {
+			addField(column)
+			continue
+		}
+
+		dot := strings.LastIndex(column, ".")
+		if dot < 0 {
+			return Header{}, fmt.Errorf("unrecognised column %q", column)
+		}
+		prefix, suffix := column[:dot], column[dot+1:]
+
+		switch {
+		case prefix == FieldAngularMomentum:
+			addField(FieldAngularMomentum)
+		case prefix == FieldDrift:
+			addField(FieldDrift)
+		case suffix == "x" || suffix == "y" || suffix == "z":
+			addField(FieldPositions)
+			if suffix == "x" {
+				addBody(FieldPositions, prefix)
+			}
+		case suffix == "vx" || suffix == "vy" || suffix == "vz":
+			addField(FieldVelocities)
+			if suffix == "vx" {
+				addBody(FieldVelocities, prefix)
+			}
+		default:
+			return Header{}, fmt.Errorf("unrecognised column %q", column)
+		}
+	}
+
+	header := Header{Version: 1, Bodies: bodies, Fields: fields}
+	if strings.Join(header.Columns(), ",") != strings.Join(columns, ",") {
+		return Header{}, fmt.Errorf("columns are not in trajectory order")
+	}
+	return header, nil
+}
@@ -0,0 +1,231 @@
+package trajectory
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+type encoder interface {
+	writeHeader() error
+	writeFrame(frame *Frame) error
+	flush() error
+}
+
+type decoder interface {
+	header() Header
+	next(frame *Frame) error
+}
+
+// Writer streams frames to a file. Memory use is independent of the number
+// of frames written.
+type Writer struct {
+	Header Header
+	Format string
+	Frames int
+	file   *os.File
+	out    *stream
+	enc    encoder
+}
+
+// stream buffers writes and, when compressing, wraps them in a gzip member
+// that is closed at every Sync so the file can be truncated there.
+type stream struct {
+	buf      *bufio.Writer
+	gz       *gzip.Writer
+	compress bool
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	if !s.compress {
+		return s.buf.Write(p)
+	}
+	if s.gz == nil {
+		s.gz = gzip.NewWriter(s.buf)
+	}
+	return s.gz.Write(p)
+}
+
+func (s *stream) sync() error {
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			return err
+		}
+		s.gz = nil
+	}
+	return s.buf.Flush()
+}
+
+// Create starts a new trajectory file, replacing any existing one.
+func Create(filename, format string, header Header, compress bool) (*Writer, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newWriter(file, format, header, compress)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := w.enc.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Append reopens a trajectory file written with the same format and header,
+// discards everything after offset (a value returned by Sync) and continues
+// writing from there.
+func Append(filename, format string, header Header, compress bool, offset int64) (*Writer, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	w, err := newWriter(file, format, header, compress)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func newWriter(file *os.File, format string, header Header, compress bool) (*Writer, error) {
+	out := &stream{buf: bufio.NewWriterSize(file, 64*1024), compress: compress}
+
+	var enc encoder
+	switch format {
+	case FormatCSV:
+		enc = newCSVEncoder(out, header)
+	case FormatJSONL:
+		enc = newJSONLEncoder(out, header)
+	case FormatBinary:
+		enc = newBinaryEncoder(out, header)
+	default:
+		return nil, fmt.Errorf("unknown trajectory format %q", format)
+	}
+
+	return &Writer{Header: header, Format: format, file: file, out: out, enc: enc}, nil
+}
+
+func (w *Writer) WriteFrame(frame *Frame) error {
+	if err := w.enc.writeFrame(frame); err != nil {
+		return err
+	}
+	w.Frames++
+	return nil
+}
+
+// Sync writes out everything buffered so far and returns the file offset
+// at which the file is complete and valid, for use with Append.
+func (w *Writer) Sync() (int64, error) {
+	if err := w.enc.flush(); err != nil {
+		return 0, err
+	}
+	if err := w.out.sync(); err != nil {
+		return 0, err
+	}
+	return w.file.Seek(0, io.SeekCurrent)
+}
+
+func (w *Writer) Close() error {
+	if _, err := w.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Reader reads a trajectory in any supported format, compressed or not.
+type Reader struct {
+	Header Header
+	Format string
+	file   *os.File
+	gz     *gzip.Reader
+	dec    decoder
+	frame  *Frame
+}
+
+func Open(filename string) (*Reader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	r.file = file
+	return r, nil
+}
+
+// NewReader detects gzip compression and the format from the content of
+// input rather than from a file name.
+func NewReader(input io.Reader) (*Reader, error) {
+	r := &Reader{}
+
+	buffered := bufio.NewReaderSize(input, 64*1024)
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		r.gz = gz
+		buffered = bufio.NewReaderSize(gz, 64*1024)
+	}
+
+	prefix, _ := buffered.Peek(len(binaryMagic))
+	var err error
+	switch {
+	case bytes.Equal(prefix, []byte(binaryMagic)):
+		r.Format = FormatBinary
+		r.dec, err = newBinaryDecoder(buffered)
+	case len(bytes.TrimLeft(prefix, " \t\r\n")) > 0 && bytes.TrimLeft(prefix, " \t\r\n")[0] == '{':
+		r.Format = FormatJSONL
+		r.dec, err = newJSONLDecoder(buffered)
+	default:
+		r.Format = FormatCSV
+		r.dec, err = newCSVDecoder(buffered)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s trajectory header: %w", r.Format, err)
+	}
+
+	r.Header = r.dec.header()
+	r.frame = r.Header.NewFrame()
+	return r, nil
+}
+
+// Next returns the next frame, or io.EOF after the last one. The frame is
+// reused by the following call.
+func (r *Reader) Next() (*Frame, error) {
+	if err := r.dec.next(r.frame); err != nil {
+		return nil, err
+	}
+	return r.frame, nil
+}
+
+func (r *Reader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
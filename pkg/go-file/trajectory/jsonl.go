@@ -0,0 +1,160 @@
+package trajectory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+const jsonlFormatName = "elder-trajectory"
+
+// jsonlHeader is the first line of a JSONL trajectory.
+type jsonlHeader struct {
+	Format string `json:"format"`
+	Header
+}
+
+// jsonlFrame is one JSONL line. Absent bodies are written as null, and an
+// unsampled drift is left out.
+type jsonlFrame struct {
+	Time            *float64      `json:"time,omitempty"`
+	Positions       []*[3]float64 `json:"positions,omitempty"`
+	Velocities      []*[3]float64 `json:"velocities,omitempty"`
+	Energy          *float64      `json:"energy,omitempty"`
+	AngularMomentum *[3]float64   `json:"angular_momentum,omitempty"`
+	Drift           *Drift        `json:"drift,omitempty"`
+}
+
+type jsonlEncoder struct {
+	header  Header
+	encoder *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer, header Header) *jsonlEncoder {
+	return &jsonlEncoder{header: header, encoder: json.NewEncoder(w)}
+}
+
+func (e *jsonlEncoder) writeHeader() error {
+	return e.encoder.Encode(jsonlHeader{Format: jsonlFormatName, Header: e.header})
+}
+
+func (e *jsonlEncoder) writeFrame(frame *Frame) error {
+	line := jsonlFrame{}
+	for _, field := range e.header.Fields {
+		switch field {
+		case FieldTime:
+			line.Time = &frame.Time
+		case FieldEnergy:
+			line.Energy = &frame.Energy
+		case FieldPositions:
+			line.Positions = nullable(frame.Positions, len(e.header.Bodies))
+		case FieldVelocities:
+			line.Velocities = nullable(frame.Velocities, len(e.header.Bodies))
+		case FieldAngularMomentum:
+			line.AngularMomentum = &frame.AngularMomentum
+		case FieldDrift:
+			if !math.IsNaN(frame.Drift.Energy) {
+				line.Drift = &frame.Drift
+			}
+		}
+	}
+	return e.encoder.Encode(line)
+}
+
+func (e *jsonlEncoder) flush() error {
+	return nil
+}
+
+type jsonlDecoder struct {
+	hdr     Header
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLDecoder(r io.Reader) (*jsonlDecoder, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var header jsonlHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, err
+	}
+	if header.Format != jsonlFormatName {
+		return nil, fmt.Errorf("first line is not a %s header", jsonlFormatName)
+	}
+	return &jsonlDecoder{hdr: header.Header, scanner: scanner, line: 1}, nil
+}
+
+func (d *jsonlDecoder) header() Header {
+	return d.hdr
+}
+
+func (d *jsonlDecoder) next(frame *Frame) error {
+	if !d.scanner.Scan() {
+		if err := d.scanner.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	d.line++
+
+	var line jsonlFrame
+	if err := json.Unmarshal(d.scanner.Bytes(), &line); err != nil {
+		return fmt.Errorf("line %d: %w", d.line, err)
+	}
+
+	if line.Time != nil {
+		frame.Time = *line.Time
+	}
+	if line.Energy != nil {
+		frame.Energy = *line.Energy
+	}
+	if line.AngularMomentum != nil {
+		frame.AngularMomentum = *line.AngularMomentum
+	}
+	if line.Drift != nil {
+		frame.Drift = *line.Drift
+	} else if d.hdr.Has(FieldDrift) {
+		frame.Drift = Drift{Energy: math.NaN(), AngularMomentum: math.NaN()}
+	}
+	frame.Positions = fromNullable(frame.Positions, line.Positions)
+	frame.Velocities = fromNullable(frame.Velocities, line.Velocities)
+	return nil
+}
+
+func nullable(vectors [][3]float64, n int) []*[3]float64 {
+	out := make([]*[3]float64, n)
+	for i := 0; i < n && i < len(vectors); i++ {
+		if !math.IsNaN(vectors[i][0]) {
+			out[i] = &vectors[i]
+		}
+	}
+	return out
+}
+
+func fromNullable(dst [][3]float64, vectors []*[3]float64) [][3]float64 {
+	if vectors == nil {
+		return dst
+	}
+	if cap(dst) < len(vectors) {
+		dst = make([][3]float64, len(vectors))
+	}
+	dst = dst[:len(vectors)]
+	for i, vector := range vectors {
+		if vector == nil {
+			dst[i] = [3]float64{math.NaN(), math.NaN(), math.NaN()}
+		} else {
+			dst[i] = *vector
+		}
+	}
+	return dst
+}
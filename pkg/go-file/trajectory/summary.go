@@ -0,0 +1,107 @@
+package trajectory
+
+import (
+	"io"
+	"math"
+)
+
+// Summary accumulates conservation and extent statistics over a trajectory
+// in a single pass and constant memory.
+type Summary struct {
+	Frames                  int
+	Bodies                  int
+	Survivors               int
+	StartTime               float64
+	EndTime                 float64
+	InitialEnergy           float64
+	FinalEnergy             float64
+	MaxEnergyDrift          float64
+	InitialAngularMomentum  [3]float64
+	FinalAngularMomentum    [3]float64
+	MaxAngularMomentumDrift float64
+	MaxRadius               float64
+	header                  Header
+}
+
+func NewSummary(header Header) *Summary {
+	return &Summary{Bodies: len(header.Bodies), header: header}
+}
+
+// Summarize reads every remaining frame from r.
+func Summarize(r *Reader) (*Summary, error) {
+	summary := NewSummary(r.Header)
+	for {
+		frame, err := r.Next()
+		if err == io.EOF {
+			return summary, nil
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Add(frame)
+	}
+}
+
+func (s *Summary) Add(frame *Frame) {
+	if s.Frames == 0 {
+		s.StartTime = frame.Time
+		s.InitialEnergy = frame.Energy
+		s.InitialAngularMomentum = frame.AngularMomentum
+	}
+	s.Frames++
+	s.EndTime = frame.Time
+
+	if s.header.Has(FieldEnergy) {
+		s.FinalEnergy = frame.Energy
+		s.MaxEnergyDrift = math.Max(s.MaxEnergyDrift, relativeDrift(frame.Energy, s.InitialEnergy))
+	}
+
+	if s.header.Has(FieldAngularMomentum) {
+		s.FinalAngularMomentum = frame.AngularMomentum
+		delta := 0.0
+		norm := 0.0
+		for k := 0; k < 3; k++ {
+			d := frame.AngularMomentum[k] - s.InitialAngularMomentum[k]
+			delta += d * d
+			norm += s.InitialAngularMomentum[k] * s.InitialAngularMomentum[k]
+		}
+		drift := math.Sqrt(delta)
+		if norm > 0 {
+			drift /= math.Sqrt(norm)
+		}
+		s.MaxAngularMomentumDrift = math.Max(s.MaxAngularMomentumDrift, drift)
+	}
+
+	survivors := 0
+	for i := 0; i < s.Bodies; i++ {
+		if !frame.Present(i) {
+			continue
+		}
+		survivors++
+		if i < len(frame.Positions) {
+			p := frame.Positions[i]
+			s.MaxRadius = math.Max(s.MaxRadius, math.Sqrt(p[0]*p[0]+p[1]*p[1]+p[2]*p[2]))
+		}
+	}
+	if len(frame.Positions) > 0 || len(frame.Velocities) > 0 {
+		s.Survivors = survivors
+	} else {
+		s.Survivors = s.Bodies
+	}
+}
+
+// EnergyDriftRate is the final relative energy error per unit time.
+func (s *Summary) EnergyDriftRate() float64 {
+	span := s.EndTime - s.StartTime
+	if span <= 0 {
+		return 0.0
+	}
+	return relativeDrift(s.FinalEnergy, s.InitialEnergy) / span
+}
+
+func relativeDrift(value, reference float64) float64 {
+	if reference == 0 {
+		return math.Abs(value)
+	}
+	return math.Abs((value - reference) / reference)
+}
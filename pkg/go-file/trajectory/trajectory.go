@@ -0,0 +1,260 @@
+package trajectory
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Formats understood by Create and Open.
+const (
+	FormatCSV    = "csv"
+	FormatJSONL  = "jsonl"
+	FormatBinary = "binary"
+)
+
+// Fields that can be recorded in a trajectory, in the order they are laid
+// out in every record.
+const (
+	FieldTime            = "time"
+	FieldPositions       = "positions"
+	FieldVelocities      = "velocities"
+	FieldEnergy          = "energy"
+	FieldAngularMomentum = "angular_momentum"
+	FieldDrift           = "drift"
+)
+
+var Formats = []string{FormatCSV, FormatJSONL, FormatBinary}
+
+var Fields = []string{FieldTime, FieldPositions, FieldVelocities, FieldEnergy, FieldAngularMomentum, FieldDrift}
+
+// Header describes a trajectory. Bodies lists every body present at the
+// start; bodies that later merge into another keep their slot and are
+// recorded as NaN.
+type Header struct {
+	Version int      `json:"version"`
+	Bodies  []string `json:"bodies"`
+	Fields  []string `json:"fields"`
+}
+
+// Frame is one sampled step. Positions and Velocities are indexed like
+// Header.Bodies. Fields not listed in the header are left zero.
+type Frame struct {
+	Time            float64
+	Positions       [][3]float64
+	Velocities      [][3]float64
+	Energy          float64
+	AngularMomentum [3]float64
+	Drift           Drift
+}
+
+// Drift is the relative change in the conserved quantities since the start
+// of the run, as sampled by a conservation monitor at the frame's step. It
+// is NaN in frames where the monitor took no sample.
+type Drift struct {
+	Energy          float64 `json:"energy"`
+	AngularMomentum float64 `json:"angular_momentum"`
+}
+
+func NewHeader(bodies, fields []string) (Header, error) {
+	header := Header{Version: 1, Bodies: bodies, Fields: make([]string, 0, len(fields))}
+
+	// Normalise to the canonical field order so every format lays records
+	// out the same way.
+	for _, field := range Fields {
+		for _, requested := range fields {
+			if requested == field {
+				header.Fields = append(header.Fields, field)
+				break
+			}
+		}
+	}
+	for _, requested := range fields {
+		if !header.Has(requested) {
+			return Header{}, fmt.Errorf("unknown trajectory field %q", requested)
+		}
+	}
+	if len(header.Fields) == 0 {
+		return Header{}, fmt.Errorf("no trajectory fields selected")
+	}
+	return header, nil
+}
+
+func (h Header) Has(field string) bool {
+	for _, f := range h.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Width is the number of float64 values in one record.
+func (h Header) Width() int {
+	width := 0
+	for _, field := range h.Fields {
+		switch field {
+		case FieldTime, FieldEnergy:
+			width++
+		case FieldPositions, FieldVelocities:
+			width += 3 * len(h.Bodies)
+		case FieldAngularMomentum:
+			width += 3
+		case FieldDrift:
+			width += 2
+		}
+	}
+	return width
+}
+
+// Columns names every value of a record, e.g. "time", "mentor-0.x",
+// "mentor-0.vx", "energy", "angular_momentum.z".
+func (h Header) Columns() []string {
+	columns := make([]string, 0, h.Width())
+	for _, field := range h.Fields {
+		switch field {
+		case FieldTime, FieldEnergy:
+			columns = append(columns, field)
+		case FieldPositions:
+			for _, body := range h.Bodies {
+				columns = append(columns, body+".x", body+".y", body+".z")
+			}
+		case FieldVelocities:
+			for _, body := range h.Bodies {
+				columns = append(columns, body+".vx", body+".vy", body+".vz")
+			}
+		case FieldAngularMomentum:
+			columns = append(columns, field+".x", field+".y", field+".z")
+		case FieldDrift:
+			columns = append(columns, field+".energy", field+".angular_momentum")
+		}
+	}
+	return columns
+}
+
+// NewFrame returns a frame sized for h with every body marked absent.
+func (h Header) NewFrame() *Frame {
+	frame := &Frame{}
+	if h.Has(FieldPositions) {
+		frame.Positions = absent(len(h.Bodies))
+	}
+	if h.Has(FieldVelocities) {
+		frame.Velocities = absent(len(h.Bodies))
+	}
+	return frame
+}
+
+// Values flattens frame into dst in column order.
+func (h Header) Values(frame *Frame, dst []float64) []float64 {
+	dst = dst[:0]
+	for _, field := range h.Fields {
+		switch field {
+		case FieldTime:
+			dst = append(dst, frame.Time)
+		case FieldEnergy:
+			dst = append(dst, frame.Energy)
+		case FieldPositions:
+			dst = appendVectors(dst, frame.Positions, len(h.Bodies))
+		case FieldVelocities:
+			dst = appendVectors(dst, frame.Velocities, len(h.Bodies))
+		case FieldAngularMomentum:
+			dst = append(dst, frame.AngularMomentum[:]...)
+		case FieldDrift:
+			dst = append(dst, frame.Drift.Energy, frame.Drift.AngularMomentum)
+		}
+	}
+	return dst
+}
+
+// SetValues is the inverse of Values.
+func (h Header) SetValues(frame *Frame, values []float64) error {
+	if len(values) != h.Width() {
+		return fmt.Errorf("record has %d values, header expects %d", len(values), h.Width())
+	}
+
+	n := len(h.Bodies)
+	for _, field := range h.Fields {
+		switch field {
+		case FieldTime:
+			frame.Time, values = values[0], values[1:]
+		case FieldEnergy:
+			frame.Energy, values = values[0], values[1:]
+		case FieldPositions:
+			frame.Positions = readVectors(frame.Positions, values[:3*n])
+			values = values[3*n:]
+		case FieldVelocities:
+			frame.Velocities = readVectors(frame.Velocities, values[:3*n])
+			values = values[3*n:]
+		case FieldAngularMomentum:
+			copy(frame.AngularMomentum[:], values[:3])
+			values = values[3:]
+		case FieldDrift:
+			frame.Drift = Drift{Energy: values[0], AngularMomentum: values[1]}
+			values = values[2:]
+		}
+	}
+	return nil
+}
+
+// Present reports whether body i still exists in frame.
+func (f *Frame) Present(i int) bool {
+	if i < len(f.Positions) {
+		return !math.IsNaN(f.Positions[i][0])
+	}
+	if i < len(f.Velocities) {
+		return !math.IsNaN(f.Velocities[i][0])
+	}
+	return false
+}
+
+// DetectFormat infers a format from a file name, ignoring a trailing .gz.
+// It returns "" for names that do not identify a format, such as .json.
+func DetectFormat(filename string) (format string, compressed bool) {
+	name := strings.ToLower(filename)
+	if strings.HasSuffix(name, ".gz") {
+		compressed = true
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	switch filepath.Ext(name) {
+	case ".csv":
+		return FormatCSV, compressed
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, compressed
+	case ".bin", ".traj":
+		return FormatBinary, compressed
+	}
+	return "", compressed
+}
+
+func absent(n int) [][3]float64 {
+	vectors := make([][3]float64, n)
+	for i := range vectors {
+		vectors[i] = [3]float64{math.NaN(), math.NaN(), math.NaN()}
+	}
+	return vectors
+}
+
+func appendVectors(dst []float64, vectors [][3]float64, n int) []float64 {
+	for i := 0; i < n; i++ {
+		if i < len(vectors) {
+			dst = append(dst, vectors[i][:]...)
+		} else {
+			dst = append(dst, math.NaN(), math.NaN(), math.NaN())
+		}
+	}
+	return dst
+}
+
+func readVectors(dst [][3]float64, values []float64) [][3]float64 {
+	n := len(values) / 3
+	if cap(dst) < n {
+		dst = make([][3]float64, n)
+	}
+	dst = dst[:n]
+	for i := range dst {
+		copy(dst[i][:], values[3*i:3*i+3])
+	}
+	return dst
+}
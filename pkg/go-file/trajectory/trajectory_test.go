@@ -0,0 +1,212 @@
+package trajectory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// testFrame is frame i of a two-body trajectory. The second body is absent
+// from every fourth frame and drift is only sampled in even frames.
+func testFrame(header Header, i int) *Frame {
+	frame := header.NewFrame()
+	x := float64(i)
+	frame.Time = x / 3
+	frame.Energy = -1 / (7 + x)
+	frame.AngularMomentum = [3]float64{0, math.Pi / (1 + x), 1e-300 * x}
+	for body := range header.Bodies {
+		if body == 1 && i%4 == 0 {
+			continue
+		}
+		b := float64(body + 1)
+		frame.Positions[body] = [3]float64{math.Sin(x * b), math.Cos(x * b), x / 11}
+		frame.Velocities[body] = [3]float64{-b * math.Cos(x), 1e10 / (3 + x), 0.1 * x}
+	}
+	frame.Drift = Drift{Energy: math.NaN(), AngularMomentum: math.NaN()}
+	if i%2 == 0 {
+		frame.Drift = Drift{Energy: 1e-9 * x, AngularMomentum: 2.5e-16 * x}
+	}
+	return frame
+}
+
+func sameValue(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func sameFrame(header Header, a, b *Frame) bool {
+	got, want := header.Values(a, nil), header.Values(b, nil)
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !sameValue(got[i], want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundTripWithAppend(t *testing.T) {
+	header, err := NewHeader([]string{"elder-0", "mentor-0"}, Fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL, FormatBinary} {
+		for _, compress := range []bool{false, true} {
+			name := format
+			if compress {
+				name += ".gz"
+			}
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "trajectory."+name)
+
+				// Write five frames, mark the file complete there and write
+				// three more that Append must discard.
+				w, err := Create(path, format, header, compress)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < 5; i++ {
+					if err := w.WriteFrame(testFrame(header, i)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				offset, err := w.Sync()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 100; i < 103; i++ {
+					if err := w.WriteFrame(testFrame(header, i)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				w, err = Append(path, format, header, compress, offset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 5; i < 10; i++ {
+					if err := w.WriteFrame(testFrame(header, i)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				r, err := Open(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer r.Close()
+				if r.Format != format {
+					t.Errorf("detected format %q, want %q", r.Format, format)
+				}
+				if len(r.Header.Bodies) != 2 || len(r.Header.Fields) != len(Fields) {
+					t.Errorf("read header %+v, want %+v", r.Header, header)
+				}
+
+				frames := 0
+				for ; ; frames++ {
+					frame, err := r.Next()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						t.Fatalf("frame %d: %v", frames, err)
+					}
+					if want := testFrame(header, frames); !sameFrame(header, frame, want) {
+						t.Errorf("frame %d = %+v, want %+v", frames, frame, want)
+					}
+					if present := frame.Present(1); present != (frames%4 != 0) {
+						t.Errorf("frame %d: second body present = %t", frames, present)
+					}
+				}
+				if frames != 10 {
+					t.Errorf("read %d frames, want 10", frames)
+				}
+			})
+		}
+	}
+}
+
+// chunkWriter records the size of every write.
+type chunkWriter struct {
+	bytes.Buffer
+	writes []int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestBinaryChunksBoundedForManyBodies(t *testing.T) {
+	// Positions take 24 bytes per body: 10k bodies make 240 kB records and
+	// 50k bodies records larger than a chunk.
+	for _, n := range []int{2, 10000, 50000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			bodies := make([]string, n)
+			for i := range bodies {
+				bodies[i] = fmt.Sprint(i)
+			}
+			header, err := NewHeader(bodies, []string{FieldTime, FieldPositions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := 8 * header.Width()
+
+			w := &chunkWriter{}
+			encoder := newBinaryEncoder(w, header)
+			if err := encoder.writeHeader(); err != nil {
+				t.Fatal(err)
+			}
+			const frames = 12
+			for i := 0; i < frames; i++ {
+				frame := header.NewFrame()
+				frame.Time = float64(i)
+				frame.Positions[n-1] = [3]float64{float64(i), 1, 2}
+				if err := encoder.writeFrame(frame); err != nil {
+					t.Fatal(err)
+				}
+				if limit := 2 * (4 + binaryChunkBytes + record); cap(encoder.pending) > limit {
+					t.Fatalf("frame %d: buffer holds %d bytes, more than %d", i, cap(encoder.pending), limit)
+				}
+			}
+			if err := encoder.flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, size := range w.writes[2:] {
+				if size > 4+binaryChunkBytes+record {
+					t.Errorf("wrote a %d-byte chunk for %d-byte records", size, record)
+				}
+			}
+
+			decoder, err := newBinaryDecoder(&w.Buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frame := header.NewFrame()
+			for i := 0; i < frames; i++ {
+				if err := decoder.next(frame); err != nil {
+					t.Fatalf("frame %d: %v", i, err)
+				}
+				if frame.Time != float64(i) || frame.Positions[n-1][0] != float64(i) {
+					t.Fatalf("frame %d read back as time %g", i, frame.Time)
+				}
+			}
+			if err := decoder.next(frame); err != io.EOF {
+				t.Fatalf("expected EOF after %d frames, got %v", frames, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package elder_spaces
 
-import "math"
+import (
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type SpectralDecomposer struct {
 	Basis       [][]float64
@@ -16,30 +19,31 @@ func NewSpectralDecomposer(dimension int) *SpectralDecomposer {
 	}
 }
 
-func (sd *SpectralDecomposer) Decompose(matrix [][]float64) {
-	sd.computeEigendecomposition(matrix)
+// Decompose diagonalizes a symmetric matrix: Eigenvalues are in ascending
+// order and Basis holds the orthonormal eigenvector of each.
+func (sd *SpectralDecomposer) Decompose(matrix [][]float64) error {
+	return sd.computeEigendecomposition(matrix)
 }
 
-func (sd *SpectralDecomposer) computeEigendecomposition(matrix [][]float64) {
-	for i := 0; i < sd.Dimension; i++ {
-		eigenvalue := 0.0
-		eigenvector := make([]float64, sd.Dimension)
-		
-		for j := 0; j < sd.Dimension; j++ {
-			if i < len(matrix) && j < len(matrix[i]) {
-				eigenvalue += matrix[i][j]
-			}
-			
-			if i == j {
-				eigenvector[j] = 1.0
-			} else {
-				eigenvector[j] = 0.0
-			}
-		}
-		
-		sd.Eigenvalues[i] = eigenvalue / float64(sd.Dimension)
-		sd.Basis[i] = eigenvector
+func (sd *SpectralDecomposer) computeEigendecomposition(matrix [][]float64) error {
+	a, err := tensor.FromRows(matrix)
+	if err != nil {
+		return err
+	}
+	if a.Dim(0) != sd.Dimension || a.Dim(1) != sd.Dimension {
+		return &tensor.ShapeError{Op: "spectral decomposition", Shapes: [][]int{a.Shape()}, Reason: "does not match the space's dimension"}
 	}
+	
+	eigenvalues, eigenvectors, err := linalg.EigenSymmetric(a)
+	if err != nil {
+		return err
+	}
+	
+	columns, _ := eigenvectors.Transpose()
+	basis, _ := columns.Rows()
+	sd.Eigenvalues = eigenvalues.Values()
+	sd.Basis = basis
+	return nil
 }
 
 func (sd *SpectralDecomposer) Project(vector []float64, basisIndex int) float64 {
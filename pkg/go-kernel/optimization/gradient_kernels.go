@@ -1,7 +1,5 @@
 package optimization
 
-import "math"
-
 type GradientKernel struct {
 	LearningRate float64
 	Momentum     float64
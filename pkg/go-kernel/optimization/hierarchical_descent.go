@@ -1,5 +1,7 @@
 package optimization
 
+import "sort"
+
 type HierarchicalDescent struct {
 	Levels       map[int]OptimizationLevel
 	GlobalParams []float64
@@ -41,6 +43,69 @@ func (hd *HierarchicalDescent) OptimizeHierarchy(iterations int) {
 	}
 }
 
+// OptimizeWith minimises each level's objective with optimizer, parents
+// before children, for the given number of rounds. As in OptimizeHierarchy a
+// child is pulled along its parents' gradients: its objective gains the
+// coordination term 0.1·g·x for each parent gradient g. It returns the
+// diagnostics of each level's last minimisation.
+func (hd *HierarchicalDescent) OptimizeWith(optimizer Optimizer, rounds int) (map[int]*Result, error) {
+	levels := make([]int, 0, len(hd.Levels))
+	for level := range hd.Levels {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+	
+	results := make(map[int]*Result)
+	for round := 0; round < rounds; round++ {
+		for _, level := range levels {
+			optLevel := hd.Levels[level]
+			pull := hd.parentPull(level, len(optLevel.Parameters))
+			objective := optLevel.Objective
+			
+			result, err := optimizer.Minimize(Problem{
+				Function: func(x []float64) float64 {
+					return objective(x) + dot(pull, x)
+				},
+			}, optLevel.Parameters)
+			if err != nil {
+				return results, err
+			}
+			
+			copy(optLevel.Parameters, result.X)
+			optLevel.Gradient = hd.computeGradient(objective, optLevel.Parameters)
+			hd.Levels[level] = optLevel
+			results[level] = result
+		}
+	}
+	
+	return results, nil
+}
+
+func (hd *HierarchicalDescent) parentPull(level, size int) []float64 {
+	coordination := 0.1
+	pull := make([]float64, size)
+	
+	for parentLevel, children := range hd.Coordination {
+		parent, exists := hd.Levels[parentLevel]
+		if !exists {
+			continue
+		}
+		for _, child := range children {
+			if child != level {
+				continue
+			}
+			gradient := hd.computeGradient(parent.Objective, parent.Parameters)
+			for i := range pull {
+				if i < len(gradient) {
+					pull[i] += coordination * gradient[i]
+				}
+			}
+		}
+	}
+	
+	return pull
+}
+
 func (hd *HierarchicalDescent) computeHierarchicalGradients() {
 	for level, optLevel := range hd.Levels {
 		gradient := hd.computeGradient(optLevel.Objective, optLevel.Parameters)
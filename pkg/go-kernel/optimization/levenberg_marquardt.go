@@ -0,0 +1,182 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+)
+
+// LevenbergMarquardt minimises half the sum of squared residuals. Each step
+// solves the Gauss–Newton equations with Damping times the diagonal of JᵀJ
+// added; the damping shrinks by DampingFactor after a step that lowers the
+// objective and grows by it until one does, moving between Gauss–Newton
+// and short gradient descent steps. It stops when the gradient's largest
+// component falls to GradientTolerance, a step moves x by less than
+// StepTolerance relative to its size, or the objective changes by less
+// than Tolerance.
+type LevenbergMarquardt struct {
+	MaxIterations     int
+	Tolerance         float64
+	GradientTolerance float64
+	StepTolerance     float64
+	Damping           float64
+	DampingFactor     float64
+}
+
+func NewLevenbergMarquardt() *LevenbergMarquardt {
+	return &LevenbergMarquardt{
+		MaxIterations:     100,
+		Tolerance:         1e-15,
+		GradientTolerance: 1e-10,
+		StepTolerance:     1e-10,
+		Damping:           1e-3,
+		DampingFactor:     10,
+	}
+}
+
+// maxDamping is the damping beyond which no step will lower the objective.
+const maxDamping = 1e16
+
+func (lm *LevenbergMarquardt) Minimize(problem Problem, start []float64) (*Result, error) {
+	eval, err := newEvaluator(problem, start, true)
+	if err != nil {
+		return nil, err
+	}
+	if lm.DampingFactor <= 1 {
+		return nil, fmt.Errorf("optimization: damping factor must exceed 1, got %g", lm.DampingFactor)
+	}
+
+	x := append([]float64{}, start...)
+	r := eval.residuals(x)
+	cost := halfSquaredNorm(r)
+	if !finite(cost) {
+		return nil, fmt.Errorf("optimization: objective is %g at the starting point", cost)
+	}
+	kernel := NewConvergenceKernel(lm.Tolerance, lm.MaxIterations)
+	kernel.CheckConvergence(cost)
+
+	damping := lm.Damping
+	var normal [][]float64
+	var gradient []float64
+	stale := true
+	result := &Result{Reason: "iteration limit reached"}
+	for {
+		jacobian := eval.jacobian(x, r)
+		normal, gradient = normalEquations(jacobian, r)
+		stale = false
+		if maxAbs(gradient) <= lm.GradientTolerance {
+			result.Converged, result.Reason = true, "gradient norm below tolerance"
+			break
+		}
+		if result.Iterations >= lm.MaxIterations {
+			break
+		}
+		result.Iterations++
+
+		accepted := false
+		var step []float64
+		for !accepted && damping <= maxDamping {
+			damped := make([][]float64, len(normal))
+			for i := range normal {
+				damped[i] = append([]float64{}, normal[i]...)
+				damped[i][i] += damping * math.Max(normal[i][i], 1e-12)
+			}
+			step, err = solve(damped, axpy(make([]float64, len(gradient)), -1, gradient))
+			if err == nil {
+				candidate := axpy(x, 1, step)
+				residuals := eval.residuals(candidate)
+				if next := halfSquaredNorm(residuals); finite(next) && next < cost {
+					x, r, cost = candidate, residuals, next
+					damping = math.Max(damping/lm.DampingFactor, 1e-15)
+					accepted, stale = true, true
+					continue
+				}
+			}
+			damping *= lm.DampingFactor
+		}
+		if !accepted {
+			result.Reason = "damping grew too large to find a lower objective"
+			break
+		}
+
+		if kernel.CheckConvergence(cost) {
+			result.Converged, result.Reason = true, "objective change below tolerance"
+			break
+		}
+		if norm(step) <= lm.StepTolerance*(norm(x)+lm.StepTolerance) {
+			result.Converged, result.Reason = true, "step below tolerance"
+			break
+		}
+	}
+
+	if stale {
+		_, gradient = normalEquations(eval.jacobian(x, r), r)
+	}
+	result.X, result.Value, result.GradientNorm = x, cost, norm(gradient)
+	return eval.finish(result, kernel), nil
+}
+
+// normalEquations returns JᵀJ and Jᵀr.
+func normalEquations(jacobian [][]float64, r []float64) ([][]float64, []float64) {
+	n := 0
+	if len(jacobian) > 0 {
+		n = len(jacobian[0])
+	}
+	normal := make([][]float64, n)
+	for i := range normal {
+		normal[i] = make([]float64, n)
+	}
+	gradient := make([]float64, n)
+	for k, row := range jacobian {
+		for i := range row {
+			gradient[i] += row[i] * r[k]
+			for j := range row {
+				normal[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	return normal, gradient
+}
+
+// solve solves a·x = b by Gaussian elimination with partial pivoting,
+// overwriting a.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	x := append([]float64{}, b...)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-300 {
+			return nil, fmt.Errorf("optimization: singular system")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		x[col], x[pivot] = x[pivot], x[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			x[row] -= factor * x[col]
+		}
+	}
+
+	for row := n - 1; row >= 0; row-- {
+		for k := row + 1; k < n; k++ {
+			x[row] -= a[row][k] * x[k]
+		}
+		x[row] /= a[row][row]
+	}
+	return x, nil
+}
+
+func maxAbs(v []float64) float64 {
+	largest := 0.0
+	for _, value := range v {
+		largest = math.Max(largest, math.Abs(value))
+	}
+	return largest
+}
@@ -0,0 +1,154 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// NelderMead is the downhill simplex method. It needs only objective values,
+// so it suits objectives that are noisy or not differentiable. The initial
+// simplex steps InitialStep times each coordinate away from the start, or
+// InitialStep itself for zero coordinates. It stops when the objective
+// differs by at most Tolerance across the simplex and every vertex is within
+// StepTolerance of the best in each coordinate, and a fresh simplex around
+// the best vertex does not improve on it. The expansion, contraction and
+// shrink coefficients adapt to the dimension as Gao and Han suggest, which
+// keeps the simplex from collapsing early in many dimensions.
+type NelderMead struct {
+	MaxIterations int
+	Tolerance     float64
+	StepTolerance float64
+	InitialStep   float64
+}
+
+func NewNelderMead() *NelderMead {
+	return &NelderMead{
+		MaxIterations: 1000,
+		Tolerance:     1e-10,
+		StepTolerance: 1e-8,
+		InitialStep:   0.05,
+	}
+}
+
+type vertex struct {
+	x []float64
+	f float64
+}
+
+func (nm *NelderMead) Minimize(problem Problem, start []float64) (*Result, error) {
+	eval, err := newEvaluator(problem, start, false)
+	if err != nil {
+		return nil, err
+	}
+	value := func(x []float64) float64 {
+		f := eval.value(x)
+		if math.IsNaN(f) {
+			return math.Inf(1)
+		}
+		return f
+	}
+
+	n := len(start)
+	first := vertex{append([]float64{}, start...), value(start)}
+	if math.IsInf(first.f, 0) {
+		return nil, fmt.Errorf("optimization: objective is %g at the starting point", first.f)
+	}
+	simplex := nm.simplexAround(first, value)
+	dimension := math.Max(float64(n), 2)
+	expand, contract, shrink := 1+2/dimension, 0.75-1/(2*dimension), 1-1/dimension
+
+	kernel := NewConvergenceKernel(nm.Tolerance, nm.MaxIterations)
+	result := &Result{Reason: "iteration limit reached"}
+	for {
+		sort.SliceStable(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+		best, worst := simplex[0], simplex[n]
+		kernel.History = append(kernel.History, best.f)
+
+		if worst.f-best.f <= nm.Tolerance && simplexSize(simplex) <= nm.StepTolerance {
+			restarted := nm.simplexAround(best, value)
+			sort.SliceStable(restarted, func(i, j int) bool { return restarted[i].f < restarted[j].f })
+			if best.f-restarted[0].f <= nm.Tolerance {
+				result.Converged, result.Reason = true, "simplex collapsed below tolerance"
+				break
+			}
+			simplex = restarted
+			continue
+		}
+		if result.Iterations >= nm.MaxIterations {
+			break
+		}
+		result.Iterations++
+
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for j := range centroid {
+				centroid[j] += v.x[j] / float64(n)
+			}
+		}
+		toward := func(from []float64, coefficient float64) vertex {
+			x := axpy(centroid, coefficient, axpy(from, -1, centroid))
+			return vertex{x, value(x)}
+		}
+
+		reflected := toward(worst.x, -1)
+		switch {
+		case reflected.f < best.f:
+			if expanded := toward(reflected.x, expand); expanded.f < reflected.f {
+				simplex[n] = expanded
+			} else {
+				simplex[n] = reflected
+			}
+			continue
+		case reflected.f < simplex[n-1].f:
+			simplex[n] = reflected
+			continue
+		case reflected.f < worst.f:
+			if contracted := toward(reflected.x, contract); contracted.f <= reflected.f {
+				simplex[n] = contracted
+				continue
+			}
+		default:
+			if contracted := toward(worst.x, contract); contracted.f < worst.f {
+				simplex[n] = contracted
+				continue
+			}
+		}
+
+		for i := 1; i <= n; i++ {
+			x := axpy(best.x, shrink, axpy(simplex[i].x, -1, best.x))
+			simplex[i] = vertex{x, value(x)}
+		}
+	}
+
+	result.X, result.Value = simplex[0].x, simplex[0].f
+	return eval.finish(result, kernel), nil
+}
+
+// simplexAround is the initial simplex: first and a vertex stepped away
+// from it along each coordinate.
+func (nm *NelderMead) simplexAround(first vertex, value func([]float64) float64) []vertex {
+	simplex := []vertex{first}
+	for i := range first.x {
+		x := append([]float64{}, first.x...)
+		if x[i] != 0 {
+			x[i] *= 1 + nm.InitialStep
+		} else {
+			x[i] = nm.InitialStep
+		}
+		simplex = append(simplex, vertex{x, value(x)})
+	}
+	return simplex
+}
+
+// simplexSize is the largest distance in any coordinate from the best
+// vertex, which simplex must be sorted to put first, to another.
+func simplexSize(simplex []vertex) float64 {
+	size := 0.0
+	for _, v := range simplex[1:] {
+		for j := range v.x {
+			size = math.Max(size, math.Abs(v.x[j]-simplex[0].x[j]))
+		}
+	}
+	return size
+}
@@ -0,0 +1,187 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+)
+
+// Optimizer minimises a problem starting from start.
+type Optimizer interface {
+	Minimize(problem Problem, start []float64) (*Result, error)
+}
+
+// Problem is an objective to minimise. Function is the objective itself;
+// Gradient, when set, is its gradient, which otherwise is approximated by
+// central differences. Least-squares problems give Residuals, and
+// optionally their Jacobian with one row per residual, instead: their
+// objective is half the sum of the squared residuals.
+type Problem struct {
+	Function  func(x []float64) float64
+	Gradient  func(x []float64) []float64
+	Residuals func(x []float64) []float64
+	Jacobian  func(x []float64) [][]float64
+}
+
+// Result is the point an optimizer stopped at, with diagnostics: how many
+// iterations and evaluations it took, whether it converged and why it
+// stopped, the objective after every iteration and the rate at which the
+// objective's changes were shrinking at the end. GradientNorm stays zero
+// for optimizers that use no gradients.
+type Result struct {
+	X                   []float64 `json:"x"`
+	Value               float64   `json:"value"`
+	GradientNorm        float64   `json:"gradient_norm"`
+	Iterations          int       `json:"iterations"`
+	Evaluations         int       `json:"evaluations"`
+	GradientEvaluations int       `json:"gradient_evaluations"`
+	Converged           bool      `json:"converged"`
+	Reason              string    `json:"reason"`
+	History             []float64 `json:"history"`
+	Rate                float64   `json:"rate"`
+}
+
+func (r *Result) String() string {
+	status := "stopped"
+	if r.Converged {
+		status = "converged"
+	}
+	return fmt.Sprintf("%s after %d iterations (%d evaluations): %s; value %g, gradient norm %g, rate %.3g",
+		status, r.Iterations, r.Evaluations, r.Reason, r.Value, r.GradientNorm, r.Rate)
+}
+
+// evaluator counts the evaluations an optimizer makes and supplies
+// numerical derivatives for whatever the problem leaves out.
+type evaluator struct {
+	problem     Problem
+	evaluations int
+	gradients   int
+}
+
+func newEvaluator(problem Problem, start []float64, needResiduals bool) (*evaluator, error) {
+	if len(start) == 0 {
+		return nil, fmt.Errorf("optimization: empty starting point")
+	}
+	if needResiduals && problem.Residuals == nil {
+		return nil, fmt.Errorf("optimization: least-squares optimizer needs residuals")
+	}
+	if problem.Function == nil && problem.Residuals == nil {
+		return nil, fmt.Errorf("optimization: problem has neither a function nor residuals")
+	}
+	return &evaluator{problem: problem}, nil
+}
+
+func (e *evaluator) value(x []float64) float64 {
+	if e.problem.Function != nil {
+		e.evaluations++
+		return e.problem.Function(x)
+	}
+	return halfSquaredNorm(e.residuals(x))
+}
+
+func (e *evaluator) residuals(x []float64) []float64 {
+	e.evaluations++
+	return e.problem.Residuals(x)
+}
+
+func (e *evaluator) gradient(x []float64) []float64 {
+	e.gradients++
+	switch {
+	case e.problem.Gradient != nil:
+		return e.problem.Gradient(x)
+	case e.problem.Function == nil:
+		// The gradient of half the squared residuals is Jᵀr.
+		r := e.residuals(x)
+		jacobian := e.jacobian(x, r)
+		gradient := make([]float64, len(x))
+		for i, row := range jacobian {
+			for j, value := range row {
+				gradient[j] += value * r[i]
+			}
+		}
+		return gradient
+	}
+
+	gradient := make([]float64, len(x))
+	point := append([]float64{}, x...)
+	for i := range x {
+		h := differenceStep(x[i])
+		point[i] = x[i] + h
+		plus := e.value(point)
+		point[i] = x[i] - h
+		minus := e.value(point)
+		point[i] = x[i]
+		gradient[i] = (plus - minus) / (2 * h)
+	}
+	return gradient
+}
+
+// jacobian is the Jacobian of the residuals r at x, by central differences
+// when the problem does not give it.
+func (e *evaluator) jacobian(x, r []float64) [][]float64 {
+	if e.problem.Jacobian != nil {
+		e.gradients++
+		return e.problem.Jacobian(x)
+	}
+
+	jacobian := make([][]float64, len(r))
+	for i := range jacobian {
+		jacobian[i] = make([]float64, len(x))
+	}
+	point := append([]float64{}, x...)
+	for j := range x {
+		h := differenceStep(x[j])
+		point[j] = x[j] + h
+		plus := e.residuals(point)
+		point[j] = x[j] - h
+		minus := e.residuals(point)
+		point[j] = x[j]
+		for i := range jacobian {
+			jacobian[i][j] = (plus[i] - minus[i]) / (2 * h)
+		}
+	}
+	return jacobian
+}
+
+// differenceStep balances truncation against rounding error for central
+// differences at x.
+func differenceStep(x float64) float64 {
+	return 1e-6 * math.Max(1, math.Abs(x))
+}
+
+// finish fills in the diagnostics common to every optimizer.
+func (e *evaluator) finish(result *Result, kernel *ConvergenceKernel) *Result {
+	result.Evaluations = e.evaluations
+	result.GradientEvaluations = e.gradients
+	result.History = kernel.History
+	result.Rate = kernel.EstimateConvergenceRate()
+	return result
+}
+
+func halfSquaredNorm(v []float64) float64 {
+	return 0.5 * dot(v, v)
+}
+
+func dot(a, b []float64) float64 {
+	total := 0.0
+	for i := range a {
+		total += a[i] * b[i]
+	}
+	return total
+}
+
+func norm(v []float64) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+// axpy returns x + a·y.
+func axpy(x []float64, a float64, y []float64) []float64 {
+	result := make([]float64, len(x))
+	for i := range x {
+		result[i] = x[i] + a*y[i]
+	}
+	return result
+}
+
+func finite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
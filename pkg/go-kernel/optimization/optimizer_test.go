@@ -0,0 +1,130 @@
+package optimization
+
+import (
+	"math"
+	"testing"
+)
+
+// rosenbrock is the extended Rosenbrock function, a sum of banana-shaped
+// valleys with its minimum of zero at (1, …, 1).
+func rosenbrock(x []float64) float64 {
+	total := 0.0
+	for i := 0; i+1 < len(x); i++ {
+		a := 1 - x[i]
+		b := x[i+1] - x[i]*x[i]
+		total += a*a + 100*b*b
+	}
+	return total
+}
+
+func rosenbrockGradient(x []float64) []float64 {
+	gradient := make([]float64, len(x))
+	for i := 0; i+1 < len(x); i++ {
+		b := x[i+1] - x[i]*x[i]
+		gradient[i] += -2*(1-x[i]) - 400*x[i]*b
+		gradient[i+1] += 200 * b
+	}
+	return gradient
+}
+
+// rosenbrockResiduals are the residuals whose half squared norm is half
+// the Rosenbrock function.
+func rosenbrockResiduals(x []float64) []float64 {
+	residuals := make([]float64, 0, 2*(len(x)-1))
+	for i := 0; i+1 < len(x); i++ {
+		residuals = append(residuals, 1-x[i], 10*(x[i+1]-x[i]*x[i]))
+	}
+	return residuals
+}
+
+func rosenbrockJacobian(x []float64) [][]float64 {
+	jacobian := make([][]float64, 0, 2*(len(x)-1))
+	for i := 0; i+1 < len(x); i++ {
+		first := make([]float64, len(x))
+		first[i] = -1
+		second := make([]float64, len(x))
+		second[i] = -20 * x[i]
+		second[i+1] = 10
+		jacobian = append(jacobian, first, second)
+	}
+	return jacobian
+}
+
+func TestRosenbrockConvergence(t *testing.T) {
+	tests := []struct {
+		name      string
+		optimizer Optimizer
+		problem   Problem
+		start     []float64
+		tolerance float64
+	}{
+		{"lbfgs", NewLBFGS(5), Problem{Function: rosenbrock, Gradient: rosenbrockGradient}, []float64{-1.2, 1}, 1e-6},
+		{"lbfgs numerical gradient", NewLBFGS(5), Problem{Function: rosenbrock}, []float64{-1.2, 1}, 1e-5},
+		{"lbfgs 6d", NewLBFGS(7), Problem{Function: rosenbrock, Gradient: rosenbrockGradient}, []float64{-1.2, 1, -1.2, 1, -1.2, 1}, 1e-6},
+		{"nelder-mead", NewNelderMead(), Problem{Function: rosenbrock}, []float64{-1.2, 1}, 1e-4},
+		{"nelder-mead 3d", NewNelderMead(), Problem{Function: rosenbrock}, []float64{-1.2, 1, -1.2}, 1e-4},
+		{"levenberg-marquardt", NewLevenbergMarquardt(), Problem{Residuals: rosenbrockResiduals, Jacobian: rosenbrockJacobian}, []float64{-1.2, 1}, 1e-8},
+		{"levenberg-marquardt numerical jacobian", NewLevenbergMarquardt(), Problem{Residuals: rosenbrockResiduals}, []float64{-1.2, 1}, 1e-6},
+		{"levenberg-marquardt 6d", NewLevenbergMarquardt(), Problem{Residuals: rosenbrockResiduals, Jacobian: rosenbrockJacobian}, []float64{-1.2, 1, -1.2, 1, -1.2, 1}, 1e-8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := append([]float64{}, tt.start...)
+			result, err := tt.optimizer.Minimize(tt.problem, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Converged {
+				t.Fatalf("did not converge: %s", result)
+			}
+			for i, x := range result.X {
+				if math.Abs(x-1) > tt.tolerance {
+					t.Fatalf("x[%d] = %g, want 1 within %g (%s)", i, x, tt.tolerance, result)
+				}
+			}
+			if value := rosenbrock(result.X); value > tt.tolerance*tt.tolerance {
+				t.Errorf("objective %g at the solution", value)
+			}
+			for i := range start {
+				if start[i] != tt.start[i] {
+					t.Fatalf("starting point was modified: %v", start)
+				}
+			}
+			// The history has an entry before the first iteration and never
+			// rises, since every optimizer keeps its best point.
+			if len(result.History) != result.Iterations+1 {
+				t.Fatalf("history has %d entries for %d iterations", len(result.History), result.Iterations)
+			}
+			for i := 1; i < len(result.History); i++ {
+				if result.History[i] > result.History[i-1] {
+					t.Fatalf("objective rose from %g to %g at iteration %d", result.History[i-1], result.History[i], i)
+				}
+			}
+			if result.Evaluations == 0 {
+				t.Error("no evaluations counted")
+			}
+		})
+	}
+}
+
+func TestMinimizeRejectsBadProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		optimizer Optimizer
+		problem   Problem
+		start     []float64
+	}{
+		{"empty start", NewLBFGS(5), Problem{Function: rosenbrock}, nil},
+		{"no objective", NewNelderMead(), Problem{}, []float64{0, 0}},
+		{"least squares without residuals", NewLevenbergMarquardt(), Problem{Function: rosenbrock}, []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.optimizer.Minimize(tt.problem, tt.start); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package optimization
+
+import (
+	"fmt"
+	"math"
+)
+
+// Strong Wolfe conditions: sufficient decrease with c1 and curvature with c2.
+const (
+	wolfeDecrease  = 1e-4
+	wolfeCurvature = 0.9
+	maxLineSearch  = 30
+)
+
+// LBFGS is the limited-memory BFGS quasi-Newton method: it approximates the
+// inverse Hessian from the last Memory steps and their gradient changes and
+// picks each step length by a line search satisfying the strong Wolfe
+// conditions. It stops when the gradient norm falls to GradientTolerance
+// or the objective changes by less than Tolerance in an iteration.
+type LBFGS struct {
+	Memory            int
+	MaxIterations     int
+	Tolerance         float64
+	GradientTolerance float64
+}
+
+func NewLBFGS(memory int) *LBFGS {
+	return &LBFGS{
+		Memory:            memory,
+		MaxIterations:     200,
+		Tolerance:         1e-12,
+		GradientTolerance: 1e-8,
+	}
+}
+
+func (lb *LBFGS) Minimize(problem Problem, start []float64) (*Result, error) {
+	eval, err := newEvaluator(problem, start, false)
+	if err != nil {
+		return nil, err
+	}
+	if lb.Memory < 1 {
+		return nil, fmt.Errorf("optimization: L-BFGS memory must be positive, got %d", lb.Memory)
+	}
+
+	x := append([]float64{}, start...)
+	f := eval.value(x)
+	if !finite(f) {
+		return nil, fmt.Errorf("optimization: objective is %g at the starting point", f)
+	}
+	g := eval.gradient(x)
+	kernel := NewConvergenceKernel(lb.Tolerance, lb.MaxIterations)
+	kernel.CheckConvergence(f)
+
+	steps, changes := make([][]float64, 0, lb.Memory), make([][]float64, 0, lb.Memory)
+	result := &Result{Reason: "iteration limit reached"}
+	for result.Iterations < lb.MaxIterations {
+		if norm(g) <= lb.GradientTolerance {
+			result.Converged, result.Reason = true, "gradient norm below tolerance"
+			break
+		}
+
+		direction := twoLoop(g, steps, changes)
+		initial := 1.0
+		if len(steps) == 0 {
+			initial = math.Min(1, 1/norm(g))
+		}
+		if dot(direction, g) >= 0 {
+			// Not a descent direction: start afresh from steepest descent.
+			steps, changes = steps[:0], changes[:0]
+			direction = axpy(make([]float64, len(g)), -1, g)
+			initial = math.Min(1, 1/norm(g))
+		}
+
+		alpha, fNew, gNew, ok := lineSearch(eval, x, f, g, direction, initial)
+		if !ok {
+			result.Reason = "line search found no acceptable step"
+			break
+		}
+		result.Iterations++
+
+		step := axpy(make([]float64, len(x)), alpha, direction)
+		change := axpy(gNew, -1, g)
+		if dot(step, change) > 1e-12*norm(step)*norm(change) {
+			if len(steps) == lb.Memory {
+				steps, changes = steps[1:], changes[1:]
+			}
+			steps, changes = append(steps, step), append(changes, change)
+		}
+
+		x = axpy(x, 1, step)
+		f, g = fNew, gNew
+		if kernel.CheckConvergence(f) {
+			result.Converged, result.Reason = true, "objective change below tolerance"
+			break
+		}
+	}
+
+	result.X, result.Value, result.GradientNorm = x, f, norm(g)
+	return eval.finish(result, kernel), nil
+}
+
+// twoLoop applies the inverse Hessian approximation built from steps and
+// gradient changes to g and returns the resulting descent direction.
+func twoLoop(g []float64, steps, changes [][]float64) []float64 {
+	q := append([]float64{}, g...)
+	alphas := make([]float64, len(steps))
+	for i := len(steps) - 1; i >= 0; i-- {
+		alphas[i] = dot(steps[i], q) / dot(changes[i], steps[i])
+		q = axpy(q, -alphas[i], changes[i])
+	}
+
+	if last := len(steps) - 1; last >= 0 {
+		gamma := dot(steps[last], changes[last]) / dot(changes[last], changes[last])
+		for i := range q {
+			q[i] *= gamma
+		}
+	}
+
+	for i := range steps {
+		beta := dot(changes[i], q) / dot(changes[i], steps[i])
+		q = axpy(q, alphas[i]-beta, steps[i])
+	}
+	for i := range q {
+		q[i] = -q[i]
+	}
+	return q
+}
+
+// lineSearch finds a step length along direction satisfying the strong
+// Wolfe conditions by expanding the step until it brackets one and then
+// zooming in, as in Nocedal and Wright's algorithms 3.5 and 3.6. It returns
+// the step and the objective and gradient there.
+func lineSearch(eval *evaluator, x []float64, f0 float64, g0, direction []float64, initial float64) (float64, float64, []float64, bool) {
+	slope0 := dot(g0, direction)
+	at := func(alpha float64) (float64, []float64, float64) {
+		point := axpy(x, alpha, direction)
+		f := eval.value(point)
+		if !finite(f) {
+			return f, nil, math.NaN()
+		}
+		g := eval.gradient(point)
+		return f, g, dot(g, direction)
+	}
+
+	zoom := func(lo, hi, fLo, fHi, slopeLo float64) (float64, float64, []float64, bool) {
+		for i := 0; i < maxLineSearch; i++ {
+			// Minimise the quadratic through lo's value and slope and hi's
+			// value, kept away from the ends of the bracket.
+			width := hi - lo
+			alpha := lo - slopeLo*width*width/(2*(fHi-fLo-slopeLo*width))
+			if low, high := math.Min(lo, hi)+0.1*math.Abs(width), math.Max(lo, hi)-0.1*math.Abs(width); !(alpha >= low && alpha <= high) {
+				alpha = lo + width/2
+			}
+
+			f, g, slope := at(alpha)
+			if !finite(f) || f > f0+wolfeDecrease*alpha*slope0 || f >= fLo {
+				hi, fHi = alpha, f
+				if !finite(f) {
+					fHi = math.MaxFloat64
+				}
+				continue
+			}
+			if math.Abs(slope) <= -wolfeCurvature*slope0 {
+				return alpha, f, g, true
+			}
+			if slope*(hi-lo) >= 0 {
+				hi, fHi = lo, fLo
+			}
+			lo, fLo, slopeLo = alpha, f, slope
+		}
+		if lo > 0 && fLo < f0 {
+			// Settle for sufficient decrease without the curvature condition.
+			f, g, _ := at(lo)
+			return lo, f, g, true
+		}
+		return 0, f0, g0, false
+	}
+
+	previous, fPrevious, slopePrevious := 0.0, f0, slope0
+	alpha := initial
+	for i := 0; i < maxLineSearch; i++ {
+		f, g, slope := at(alpha)
+		if !finite(f) {
+			// Overshot into a region where the objective is undefined.
+			alpha = previous + (alpha-previous)/4
+			continue
+		}
+		if f > f0+wolfeDecrease*alpha*slope0 || (i > 0 && f >= fPrevious) {
+			return zoom(previous, alpha, fPrevious, f, slopePrevious)
+		}
+		if math.Abs(slope) <= -wolfeCurvature*slope0 {
+			return alpha, f, g, true
+		}
+		if slope >= 0 {
+			return zoom(alpha, previous, f, fPrevious, slope)
+		}
+		previous, fPrevious, slopePrevious = alpha, f, slope
+		alpha *= 2
+	}
+	return 0, f0, g0, false
+}
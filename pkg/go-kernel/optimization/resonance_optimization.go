@@ -53,6 +53,13 @@ func (ro *ResonanceOptimizer) OptimizeResonance(objective func([]float64) float6
 	return optimized
 }
 
+// OptimizeResonanceWith explores with the resonance search and then
+// refines its result with optimizer, whose diagnostics it returns.
+func (ro *ResonanceOptimizer) OptimizeResonanceWith(optimizer Optimizer, objective func([]float64) float64, params []float64) (*Result, error) {
+	explored := ro.OptimizeResonance(objective, params)
+	return optimizer.Minimize(Problem{Function: objective}, explored)
+}
+
 func (ro *ResonanceOptimizer) generateResonanceSignal(t float64) float64 {
 	signal := ro.Amplitude * math.Cos(ro.Frequency*t + ro.Phase)
 	return signal * math.Exp(-ro.Damping*t)
@@ -1,15 +1,34 @@
 package elder
 
-import "math"
+import "github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
 
 type ElderLossFunction struct {
-	Type        string
-	Weight      float64
+	Type           string
+	Weight         float64
 	Regularization float64
 }
 
 func (elf *ElderLossFunction) ComputeLoss(predicted, actual []float64) float64 {
-	var loss float64
+	return elf.Graph(autodiff.Constant(predicted), autodiff.Constant(actual)).Scalar()
+}
+
+// Gradient returns the loss and its gradient with respect to predicted.
+func (elf *ElderLossFunction) Gradient(predicted, actual []float64) (float64, []float64) {
+	tape := autodiff.NewTape()
+	p := tape.Variable(predicted)
+	loss := elf.Graph(p, autodiff.Constant(actual))
+	loss.Backward()
+	return loss.Scalar(), p.Grad.Values()
+}
+
+// Graph records the loss on the tape of its inputs so it can be
+// differentiated. Extra elements of actual are ignored.
+func (elf *ElderLossFunction) Graph(predicted, actual *autodiff.Variable) *autodiff.Variable {
+	if actual.Len() > predicted.Len() {
+		actual = autodiff.Slice(actual, 0, predicted.Len())
+	}
+
+	var loss *autodiff.Variable
 	switch elf.Type {
 	case "mse":
 		loss = elf.meanSquaredError(predicted, actual)
@@ -18,22 +37,13 @@ func (elf *ElderLossFunction) ComputeLoss(predicted, actual []float64) float64 {
 	default:
 		loss = elf.meanSquaredError(predicted, actual)
 	}
-	return loss * elf.Weight
+	return autodiff.Scale(loss, elf.Weight)
 }
 
-func (elf *ElderLossFunction) meanSquaredError(predicted, actual []float64) float64 {
-	var sum float64
-	for i := range predicted {
-		diff := predicted[i] - actual[i]
-		sum += diff * diff
-	}
-	return sum / float64(len(predicted))
+func (elf *ElderLossFunction) meanSquaredError(predicted, actual *autodiff.Variable) *autodiff.Variable {
+	return autodiff.Mean(autodiff.Square(autodiff.Sub(predicted, actual)))
 }
 
-func (elf *ElderLossFunction) meanAbsoluteError(predicted, actual []float64) float64 {
-	var sum float64
-	for i := range predicted {
-		sum += math.Abs(predicted[i] - actual[i])
-	}
-	return sum / float64(len(predicted))
+func (elf *ElderLossFunction) meanAbsoluteError(predicted, actual *autodiff.Variable) *autodiff.Variable {
+	return autodiff.Mean(autodiff.Abs(autodiff.Sub(predicted, actual)))
 }
@@ -1,12 +1,19 @@
 package hierarchical
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
 
 type CrossLevelLoss struct {
 	InformationFlow    float64
 	HierarchyIntegrity float64
 	CausalConsistency  float64
 	TemporalCoherence  float64
+
+	// Components holds the unweighted terms of the last loss computed.
+	Components map[string]float64
 }
 
 func NewCrossLevelLoss() *CrossLevelLoss {
@@ -19,158 +26,127 @@ func NewCrossLevelLoss() *CrossLevelLoss {
 }
 
 func (cll *CrossLevelLoss) ComputeLoss(elderState []float64, mentorStates, eruditeStates [][]float64) float64 {
+	return cll.Graph(autodiff.Constant(elderState), constants(mentorStates), constants(eruditeStates)).Scalar()
+}
+
+// Graph records the loss on the tape of its inputs so gradients can flow to
+// every level of the hierarchy.
+func (cll *CrossLevelLoss) Graph(elderState *autodiff.Variable, mentorStates, eruditeStates []*autodiff.Variable) *autodiff.Variable {
 	infoFlowLoss := cll.computeInformationFlowLoss(elderState, mentorStates, eruditeStates)
 	integrityLoss := cll.computeHierarchyIntegrityLoss(elderState, mentorStates, eruditeStates)
 	causalLoss := cll.computeCausalConsistencyLoss(elderState, mentorStates, eruditeStates)
 	temporalLoss := cll.computeTemporalCoherenceLoss(elderState, mentorStates, eruditeStates)
-	
-	totalLoss := cll.InformationFlow*infoFlowLoss +
-		cll.HierarchyIntegrity*integrityLoss +
-		cll.CausalConsistency*causalLoss +
-		cll.TemporalCoherence*temporalLoss
-	
-	return totalLoss
+
+	cll.Components = map[string]float64{
+		"information_flow":    infoFlowLoss.Scalar(),
+		"hierarchy_integrity": integrityLoss.Scalar(),
+		"causal_consistency":  causalLoss.Scalar(),
+		"temporal_coherence":  temporalLoss.Scalar(),
+	}
+	return weighted(
+		[]float64{cll.InformationFlow, cll.HierarchyIntegrity, cll.CausalConsistency, cll.TemporalCoherence},
+		infoFlowLoss, integrityLoss, causalLoss, temporalLoss,
+	)
 }
 
-func (cll *CrossLevelLoss) computeInformationFlowLoss(elderState []float64, mentorStates, eruditeStates [][]float64) float64 {
+func (cll *CrossLevelLoss) computeInformationFlowLoss(elderState *autodiff.Variable, mentorStates, eruditeStates []*autodiff.Variable) *autodiff.Variable {
 	elderEntropy := cll.computeEntropy(elderState)
-	
-	mentorEntropy := 0.0
-	for _, mentorState := range mentorStates {
-		mentorEntropy += cll.computeEntropy(mentorState)
+
+	mentorEntropies := make([]*autodiff.Variable, len(mentorStates))
+	for i, mentorState := range mentorStates {
+		mentorEntropies[i] = cll.computeEntropy(mentorState)
 	}
-	mentorEntropy /= float64(len(mentorStates))
-	
-	eruditeEntropy := 0.0
-	for _, eruditeState := range eruditeStates {
-		eruditeEntropy += cll.computeEntropy(eruditeState)
+	mentorEntropy := average(mentorEntropies)
+
+	eruditeEntropies := make([]*autodiff.Variable, len(eruditeStates))
+	for i, eruditeState := range eruditeStates {
+		eruditeEntropies[i] = cll.computeEntropy(eruditeState)
 	}
-	eruditeEntropy /= float64(len(eruditeStates))
-	
-	expectedFlow := elderEntropy - mentorEntropy - eruditeEntropy
-	return math.Abs(expectedFlow)
+	eruditeEntropy := average(eruditeEntropies)
+
+	expectedFlow := autodiff.Sub(autodiff.Sub(elderEntropy, mentorEntropy), eruditeEntropy)
+	return autodiff.Abs(expectedFlow)
 }
 
-func (cll *CrossLevelLoss) computeHierarchyIntegrityLoss(elderState []float64, mentorStates, eruditeStates [][]float64) float64 {
-	loss := 0.0
-	
-	elderNorm := cll.computeNorm(elderState)
-	
-	for _, mentorState := range mentorStates {
-		mentorNorm := cll.computeNorm(mentorState)
-		if mentorNorm > elderNorm {
-			loss += (mentorNorm - elderNorm) * (mentorNorm - elderNorm)
-		}
+// computeHierarchyIntegrityLoss penalises, quadratically, any mentor whose
+// norm exceeds the elder's and any erudite whose norm exceeds the mentors'
+// average.
+func (cll *CrossLevelLoss) computeHierarchyIntegrityLoss(elderState *autodiff.Variable, mentorStates, eruditeStates []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, 0, len(mentorStates)+len(eruditeStates))
+
+	elderNorm := autodiff.Norm(elderState)
+	mentorNorms := make([]*autodiff.Variable, len(mentorStates))
+	for i, mentorState := range mentorStates {
+		mentorNorms[i] = autodiff.Norm(mentorState)
+		terms = append(terms, autodiff.Square(autodiff.ReLU(autodiff.Sub(mentorNorms[i], elderNorm))))
 	}
-	
-	for _, eruditeState := range eruditeStates {
-		eruditeNorm := cll.computeNorm(eruditeState)
-		avgMentorNorm := 0.0
-		for _, mentorState := range mentorStates {
-			avgMentorNorm += cll.computeNorm(mentorState)
-		}
-		avgMentorNorm /= float64(len(mentorStates))
-		
-		if eruditeNorm > avgMentorNorm {
-			loss += (eruditeNorm - avgMentorNorm) * (eruditeNorm - avgMentorNorm)
+
+	if len(eruditeStates) > 0 {
+		avgMentorNorm := average(mentorNorms)
+		for _, eruditeState := range eruditeStates {
+			eruditeNorm := autodiff.Norm(eruditeState)
+			terms = append(terms, autodiff.Square(autodiff.ReLU(autodiff.Sub(eruditeNorm, avgMentorNorm))))
 		}
 	}
-	
-	return loss
+
+	return total(terms)
 }
 
-func (cll *CrossLevelLoss) computeCausalConsistencyLoss(elderState []float64, mentorStates, eruditeStates [][]float64) float64 {
-	loss := 0.0
-	
+// computeCausalConsistencyLoss compares the elder's influence on each mentor
+// with that mentor's total influence on its erudites, assigned round-robin,
+// and penalises ratios outside [0.5, 2].
+func (cll *CrossLevelLoss) computeCausalConsistencyLoss(elderState *autodiff.Variable, mentorStates, eruditeStates []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, 0, len(mentorStates))
+
 	for i, mentorState := range mentorStates {
 		elderInfluence := cll.computeInfluence(elderState, mentorState)
-		
-		mentorInfluenceSum := 0.0
+
+		influences := make([]*autodiff.Variable, 0)
 		for j, eruditeState := range eruditeStates {
 			if j%len(mentorStates) == i {
-				mentorInfluence := cll.computeInfluence(mentorState, eruditeState)
-				mentorInfluenceSum += mentorInfluence
+				influences = append(influences, cll.computeInfluence(mentorState, eruditeState))
 			}
 		}
-		
-		influenceRatio := mentorInfluenceSum / elderInfluence
-		if influenceRatio < 0.5 || influenceRatio > 2.0 {
-			loss += math.Abs(influenceRatio - 1.0)
+
+		influenceRatio := autodiff.Div(total(influences), elderInfluence)
+		if ratio := influenceRatio.Scalar(); ratio < 0.5 || ratio > 2.0 {
+			terms = append(terms, autodiff.Abs(autodiff.Shift(influenceRatio, -1.0)))
 		}
 	}
-	
-	return loss / float64(len(mentorStates))
-}
 
-func (cll *CrossLevelLoss) computeTemporalCoherenceLoss(elderState []float64, mentorStates, eruditeStates [][]float64) float64 {
-	loss := 0.0
-	
-	elderVariance := cll.computeVariance(elderState)
-	
-	mentorVarianceSum := 0.0
-	for _, mentorState := range mentorStates {
-		mentorVarianceSum += cll.computeVariance(mentorState)
-	}
-	avgMentorVariance := mentorVarianceSum / float64(len(mentorStates))
-	
-	eruditeVarianceSum := 0.0
-	for _, eruditeState := range eruditeStates {
-		eruditeVarianceSum += cll.computeVariance(eruditeState)
-	}
-	avgEruditeVariance := eruditeVarianceSum / float64(len(eruditeStates))
-	
-	varianceRatio1 := avgMentorVariance / elderVariance
-	varianceRatio2 := avgEruditeVariance / avgMentorVariance
-	
-	loss += math.Abs(varianceRatio1 - 1.0) + math.Abs(varianceRatio2 - 1.0)
-	
-	return loss
+	return autodiff.Scale(total(terms), 1/float64(len(mentorStates)))
 }
 
-func (cll *CrossLevelLoss) computeEntropy(state []float64) float64 {
-	entropy := 0.0
-	for _, val := range state {
-		if val > 0 {
-			entropy -= val * math.Log2(val)
-		}
+func (cll *CrossLevelLoss) computeTemporalCoherenceLoss(elderState *autodiff.Variable, mentorStates, eruditeStates []*autodiff.Variable) *autodiff.Variable {
+	elderVariance := variance(elderState)
+
+	mentorVariances := make([]*autodiff.Variable, len(mentorStates))
+	for i, mentorState := range mentorStates {
+		mentorVariances[i] = variance(mentorState)
 	}
-	return entropy
-}
+	avgMentorVariance := average(mentorVariances)
 
-func (cll *CrossLevelLoss) computeNorm(state []float64) float64 {
-	norm := 0.0
-	for _, val := range state {
-		norm += val * val
+	eruditeVariances := make([]*autodiff.Variable, len(eruditeStates))
+	for i, eruditeState := range eruditeStates {
+		eruditeVariances[i] = variance(eruditeState)
 	}
-	return math.Sqrt(norm)
+	avgEruditeVariance := average(eruditeVariances)
+
+	varianceRatio1 := autodiff.Div(avgMentorVariance, elderVariance)
+	varianceRatio2 := autodiff.Div(avgEruditeVariance, avgMentorVariance)
+
+	return autodiff.Add(
+		autodiff.Abs(autodiff.Shift(varianceRatio1, -1.0)),
+		autodiff.Abs(autodiff.Shift(varianceRatio2, -1.0)),
+	)
 }
 
-func (cll *CrossLevelLoss) computeInfluence(source, target []float64) float64 {
-	influence := 0.0
-	minLen := len(source)
-	if len(target) < minLen {
-		minLen = len(target)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		influence += source[i] * target[i]
-	}
-	
-	return math.Abs(influence)
+// computeEntropy is the Shannon entropy in bits of the positive elements.
+func (cll *CrossLevelLoss) computeEntropy(state *autodiff.Variable) *autodiff.Variable {
+	return autodiff.Scale(autodiff.Entropy(state), 1/math.Ln2)
 }
 
-func (cll *CrossLevelLoss) computeVariance(state []float64) float64 {
-	mean := 0.0
-	for _, val := range state {
-		mean += val
-	}
-	mean /= float64(len(state))
-	
-	variance := 0.0
-	for _, val := range state {
-		diff := val - mean
-		variance += diff * diff
-	}
-	
-	return variance / float64(len(state))
+func (cll *CrossLevelLoss) computeInfluence(source, target *autodiff.Variable) *autodiff.Variable {
+	source, target = autodiff.Truncate(source, target)
+	return autodiff.Abs(autodiff.Dot(source, target))
 }
@@ -1,13 +1,17 @@
 package hierarchical
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
 
 type ElderMentorLoss struct {
-	CoordinationWeight  float64
-	AlignmentWeight     float64
-	EfficiencyWeight    float64
-	StabilityWeight     float64
-	HierarchyLevels     int
+	CoordinationWeight float64
+	AlignmentWeight    float64
+	EfficiencyWeight   float64
+	StabilityWeight    float64
+	HierarchyLevels    int
 }
 
 func NewElderMentorLoss(levels int) *ElderMentorLoss {
@@ -20,92 +24,56 @@ func NewElderMentorLoss(levels int) *ElderMentorLoss {
 	}
 }
 
-func (eml *ElderMentorLoss) ComputeLoss(elderState, mentorStates [][]float64) float64 {
+func (eml *ElderMentorLoss) ComputeLoss(elderState []float64, mentorStates [][]float64) float64 {
+	return eml.Graph(autodiff.Constant(elderState), constants(mentorStates)).Scalar()
+}
+
+// Graph records the loss on the tape of its inputs.
+func (eml *ElderMentorLoss) Graph(elderState *autodiff.Variable, mentorStates []*autodiff.Variable) *autodiff.Variable {
 	coordinationLoss := eml.computeCoordinationLoss(elderState, mentorStates)
-	alignmentLoss := eml.computeAlignmentLoss(elderState, mentorStates)
+	alignmentLoss := eml.computeAlignmentLoss(mentorStates)
 	efficiencyLoss := eml.computeEfficiencyLoss(mentorStates)
 	stabilityLoss := eml.computeStabilityLoss(elderState)
-	
-	totalLoss := eml.CoordinationWeight*coordinationLoss +
-		eml.AlignmentWeight*alignmentLoss +
-		eml.EfficiencyWeight*efficiencyLoss +
-		eml.StabilityWeight*stabilityLoss
-	
-	return totalLoss
-}
 
-func (eml *ElderMentorLoss) computeCoordinationLoss(elderState []float64, mentorStates [][]float64) float64 {
-	loss := 0.0
-	
-	for _, mentorState := range mentorStates {
-		deviation := eml.computeDeviation(elderState, mentorState)
-		loss += deviation * deviation
-	}
-	
-	return loss / float64(len(mentorStates))
+	return weighted(
+		[]float64{eml.CoordinationWeight, eml.AlignmentWeight, eml.EfficiencyWeight, eml.StabilityWeight},
+		coordinationLoss, alignmentLoss, efficiencyLoss, stabilityLoss,
+	)
 }
 
-func (eml *ElderMentorLoss) computeAlignmentLoss(elderState []float64, mentorStates [][]float64) float64 {
-	loss := 0.0
-	
+func (eml *ElderMentorLoss) computeCoordinationLoss(elderState *autodiff.Variable, mentorStates []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, len(mentorStates))
 	for i, mentorState := range mentorStates {
-		for j := i + 1; j < len(mentorStates); j++ {
-			otherMentor := mentorStates[j]
-			misalignment := eml.computeDeviation(mentorState, otherMentor)
-			loss += misalignment
-		}
-	}
-	
-	if len(mentorStates) > 1 {
-		pairs := len(mentorStates) * (len(mentorStates) - 1) / 2
-		loss /= float64(pairs)
+		terms[i] = autodiff.Square(deviation(elderState, mentorState))
 	}
-	
-	return loss
+	return average(terms)
 }
 
-func (eml *ElderMentorLoss) computeEfficiencyLoss(mentorStates [][]float64) float64 {
-	loss := 0.0
-	
-	for _, mentorState := range mentorStates {
-		energy := 0.0
-		for _, val := range mentorState {
-			energy += val * val
+// computeAlignmentLoss is the mean distance between every pair of mentors.
+func (eml *ElderMentorLoss) computeAlignmentLoss(mentorStates []*autodiff.Variable) *autodiff.Variable {
+	if len(mentorStates) < 2 {
+		return autodiff.ConstantScalar(0)
+	}
+
+	terms := make([]*autodiff.Variable, 0, len(mentorStates)*(len(mentorStates)-1)/2)
+	for i, mentorState := range mentorStates {
+		for _, otherMentor := range mentorStates[i+1:] {
+			terms = append(terms, deviation(mentorState, otherMentor))
 		}
-		loss += energy
 	}
-	
-	return loss / float64(len(mentorStates))
+	return average(terms)
 }
 
-func (eml *ElderMentorLoss) computeStabilityLoss(elderState []float64) float64 {
-	variance := 0.0
-	mean := 0.0
-	
-	for _, val := range elderState {
-		mean += val
-	}
-	mean /= float64(len(elderState))
-	
-	for _, val := range elderState {
-		diff := val - mean
-		variance += diff * diff
+func (eml *ElderMentorLoss) computeEfficiencyLoss(mentorStates []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, len(mentorStates))
+	for i, mentorState := range mentorStates {
+		terms[i] = autodiff.Sum(autodiff.Square(mentorState))
 	}
-	
-	return math.Sqrt(variance / float64(len(elderState)))
+	return average(terms)
 }
 
-func (eml *ElderMentorLoss) computeDeviation(state1, state2 []float64) float64 {
-	deviation := 0.0
-	minLen := len(state1)
-	if len(state2) < minLen {
-		minLen = len(state2)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		diff := state1[i] - state2[i]
-		deviation += diff * diff
-	}
-	
-	return math.Sqrt(deviation)
+// computeStabilityLoss is the standard deviation of the elder state.
+func (eml *ElderMentorLoss) computeStabilityLoss(elderState *autodiff.Variable) *autodiff.Variable {
+	spread := autodiff.Norm(autodiff.Sub(elderState, autodiff.Mean(elderState)))
+	return autodiff.Scale(spread, 1/math.Sqrt(float64(elderState.Len())))
 }
@@ -0,0 +1,73 @@
+package hierarchical
+
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
+
+// Helpers shared by the Graph forms of the hierarchical losses.
+
+func constants(states [][]float64) []*autodiff.Variable {
+	variables := make([]*autodiff.Variable, len(states))
+	for i, state := range states {
+		variables[i] = autodiff.Constant(state)
+	}
+	return variables
+}
+
+// average is the mean of scalar terms; an empty list gives NaN, like the
+// float division it replaces.
+func average(terms []*autodiff.Variable) *autodiff.Variable {
+	if len(terms) == 0 {
+		return autodiff.ConstantScalar(math.NaN())
+	}
+	return autodiff.Mean(autodiff.Concat(terms...))
+}
+
+func total(terms []*autodiff.Variable) *autodiff.Variable {
+	if len(terms) == 0 {
+		return autodiff.ConstantScalar(0)
+	}
+	return autodiff.Sum(autodiff.Concat(terms...))
+}
+
+// weighted is Σ weights[i]*terms[i].
+func weighted(weights []float64, terms ...*autodiff.Variable) *autodiff.Variable {
+	scaled := make([]*autodiff.Variable, len(terms))
+	for i, term := range terms {
+		scaled[i] = autodiff.Scale(term, weights[i])
+	}
+	return total(scaled)
+}
+
+// deviation is the Euclidean distance over the common prefix of a and b.
+func deviation(a, b *autodiff.Variable) *autodiff.Variable {
+	a, b = autodiff.Truncate(a, b)
+	return autodiff.Norm(autodiff.Sub(a, b))
+}
+
+func variance(state *autodiff.Variable) *autodiff.Variable {
+	return autodiff.Mean(autodiff.Square(autodiff.Sub(state, autodiff.Mean(state))))
+}
+
+// centroid averages states element by element over the length of the first.
+func centroid(states []*autodiff.Variable) *autodiff.Variable {
+	dimension := states[0].Len()
+	terms := make([]*autodiff.Variable, len(states))
+	for i, state := range states {
+		switch {
+		case state.Len() > dimension:
+			state = autodiff.Slice(state, 0, dimension)
+		case state.Len() < dimension:
+			state = autodiff.Concat(state, autodiff.Constant(make([]float64, dimension-state.Len())))
+		}
+		terms[i] = state
+	}
+
+	sum := terms[0]
+	for _, term := range terms[1:] {
+		sum = autodiff.Add(sum, term)
+	}
+	return autodiff.Scale(sum, 1/float64(len(states)))
+}
@@ -1,12 +1,12 @@
 package hierarchical
 
-import "math"
+import "github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
 
 type MentorEruditeLoss struct {
-	SupervisionWeight   float64
+	SupervisionWeight    float64
 	SpecializationWeight float64
-	ConvergenceWeight   float64
-	DiversityWeight     float64
+	ConvergenceWeight    float64
+	DiversityWeight      float64
 }
 
 func NewMentorEruditeLoss() *MentorEruditeLoss {
@@ -19,149 +19,84 @@ func NewMentorEruditeLoss() *MentorEruditeLoss {
 }
 
 func (mel *MentorEruditeLoss) ComputeLoss(mentorState []float64, eruditeStates [][]float64, targets [][]float64) float64 {
+	return mel.Graph(autodiff.Constant(mentorState), constants(eruditeStates), constants(targets)).Scalar()
+}
+
+// Graph records the loss on the tape of its inputs. Targets are usually
+// constants.
+func (mel *MentorEruditeLoss) Graph(mentorState *autodiff.Variable, eruditeStates, targets []*autodiff.Variable) *autodiff.Variable {
 	supervisionLoss := mel.computeSupervisionLoss(mentorState, eruditeStates)
 	specializationLoss := mel.computeSpecializationLoss(eruditeStates, targets)
 	convergenceLoss := mel.computeConvergenceLoss(eruditeStates)
 	diversityLoss := mel.computeDiversityLoss(eruditeStates)
-	
-	totalLoss := mel.SupervisionWeight*supervisionLoss +
-		mel.SpecializationWeight*specializationLoss +
-		mel.ConvergenceWeight*convergenceLoss +
-		mel.DiversityWeight*diversityLoss
-	
-	return totalLoss
+
+	return weighted(
+		[]float64{mel.SupervisionWeight, mel.SpecializationWeight, mel.ConvergenceWeight, mel.DiversityWeight},
+		supervisionLoss, specializationLoss, convergenceLoss, diversityLoss,
+	)
 }
 
-func (mel *MentorEruditeLoss) computeSupervisionLoss(mentorState []float64, eruditeStates [][]float64) float64 {
-	loss := 0.0
-	
-	for _, eruditeState := range eruditeStates {
-		deviation := mel.computeStateDeviation(mentorState, eruditeState)
-		loss += deviation
+func (mel *MentorEruditeLoss) computeSupervisionLoss(mentorState *autodiff.Variable, eruditeStates []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, len(eruditeStates))
+	for i, eruditeState := range eruditeStates {
+		terms[i] = deviation(mentorState, eruditeState)
 	}
-	
-	return loss / float64(len(eruditeStates))
+	return average(terms)
 }
 
-func (mel *MentorEruditeLoss) computeSpecializationLoss(eruditeStates, targets [][]float64) float64 {
-	loss := 0.0
-	
+// computeSpecializationLoss averages the task loss over all erudites; those
+// without a target contribute nothing.
+func (mel *MentorEruditeLoss) computeSpecializationLoss(eruditeStates, targets []*autodiff.Variable) *autodiff.Variable {
+	terms := make([]*autodiff.Variable, 0, len(eruditeStates))
 	for i, eruditeState := range eruditeStates {
 		if i < len(targets) {
-			target := targets[i]
-			taskLoss := mel.computeTaskLoss(eruditeState, target)
-			loss += taskLoss
+			terms = append(terms, mel.computeTaskLoss(eruditeState, targets[i]))
 		}
 	}
-	
-	return loss / float64(len(eruditeStates))
+	return autodiff.Scale(total(terms), 1/float64(len(eruditeStates)))
 }
 
-func (mel *MentorEruditeLoss) computeTaskLoss(prediction, target []float64) float64 {
-	loss := 0.0
-	minLen := len(prediction)
-	if len(target) < minLen {
-		minLen = len(target)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		diff := prediction[i] - target[i]
-		loss += diff * diff
-	}
-	
-	return loss / float64(minLen)
+func (mel *MentorEruditeLoss) computeTaskLoss(prediction, target *autodiff.Variable) *autodiff.Variable {
+	prediction, target = autodiff.Truncate(prediction, target)
+	return autodiff.Mean(autodiff.Square(autodiff.Sub(prediction, target)))
 }
 
-func (mel *MentorEruditeLoss) computeConvergenceLoss(eruditeStates [][]float64) float64 {
+func (mel *MentorEruditeLoss) computeConvergenceLoss(eruditeStates []*autodiff.Variable) *autodiff.Variable {
 	if len(eruditeStates) < 2 {
-		return 0.0
+		return autodiff.ConstantScalar(0)
 	}
-	
-	centroid := mel.computeCentroid(eruditeStates)
-	loss := 0.0
-	
-	for _, state := range eruditeStates {
-		deviation := mel.computeStateDeviation(state, centroid)
-		loss += deviation * deviation
+
+	center := centroid(eruditeStates)
+	terms := make([]*autodiff.Variable, len(eruditeStates))
+	for i, state := range eruditeStates {
+		terms[i] = autodiff.Square(deviation(state, center))
 	}
-	
-	return loss / float64(len(eruditeStates))
+	return average(terms)
 }
 
-func (mel *MentorEruditeLoss) computeDiversityLoss(eruditeStates [][]float64) float64 {
+// computeDiversityLoss penalises an average pairwise cosine similarity above
+// one half.
+func (mel *MentorEruditeLoss) computeDiversityLoss(eruditeStates []*autodiff.Variable) *autodiff.Variable {
 	if len(eruditeStates) < 2 {
-		return 0.0
+		return autodiff.ConstantScalar(0)
 	}
-	
-	diversitySum := 0.0
-	pairs := 0
-	
-	for i := 0; i < len(eruditeStates); i++ {
-		for j := i + 1; j < len(eruditeStates); j++ {
-			similarity := mel.computeSimilarity(eruditeStates[i], eruditeStates[j])
-			diversitySum += similarity
-			pairs++
-		}
-	}
-	
-	averageSimilarity := diversitySum / float64(pairs)
-	return math.Max(0, averageSimilarity-0.5)
-}
 
-func (mel *MentorEruditeLoss) computeCentroid(states [][]float64) []float64 {
-	if len(states) == 0 {
-		return []float64{}
-	}
-	
-	dimension := len(states[0])
-	centroid := make([]float64, dimension)
-	
-	for _, state := range states {
-		for i := 0; i < dimension && i < len(state); i++ {
-			centroid[i] += state[i]
+	similarities := make([]*autodiff.Variable, 0, len(eruditeStates)*(len(eruditeStates)-1)/2)
+	for i, state := range eruditeStates {
+		for _, other := range eruditeStates[i+1:] {
+			similarities = append(similarities, mel.computeSimilarity(state, other))
 		}
 	}
-	
-	for i := range centroid {
-		centroid[i] /= float64(len(states))
-	}
-	
-	return centroid
+	return autodiff.ReLU(autodiff.Shift(average(similarities), -0.5))
 }
 
-func (mel *MentorEruditeLoss) computeStateDeviation(state1, state2 []float64) float64 {
-	deviation := 0.0
-	minLen := len(state1)
-	if len(state2) < minLen {
-		minLen = len(state2)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		diff := state1[i] - state2[i]
-		deviation += diff * diff
-	}
-	
-	return math.Sqrt(deviation)
-}
-
-func (mel *MentorEruditeLoss) computeSimilarity(state1, state2 []float64) float64 {
-	dotProduct := 0.0
-	norm1 := 0.0
-	norm2 := 0.0
-	minLen := len(state1)
-	if len(state2) < minLen {
-		minLen = len(state2)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		dotProduct += state1[i] * state2[i]
-		norm1 += state1[i] * state1[i]
-		norm2 += state2[i] * state2[i]
-	}
-	
-	if norm1 == 0 || norm2 == 0 {
-		return 0.0
-	}
-	
-	return dotProduct / (math.Sqrt(norm1) * math.Sqrt(norm2))
+// computeSimilarity is the cosine similarity over the common prefix, or zero
+// when either state vanishes there.
+func (mel *MentorEruditeLoss) computeSimilarity(state1, state2 *autodiff.Variable) *autodiff.Variable {
+	state1, state2 = autodiff.Truncate(state1, state2)
+	norm1, norm2 := autodiff.Norm(state1), autodiff.Norm(state2)
+	if norm1.Scalar() == 0 || norm2.Scalar() == 0 {
+		return autodiff.ConstantScalar(0)
+	}
+	return autodiff.Div(autodiff.Dot(state1, state2), autodiff.Mul(norm1, norm2))
 }
@@ -1,12 +1,16 @@
 package optimization
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
 
 type ConvergenceLoss struct {
-	TargetRate     float64
+	TargetRate      float64
 	ToleranceWindow float64
-	HistoryLength  int
-	History        []float64
+	HistoryLength   int
+	History         []float64
 }
 
 func NewConvergenceLoss(targetRate, tolerance float64, historyLen int) *ConvergenceLoss {
@@ -19,88 +23,68 @@ func NewConvergenceLoss(targetRate, tolerance float64, historyLen int) *Converge
 }
 
 func (cl *ConvergenceLoss) ComputeLoss(currentValue float64, gradient []float64) float64 {
-	cl.updateHistory(currentValue)
-	
-	convergenceRateLoss := cl.computeConvergenceRateLoss()
-	stabilityLoss := cl.computeStabilityLoss()
+	return cl.Graph(autodiff.ConstantScalar(currentValue), autodiff.Constant(gradient)).Scalar()
+}
+
+// Graph appends the current value to the history and records the loss on
+// the tape of its inputs. Earlier history entries are constants.
+func (cl *ConvergenceLoss) Graph(currentValue, gradient *autodiff.Variable) *autodiff.Variable {
+	cl.updateHistory(currentValue.Scalar())
+
+	convergenceRateLoss := cl.computeConvergenceRateLoss(currentValue)
+	stabilityLoss := cl.computeStabilityLoss(currentValue)
 	gradientLoss := cl.computeGradientLoss(gradient)
-	
-	return convergenceRateLoss + stabilityLoss + gradientLoss
+
+	return autodiff.Add(autodiff.Add(convergenceRateLoss, stabilityLoss), gradientLoss)
 }
 
 func (cl *ConvergenceLoss) updateHistory(value float64) {
 	cl.History = append(cl.History, value)
-	
+
 	if len(cl.History) > cl.HistoryLength {
 		cl.History = cl.History[1:]
 	}
 }
 
-func (cl *ConvergenceLoss) computeConvergenceRateLoss() float64 {
+func (cl *ConvergenceLoss) computeConvergenceRateLoss(recent *autodiff.Variable) *autodiff.Variable {
 	if len(cl.History) < 3 {
-		return 0.0
+		return autodiff.ConstantScalar(0)
 	}
-	
+
 	n := len(cl.History)
-	recent := cl.History[n-1]
 	previous := cl.History[n-2]
 	earlier := cl.History[n-3]
-	
-	actualRate := math.Abs(recent-previous) / math.Abs(previous-earlier)
-	rateDifference := math.Abs(actualRate - cl.TargetRate)
-	
-	return rateDifference * rateDifference
+
+	step := autodiff.Abs(autodiff.Shift(recent, -previous))
+	actualRate := autodiff.Scale(step, 1/math.Abs(previous-earlier))
+	return autodiff.Square(autodiff.Shift(actualRate, -cl.TargetRate))
 }
 
-func (cl *ConvergenceLoss) computeStabilityLoss() float64 {
+// computeStabilityLoss penalises history variance beyond the tolerance
+// window.
+func (cl *ConvergenceLoss) computeStabilityLoss(recent *autodiff.Variable) *autodiff.Variable {
 	if len(cl.History) < 2 {
-		return 0.0
+		return autodiff.ConstantScalar(0)
 	}
-	
-	variance := 0.0
-	mean := 0.0
-	
-	for _, val := range cl.History {
-		mean += val
-	}
-	mean /= float64(len(cl.History))
-	
-	for _, val := range cl.History {
-		diff := val - mean
-		variance += diff * diff
-	}
-	variance /= float64(len(cl.History))
-	
-	if variance > cl.ToleranceWindow*cl.ToleranceWindow {
-		return variance - cl.ToleranceWindow*cl.ToleranceWindow
-	}
-	
-	return 0.0
+
+	history := autodiff.Concat(autodiff.Constant(cl.History[:len(cl.History)-1]), recent)
+	variance := autodiff.Mean(autodiff.Square(autodiff.Sub(history, autodiff.Mean(history))))
+	return autodiff.ReLU(autodiff.Shift(variance, -cl.ToleranceWindow*cl.ToleranceWindow))
 }
 
-func (cl *ConvergenceLoss) computeGradientLoss(gradient []float64) float64 {
-	gradientNorm := 0.0
-	for _, g := range gradient {
-		gradientNorm += g * g
-	}
-	gradientNorm = math.Sqrt(gradientNorm)
-	
-	if gradientNorm > 1.0 {
-		return (gradientNorm - 1.0) * (gradientNorm - 1.0)
-	}
-	
-	return 0.0
+func (cl *ConvergenceLoss) computeGradientLoss(gradient *autodiff.Variable) *autodiff.Variable {
+	return autodiff.Square(autodiff.ReLU(autodiff.Shift(autodiff.Norm(gradient), -1.0)))
 }
 
 func (cl *ConvergenceLoss) IsConverged() bool {
 	if len(cl.History) < 2 {
 		return false
 	}
-	
+
 	n := len(cl.History)
 	recent := cl.History[n-1]
 	previous := cl.History[n-2]
-	
+
 	return math.Abs(recent-previous) < cl.ToleranceWindow
 }
 
@@ -108,15 +92,15 @@ func (cl *ConvergenceLoss) GetConvergenceRate() float64 {
 	if len(cl.History) < 3 {
 		return 0.0
 	}
-	
+
 	n := len(cl.History)
 	recent := cl.History[n-1]
 	previous := cl.History[n-2]
 	earlier := cl.History[n-3]
-	
+
 	if math.Abs(previous-earlier) < 1e-12 {
 		return 0.0
 	}
-	
+
 	return math.Abs(recent-previous) / math.Abs(previous-earlier)
 }
@@ -1,12 +1,20 @@
 package optimization
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/autodiff"
+)
 
 type StabilityLoss struct {
 	LyapunovThreshold float64
 	EnergyBound       float64
 	PhaseSpace        [][]float64
 	EnergyHistory     []float64
+
+	// Components holds the terms of the last loss computed. It is not
+	// serialized, as it is recomputed with the next loss.
+	Components map[string]float64 `json:"-"`
 }
 
 func NewStabilityLoss(lyapunovThreshold, energyBound float64) *StabilityLoss {
@@ -19,25 +27,33 @@ func NewStabilityLoss(lyapunovThreshold, energyBound float64) *StabilityLoss {
 }
 
 func (sl *StabilityLoss) ComputeLoss(state, velocity []float64) float64 {
-	sl.updatePhaseSpace(state, velocity)
-	
-	lyapunovLoss := sl.computeLyapunovLoss()
-	energyLoss := sl.computeEnergyLoss()
-	phaseLoss := sl.computePhaseStabilityLoss()
-	
-	return lyapunovLoss + energyLoss + phaseLoss
-}
-
-func (sl *StabilityLoss) updatePhaseSpace(state, velocity []float64) {
-	phasePoint := make([]float64, len(state)+len(velocity))
-	copy(phasePoint[:len(state)], state)
-	copy(phasePoint[len(state):], velocity)
-	
-	sl.PhaseSpace = append(sl.PhaseSpace, phasePoint)
-	
+	return sl.Graph(autodiff.Constant(state), autodiff.Constant(velocity)).Scalar()
+}
+
+// Graph appends the current phase point to the history and records the loss
+// on the tape of its inputs. Earlier phase points are constants, so the
+// gradient is with respect to the current state and velocity only.
+func (sl *StabilityLoss) Graph(state, velocity *autodiff.Variable) *autodiff.Variable {
+	current := autodiff.Concat(state, velocity)
 	energy := sl.computeEnergy(state, velocity)
+	sl.updatePhaseSpace(current.Value.Values(), energy.Scalar())
+
+	lyapunovLoss := sl.computeLyapunovLoss(current)
+	energyLoss := sl.computeEnergyLoss(energy)
+	phaseLoss := sl.computePhaseStabilityLoss(current)
+
+	sl.Components = map[string]float64{
+		"lyapunov": lyapunovLoss.Scalar(),
+		"energy":   energyLoss.Scalar(),
+		"phase":    phaseLoss.Scalar(),
+	}
+	return autodiff.Add(autodiff.Add(lyapunovLoss, energyLoss), phaseLoss)
+}
+
+func (sl *StabilityLoss) updatePhaseSpace(phasePoint []float64, energy float64) {
+	sl.PhaseSpace = append(sl.PhaseSpace, append([]float64{}, phasePoint...))
 	sl.EnergyHistory = append(sl.EnergyHistory, energy)
-	
+
 	maxHistory := 1000
 	if len(sl.PhaseSpace) > maxHistory {
 		sl.PhaseSpace = sl.PhaseSpace[1:]
@@ -47,145 +63,130 @@ func (sl *StabilityLoss) updatePhaseSpace(state, velocity []float64) {
 	}
 }
 
-func (sl *StabilityLoss) computeLyapunovLoss() float64 {
+func (sl *StabilityLoss) computeLyapunovLoss(current *autodiff.Variable) *autodiff.Variable {
 	if len(sl.PhaseSpace) < 10 {
-		return 0.0
-	}
-	
-	lyapunovExponent := sl.estimateLyapunovExponent()
-	
-	if lyapunovExponent > sl.LyapunovThreshold {
-		excess := lyapunovExponent - sl.LyapunovThreshold
-		return excess * excess
+		return autodiff.ConstantScalar(0)
 	}
-	
-	return 0.0
+
+	lyapunovExponent := sl.estimateLyapunovExponent(current)
+	return autodiff.Square(autodiff.ReLU(autodiff.Shift(lyapunovExponent, -sl.LyapunovThreshold)))
 }
 
-func (sl *StabilityLoss) estimateLyapunovExponent() float64 {
-	if len(sl.PhaseSpace) < 2 {
-		return 0.0
-	}
-	
+// estimateLyapunovExponent averages the log distance between consecutive
+// phase points. Only the last step depends on the current point.
+func (sl *StabilityLoss) estimateLyapunovExponent(current *autodiff.Variable) *autodiff.Variable {
 	n := len(sl.PhaseSpace)
 	divergenceSum := 0.0
 	count := 0
-	
-	for i := 1; i < n; i++ {
+
+	for i := 1; i < n-1; i++ {
 		distance := sl.computeDistance(sl.PhaseSpace[i], sl.PhaseSpace[i-1])
 		if distance > 0 {
 			divergenceSum += math.Log(distance)
 			count++
 		}
 	}
-	
+
+	divergence := autodiff.ConstantScalar(divergenceSum)
+	step := sl.distanceTo(current, sl.PhaseSpace[n-2])
+	if step.Scalar() > 0 {
+		divergence = autodiff.Add(divergence, autodiff.Log(step))
+		count++
+	}
+
 	if count == 0 {
-		return 0.0
+		return autodiff.ConstantScalar(0)
 	}
-	
-	return divergenceSum / float64(count)
+
+	return autodiff.Scale(divergence, 1/float64(count))
 }
 
-func (sl *StabilityLoss) computeEnergyLoss() float64 {
-	if len(sl.EnergyHistory) == 0 {
-		return 0.0
+func (sl *StabilityLoss) computeEnergyLoss(currentEnergy *autodiff.Variable) *autodiff.Variable {
+	if currentEnergy.Scalar() > sl.EnergyBound {
+		return autodiff.Square(autodiff.Shift(currentEnergy, -sl.EnergyBound))
 	}
-	
-	currentEnergy := sl.EnergyHistory[len(sl.EnergyHistory)-1]
-	
-	if currentEnergy > sl.EnergyBound {
-		excess := currentEnergy - sl.EnergyBound
-		return excess * excess
-	}
-	
+
 	if len(sl.EnergyHistory) > 1 {
-		energyVariation := sl.computeEnergyVariation()
-		return energyVariation
+		return sl.computeEnergyVariation(currentEnergy)
 	}
-	
-	return 0.0
+
+	return autodiff.ConstantScalar(0)
 }
 
-func (sl *StabilityLoss) computeEnergyVariation() float64 {
-	if len(sl.EnergyHistory) < 2 {
-		return 0.0
-	}
-	
+// computeEnergyVariation is the mean squared change in energy between
+// consecutive steps.
+func (sl *StabilityLoss) computeEnergyVariation(currentEnergy *autodiff.Variable) *autodiff.Variable {
+	n := len(sl.EnergyHistory)
 	variation := 0.0
-	for i := 1; i < len(sl.EnergyHistory); i++ {
+	for i := 1; i < n-1; i++ {
 		diff := sl.EnergyHistory[i] - sl.EnergyHistory[i-1]
 		variation += diff * diff
 	}
-	
-	return variation / float64(len(sl.EnergyHistory)-1)
+
+	last := autodiff.Square(autodiff.Shift(currentEnergy, -sl.EnergyHistory[n-2]))
+	return autodiff.Scale(autodiff.Shift(last, variation), 1/float64(n-1))
 }
 
-func (sl *StabilityLoss) computePhaseStabilityLoss() float64 {
+// computePhaseStabilityLoss penalises a current point more than twice the
+// attractor radius from the centroid of the history.
+func (sl *StabilityLoss) computePhaseStabilityLoss(current *autodiff.Variable) *autodiff.Variable {
 	if len(sl.PhaseSpace) < 3 {
-		return 0.0
-	}
-	
-	attractorRadius := sl.estimateAttractorRadius()
-	currentRadius := sl.computeCurrentRadius()
-	
-	if currentRadius > attractorRadius*2 {
-		excess := currentRadius - attractorRadius*2
-		return excess * excess
-	}
-	
-	return 0.0
-}
-
-func (sl *StabilityLoss) estimateAttractorRadius() float64 {
-	if len(sl.PhaseSpace) < 2 {
-		return 1.0
-	}
-	
-	centroid := sl.computeCentroid()
-	maxDistance := 0.0
-	
-	for _, point := range sl.PhaseSpace {
-		distance := sl.computeDistance(point, centroid)
-		if distance > maxDistance {
-			maxDistance = distance
-		}
+		return autodiff.ConstantScalar(0)
 	}
-	
-	return maxDistance
-}
 
-func (sl *StabilityLoss) computeCurrentRadius() float64 {
-	if len(sl.PhaseSpace) == 0 {
-		return 0.0
+	centroid := sl.computeCentroid(current)
+	attractorRadius := sl.estimateAttractorRadius(current, centroid)
+	currentRadius := sl.distance(current, centroid)
+
+	if currentRadius.Scalar() > attractorRadius.Scalar()*2 {
+		excess := autodiff.Sub(currentRadius, autodiff.Scale(attractorRadius, 2))
+		return autodiff.Square(excess)
 	}
-	
-	centroid := sl.computeCentroid()
-	current := sl.PhaseSpace[len(sl.PhaseSpace)-1]
-	
-	return sl.computeDistance(current, centroid)
+
+	return autodiff.ConstantScalar(0)
 }
 
-func (sl *StabilityLoss) computeCentroid() []float64 {
-	if len(sl.PhaseSpace) == 0 {
-		return []float64{}
+// estimateAttractorRadius is the largest distance of any phase point from
+// the centroid.
+func (sl *StabilityLoss) estimateAttractorRadius(current, centroid *autodiff.Variable) *autodiff.Variable {
+	n := len(sl.PhaseSpace)
+	farthest := n - 1
+	maxDistance := sl.computeDistance(sl.PhaseSpace[n-1], centroid.Value.Values())
+	for i, point := range sl.PhaseSpace[:n-1] {
+		if distance := sl.computeDistance(point, centroid.Value.Values()); distance > maxDistance {
+			farthest, maxDistance = i, distance
+		}
 	}
-	
+
+	if farthest == n-1 {
+		return sl.distance(current, centroid)
+	}
+	return sl.distanceTo(centroid, sl.PhaseSpace[farthest])
+}
+
+// computeCentroid averages the phase space, with the current point as its
+// last entry.
+func (sl *StabilityLoss) computeCentroid(current *autodiff.Variable) *autodiff.Variable {
+	n := len(sl.PhaseSpace)
 	dimension := len(sl.PhaseSpace[0])
-	centroid := make([]float64, dimension)
-	
-	for _, point := range sl.PhaseSpace {
+	sum := make([]float64, dimension)
+
+	for _, point := range sl.PhaseSpace[:n-1] {
 		for i, val := range point {
-			if i < len(centroid) {
-				centroid[i] += val
+			if i < len(sum) {
+				sum[i] += val
 			}
 		}
 	}
-	
-	for i := range centroid {
-		centroid[i] /= float64(len(sl.PhaseSpace))
+
+	switch {
+	case current.Len() > dimension:
+		current = autodiff.Slice(current, 0, dimension)
+	case current.Len() < dimension:
+		current = autodiff.Concat(current, autodiff.Constant(make([]float64, dimension-current.Len())))
 	}
-	
-	return centroid
+
+	return autodiff.Scale(autodiff.Add(autodiff.Constant(sum), current), 1/float64(n))
 }
 
 func (sl *StabilityLoss) computeDistance(point1, point2 []float64) float64 {
@@ -194,28 +195,27 @@ func (sl *StabilityLoss) computeDistance(point1, point2 []float64) float64 {
 	if len(point2) < minLen {
 		minLen = len(point2)
 	}
-	
+
 	for i := 0; i < minLen; i++ {
 		diff := point1[i] - point2[i]
 		distance += diff * diff
 	}
-	
+
 	return math.Sqrt(distance)
 }
 
-func (sl *StabilityLoss) computeEnergy(state, velocity []float64) float64 {
-	kinetic := 0.0
-	potential := 0.0
-	
-	for _, v := range velocity {
-		kinetic += v * v
-	}
-	kinetic *= 0.5
-	
-	for _, s := range state {
-		potential += s * s
-	}
-	potential *= 0.5
-	
-	return kinetic + potential
+// distance is computeDistance recorded on the tape.
+func (sl *StabilityLoss) distance(point1, point2 *autodiff.Variable) *autodiff.Variable {
+	point1, point2 = autodiff.Truncate(point1, point2)
+	return autodiff.Norm(autodiff.Sub(point1, point2))
+}
+
+func (sl *StabilityLoss) distanceTo(point *autodiff.Variable, fixed []float64) *autodiff.Variable {
+	return sl.distance(point, autodiff.Constant(fixed))
+}
+
+func (sl *StabilityLoss) computeEnergy(state, velocity *autodiff.Variable) *autodiff.Variable {
+	kinetic := autodiff.Scale(autodiff.Sum(autodiff.Square(velocity)), 0.5)
+	potential := autodiff.Scale(autodiff.Sum(autodiff.Square(state)), 0.5)
+	return autodiff.Add(kinetic, potential)
 }
@@ -0,0 +1,88 @@
+package autodiff
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+func TestGradientsMatchFiniteDifferences(t *testing.T) {
+	bias := []float64{0.1, -0.2}
+	weights := []float64{0.5, -1, 0.25, 2, 1.5, -0.5}
+	loss := func(tape *Tape, x []float64) (*Variable, *Variable) {
+		input := tape.Variable(x, 2, 3)
+		w := Constant(weights, 3, 2)
+		hidden := Tanh(Add(MatMul(input, w), Constant(bias)))
+		probabilities := Softmax(hidden)
+		return input, Add(Mean(Square(Sub(probabilities, ConstantScalar(0.5)))), Entropy(probabilities))
+	}
+
+	x := []float64{0.3, -0.7, 1.1, 0.2, 0.9, -0.4}
+	tape := NewTape()
+	input, value := loss(tape, x)
+	if err := value.Backward(); err != nil {
+		t.Fatal(err)
+	}
+
+	numeric := NumericalGradient(func(x []float64) float64 {
+		_, value := loss(NewTape(), x)
+		return value.Scalar()
+	}, x, 1e-6)
+	if worst := GradientError(input.Grad.Values(), numeric); worst > 1e-8 {
+		t.Errorf("gradient differs from finite differences by %g: %v, want %v", worst, input.Grad, numeric)
+	}
+}
+
+func TestShapeErrorsPropagate(t *testing.T) {
+	tape := NewTape()
+	a := tape.Variable([]float64{1, 2, 3})
+	b := Constant([]float64{1, 2}, 2)
+
+	for _, tc := range []struct {
+		name string
+		out  *Variable
+	}{
+		{"add", Sum(Add(a, b))},
+		{"matmul", Sum(MatMul(a, Constant([]float64{1, 2}, 2, 1)))},
+		{"reshape", Sum(Reshape(a, 2, 2))},
+		{"slice", Sum(Slice(a, 2, 5))},
+		{"variable", Sum(Mul(a, Constant([]float64{1, 2, 3}, 2, 2)))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var shapeError *tensor.ShapeError
+			if err := tc.out.Backward(); !errors.As(err, &shapeError) {
+				t.Errorf("Backward error = %v, want a ShapeError", err)
+			}
+			if !errors.Is(tc.out.Err(), tc.out.Backward()) || !math.IsNaN(tc.out.Scalar()) {
+				t.Errorf("a failed variable should report its error and a NaN value, got %v and %g", tc.out.Err(), tc.out.Scalar())
+			}
+		})
+	}
+}
+
+func TestBroadcastGradientsSumRepeats(t *testing.T) {
+	tape := NewTape()
+	column := tape.Variable([]float64{1, 2, 3}, 3, 1)
+	row := tape.Variable([]float64{10, 20}, 1, 2)
+	product := Mul(column, row)
+	if got := product.Shape(); len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Fatalf("product shape = %v, want [3 2]", got)
+	}
+	if err := Sum(product).Backward(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		got, want []float64
+	}{
+		{"column", column.Grad.Values(), []float64{30, 30, 30}},
+		{"row", row.Grad.Values(), []float64{6, 6}},
+	} {
+		if GradientError(tc.got, tc.want) != 0 {
+			t.Errorf("gradient of %s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
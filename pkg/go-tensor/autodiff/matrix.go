@@ -0,0 +1,190 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// rowsCols views a as a matrix: vectors are a single row. It reports false
+// for a of higher rank.
+func rowsCols(a *Variable) (int, int, bool) {
+	shape := a.Shape()
+	switch len(shape) {
+	case 0:
+		return 1, 1, true
+	case 1:
+		return 1, shape[0], true
+	case 2:
+		return shape[0], shape[1], true
+	}
+	return 0, 0, false
+}
+
+// matrix views a as an m×n matrix.
+func matrix(a *Variable) *tensor.Tensor[float64] {
+	m, n, _ := rowsCols(a)
+	view, _ := a.Value.Reshape(m, n)
+	return view
+}
+
+// MatMul is the matrix product of an m×k matrix a and a k×n matrix b, as in
+// tensor.MatMul. A vector a is treated as a single row, giving a vector of
+// length n.
+func MatMul(a, b *Variable) *Variable {
+	if out := propagate(a, b); out != nil {
+		return out
+	}
+	if _, _, ok := rowsCols(a); !ok || b.Value.Rank() != 2 {
+		return failed("matmul", "cannot be multiplied", a.Shape(), b.Shape())
+	}
+	product, err := tensor.MatMul(matrix(a), b.Value)
+	if err != nil {
+		return withError(err)
+	}
+
+	shape := product.Shape()
+	if a.Value.Rank() < 2 {
+		shape = shape[1:]
+	}
+	out := result(product.Values(), shape, a, b)
+	out.backward = func() {
+		// dL/da = dL/dout bᵀ and dL/db = aᵀ dL/dout.
+		g, _ := out.Grad.Reshape(product.Shape()...)
+		if a.tape != nil {
+			bt, _ := b.Value.Transpose()
+			da, _ := tensor.MatMul(g, bt)
+			a.accumulateTensor(da)
+		}
+		if b.tape != nil {
+			at, _ := matrix(a).Transpose()
+			db, _ := tensor.MatMul(at, g)
+			b.accumulateTensor(db)
+		}
+	}
+	return out
+}
+
+// Transpose swaps the rows and columns of a matrix; a vector becomes a
+// column.
+func Transpose(a *Variable) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	if _, _, ok := rowsCols(a); !ok {
+		return failed("transpose", "is not a vector or matrix", a.Shape())
+	}
+	transposed, _ := matrix(a).Transpose()
+
+	out := result(transposed.Values(), transposed.Shape(), a)
+	out.backward = func() {
+		g, _ := out.Grad.Transpose()
+		a.accumulateTensor(g)
+	}
+	return out
+}
+
+// Reshape returns a with a new shape of the same size, one dimension of
+// which may be -1 as in tensor.Reshape.
+func Reshape(a *Variable, shape ...int) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	reshaped, err := a.Value.Reshape(shape...)
+	if err != nil {
+		return withError(err)
+	}
+
+	out := result(reshaped.Values(), reshaped.Shape(), a)
+	out.backward = func() {
+		for i, g := range out.grad {
+			a.accumulate(i, g)
+		}
+	}
+	return out
+}
+
+// Softmax normalises the last axis into probabilities: every element of a
+// vector, or each row of a matrix.
+func Softmax(a *Variable) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	m, n, ok := rowsCols(a)
+	if !ok {
+		return failed("softmax", "is not a vector or matrix", a.Shape())
+	}
+	values := make([]float64, len(a.value))
+	for i := 0; i < m; i++ {
+		row := a.value[i*n : (i+1)*n]
+		peak := math.Inf(-1)
+		for _, x := range row {
+			peak = math.Max(peak, x)
+		}
+		total := 0.0
+		for j, x := range row {
+			values[i*n+j] = math.Exp(x - peak)
+			total += values[i*n+j]
+		}
+		for j := range row {
+			values[i*n+j] /= total
+		}
+	}
+
+	out := result(values, a.Shape(), a)
+	out.backward = func() {
+		for i := 0; i < m; i++ {
+			y := values[i*n : (i+1)*n]
+			g := out.grad[i*n : (i+1)*n]
+			inner := 0.0
+			for j := range y {
+				inner += g[j] * y[j]
+			}
+			for j := range y {
+				a.accumulate(i*n+j, y[j]*(g[j]-inner))
+			}
+		}
+	}
+	return out
+}
+
+// Concat joins variables end to end into a vector.
+func Concat(parts ...*Variable) *Variable {
+	if out := propagate(parts...); out != nil {
+		return out
+	}
+	values := make([]float64, 0)
+	for _, part := range parts {
+		values = append(values, part.value...)
+	}
+
+	out := result(values, []int{len(values)}, parts...)
+	out.backward = func() {
+		offset := 0
+		for _, part := range parts {
+			for i := range part.value {
+				part.accumulate(i, out.grad[offset+i])
+			}
+			offset += len(part.value)
+		}
+	}
+	return out
+}
+
+// Slice returns elements [start, end) of a as a vector.
+func Slice(a *Variable, start, end int) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	if start < 0 || end > len(a.value) || start > end {
+		return failed("slice", fmt.Sprintf("cannot slice [%d:%d]", start, end), a.Shape())
+	}
+	out := result(append([]float64{}, a.value[start:end]...), []int{end - start}, a)
+	out.backward = func() {
+		for i, g := range out.grad {
+			a.accumulate(start+i, g)
+		}
+	}
+	return out
+}
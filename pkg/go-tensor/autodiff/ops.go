@@ -0,0 +1,268 @@
+package autodiff
+
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// broadcastIndex is, for each element of a broadcast to shape, the index
+// of the element of a it repeats.
+func broadcastIndex(a *Variable, shape []int) []int {
+	view, _ := a.Value.BroadcastTo(shape...)
+	strides := view.Strides()
+	index := make([]int, 0, view.Size())
+	position := make([]int, len(shape))
+	offset := 0
+	for range view.Size() {
+		index = append(index, offset)
+		for axis := len(shape) - 1; axis >= 0; axis-- {
+			position[axis]++
+			offset += strides[axis]
+			if position[axis] < shape[axis] {
+				break
+			}
+			offset -= position[axis] * strides[axis]
+			position[axis] = 0
+		}
+	}
+	return index
+}
+
+// binary applies f elementwise to a and b broadcast to a common shape, as
+// tensor.Zip does. dx and dy are the partial derivatives of f given the
+// inputs x, y and the output z; the gradient of a repeated element is the
+// sum over its repetitions.
+func binary(op string, a, b *Variable, f func(x, y float64) float64, dx, dy func(x, y, z float64) float64) *Variable {
+	if out := propagate(a, b); out != nil {
+		return out
+	}
+	shape, err := tensor.BroadcastShapes(a.Shape(), b.Shape())
+	if err != nil {
+		return failed(op, "do not broadcast together", a.Shape(), b.Shape())
+	}
+	ia, ib := broadcastIndex(a, shape), broadcastIndex(b, shape)
+	values := make([]float64, len(ia))
+	for i := range values {
+		values[i] = f(a.value[ia[i]], b.value[ib[i]])
+	}
+
+	out := result(values, shape, a, b)
+	out.backward = func() {
+		for i, g := range out.grad {
+			x, y := a.value[ia[i]], b.value[ib[i]]
+			a.accumulate(ia[i], g*dx(x, y, values[i]))
+			b.accumulate(ib[i], g*dy(x, y, values[i]))
+		}
+	}
+	return out
+}
+
+// unary applies f elementwise; df is its derivative given the input x and
+// output y.
+func unary(a *Variable, f func(x float64) float64, df func(x, y float64) float64) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	values := make([]float64, len(a.value))
+	for i, x := range a.value {
+		values[i] = f(x)
+	}
+
+	out := result(values, a.Shape(), a)
+	out.backward = func() {
+		for i, g := range out.grad {
+			a.accumulate(i, g*df(a.value[i], values[i]))
+		}
+	}
+	return out
+}
+
+func Add(a, b *Variable) *Variable {
+	return binary("add", a, b,
+		func(x, y float64) float64 { return x + y },
+		func(x, y, z float64) float64 { return 1 },
+		func(x, y, z float64) float64 { return 1 })
+}
+
+func Sub(a, b *Variable) *Variable {
+	return binary("sub", a, b,
+		func(x, y float64) float64 { return x - y },
+		func(x, y, z float64) float64 { return 1 },
+		func(x, y, z float64) float64 { return -1 })
+}
+
+// Mul is the elementwise (Hadamard) product.
+func Mul(a, b *Variable) *Variable {
+	return binary("mul", a, b,
+		func(x, y float64) float64 { return x * y },
+		func(x, y, z float64) float64 { return y },
+		func(x, y, z float64) float64 { return x })
+}
+
+func Div(a, b *Variable) *Variable {
+	return binary("div", a, b,
+		func(x, y float64) float64 { return x / y },
+		func(x, y, z float64) float64 { return 1 / y },
+		func(x, y, z float64) float64 { return -z / y })
+}
+
+// Scale multiplies every element by a constant.
+func Scale(a *Variable, c float64) *Variable {
+	return unary(a,
+		func(x float64) float64 { return c * x },
+		func(x, y float64) float64 { return c })
+}
+
+// Shift adds a constant to every element.
+func Shift(a *Variable, c float64) *Variable {
+	return unary(a,
+		func(x float64) float64 { return x + c },
+		func(x, y float64) float64 { return 1 })
+}
+
+func Neg(a *Variable) *Variable {
+	return Scale(a, -1)
+}
+
+func Exp(a *Variable) *Variable {
+	return unary(a, math.Exp, func(x, y float64) float64 { return y })
+}
+
+func Log(a *Variable) *Variable {
+	return unary(a, math.Log, func(x, y float64) float64 { return 1 / x })
+}
+
+func Sqrt(a *Variable) *Variable {
+	return unary(a, math.Sqrt, func(x, y float64) float64 { return 0.5 / y })
+}
+
+func Square(a *Variable) *Variable {
+	return unary(a,
+		func(x float64) float64 { return x * x },
+		func(x, y float64) float64 { return 2 * x })
+}
+
+// Abs uses a subgradient of zero at the origin.
+func Abs(a *Variable) *Variable {
+	return unary(a, math.Abs, func(x, y float64) float64 {
+		switch {
+		case x > 0:
+			return 1
+		case x < 0:
+			return -1
+		}
+		return 0
+	})
+}
+
+func Tanh(a *Variable) *Variable {
+	return unary(a, math.Tanh, func(x, y float64) float64 { return 1 - y*y })
+}
+
+func Sigmoid(a *Variable) *Variable {
+	return unary(a,
+		func(x float64) float64 { return 1 / (1 + math.Exp(-x)) },
+		func(x, y float64) float64 { return y * (1 - y) })
+}
+
+// ReLU is max(0, x), with a subgradient of zero at the origin.
+func ReLU(a *Variable) *Variable {
+	return unary(a,
+		func(x float64) float64 { return math.Max(0, x) },
+		func(x, y float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		})
+}
+
+// Sum adds every element into a scalar.
+func Sum(a *Variable) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	total := 0.0
+	for _, x := range a.value {
+		total += x
+	}
+
+	out := result([]float64{total}, []int{}, a)
+	out.backward = func() {
+		for i := range a.value {
+			a.accumulate(i, out.grad[0])
+		}
+	}
+	return out
+}
+
+// Mean averages every element into a scalar.
+func Mean(a *Variable) *Variable {
+	return Scale(Sum(a), 1/float64(len(a.value)))
+}
+
+// Dot is the sum of the elementwise product of a and b.
+func Dot(a, b *Variable) *Variable {
+	return Sum(Mul(a, b))
+}
+
+// Norm is the Euclidean norm of all elements. Its gradient at the origin is
+// taken to be zero.
+func Norm(a *Variable) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	total := 0.0
+	for _, x := range a.value {
+		total += x * x
+	}
+	norm := math.Sqrt(total)
+
+	out := result([]float64{norm}, []int{}, a)
+	out.backward = func() {
+		if norm == 0 {
+			return
+		}
+		for i, x := range a.value {
+			a.accumulate(i, out.grad[0]*x/norm)
+		}
+	}
+	return out
+}
+
+// Entropy is -Σ x ln x over the positive elements of a; other elements
+// contribute nothing.
+func Entropy(a *Variable) *Variable {
+	if out := propagate(a); out != nil {
+		return out
+	}
+	total := 0.0
+	for _, x := range a.value {
+		if x > 0 {
+			total -= x * math.Log(x)
+		}
+	}
+
+	out := result([]float64{total}, []int{}, a)
+	out.backward = func() {
+		for i, x := range a.value {
+			if x > 0 {
+				a.accumulate(i, -out.grad[0]*(math.Log(x)+1))
+			}
+		}
+	}
+	return out
+}
+
+// Truncate shortens the longer of two vectors to the length of the other, as
+// the loss functions do when comparing states of different sizes.
+func Truncate(a, b *Variable) (*Variable, *Variable) {
+	switch {
+	case a.Len() > b.Len():
+		return Slice(a, 0, b.Len()), b
+	case b.Len() > a.Len():
+		return a, Slice(b, 0, a.Len())
+	}
+	return a, b
+}
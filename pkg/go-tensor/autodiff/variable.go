@@ -0,0 +1,234 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// Tape records every operation on its variables in evaluation order, so
+// Backward can replay them in reverse. A tape is not safe for concurrent use.
+type Tape struct {
+	leaves []*Variable
+	nodes  []*Variable
+}
+
+// Variable is a tensor of float64 values that may take part in
+// differentiation. Value is contiguous and its shape is empty for scalars,
+// [n] for vectors and [rows, cols] for matrices. Grad has the same shape and
+// holds d(output)/d(Value) after Backward; it is nil for constants.
+//
+// Operations do not return errors, so that losses can be written as
+// expressions. An operation on shapes it cannot work with instead returns a
+// variable holding a *tensor.ShapeError and no values; every operation on it
+// passes the error on, and Err and Backward report it.
+type Variable struct {
+	Value    *tensor.Tensor[float64]
+	Grad     *tensor.Tensor[float64]
+	value    []float64 // the buffer of Value
+	grad     []float64 // the buffer of Grad
+	tape     *Tape
+	backward func()
+	err      error
+}
+
+func NewTape() *Tape {
+	return &Tape{
+		leaves: make([]*Variable, 0),
+		nodes:  make([]*Variable, 0),
+	}
+}
+
+// Variable creates a leaf whose gradient is computed by Backward. Without a
+// shape the values form a vector.
+func (t *Tape) Variable(values []float64, shape ...int) *Variable {
+	v := newVariable(values, shape)
+	if v.err != nil {
+		return v
+	}
+	v.track(t)
+	t.leaves = append(t.leaves, v)
+	return v
+}
+
+// Scalar creates a scalar leaf.
+func (t *Tape) Scalar(value float64) *Variable {
+	return t.Variable([]float64{value}, []int{}...)
+}
+
+// Reset forgets every recorded operation but keeps the leaves, so the same
+// parameters can be reused for the next forward pass.
+func (t *Tape) Reset() {
+	t.nodes = t.nodes[:0]
+}
+
+// Len is the number of recorded operations.
+func (t *Tape) Len() int {
+	return len(t.nodes)
+}
+
+// Constant wraps values that are not differentiated. Operations on constants
+// alone are evaluated without recording.
+func Constant(values []float64, shape ...int) *Variable {
+	return newVariable(values, shape)
+}
+
+func ConstantScalar(value float64) *Variable {
+	return newVariable([]float64{value}, []int{})
+}
+
+func newVariable(values []float64, shape []int) *Variable {
+	t, err := tensor.FromSlice(values, shape...)
+	if err != nil {
+		return withError(err)
+	}
+	return &Variable{Value: t, value: values}
+}
+
+// withError is a variable holding err in place of values.
+func withError(err error) *Variable {
+	return &Variable{Value: tensor.Zeros[float64](0), err: err}
+}
+
+// failed is a variable holding a shape error in place of values.
+func failed(op, reason string, shapes ...[]int) *Variable {
+	return withError(&tensor.ShapeError{Op: op, Shapes: shapes, Reason: reason})
+}
+
+// propagate is a variable holding the first error among inputs, or nil if
+// they have none.
+func propagate(inputs ...*Variable) *Variable {
+	for _, in := range inputs {
+		if in.err != nil {
+			return withError(in.err)
+		}
+	}
+	return nil
+}
+
+// track records gradients for v on t.
+func (v *Variable) track(t *Tape) {
+	v.tape = t
+	v.grad = make([]float64, len(v.value))
+	v.Grad, _ = tensor.FromSlice(v.grad, v.Value.Shape()...)
+}
+
+func (v *Variable) Shape() []int {
+	return v.Value.Shape()
+}
+
+// Len is the number of elements.
+func (v *Variable) Len() int {
+	return len(v.value)
+}
+
+// Scalar returns the value of a single-element variable, or NaN for any
+// other.
+func (v *Variable) Scalar() float64 {
+	if len(v.value) != 1 {
+		return math.NaN()
+	}
+	return v.value[0]
+}
+
+// Err is the error of the operation that produced v or of one of its
+// inputs.
+func (v *Variable) Err() error {
+	return v.err
+}
+
+// Tracked reports whether gradients flow to v.
+func (v *Variable) Tracked() bool {
+	return v.tape != nil
+}
+
+// Backward computes the gradient of the scalar v with respect to every
+// variable recorded on its tape, overwriting any previous gradients.
+func (v *Variable) Backward() error {
+	if v.err != nil {
+		return v.err
+	}
+	if len(v.value) != 1 {
+		return &tensor.ShapeError{Op: "backward", Shapes: [][]int{v.Shape()}, Reason: "is not a single value"}
+	}
+	if v.tape == nil {
+		return fmt.Errorf("backward called on a constant")
+	}
+
+	t := v.tape
+	for _, leaf := range t.leaves {
+		clear(leaf.grad)
+	}
+	for _, node := range t.nodes {
+		clear(node.grad)
+	}
+
+	v.grad[0] = 1
+	for i := len(t.nodes) - 1; i >= 0; i-- {
+		if node := t.nodes[i]; node.backward != nil {
+			node.backward()
+		}
+	}
+	return nil
+}
+
+// result creates the output of an operation on inputs. It is recorded on the
+// tape of the first tracked input; if there is none the output is a constant
+// and backward is never called.
+func result(values []float64, shape []int, inputs ...*Variable) *Variable {
+	value, _ := tensor.FromSlice(values, shape...)
+	out := &Variable{Value: value, value: values}
+	for _, in := range inputs {
+		if in.tape != nil {
+			out.track(in.tape)
+			in.tape.nodes = append(in.tape.nodes, out)
+			break
+		}
+	}
+	return out
+}
+
+// accumulate adds delta to the gradient of v when v is tracked.
+func (v *Variable) accumulate(i int, delta float64) {
+	if v.tape != nil {
+		v.grad[i] += delta
+	}
+}
+
+// accumulateTensor adds delta, which has v's size, to the gradient of v when
+// v is tracked.
+func (v *Variable) accumulateTensor(delta *tensor.Tensor[float64]) {
+	if v.tape != nil {
+		for i, d := range delta.Values() {
+			v.grad[i] += d
+		}
+	}
+}
+
+// NumericalGradient estimates the gradient of f at x with central
+// differences. It exists to cross-check Backward.
+func NumericalGradient(f func(x []float64) float64, x []float64, h float64) []float64 {
+	gradient := make([]float64, len(x))
+	probe := append([]float64{}, x...)
+	for i := range x {
+		probe[i] = x[i] + h
+		upper := f(probe)
+		probe[i] = x[i] - h
+		lower := f(probe)
+		probe[i] = x[i]
+		gradient[i] = (upper - lower) / (2 * h)
+	}
+	return gradient
+}
+
+// GradientError is the largest difference between two gradients, relative to
+// their magnitude where that exceeds one.
+func GradientError(analytic, numeric []float64) float64 {
+	worst := 0.0
+	for i := range analytic {
+		scale := math.Max(1, math.Max(math.Abs(analytic[i]), math.Abs(numeric[i])))
+		worst = math.Max(worst, math.Abs(analytic[i]-numeric[i])/scale)
+	}
+	return worst
+}
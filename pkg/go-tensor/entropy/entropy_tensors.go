@@ -1,31 +1,29 @@
 package entropy
 
-import "math"
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type EntropyTensor struct {
-	Dimensions []int
-	Data       []float64
-	Rank       int
-	Shape      []int
+	Data *tensor.Tensor[float64]
 }
 
 func NewEntropyTensor(dimensions []int) *EntropyTensor {
-	size := 1
-	for _, dim := range dimensions {
-		size *= dim
-	}
-	
 	return &EntropyTensor{
-		Dimensions: make([]int, len(dimensions)),
-		Data:       make([]float64, size),
-		Rank:       len(dimensions),
-		Shape:      make([]int, len(dimensions)),
+		Data: tensor.Zeros[float64](dimensions...),
 	}
 }
 
 func (et *EntropyTensor) ComputeEntropy() float64 {
+	return shannonEntropy(et.Data)
+}
+
+func shannonEntropy(data *tensor.Tensor[float64]) float64 {
+	values := data.Values()
 	total := 0.0
-	for _, val := range et.Data {
+	for _, val := range values {
 		total += val
 	}
 	
@@ -34,7 +32,7 @@ func (et *EntropyTensor) ComputeEntropy() float64 {
 	}
 	
 	entropy := 0.0
-	for _, val := range et.Data {
+	for _, val := range values {
 		if val > 0 {
 			prob := val / total
 			entropy -= prob * math.Log2(prob)
@@ -44,92 +42,79 @@ func (et *EntropyTensor) ComputeEntropy() float64 {
 	return entropy
 }
 
-func (et *EntropyTensor) ComputeConditionalEntropy(conditioningTensor *EntropyTensor) float64 {
-	jointEntropy := et.computeJointEntropy(conditioningTensor)
+func (et *EntropyTensor) ComputeConditionalEntropy(conditioningTensor *EntropyTensor) (float64, error) {
+	jointEntropy, err := et.computeJointEntropy(conditioningTensor)
+	if err != nil {
+		return 0, err
+	}
 	conditioningEntropy := conditioningTensor.ComputeEntropy()
 	
-	return jointEntropy - conditioningEntropy
+	return jointEntropy - conditioningEntropy, nil
 }
 
-func (et *EntropyTensor) computeJointEntropy(other *EntropyTensor) float64 {
-	minSize := len(et.Data)
-	if len(other.Data) < minSize {
-		minSize = len(other.Data)
-	}
-	
-	jointData := make([]float64, minSize)
-	total := 0.0
-	
-	for i := 0; i < minSize; i++ {
-		jointData[i] = et.Data[i] * other.Data[i]
-		total += jointData[i]
-	}
-	
-	if total == 0 {
-		return 0.0
+// computeJointEntropy is the entropy of the elementwise product of the two
+// tensors, broadcast to a common shape.
+func (et *EntropyTensor) computeJointEntropy(other *EntropyTensor) (float64, error) {
+	jointData, err := tensor.Mul(et.Data, other.Data)
+	if err != nil {
+		return 0, err
 	}
 	
-	entropy := 0.0
-	for _, val := range jointData {
-		if val > 0 {
-			prob := val / total
-			entropy -= prob * math.Log2(prob)
-		}
-	}
-	
-	return entropy
+	return shannonEntropy(jointData), nil
 }
 
-func (et *EntropyTensor) ComputeMutualInformation(other *EntropyTensor) float64 {
+func (et *EntropyTensor) ComputeMutualInformation(other *EntropyTensor) (float64, error) {
 	entropyA := et.ComputeEntropy()
 	entropyB := other.ComputeEntropy()
-	jointEntropy := et.computeJointEntropy(other)
+	jointEntropy, err := et.computeJointEntropy(other)
+	if err != nil {
+		return 0, err
+	}
 	
-	return entropyA + entropyB - jointEntropy
+	return entropyA + entropyB - jointEntropy, nil
 }
 
-func (et *EntropyTensor) ComputeKLDivergence(reference *EntropyTensor) float64 {
-	minSize := len(et.Data)
-	if len(reference.Data) < minSize {
-		minSize = len(reference.Data)
+// ComputeKLDivergence compares et with reference, which is broadcast to
+// et's shape.
+func (et *EntropyTensor) ComputeKLDivergence(reference *EntropyTensor) (float64, error) {
+	broadcast, err := reference.Data.BroadcastTo(et.Data.Shape()...)
+	if err != nil {
+		return 0, err
 	}
 	
+	pValues := et.Data.Values()
+	qValues := broadcast.Values()
 	totalP := 0.0
 	totalQ := 0.0
 	
-	for i := 0; i < minSize; i++ {
-		totalP += et.Data[i]
-		totalQ += reference.Data[i]
+	for i := range pValues {
+		totalP += pValues[i]
+		totalQ += qValues[i]
 	}
 	
 	if totalP == 0 || totalQ == 0 {
-		return math.Inf(1)
+		return math.Inf(1), nil
 	}
 	
 	kl := 0.0
-	for i := 0; i < minSize; i++ {
-		p := et.Data[i] / totalP
-		q := reference.Data[i] / totalQ
+	for i := range pValues {
+		p := pValues[i] / totalP
+		q := qValues[i] / totalQ
 		
 		if p > 0 && q > 0 {
 			kl += p * math.Log2(p/q)
 		} else if p > 0 && q == 0 {
-			return math.Inf(1)
+			return math.Inf(1), nil
 		}
 	}
 	
-	return kl
+	return kl, nil
 }
 
 func (et *EntropyTensor) Normalize() {
-	total := 0.0
-	for _, val := range et.Data {
-		total += val
-	}
+	total := tensor.Sum(et.Data)
 	
 	if total > 0 {
-		for i := range et.Data {
-			et.Data[i] /= total
-		}
+		et.Data.CopyFrom(tensor.Scale(et.Data, 1/total))
 	}
 }
@@ -18,7 +18,7 @@ func NewInformationTensor(dimensions []int, capacity float64) *InformationTensor
 
 func (it *InformationTensor) ComputeInformationContent() float64 {
 	entropy := it.Tensor.ComputeEntropy()
-	maxEntropy := math.Log2(float64(len(it.Tensor.Data)))
+	maxEntropy := math.Log2(float64(it.Tensor.Data.Size()))
 	
 	if maxEntropy > 0 {
 		it.Efficiency = entropy / maxEntropy
@@ -39,7 +39,7 @@ func (it *InformationTensor) ComputeChannelCapacity() float64 {
 }
 
 func (it *InformationTensor) ComputeCompressionRatio() float64 {
-	originalSize := float64(len(it.Tensor.Data))
+	originalSize := float64(it.Tensor.Data.Size())
 	informationContent := it.ComputeInformationContent()
 	
 	if informationContent > 0 {
@@ -57,17 +57,18 @@ func (it *InformationTensor) EstimateComplexity() float64 {
 }
 
 func (it *InformationTensor) computeVariance() float64 {
+	values := it.Tensor.Data.Values()
 	mean := 0.0
-	for _, val := range it.Tensor.Data {
+	for _, val := range values {
 		mean += val
 	}
-	mean /= float64(len(it.Tensor.Data))
+	mean /= float64(len(values))
 	
 	variance := 0.0
-	for _, val := range it.Tensor.Data {
+	for _, val := range values {
 		diff := val - mean
 		variance += diff * diff
 	}
 	
-	return variance / float64(len(it.Tensor.Data))
+	return variance / float64(len(values))
 }
@@ -1,24 +1,209 @@
 package gravitational
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// MetricFunc is a metric g_ab as a function of the coordinates.
+type MetricFunc func(coordinates []float64) *tensor.Tensor[float64]
+
+// GravitationalTensor holds the metric g_ab, the Christoffel symbols
+// Γ^a_bc, the Riemann tensor R^a_bcd, the Ricci tensor R_bd = R^a_bad, the
+// scalar curvature R = g^bd R_bd and the Einstein tensor
+// G_ab = R_ab - R g_ab / 2 of a space of the given dimension at a point.
 type GravitationalTensor struct {
-	MetricTensor   [][]float64
-	RiemannTensor  [][][][]float64
-	EinsteinTensor [][]float64
-	Dimension      int
+	MetricTensor       *tensor.Tensor[float64]
+	ChristoffelSymbols *tensor.Tensor[float64]
+	RiemannTensor      *tensor.Tensor[float64]
+	RicciTensor        *tensor.Tensor[float64]
+	EinsteinTensor     *tensor.Tensor[float64]
+	ScalarCurvature    float64
+	Dimension          int
+	// Step is the finite difference step used by Evaluate, relative to the
+	// size of the coordinate it varies once that exceeds one.
+	Step float64
 }
 
 func NewGravitationalTensor(dim int) *GravitationalTensor {
 	return &GravitationalTensor{
-		MetricTensor:   make([][]float64, dim),
-		EinsteinTensor: make([][]float64, dim),
-		Dimension:      dim,
+		MetricTensor:       tensor.Zeros[float64](dim, dim),
+		ChristoffelSymbols: tensor.Zeros[float64](dim, dim, dim),
+		RiemannTensor:      tensor.Zeros[float64](dim, dim, dim, dim),
+		RicciTensor:        tensor.Zeros[float64](dim, dim),
+		EinsteinTensor:     tensor.Zeros[float64](dim, dim),
+		Dimension:          dim,
+		Step:               1e-4,
+	}
+}
+
+// Evaluate computes the curvature of metric at point. Derivatives of the
+// metric are taken by central differences, and the Riemann tensor
+// differentiates the Christoffel symbols the same way, so its error is of
+// order Step² plus rounding error over Step².
+func (gt *GravitationalTensor) Evaluate(metric MetricFunc, point []float64) error {
+	if len(point) != gt.Dimension {
+		return fmt.Errorf("gravitational: point has %d coordinates in a space of dimension %d", len(point), gt.Dimension)
+	}
+
+	g, err := gt.metricAt(metric, point)
+	if err != nil {
+		return err
+	}
+	christoffel, err := gt.christoffel(metric, point)
+	if err != nil {
+		return err
+	}
+	derivative, err := gt.derivative(func(x []float64) (*tensor.Tensor[float64], error) {
+		return gt.christoffel(metric, x)
+	}, point)
+	if err != nil {
+		return err
+	}
+
+	// R^a_bcd = ∂_c Γ^a_db - ∂_d Γ^a_cb + Γ^a_ce Γ^e_db - Γ^a_de Γ^e_cb, where
+	// derivative holds ∂_c Γ^a_db at [c, a, d, b].
+	n := gt.Dimension
+	riemann := tensor.Zeros[float64](n, n, n, n)
+	for _, term := range []struct {
+		spec     string
+		operands []*tensor.Tensor[float64]
+		sign     float64
+	}{
+		{"cadb->abcd", []*tensor.Tensor[float64]{derivative}, 1},
+		{"dacb->abcd", []*tensor.Tensor[float64]{derivative}, -1},
+		{"ace,edb->abcd", []*tensor.Tensor[float64]{christoffel, christoffel}, 1},
+		{"ade,ecb->abcd", []*tensor.Tensor[float64]{christoffel, christoffel}, -1},
+	} {
+		value, err := tensor.Einsum(term.spec, term.operands...)
+		if err != nil {
+			return err
+		}
+		if riemann, err = tensor.Add(riemann, tensor.Scale(value, term.sign)); err != nil {
+			return err
+		}
+	}
+
+	gt.MetricTensor = g
+	gt.ChristoffelSymbols = christoffel
+	gt.RiemannTensor = riemann
+	if gt.RicciTensor, err = gt.ComputeCurvature(); err != nil {
+		return err
+	}
+
+	inverse, err := linalg.Inverse(g)
+	if err != nil {
+		return err
+	}
+	scalar, err := tensor.Einsum("bd,bd->", inverse, gt.RicciTensor)
+	if err != nil {
+		return err
+	}
+	gt.ScalarCurvature, _ = scalar.Item()
+
+	gt.EinsteinTensor, err = tensor.Sub(gt.RicciTensor, tensor.Scale(g, gt.ScalarCurvature/2))
+	return err
+}
+
+// ComputeCurvature is the Ricci tensor R_bd = R^a_bad contracted from the
+// Riemann tensor.
+func (gt *GravitationalTensor) ComputeCurvature() (*tensor.Tensor[float64], error) {
+	return tensor.Einsum("abad->bd", gt.RiemannTensor)
+}
+
+// LowerIndex is v_a = g_ab v^b.
+func (gt *GravitationalTensor) LowerIndex(vector *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Einsum("ab,b->a", gt.MetricTensor, vector)
+}
+
+// Interval is g_ab u^a v^b.
+func (gt *GravitationalTensor) Interval(u, v *tensor.Tensor[float64]) (float64, error) {
+	interval, err := tensor.Einsum("ab,a,b->", gt.MetricTensor, u, v)
+	if err != nil {
+		return 0, err
 	}
+	return interval.Item()
 }
 
-func (gt *GravitationalTensor) ComputeCurvature() [][]float64 {
-	curvature := make([][]float64, gt.Dimension)
-	for i := range curvature {
-		curvature[i] = make([]float64, gt.Dimension)
+func (gt *GravitationalTensor) metricAt(metric MetricFunc, point []float64) (*tensor.Tensor[float64], error) {
+	g := metric(point)
+	if g == nil || g.Rank() != 2 || g.Dim(0) != gt.Dimension || g.Dim(1) != gt.Dimension {
+		var shape []int
+		if g != nil {
+			shape = g.Shape()
+		}
+		return nil, &tensor.ShapeError{Op: "metric", Shapes: [][]int{shape}, Reason: fmt.Sprintf("is not %d×%d", gt.Dimension, gt.Dimension)}
+	}
+	return g, nil
+}
+
+// christoffel is Γ^a_bc = g^ad (∂_b g_dc + ∂_c g_db - ∂_d g_bc) / 2 at point.
+func (gt *GravitationalTensor) christoffel(metric MetricFunc, point []float64) (*tensor.Tensor[float64], error) {
+	g, err := gt.metricAt(metric, point)
+	if err != nil {
+		return nil, err
+	}
+	inverse, err := linalg.Inverse(g)
+	if err != nil {
+		return nil, err
+	}
+	dg, err := gt.derivative(func(x []float64) (*tensor.Tensor[float64], error) {
+		return gt.metricAt(metric, x)
+	}, point)
+	if err != nil {
+		return nil, err
+	}
+
+	// dg holds ∂_e g_ab at [e, a, b]; arrange each term at [d, b, c].
+	first, _ := dg.Transpose(1, 0, 2)
+	second, _ := dg.Transpose(1, 2, 0)
+	sum, err := tensor.Add(first, second)
+	if err != nil {
+		return nil, err
+	}
+	if sum, err = tensor.Sub(sum, dg); err != nil {
+		return nil, err
+	}
+	christoffel, err := tensor.Einsum("ad,dbc->abc", inverse, sum)
+	if err != nil {
+		return nil, err
+	}
+	return tensor.Scale(christoffel, 0.5), nil
+}
+
+// derivative stacks the partial derivatives of f at point along a new
+// first axis, one per coordinate.
+func (gt *GravitationalTensor) derivative(f func([]float64) (*tensor.Tensor[float64], error), point []float64) (*tensor.Tensor[float64], error) {
+	var result *tensor.Tensor[float64]
+	x := append([]float64{}, point...)
+	for c := range point {
+		h := gt.Step * math.Max(1, math.Abs(point[c]))
+		x[c] = point[c] + h
+		plus, err := f(x)
+		if err != nil {
+			return nil, err
+		}
+		x[c] = point[c] - h
+		minus, err := f(x)
+		if err != nil {
+			return nil, err
+		}
+		x[c] = point[c]
+
+		difference, err := tensor.Sub(plus, minus)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = tensor.Zeros[float64](append([]int{len(point)}, difference.Shape()...)...)
+		}
+		slot, _ := result.Select(0, c)
+		if err := slot.CopyFrom(tensor.Scale(difference, 1/(2*h))); err != nil {
+			return nil, err
+		}
 	}
-	return curvature
+	return result, nil
 }
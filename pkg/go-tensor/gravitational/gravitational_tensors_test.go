@@ -0,0 +1,128 @@
+package gravitational
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// tolerance is the agreement expected from second differences with the
+// default Step.
+const tolerance = 1e-6
+
+func diagonal(values ...float64) *tensor.Tensor[float64] {
+	g := tensor.Zeros[float64](len(values), len(values))
+	for i, value := range values {
+		g.Set(value, i, i)
+	}
+	return g
+}
+
+func evaluate(t *testing.T, dim int, metric MetricFunc, point []float64) *GravitationalTensor {
+	t.Helper()
+	gt := NewGravitationalTensor(dim)
+	if err := gt.Evaluate(metric, point); err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	return gt
+}
+
+func assertZero(t *testing.T, name string, values *tensor.Tensor[float64], limit float64) {
+	t.Helper()
+	for i, value := range values.Values() {
+		if math.Abs(value) > limit {
+			t.Errorf("%s component %d = %g, want 0", name, i, value)
+		}
+	}
+}
+
+func TestFlatMetric(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		metric MetricFunc
+		point  []float64
+	}{
+		{"cartesian", func(x []float64) *tensor.Tensor[float64] { return diagonal(1, 1, 1) }, []float64{1, -2, 3}},
+		{"minkowski", func(x []float64) *tensor.Tensor[float64] { return diagonal(-1, 1, 1, 1) }, []float64{0, 1, 2, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gt := evaluate(t, len(tt.point), tt.metric, tt.point)
+			assertZero(t, "christoffel", gt.ChristoffelSymbols, 0)
+			assertZero(t, "riemann", gt.RiemannTensor, 0)
+			assertZero(t, "ricci", gt.RicciTensor, 0)
+			assertZero(t, "einstein", gt.EinsteinTensor, 0)
+			if gt.ScalarCurvature != 0 {
+				t.Errorf("scalar curvature = %g, want 0", gt.ScalarCurvature)
+			}
+		})
+	}
+
+	// Polar coordinates on the plane have Christoffel symbols but no
+	// curvature.
+	gt := evaluate(t, 2, func(x []float64) *tensor.Tensor[float64] {
+		return diagonal(1, x[0]*x[0])
+	}, []float64{2, 0.3})
+	assertZero(t, "polar riemann", gt.RiemannTensor, tolerance)
+	if got, _ := gt.ChristoffelSymbols.At(0, 1, 1); math.Abs(got+2) > tolerance {
+		t.Errorf("polar Γ^r_θθ = %g, want -2", got)
+	}
+}
+
+func TestSphere(t *testing.T) {
+	for _, radius := range []float64{0.5, 2, 3} {
+		gt := evaluate(t, 2, func(x []float64) *tensor.Tensor[float64] {
+			s := math.Sin(x[0])
+			return diagonal(radius*radius, radius*radius*s*s)
+		}, []float64{1.1, 0.4})
+
+		want := 2 / (radius * radius)
+		if math.Abs(gt.ScalarCurvature-want) > tolerance*want {
+			t.Errorf("radius %g: scalar curvature = %g, want %g", radius, gt.ScalarCurvature, want)
+		}
+
+		// A 2-sphere is maximally symmetric: R_ab = g_ab / r², so its
+		// Einstein tensor vanishes.
+		expected := tensor.Scale(gt.MetricTensor, 1/(radius*radius))
+		difference, _ := tensor.Sub(gt.RicciTensor, expected)
+		assertZero(t, "ricci - g/r²", difference, tolerance)
+		assertZero(t, "einstein", gt.EinsteinTensor, tolerance)
+	}
+}
+
+func TestSchwarzschild(t *testing.T) {
+	const mass = 1.0
+	metric := func(x []float64) *tensor.Tensor[float64] {
+		f := 1 - 2*mass/x[1]
+		s := math.Sin(x[2])
+		return diagonal(-f, 1/f, x[1]*x[1], x[1]*x[1]*s*s)
+	}
+
+	for _, r := range []float64{4, 6, 10} {
+		theta := 1.2
+		gt := evaluate(t, 4, metric, []float64{0, r, theta, 0.5})
+
+		// The vacuum solution is Ricci flat but not Riemann flat.
+		assertZero(t, "ricci", gt.RicciTensor, tolerance)
+		assertZero(t, "einstein", gt.EinsteinTensor, tolerance)
+		if math.Abs(gt.ScalarCurvature) > tolerance {
+			t.Errorf("r %g: scalar curvature = %g, want 0", r, gt.ScalarCurvature)
+		}
+
+		got, _ := gt.RiemannTensor.At(2, 3, 2, 3)
+		want := 2 * mass * math.Pow(math.Sin(theta), 2) / r
+		if math.Abs(got-want) > tolerance {
+			t.Errorf("r %g: R^θ_φθφ = %g, want %g", r, got, want)
+		}
+	}
+}
+
+func TestEvaluateRejectsMismatchedMetric(t *testing.T) {
+	gt := NewGravitationalTensor(3)
+	if err := gt.Evaluate(func(x []float64) *tensor.Tensor[float64] { return diagonal(1, 1) }, []float64{0, 0, 0}); err == nil {
+		t.Error("Evaluate accepted a 2×2 metric in three dimensions")
+	}
+	if err := gt.Evaluate(func(x []float64) *tensor.Tensor[float64] { return diagonal(1, 1, 1) }, []float64{0, 0}); err == nil {
+		t.Error("Evaluate accepted a point with two coordinates in three dimensions")
+	}
+}
@@ -1,22 +1,72 @@
 package heliomorphic
 
+import "github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+
 type HeliomorphicTensor struct {
-	Data        [][][]complex128
-	Shape       []int
-	Rank        int
+	Data        *tensor.Tensor[complex128]
 	Symmetries  []string
 }
 
 func NewHeliomorphicTensor(shape []int) *HeliomorphicTensor {
 	return &HeliomorphicTensor{
-		Shape: shape,
-		Rank:  len(shape),
+		Data:       tensor.Zeros[complex128](shape...),
 		Symmetries: make([]string, 0),
 	}
 }
 
-func (ht *HeliomorphicTensor) Contract(other *HeliomorphicTensor) *HeliomorphicTensor {
-	// Simplified tensor contraction
-	resultShape := []int{ht.Shape[0], other.Shape[1]}
-	return NewHeliomorphicTensor(resultShape)
+func (ht *HeliomorphicTensor) Shape() []int {
+	return ht.Data.Shape()
+}
+
+func (ht *HeliomorphicTensor) Rank() int {
+	return ht.Data.Rank()
+}
+
+// Contract sums over the last axis of ht and the first axis of other.
+func (ht *HeliomorphicTensor) Contract(other *HeliomorphicTensor) (*HeliomorphicTensor, error) {
+	if ht.Rank() == 0 || other.Rank() == 0 {
+		return nil, &tensor.ShapeError{Op: "contract", Shapes: [][]int{ht.Shape(), other.Shape()}, Reason: "need an axis each"}
+	}
+	
+	data, err := tensor.Tensordot(ht.Data, other.Data, ht.Rank()-1, 0)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &HeliomorphicTensor{Data: data, Symmetries: make([]string, 0)}, nil
+}
+
+// einsumLabels subscripts tensors of up to 52 axes.
+const einsumLabels = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// InnerProduct is the Hermitian inner product Σ conj(ht)·other, which is
+// conjugate-linear in ht. Both tensors must have the same shape.
+func (ht *HeliomorphicTensor) InnerProduct(other *HeliomorphicTensor) (complex128, error) {
+	if ht.Rank() != other.Rank() || ht.Rank() > len(einsumLabels) {
+		return 0, &tensor.ShapeError{Op: "inner product", Shapes: [][]int{ht.Shape(), other.Shape()}, Reason: "differ in rank"}
+	}
+	labels := einsumLabels[:ht.Rank()]
+	
+	product, err := tensor.Einsum("*"+labels+","+labels+"->", ht.Data, other.Data)
+	if err != nil {
+		return 0, err
+	}
+	
+	return product.Item()
+}
+
+// Einsum contracts heliomorphic tensors as tensor.Einsum does; an operand
+// marked '*' in spec is conjugated.
+func Einsum(spec string, operands ...*HeliomorphicTensor) (*HeliomorphicTensor, error) {
+	data := make([]*tensor.Tensor[complex128], len(operands))
+	for i, operand := range operands {
+		data[i] = operand.Data
+	}
+	
+	result, err := tensor.Einsum(spec, data...)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &HeliomorphicTensor{Data: result, Symmetries: make([]string, 0)}, nil
 }
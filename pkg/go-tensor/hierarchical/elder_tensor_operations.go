@@ -1,6 +1,11 @@
 package hierarchical
 
-import "math"
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type ElderTensorOperations struct {
 	ElderLevel  int
@@ -13,7 +18,7 @@ type TensorOperation struct {
 	Name        string
 	InputLevels []int
 	OutputLevel int
-	Function    func([][]float64) []float64
+	Function    func([]*tensor.Tensor[float64]) (*tensor.Tensor[float64], error)
 }
 
 func NewElderTensorOperations() *ElderTensorOperations {
@@ -58,99 +63,86 @@ func (eto *ElderTensorOperations) registerOperations() {
 	}
 }
 
-func (eto *ElderTensorOperations) coordinationOperation(inputs [][]float64) []float64 {
+func (eto *ElderTensorOperations) coordinationOperation(inputs []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
 	if len(inputs) < 2 {
-		return []float64{}
+		return nil, fmt.Errorf("hierarchical: coordination needs elder and mentor tensors")
 	}
 	
 	elder := inputs[0]
 	mentor := inputs[1]
 	
-	result := make([]float64, len(mentor))
-	
-	for i := range result {
-		elderInfluence := 0.0
-		if i < len(elder) {
-			elderInfluence = elder[i]
-		}
-		
-		mentorValue := mentor[i]
-		result[i] = mentorValue + elderInfluence*0.3
-	}
-	
-	return result
+	return guide(elder, mentor, 0.3)
 }
 
-func (eto *ElderTensorOperations) supervisionOperation(inputs [][]float64) []float64 {
+func (eto *ElderTensorOperations) supervisionOperation(inputs []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
 	if len(inputs) < 2 {
-		return []float64{}
+		return nil, fmt.Errorf("hierarchical: supervision needs mentor and erudite tensors")
 	}
 	
 	mentor := inputs[0]
 	erudite := inputs[1]
 	
-	result := make([]float64, len(erudite))
-	
-	for i := range result {
-		mentorGuidance := 0.0
-		if i < len(mentor) {
-			mentorGuidance = mentor[i]
-		}
-		
-		eruditeValue := erudite[i]
-		result[i] = eruditeValue + mentorGuidance*0.2
+	return guide(mentor, erudite, 0.2)
+}
+
+// guide adds weight times the guiding tensor, broadcast to the shape of the
+// guided one, to the guided one.
+func guide(guiding, guided *tensor.Tensor[float64], weight float64) (*tensor.Tensor[float64], error) {
+	influence, err := guiding.BroadcastTo(guided.Shape()...)
+	if err != nil {
+		return nil, err
 	}
 	
-	return result
+	return tensor.Add(guided, tensor.Scale(influence, weight))
 }
 
-func (eto *ElderTensorOperations) aggregationOperation(inputs [][]float64) []float64 {
+func (eto *ElderTensorOperations) aggregationOperation(inputs []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
 	if len(inputs) == 0 {
-		return []float64{}
+		return nil, fmt.Errorf("hierarchical: aggregation needs at least one tensor")
 	}
 	
-	dimension := len(inputs[0])
-	result := make([]float64, dimension)
-	
-	for _, eruditeData := range inputs {
-		for i := 0; i < dimension && i < len(eruditeData); i++ {
-			result[i] += eruditeData[i]
-		}
-	}
-	
-	for i := range result {
-		result[i] /= float64(len(inputs))
+	weights := make([]float64, len(inputs))
+	for i := range weights {
+		weights[i] = 1 / float64(len(inputs))
 	}
 	
-	return result
+	return weightedSum(inputs, weights)
 }
 
-func (eto *ElderTensorOperations) synthesisOperation(inputs [][]float64) []float64 {
+func (eto *ElderTensorOperations) synthesisOperation(inputs []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
 	if len(inputs) == 0 {
-		return []float64{}
+		return nil, fmt.Errorf("hierarchical: synthesis needs at least one tensor")
 	}
 	
-	dimension := len(inputs[0])
-	result := make([]float64, dimension)
-	
 	weights := eto.computeAttentionWeights(inputs)
 	
-	for i, mentorData := range inputs {
-		weight := weights[i]
-		for j := 0; j < dimension && j < len(mentorData); j++ {
-			result[j] += weight * mentorData[j]
+	return weightedSum(inputs, weights)
+}
+
+// weightedSum adds up inputs, each broadcast to the first's shape, in
+// proportion to weights.
+func weightedSum(inputs []*tensor.Tensor[float64], weights []float64) (*tensor.Tensor[float64], error) {
+	result := tensor.Zeros[float64](inputs[0].Shape()...)
+	
+	for i, input := range inputs {
+		term, err := input.BroadcastTo(result.Shape()...)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = tensor.Add(result, tensor.Scale(term, weights[i])); err != nil {
+			return nil, err
 		}
 	}
 	
-	return result
+	return result, nil
 }
 
-func (eto *ElderTensorOperations) computeAttentionWeights(inputs [][]float64) []float64 {
+func (eto *ElderTensorOperations) computeAttentionWeights(inputs []*tensor.Tensor[float64]) []float64 {
 	weights := make([]float64, len(inputs))
 	totalWeight := 0.0
 	
 	for i, data := range inputs {
-		entropy := eto.computeEntropy(data)
+		entropy := eto.computeEntropy(data.Values())
 		weights[i] = math.Exp(-entropy)
 		totalWeight += weights[i]
 	}
@@ -187,39 +179,42 @@ func (eto *ElderTensorOperations) computeEntropy(data []float64) float64 {
 	return entropy
 }
 
-func (eto *ElderTensorOperations) ApplyOperation(operationName string, hierarchicalTensor *HierarchicalTensor, targetID string) {
-	if operation, exists := eto.Operations[operationName]; exists {
-		inputs := eto.gatherInputs(operation, hierarchicalTensor, targetID)
-		result := operation.Function(inputs)
-		eto.applyResult(result, hierarchicalTensor, operation.OutputLevel, targetID)
+func (eto *ElderTensorOperations) ApplyOperation(operationName string, hierarchicalTensor *HierarchicalTensor, targetID string) error {
+	operation, exists := eto.Operations[operationName]
+	if !exists {
+		return fmt.Errorf("hierarchical: unknown operation %q", operationName)
 	}
+	
+	inputs, err := eto.gatherInputs(operation, hierarchicalTensor, targetID)
+	if err != nil {
+		return err
+	}
+	result, err := operation.Function(inputs)
+	if err != nil {
+		return fmt.Errorf("hierarchical: %s of %s: %w", operationName, targetID, err)
+	}
+	return eto.applyResult(result, hierarchicalTensor, operation.OutputLevel, targetID)
 }
 
-func (eto *ElderTensorOperations) gatherInputs(operation TensorOperation, ht *HierarchicalTensor, targetID string) [][]float64 {
-	inputs := make([][]float64, 0)
+func (eto *ElderTensorOperations) gatherInputs(operation TensorOperation, ht *HierarchicalTensor, targetID string) ([]*tensor.Tensor[float64], error) {
+	inputs := make([]*tensor.Tensor[float64], 0)
 	
 	for _, level := range operation.InputLevels {
-		if levelData, exists := ht.Levels[level]; exists {
-			if tensor, exists := levelData.Tensors[targetID]; exists {
-				inputs = append(inputs, tensor.Data)
-			}
+		levelTensor, err := ht.find(level, targetID)
+		if err != nil {
+			return nil, err
 		}
+		inputs = append(inputs, levelTensor.Data)
 	}
 	
-	return inputs
+	return inputs, nil
 }
 
-func (eto *ElderTensorOperations) applyResult(result []float64, ht *HierarchicalTensor, outputLevel int, targetID string) {
-	if levelData, exists := ht.Levels[outputLevel]; exists {
-		if tensor, exists := levelData.Tensors[targetID]; exists {
-			minLen := len(result)
-			if len(tensor.Data) < minLen {
-				minLen = len(tensor.Data)
-			}
-			
-			for i := 0; i < minLen; i++ {
-				tensor.Data[i] = result[i]
-			}
-		}
+func (eto *ElderTensorOperations) applyResult(result *tensor.Tensor[float64], ht *HierarchicalTensor, outputLevel int, targetID string) error {
+	levelTensor, err := ht.find(outputLevel, targetID)
+	if err != nil {
+		return err
 	}
+	
+	return levelTensor.Data.CopyFrom(result)
 }
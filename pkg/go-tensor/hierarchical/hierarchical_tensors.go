@@ -1,5 +1,11 @@
 package hierarchical
 
+import (
+	"fmt"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
 type HierarchicalTensor struct {
 	Levels    map[int]*TensorLevel
 	MaxLevels int
@@ -14,12 +20,11 @@ type TensorLevel struct {
 }
 
 type LevelTensor struct {
-	ID         string
-	Data       []float64
-	Dimensions []int
-	Level      int
-	Parent     string
-	Children   []string
+	ID       string
+	Data     *tensor.Tensor[float64]
+	Level    int
+	Parent   string
+	Children []string
 }
 
 func NewHierarchicalTensor(maxLevels int, structure []int) *HierarchicalTensor {
@@ -42,22 +47,33 @@ func NewHierarchicalTensor(maxLevels int, structure []int) *HierarchicalTensor {
 	return ht
 }
 
-func (ht *HierarchicalTensor) AddTensor(level int, id string, data []float64, dimensions []int) {
+// AddTensor stores a copy of data, which must fill dimensions, at level.
+func (ht *HierarchicalTensor) AddTensor(level int, id string, data []float64, dimensions []int) error {
 	if level < 0 || level >= ht.MaxLevels {
-		return
+		return fmt.Errorf("hierarchical: level %d outside 0..%d", level, ht.MaxLevels-1)
 	}
 	
-	tensor := &LevelTensor{
-		ID:         id,
-		Data:       make([]float64, len(data)),
-		Dimensions: make([]int, len(dimensions)),
-		Level:      level,
-		Children:   make([]string, 0),
+	values, err := tensor.FromSlice(append([]float64{}, data...), dimensions...)
+	if err != nil {
+		return err
 	}
-	copy(tensor.Data, data)
-	copy(tensor.Dimensions, dimensions)
 	
-	ht.Levels[level].Tensors[id] = tensor
+	ht.Levels[level].Tensors[id] = &LevelTensor{
+		ID:       id,
+		Data:     values,
+		Level:    level,
+		Children: make([]string, 0),
+	}
+	return nil
+}
+
+func (ht *HierarchicalTensor) find(level int, id string) (*LevelTensor, error) {
+	if levelData, exists := ht.Levels[level]; exists {
+		if levelTensor, exists := levelData.Tensors[id]; exists {
+			return levelTensor, nil
+		}
+	}
+	return nil, fmt.Errorf("hierarchical: no tensor %q at level %d", id, level)
 }
 
 func (ht *HierarchicalTensor) EstablishHierarchy(parentID string, parentLevel int, childID string, childLevel int) {
@@ -77,60 +93,76 @@ func (ht *HierarchicalTensor) EstablishHierarchy(parentID string, parentLevel in
 	}
 }
 
-func (ht *HierarchicalTensor) PropagateDown(sourceLevel int, sourceID string) {
+func (ht *HierarchicalTensor) PropagateDown(sourceLevel int, sourceID string) error {
 	if sourceLevel >= ht.MaxLevels-1 {
-		return
+		return nil
 	}
 	
 	sourceTensor := ht.Levels[sourceLevel].Tensors[sourceID]
 	if sourceTensor == nil {
-		return
+		return nil
 	}
 	
 	for _, childID := range sourceTensor.Children {
 		if childTensor, exists := ht.Levels[sourceLevel+1].Tensors[childID]; exists {
-			ht.propagateData(sourceTensor, childTensor)
-			ht.PropagateDown(sourceLevel+1, childID)
+			if err := ht.propagateData(sourceTensor, childTensor); err != nil {
+				return fmt.Errorf("hierarchical: propagating %s to %s: %w", sourceID, childID, err)
+			}
+			if err := ht.PropagateDown(sourceLevel+1, childID); err != nil {
+				return err
+			}
 		}
 	}
+	
+	return nil
 }
 
-func (ht *HierarchicalTensor) PropagateUp(targetLevel int, targetID string) {
+func (ht *HierarchicalTensor) PropagateUp(targetLevel int, targetID string) error {
 	if targetLevel <= 0 {
-		return
+		return nil
 	}
 	
 	targetTensor := ht.Levels[targetLevel].Tensors[targetID]
 	if targetTensor == nil || targetTensor.Parent == "" {
-		return
+		return nil
 	}
 	
 	if parentTensor, exists := ht.Levels[targetLevel-1].Tensors[targetTensor.Parent]; exists {
-		ht.aggregateData(targetTensor, parentTensor)
-		ht.PropagateUp(targetLevel-1, targetTensor.Parent)
+		if err := ht.aggregateData(targetTensor, parentTensor); err != nil {
+			return fmt.Errorf("hierarchical: aggregating %s into %s: %w", targetID, targetTensor.Parent, err)
+		}
+		return ht.PropagateUp(targetLevel-1, targetTensor.Parent)
 	}
+	
+	return nil
 }
 
-func (ht *HierarchicalTensor) propagateData(source, target *LevelTensor) {
-	minLen := len(source.Data)
-	if len(target.Data) < minLen {
-		minLen = len(target.Data)
-	}
-	
-	for i := 0; i < minLen; i++ {
-		target.Data[i] += source.Data[i] * 0.1
-	}
+// propagateData adds a tenth of source, broadcast to target's shape, to
+// target.
+func (ht *HierarchicalTensor) propagateData(source, target *LevelTensor) error {
+	return combine(source, target, func(s, t float64) float64 {
+		return t + s*0.1
+	})
+}
+
+// aggregateData averages target with source broadcast to target's shape.
+func (ht *HierarchicalTensor) aggregateData(source, target *LevelTensor) error {
+	return combine(source, target, func(s, t float64) float64 {
+		return (t + s) * 0.5
+	})
 }
 
-func (ht *HierarchicalTensor) aggregateData(source, target *LevelTensor) {
-	minLen := len(source.Data)
-	if len(target.Data) < minLen {
-		minLen = len(target.Data)
+func combine(source, target *LevelTensor, f func(s, t float64) float64) error {
+	broadcast, err := source.Data.BroadcastTo(target.Data.Shape()...)
+	if err != nil {
+		return err
 	}
 	
-	for i := 0; i < minLen; i++ {
-		target.Data[i] = (target.Data[i] + source.Data[i]) * 0.5
+	combined, err := tensor.Zip(broadcast, target.Data, f)
+	if err != nil {
+		return err
 	}
+	return target.Data.CopyFrom(combined)
 }
 
 func (ht *HierarchicalTensor) ComputeLevelEntropy(level int) float64 {
@@ -138,8 +170,8 @@ func (ht *HierarchicalTensor) ComputeLevelEntropy(level int) float64 {
 		entropy := 0.0
 		count := 0
 		
-		for _, tensor := range levelData.Tensors {
-			tensorEntropy := ht.computeTensorEntropy(tensor)
+		for _, levelTensor := range levelData.Tensors {
+			tensorEntropy := ht.computeTensorEntropy(levelTensor)
 			entropy += tensorEntropy
 			count++
 		}
@@ -152,9 +184,10 @@ func (ht *HierarchicalTensor) ComputeLevelEntropy(level int) float64 {
 	return 0.0
 }
 
-func (ht *HierarchicalTensor) computeTensorEntropy(tensor *LevelTensor) float64 {
+func (ht *HierarchicalTensor) computeTensorEntropy(levelTensor *LevelTensor) float64 {
+	values := levelTensor.Data.Values()
 	total := 0.0
-	for _, val := range tensor.Data {
+	for _, val := range values {
 		if val > 0 {
 			total += val
 		}
@@ -165,7 +198,7 @@ func (ht *HierarchicalTensor) computeTensorEntropy(tensor *LevelTensor) float64
 	}
 	
 	entropy := 0.0
-	for _, val := range tensor.Data {
+	for _, val := range values {
 		if val > 0 {
 			prob := val / total
 			entropy -= prob * (prob * 0.693147) // ln(prob)
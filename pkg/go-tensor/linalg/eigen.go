@@ -0,0 +1,369 @@
+package linalg
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// maxSweeps bounds the Jacobi sweeps of EigenSymmetric and SVD; each sweep
+// converges quadratically, so a few dozen are far more than enough.
+const maxSweeps = 100
+
+// EigenSymmetric diagonalizes a symmetric matrix by cyclic Jacobi
+// rotations. It returns the eigenvalues in ascending order and a matrix
+// whose columns are the corresponding orthonormal eigenvectors.
+func EigenSymmetric(a *tensor.Tensor[float64]) (*tensor.Tensor[float64], *tensor.Tensor[float64], error) {
+	rows, err := squareRows("eigen", a)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !symmetric(rows) {
+		return nil, nil, ErrNotSymmetric
+	}
+
+	n := len(rows)
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, n)
+		vectors[i][i] = 1
+	}
+
+	scale := frobenius(rows)
+	converged := false
+	for sweep := 0; sweep < maxSweeps && !converged; sweep++ {
+		off := 0.0
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				off += rows[p][q] * rows[p][q]
+			}
+		}
+		if math.Sqrt(off) <= epsilon*scale {
+			converged = true
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if rows[p][q] == 0 {
+					continue
+				}
+				// Choose the rotation that zeroes (p, q), the smaller of the
+				// two angles that do.
+				theta := (rows[q][q] - rows[p][p]) / (2 * rows[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				rotate(rows, vectors, p, q, c, s)
+			}
+		}
+	}
+	if !converged {
+		return nil, nil, ErrNoConvergence
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return rows[order[i]][order[i]] < rows[order[j]][order[j]] })
+
+	values := make([]float64, n)
+	sorted := make([][]float64, n)
+	for i := range sorted {
+		sorted[i] = make([]float64, n)
+	}
+	for k, i := range order {
+		values[k] = rows[i][i]
+		for r := 0; r < n; r++ {
+			sorted[r][k] = vectors[r][i]
+		}
+	}
+	return tensor.Vector(values), fromRows(sorted), nil
+}
+
+// rotate applies the Jacobi rotation in the (p, q) plane to both sides of a
+// and accumulates it into the columns of v.
+func rotate(a, v [][]float64, p, q int, c, s float64) {
+	for k := range a {
+		akp, akq := a[k][p], a[k][q]
+		a[k][p] = c*akp - s*akq
+		a[k][q] = s*akp + c*akq
+	}
+	for k := range a {
+		apk, aqk := a[p][k], a[q][k]
+		a[p][k] = c*apk - s*aqk
+		a[q][k] = s*apk + c*aqk
+	}
+	for k := range v {
+		vkp, vkq := v[k][p], v[k][q]
+		v[k][p] = c*vkp - s*vkq
+		v[k][q] = s*vkp + c*vkq
+	}
+}
+
+// Eigenvalues are the possibly complex eigenvalues of a square matrix, in
+// descending order of real part and then of imaginary part. The matrix is
+// balanced, reduced to upper Hessenberg form and iterated to quasi-upper
+// triangular form by Francis double-shift QR steps.
+func Eigenvalues(a *tensor.Tensor[float64]) (*tensor.Tensor[complex128], error) {
+	rows, err := squareRows("eigenvalues", a)
+	if err != nil {
+		return nil, err
+	}
+
+	balance(rows)
+	hessenberg(rows)
+	values, err := hessenbergEigenvalues(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		if real(values[i]) != real(values[j]) {
+			return real(values[i]) > real(values[j])
+		}
+		return imag(values[i]) > imag(values[j])
+	})
+	return tensor.Vector(values), nil
+}
+
+// balance scales rows and columns by powers of two, which leaves the
+// eigenvalues unchanged, until their norms are comparable, which makes
+// them less sensitive to rounding.
+func balance(a [][]float64) {
+	const radix = 2.0
+	for done := false; !done; {
+		done = true
+		for i := range a {
+			r, c := 0.0, 0.0
+			for j := range a {
+				if j != i {
+					c += math.Abs(a[j][i])
+					r += math.Abs(a[i][j])
+				}
+			}
+			if c == 0 || r == 0 {
+				continue
+			}
+
+			g, f, s := r/radix, 1.0, c+r
+			for c < g {
+				f *= radix
+				c *= radix * radix
+			}
+			g = r * radix
+			for c > g {
+				f /= radix
+				c /= radix * radix
+			}
+			if (c+r)/f < 0.95*s {
+				done = false
+				for j := range a {
+					a[i][j] /= f
+					a[j][i] *= f
+				}
+			}
+		}
+	}
+}
+
+// hessenberg reduces a to upper Hessenberg form by similarity transforms
+// of Gaussian elimination with pivoting.
+func hessenberg(a [][]float64) {
+	n := len(a)
+	for m := 1; m < n-1; m++ {
+		x, pivot := 0.0, m
+		for j := m; j < n; j++ {
+			if math.Abs(a[j][m-1]) > math.Abs(x) {
+				x, pivot = a[j][m-1], j
+			}
+		}
+		if pivot != m {
+			a[pivot], a[m] = a[m], a[pivot]
+			for j := range a {
+				a[j][pivot], a[j][m] = a[j][m], a[j][pivot]
+			}
+		}
+		if x == 0 {
+			continue
+		}
+
+		for i := m + 1; i < n; i++ {
+			y := a[i][m-1] / x
+			if y == 0 {
+				continue
+			}
+			for j := m - 1; j < n; j++ {
+				a[i][j] -= y * a[m][j]
+			}
+			for j := range a {
+				a[j][m] += y * a[j][i]
+			}
+		}
+	}
+	for i := range a {
+		for j := 0; j < i-1; j++ {
+			a[i][j] = 0
+		}
+	}
+}
+
+// hessenbergEigenvalues finds the eigenvalues of an upper Hessenberg matrix
+// by the shifted QR algorithm, deflating one real or two complex
+// eigenvalues at a time from the bottom.
+func hessenbergEigenvalues(a [][]float64) ([]complex128, error) {
+	n := len(a)
+	values := make([]complex128, n)
+	norm := 0.0
+	for i := range a {
+		for j := max(i-1, 0); j < n; j++ {
+			norm += math.Abs(a[i][j])
+		}
+	}
+
+	shift := 0.0
+	for last := n - 1; last >= 0; {
+		for iterations := 0; ; iterations++ {
+			// Find where the matrix splits: a negligible subdiagonal element.
+			l := last
+			for ; l >= 1; l-- {
+				s := math.Abs(a[l-1][l-1]) + math.Abs(a[l][l])
+				if s == 0 {
+					s = norm
+				}
+				if math.Abs(a[l][l-1])+s == s {
+					a[l][l-1] = 0
+					break
+				}
+			}
+
+			x := a[last][last]
+			if l == last {
+				values[last] = complex(x+shift, 0)
+				last--
+				break
+			}
+			y := a[last-1][last-1]
+			w := a[last][last-1] * a[last-1][last]
+			if l == last-1 {
+				p := 0.5 * (y - x)
+				q := p*p + w
+				z := math.Sqrt(math.Abs(q))
+				x += shift
+				if q >= 0 {
+					z = p + math.Copysign(z, p)
+					values[last-1] = complex(x+z, 0)
+					values[last] = values[last-1]
+					if z != 0 {
+						values[last] = complex(x-w/z, 0)
+					}
+				} else {
+					values[last-1] = complex(x+p, -z)
+					values[last] = complex(x+p, z)
+				}
+				last -= 2
+				break
+			}
+
+			if iterations == 30*n {
+				return nil, ErrNoConvergence
+			}
+			if iterations == 10 || iterations == 20 {
+				// An exceptional shift breaks cycles that ordinary shifts
+				// fall into.
+				shift += x
+				for i := 0; i <= last; i++ {
+					a[i][i] -= x
+				}
+				s := math.Abs(a[last][last-1]) + math.Abs(a[last-1][last-2])
+				x, y, w = 0.75*s, 0.75*s, -0.4375*s*s
+			}
+
+			// Start the double step where two consecutive subdiagonal
+			// elements are small enough.
+			var m int
+			var p, q, r, z float64
+			for m = last - 2; m >= l; m-- {
+				z = a[m][m]
+				r = x - z
+				s := y - z
+				p = (r*s-w)/a[m+1][m] + a[m][m+1]
+				q = a[m+1][m+1] - z - r - s
+				r = a[m+2][m+1]
+				s = math.Abs(p) + math.Abs(q) + math.Abs(r)
+				p, q, r = p/s, q/s, r/s
+				if m == l {
+					break
+				}
+				u := math.Abs(a[m][m-1]) * (math.Abs(q) + math.Abs(r))
+				v := math.Abs(p) * (math.Abs(a[m-1][m-1]) + math.Abs(z) + math.Abs(a[m+1][m+1]))
+				if u+v == v {
+					break
+				}
+			}
+			for i := m + 2; i <= last; i++ {
+				a[i][i-2] = 0
+				if i != m+2 {
+					a[i][i-3] = 0
+				}
+			}
+
+			for k := m; k <= last-1; k++ {
+				if k != m {
+					p, q, r = a[k][k-1], a[k+1][k-1], 0
+					if k != last-1 {
+						r = a[k+2][k-1]
+					}
+					if x = math.Abs(p) + math.Abs(q) + math.Abs(r); x != 0 {
+						p, q, r = p/x, q/x, r/x
+					}
+				}
+				s := math.Copysign(math.Sqrt(p*p+q*q+r*r), p)
+				if s == 0 {
+					continue
+				}
+				if k == m {
+					if l != m {
+						a[k][k-1] = -a[k][k-1]
+					}
+				} else {
+					a[k][k-1] = -s * x
+				}
+				p += s
+				x, y, z = p/s, q/s, r/s
+				q, r = q/p, r/p
+				for j := k; j <= last; j++ {
+					p = a[k][j] + q*a[k+1][j]
+					if k != last-1 {
+						p += r * a[k+2][j]
+						a[k+2][j] -= p * z
+					}
+					a[k+1][j] -= p * y
+					a[k][j] -= p * x
+				}
+				for i := l; i <= min(last, k+3); i++ {
+					p = x*a[i][k] + y*a[i][k+1]
+					if k != last-1 {
+						p += z * a[i][k+2]
+						a[i][k+2] -= p * r
+					}
+					a[i][k+1] -= p * q
+					a[i][k] -= p
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+func frobenius(rows [][]float64) float64 {
+	sum := 0.0
+	for _, row := range rows {
+		for _, value := range row {
+			sum += value * value
+		}
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,365 @@
+package linalg
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// residualTolerance bounds every residual relative to the size of the
+// matrices involved: a backward stable factorization stays within a small
+// multiple of the unit roundoff.
+const residualTolerance = 1e-14
+
+func randomMatrix(rng *rand.Rand, m, n int) *tensor.Tensor[float64] {
+	values := make([]float64, m*n)
+	for i := range values {
+		values[i] = 2*rng.Float64() - 1
+	}
+	a, _ := tensor.FromSlice(values, m, n)
+	return a
+}
+
+// symmetricMatrix mirrors the lower triangle of a random matrix.
+func symmetricMatrix(rng *rand.Rand, n int) *tensor.Tensor[float64] {
+	values := randomMatrix(rng, n, n).Values()
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			values[j*n+i] = values[i*n+j]
+		}
+	}
+	a, _ := tensor.FromSlice(values, n, n)
+	return a
+}
+
+func matMul(t *testing.T, a, b *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	t.Helper()
+	product, err := tensor.MatMul(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+func transpose(t *testing.T, a *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	t.Helper()
+	transposed, err := a.Transpose()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transposed
+}
+
+func norm(a *tensor.Tensor[float64]) float64 {
+	total := 0.0
+	for _, value := range a.Values() {
+		total += value * value
+	}
+	return math.Sqrt(total)
+}
+
+// assertClose checks that got matches want to within residualTolerance
+// times scale in the Frobenius norm.
+func assertClose(t *testing.T, name string, got, want *tensor.Tensor[float64], scale float64) {
+	t.Helper()
+	g, w := got.Values(), want.Values()
+	if len(g) != len(w) {
+		t.Fatalf("%s: shape %v, want %v", name, got.Shape(), want.Shape())
+	}
+	total := 0.0
+	for i := range g {
+		total += (g[i] - w[i]) * (g[i] - w[i])
+	}
+	if residual := math.Sqrt(total); residual > residualTolerance*scale {
+		t.Errorf("%s: residual %.3g exceeds %.3g", name, residual, residualTolerance*scale)
+	}
+}
+
+// assertOrthonormalColumns checks that QᵀQ is the identity.
+func assertOrthonormalColumns(t *testing.T, name string, q *tensor.Tensor[float64]) {
+	t.Helper()
+	k := q.Dim(1)
+	assertClose(t, name+" orthonormality", matMul(t, transpose(t, q), q), Identity(k), float64(k))
+}
+
+func TestLU(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 5, 12} {
+		a := randomMatrix(rng, n, n)
+		scale := norm(a)
+
+		b := randomMatrix(rng, n, 3)
+		x, err := Solve(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertClose(t, "A·X = B", matMul(t, a, x), b, scale*norm(x))
+
+		vector := tensor.Vector(randomMatrix(rng, n, 1).Values())
+		y, err := Solve(a, vector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if y.Rank() != 1 {
+			t.Fatalf("solving for a vector gave shape %v", y.Shape())
+		}
+		assertClose(t, "A·y = v", matMul(t, a, y), vector, scale*norm(y))
+
+		inverse, err := Inverse(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertClose(t, "A·A⁻¹ = I", matMul(t, a, inverse), Identity(n), scale*norm(inverse))
+	}
+}
+
+func TestDet(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+		want float64
+	}{
+		{"identity", [][]float64{{1, 0}, {0, 1}}, 1},
+		{"swap", [][]float64{{0, 1}, {1, 0}}, -1},
+		{"triangular", [][]float64{{2, 7, 1}, {0, 3, 5}, {0, 0, 4}}, 24},
+		{"pivoted", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}}, -3},
+		{"singular", [][]float64{{1, 2}, {2, 4}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := tensor.FromRows(tt.rows)
+			det, err := Det(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(det-tt.want) > residualTolerance*math.Max(1, math.Abs(tt.want)) {
+				t.Errorf("det = %.17g, want %g", det, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveSingular(t *testing.T) {
+	a, _ := tensor.FromRows([][]float64{{1, 2}, {2, 4}})
+	if _, err := Solve(a, tensor.Vector([]float64{1, 1})); !errors.Is(err, ErrSingular) {
+		t.Fatalf("expected ErrSingular, got %v", err)
+	}
+}
+
+func TestQR(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, shape := range [][2]int{{4, 4}, {9, 5}, {5, 9}} {
+		a := randomMatrix(rng, shape[0], shape[1])
+		q, r, err := QR(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertClose(t, "Q·R = A", matMul(t, q, r), a, norm(a))
+		assertOrthonormalColumns(t, "Q", q)
+		values := r.Values()
+		for i := 0; i < r.Dim(0); i++ {
+			for j := 0; j < i; j++ {
+				if values[i*r.Dim(1)+j] != 0 {
+					t.Fatalf("R is not upper triangular at (%d, %d)", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	n := 8
+	m := randomMatrix(rng, n, n)
+	// MᵀM + I is symmetric positive definite.
+	a := matMul(t, transpose(t, m), m)
+	values := a.Values()
+	for i := 0; i < n; i++ {
+		values[i*n+i]++
+	}
+	a, _ = tensor.FromSlice(values, n, n)
+
+	l, err := Cholesky(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "L·Lᵀ = A", matMul(t, l, transpose(t, l)), a, norm(a))
+
+	indefinite, _ := tensor.FromRows([][]float64{{1, 2}, {2, 1}})
+	if _, err := Cholesky(indefinite); !errors.Is(err, ErrNotPositiveDefinite) {
+		t.Errorf("expected ErrNotPositiveDefinite, got %v", err)
+	}
+	asymmetric, _ := tensor.FromRows([][]float64{{2, 1}, {0, 2}})
+	if _, err := Cholesky(asymmetric); !errors.Is(err, ErrNotSymmetric) {
+		t.Errorf("expected ErrNotSymmetric, got %v", err)
+	}
+}
+
+func TestEigenSymmetric(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	n := 10
+	a := symmetricMatrix(rng, n)
+
+	values, vectors, err := EigenSymmetric(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOrthonormalColumns(t, "V", vectors)
+
+	lambda := values.Values()
+	for i := 1; i < n; i++ {
+		if lambda[i] < lambda[i-1] {
+			t.Fatalf("eigenvalues not ascending: %v", lambda)
+		}
+	}
+
+	// A·V = V·diag(λ).
+	scaled := vectors.Values()
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			scaled[i*n+j] *= lambda[j]
+		}
+	}
+	want, _ := tensor.FromSlice(scaled, n, n)
+	assertClose(t, "A·V = V·Λ", matMul(t, a, vectors), want, norm(a))
+}
+
+func TestEigenvalues(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+		want []complex128
+	}{
+		{"diagonal", [][]float64{{3, 0}, {0, -1}}, []complex128{3, -1}},
+		{"rotation", [][]float64{{0, -1}, {1, 0}}, []complex128{1i, -1i}},
+		// The companion matrix of (x-1)(x-2)(x-3).
+		{"companion", [][]float64{{6, -11, 6}, {1, 0, 0}, {0, 1, 0}}, []complex128{3, 2, 1}},
+		// The companion matrix of (x-2)(x²+2x+5), with roots 2 and -1±2i.
+		{"complex pair", [][]float64{{0, -1, 10}, {1, 0, 0}, {0, 1, 0}}, []complex128{2, -1 + 2i, -1 - 2i}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := tensor.FromRows(tt.rows)
+			eigenvalues, err := Eigenvalues(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := eigenvalues.Values()
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if cmplx.Abs(got[i]-tt.want[i]) > 10*residualTolerance*norm(a) {
+					t.Errorf("eigenvalue %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEigenvaluesMatchSymmetric compares the general solver against the
+// symmetric one, and checks that the eigenvalues of a random matrix sum to
+// its trace.
+func TestEigenvaluesMatchSymmetric(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	n := 9
+	a := symmetricMatrix(rng, n)
+
+	symmetricValues, _, err := EigenSymmetric(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	general, err := Eigenvalues(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, got := symmetricValues.Values(), general.Values()
+	for i := range want {
+		// Eigenvalues are descending, EigenSymmetric ascending.
+		if value := got[n-1-i]; math.Abs(real(value)-want[i]) > 10*residualTolerance*norm(a) || imag(value) != 0 {
+			t.Errorf("eigenvalue %v, want %g", value, want[i])
+		}
+	}
+
+	b := randomMatrix(rng, n, n)
+	eigenvalues, err := Eigenvalues(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := complex(0, 0)
+	for _, lambda := range eigenvalues.Values() {
+		total += lambda
+	}
+	trace := 0.0
+	for i := 0; i < n; i++ {
+		trace += b.Values()[i*n+i]
+	}
+	if cmplx.Abs(total-complex(trace, 0)) > 10*residualTolerance*norm(b) {
+		t.Errorf("eigenvalues sum to %v, trace is %g", total, trace)
+	}
+}
+
+func TestSVD(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for _, shape := range [][2]int{{6, 6}, {10, 4}, {4, 10}} {
+		a := randomMatrix(rng, shape[0], shape[1])
+		u, s, v, err := SVD(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertOrthonormalColumns(t, "U", u)
+		assertOrthonormalColumns(t, "V", v)
+
+		sigma := s.Values()
+		for i := 1; i < len(sigma); i++ {
+			if sigma[i] > sigma[i-1] || sigma[i] < 0 {
+				t.Fatalf("singular values not descending and non-negative: %v", sigma)
+			}
+		}
+
+		// U·diag(S)·Vᵀ = A.
+		scaled := u.Values()
+		k := len(sigma)
+		for i := 0; i < u.Dim(0); i++ {
+			for j := 0; j < k; j++ {
+				scaled[i*k+j] *= sigma[j]
+			}
+		}
+		us, _ := tensor.FromSlice(scaled, u.Dim(0), k)
+		assertClose(t, "U·S·Vᵀ = A", matMul(t, us, transpose(t, v)), a, norm(a))
+	}
+}
+
+func TestRank(t *testing.T) {
+	deficient, _ := tensor.FromRows([][]float64{{1, 2, 3}, {2, 4, 6}, {1, 0, 1}})
+	if rank, err := Rank(deficient, 0); err != nil || rank != 2 {
+		t.Errorf("rank = %d (%v), want 2", rank, err)
+	}
+
+	// The factors still reproduce A when a singular value is zero.
+	u, s, v, err := SVD(deficient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaled := u.Values()
+	sigma := s.Values()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			scaled[i*3+j] *= sigma[j]
+		}
+	}
+	us, _ := tensor.FromSlice(scaled, 3, 3)
+	assertClose(t, "U·S·Vᵀ = A", matMul(t, us, transpose(t, v)), deficient, norm(deficient))
+
+	if rank, err := Rank(Identity(4), 0); err != nil || rank != 4 {
+		t.Errorf("rank = %d (%v), want 4", rank, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package linalg factorizes and solves dense real matrices held in
+// tensor.Tensor values: LU with partial pivoting, Householder QR,
+// Cholesky, symmetric and general eigenvalue problems, and the singular
+// value decomposition.
+package linalg
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+var (
+	ErrSingular            = errors.New("linalg: matrix is singular")
+	ErrNotPositiveDefinite = errors.New("linalg: matrix is not positive definite")
+	ErrNotSymmetric        = errors.New("linalg: matrix is not symmetric")
+	ErrNoConvergence       = errors.New("linalg: iteration did not converge")
+)
+
+// epsilon is the spacing of float64 values near one.
+const epsilon = 2.220446049250313e-16
+
+// LU is the factorization P·A = L·U of a square matrix A, with L unit lower
+// triangular, U upper triangular and P the row permutation chosen by
+// partial pivoting.
+type LU struct {
+	lu       [][]float64
+	pivot    []int
+	sign     float64
+	singular bool
+}
+
+func NewLU(a *tensor.Tensor[float64]) (*LU, error) {
+	rows, err := squareRows("lu", a)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(rows)
+	f := &LU{lu: rows, pivot: make([]int, n), sign: 1}
+	for i := range f.pivot {
+		f.pivot[i] = i
+	}
+
+	// Pivots this small relative to the matrix are rounding error.
+	threshold := float64(n) * epsilon * maxAbs(rows)
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(rows[i][k]) > math.Abs(rows[p][k]) {
+				p = i
+			}
+		}
+		if p != k {
+			rows[p], rows[k] = rows[k], rows[p]
+			f.pivot[p], f.pivot[k] = f.pivot[k], f.pivot[p]
+			f.sign = -f.sign
+		}
+		if math.Abs(rows[k][k]) <= threshold {
+			f.singular = true
+			continue
+		}
+
+		for i := k + 1; i < n; i++ {
+			rows[i][k] /= rows[k][k]
+			factor := rows[i][k]
+			for j := k + 1; j < n; j++ {
+				rows[i][j] -= factor * rows[k][j]
+			}
+		}
+	}
+	return f, nil
+}
+
+// Singular reports whether a pivot vanished to within rounding error.
+func (f *LU) Singular() bool {
+	return f.singular
+}
+
+func (f *LU) Det() float64 {
+	if f.singular {
+		return 0
+	}
+	det := f.sign
+	for i := range f.lu {
+		det *= f.lu[i][i]
+	}
+	return det
+}
+
+// Solve solves A·x = b for a vector b, or for each column of a matrix b.
+func (f *LU) Solve(b *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	n := len(f.lu)
+	if (b.Rank() != 1 && b.Rank() != 2) || b.Dim(0) != n {
+		return nil, &tensor.ShapeError{Op: "solve", Shapes: [][]int{{n, n}, b.Shape()}, Reason: "right-hand side does not match"}
+	}
+	if f.singular {
+		return nil, ErrSingular
+	}
+
+	columns := 1
+	if b.Rank() == 2 {
+		columns = b.Dim(1)
+	}
+	values := b.Values()
+	x := make([]float64, len(values))
+	for i, p := range f.pivot {
+		copy(x[i*columns:(i+1)*columns], values[p*columns:(p+1)*columns])
+	}
+
+	for c := 0; c < columns; c++ {
+		for i := 0; i < n; i++ {
+			for k := 0; k < i; k++ {
+				x[i*columns+c] -= f.lu[i][k] * x[k*columns+c]
+			}
+		}
+		for i := n - 1; i >= 0; i-- {
+			for k := i + 1; k < n; k++ {
+				x[i*columns+c] -= f.lu[i][k] * x[k*columns+c]
+			}
+			x[i*columns+c] /= f.lu[i][i]
+		}
+	}
+	return tensor.FromSlice(x, b.Shape()...)
+}
+
+func (f *LU) Inverse() (*tensor.Tensor[float64], error) {
+	return f.Solve(Identity(len(f.lu)))
+}
+
+// Det is the determinant of a square matrix.
+func Det(a *tensor.Tensor[float64]) (float64, error) {
+	f, err := NewLU(a)
+	if err != nil {
+		return 0, err
+	}
+	return f.Det(), nil
+}
+
+// Solve solves a·x = b for a square matrix a.
+func Solve(a, b *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	f, err := NewLU(a)
+	if err != nil {
+		return nil, err
+	}
+	return f.Solve(b)
+}
+
+func Inverse(a *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	f, err := NewLU(a)
+	if err != nil {
+		return nil, err
+	}
+	return f.Inverse()
+}
+
+func Identity(n int) *tensor.Tensor[float64] {
+	values := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		values[i*n+i] = 1
+	}
+	identity, _ := tensor.FromSlice(values, n, n)
+	return identity
+}
+
+// rows copies a matrix into a slice per row for factorizing in place.
+func rows(op string, a *tensor.Tensor[float64]) ([][]float64, error) {
+	if a.Rank() != 2 {
+		return nil, &tensor.ShapeError{Op: op, Shapes: [][]int{a.Shape()}, Reason: "is not a matrix"}
+	}
+	return a.Rows()
+}
+
+func squareRows(op string, a *tensor.Tensor[float64]) ([][]float64, error) {
+	if a.Rank() != 2 || a.Dim(0) != a.Dim(1) {
+		return nil, &tensor.ShapeError{Op: op, Shapes: [][]int{a.Shape()}, Reason: "is not a square matrix"}
+	}
+	return a.Rows()
+}
+
+func fromRows(rows [][]float64) *tensor.Tensor[float64] {
+	t, _ := tensor.FromRows(rows)
+	return t
+}
+
+func maxAbs(rows [][]float64) float64 {
+	largest := 0.0
+	for _, row := range rows {
+		for _, value := range row {
+			largest = math.Max(largest, math.Abs(value))
+		}
+	}
+	return largest
+}
+
+// symmetric reports whether rows is symmetric to within rounding error.
+func symmetric(rows [][]float64) bool {
+	tolerance := 1e-10 * math.Max(1, maxAbs(rows))
+	for i := range rows {
+		for j := 0; j < i; j++ {
+			if math.Abs(rows[i][j]-rows[j][i]) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
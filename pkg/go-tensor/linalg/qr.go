@@ -0,0 +1,124 @@
+package linalg
+
+import (
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// QR factorizes an m×n matrix A as Q·R by Householder reflections, with
+// k = min(m, n): Q is m×k with orthonormal columns and R is k×n upper
+// triangular.
+func QR(a *tensor.Tensor[float64]) (*tensor.Tensor[float64], *tensor.Tensor[float64], error) {
+	r, err := rows("qr", a)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, n := a.Dim(0), a.Dim(1)
+	k := min(m, n)
+
+	// Reflector j maps column j's entries from row j down onto row j.
+	reflectors := make([][]float64, k)
+	for j := 0; j < k; j++ {
+		v := make([]float64, m-j)
+		for i := range v {
+			v[i] = r[j+i][j]
+		}
+		norm := euclidean(v)
+		if norm == 0 {
+			continue
+		}
+		alpha := -math.Copysign(norm, v[0])
+		v[0] -= alpha
+		scale := euclidean(v)
+		for i := range v {
+			v[i] /= scale
+		}
+		reflectors[j] = v
+
+		for c := j; c < n; c++ {
+			dot := 0.0
+			for i, vi := range v {
+				dot += vi * r[j+i][c]
+			}
+			for i, vi := range v {
+				r[j+i][c] -= 2 * dot * vi
+			}
+		}
+	}
+
+	q := make([][]float64, m)
+	for i := range q {
+		q[i] = make([]float64, k)
+		if i < k {
+			q[i][i] = 1
+		}
+	}
+	for j := k - 1; j >= 0; j-- {
+		v := reflectors[j]
+		if v == nil {
+			continue
+		}
+		for c := 0; c < k; c++ {
+			dot := 0.0
+			for i, vi := range v {
+				dot += vi * q[j+i][c]
+			}
+			for i, vi := range v {
+				q[j+i][c] -= 2 * dot * vi
+			}
+		}
+	}
+
+	upper := make([][]float64, k)
+	for i := range upper {
+		upper[i] = make([]float64, n)
+		copy(upper[i][i:], r[i][i:])
+	}
+	return fromRows(q), fromRows(upper), nil
+}
+
+// Cholesky factorizes a symmetric positive definite matrix A as L·Lᵀ and
+// returns the lower triangular L.
+func Cholesky(a *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	rows, err := squareRows("cholesky", a)
+	if err != nil {
+		return nil, err
+	}
+	if !symmetric(rows) {
+		return nil, ErrNotSymmetric
+	}
+
+	n := len(rows)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		diagonal := rows[j][j]
+		for k := 0; k < j; k++ {
+			diagonal -= l[j][k] * l[j][k]
+		}
+		if diagonal <= 0 {
+			return nil, ErrNotPositiveDefinite
+		}
+		l[j][j] = math.Sqrt(diagonal)
+
+		for i := j + 1; i < n; i++ {
+			sum := rows[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			l[i][j] = sum / l[j][j]
+		}
+	}
+	return fromRows(l), nil
+}
+
+func euclidean(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,128 @@
+package linalg
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// SVD factorizes an m×n matrix A as U·diag(S)·Vᵀ by one-sided Jacobi
+// rotations, which orthogonalize A's columns accurately even when A is
+// badly conditioned. With k = min(m, n), U is m×k, S holds the k singular
+// values in descending order and V is n×k; U and V have orthonormal
+// columns, except that U's column is zero where a singular value is.
+func SVD(a *tensor.Tensor[float64]) (*tensor.Tensor[float64], *tensor.Tensor[float64], *tensor.Tensor[float64], error) {
+	if a.Rank() != 2 {
+		return nil, nil, nil, &tensor.ShapeError{Op: "svd", Shapes: [][]int{a.Shape()}, Reason: "is not a matrix"}
+	}
+	if a.Dim(0) < a.Dim(1) {
+		// Factorize Aᵀ = V·S·Uᵀ instead, so that there are no more columns
+		// than rows.
+		transposed, _ := a.Transpose()
+		v, s, u, err := SVD(transposed)
+		return u, s, v, err
+	}
+
+	u, _ := a.Rows()
+	m, n := a.Dim(0), a.Dim(1)
+	v := make([][]float64, n)
+	for i := range v {
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	// Columns whose norm has fallen to rounding error of A's belong to zero
+	// singular values; their direction is noise that no rotation settles.
+	negligible := epsilon * frobenius(u)
+	negligible *= negligible
+
+	converged := false
+	for sweep := 0; sweep < maxSweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				alpha, beta, gamma := 0.0, 0.0, 0.0
+				for i := 0; i < m; i++ {
+					alpha += u[i][p] * u[i][p]
+					beta += u[i][q] * u[i][q]
+					gamma += u[i][p] * u[i][q]
+				}
+				if alpha <= negligible || beta <= negligible || math.Abs(gamma) <= epsilon*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				for _, rows := range [][][]float64{u, v} {
+					for _, row := range rows {
+						up, uq := row[p], row[q]
+						row[p] = c*up - s*uq
+						row[q] = s*up + c*uq
+					}
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, nil, nil, ErrNoConvergence
+	}
+
+	values := make([]float64, n)
+	for j := range values {
+		for i := 0; i < m; i++ {
+			values[j] += u[i][j] * u[i][j]
+		}
+		values[j] = math.Sqrt(values[j])
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] > values[order[j]] })
+
+	left, right := make([][]float64, m), make([][]float64, n)
+	for i := range left {
+		left[i] = make([]float64, n)
+	}
+	for i := range right {
+		right[i] = make([]float64, n)
+	}
+	singular := make([]float64, n)
+	for k, j := range order {
+		singular[k] = values[j]
+		for i := 0; i < m; i++ {
+			if values[j] > 0 {
+				left[i][k] = u[i][j] / values[j]
+			}
+		}
+		for i := 0; i < n; i++ {
+			right[i][k] = v[i][j]
+		}
+	}
+	return fromRows(left), tensor.Vector(singular), fromRows(right), nil
+}
+
+// Rank is the number of singular values of a above tolerance, or, when
+// tolerance is not positive, above the rounding error of the largest.
+func Rank(a *tensor.Tensor[float64], tolerance float64) (int, error) {
+	_, s, _, err := SVD(a)
+	if err != nil {
+		return 0, err
+	}
+	values := s.Values()
+	if tolerance <= 0 && len(values) > 0 {
+		tolerance = float64(max(a.Dim(0), a.Dim(1))) * epsilon * values[0]
+	}
+
+	rank := 0
+	for _, value := range values {
+		if value > tolerance {
+			rank++
+		}
+	}
+	return rank, nil
+}
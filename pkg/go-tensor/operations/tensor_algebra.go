@@ -1,18 +1,19 @@
 package operations
 
-import "math"
+import (
+	"github.com/ykashou/go-elder/pkg/go-tensor/linalg"
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type TensorAlgebra struct {
 	Dimension int
-	Basis     [][]float64
-	Metric    [][]float64
+	Basis     *tensor.Tensor[float64]
+	Metric    *tensor.Tensor[float64]
 }
 
 func NewTensorAlgebra(dimension int) *TensorAlgebra {
 	ta := &TensorAlgebra{
 		Dimension: dimension,
-		Basis:     make([][]float64, dimension),
-		Metric:    make([][]float64, dimension),
 	}
 	
 	ta.initializeStandardBasis()
@@ -21,162 +22,83 @@ func NewTensorAlgebra(dimension int) *TensorAlgebra {
 	return ta
 }
 
-func (ta *TensorAlgebra) initializeStandardBasis() {
-	for i := 0; i < ta.Dimension; i++ {
-		ta.Basis[i] = make([]float64, ta.Dimension)
-		ta.Basis[i][i] = 1.0
+func identity(dimension int) *tensor.Tensor[float64] {
+	matrix := tensor.Zeros[float64](dimension, dimension)
+	for i := 0; i < dimension; i++ {
+		matrix.Set(1.0, i, i)
 	}
+	return matrix
+}
+
+func (ta *TensorAlgebra) initializeStandardBasis() {
+	ta.Basis = identity(ta.Dimension)
 }
 
 func (ta *TensorAlgebra) initializeEuclideanMetric() {
-	for i := 0; i < ta.Dimension; i++ {
-		ta.Metric[i] = make([]float64, ta.Dimension)
-		ta.Metric[i][i] = 1.0
-	}
+	ta.Metric = identity(ta.Dimension)
 }
 
-func (ta *TensorAlgebra) TensorProduct(u, v []float64) [][]float64 {
-	result := make([][]float64, len(u))
-	for i := range result {
-		result[i] = make([]float64, len(v))
-		for j := range result[i] {
-			result[i][j] = u[i] * v[j]
-		}
-	}
-	return result
+func (ta *TensorAlgebra) TensorProduct(u, v *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	return tensor.Outer(u, v)
 }
 
-func (ta *TensorAlgebra) InnerProduct(u, v []float64) float64 {
-	minLen := len(u)
-	if len(v) < minLen {
-		minLen = len(v)
-	}
-	
-	result := 0.0
-	for i := 0; i < minLen; i++ {
-		for j := 0; j < minLen; j++ {
-			if i < len(ta.Metric) && j < len(ta.Metric[i]) {
-				result += u[i] * ta.Metric[i][j] * v[j]
-			}
-		}
+// InnerProduct is uᵀ·Metric·v for vectors u and v of the algebra's dimension.
+func (ta *TensorAlgebra) InnerProduct(u, v *tensor.Tensor[float64]) (float64, error) {
+	transformed, err := tensor.MatMul(ta.Metric, v)
+	if err != nil {
+		return 0, err
 	}
 	
-	return result
+	return tensor.Dot(u, transformed)
 }
 
-func (ta *TensorAlgebra) CrossProduct(u, v []float64) []float64 {
-	if len(u) != 3 || len(v) != 3 {
-		return []float64{}
+func (ta *TensorAlgebra) CrossProduct(u, v *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	if u.Rank() != 1 || v.Rank() != 1 || u.Dim(0) != 3 || v.Dim(0) != 3 {
+		return nil, &tensor.ShapeError{Op: "cross product", Shapes: [][]int{u.Shape(), v.Shape()}, Reason: "are not both 3-vectors"}
 	}
 	
-	result := make([]float64, 3)
-	result[0] = u[1]*v[2] - u[2]*v[1]
-	result[1] = u[2]*v[0] - u[0]*v[2]
-	result[2] = u[0]*v[1] - u[1]*v[0]
-	
-	return result
+	a, b := u.Values(), v.Values()
+	return tensor.Vector([]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}), nil
 }
 
-func (ta *TensorAlgebra) Trace(matrix [][]float64) float64 {
-	trace := 0.0
-	minDim := len(matrix)
-	
-	for i := 0; i < minDim; i++ {
-		if i < len(matrix[i]) {
-			trace += matrix[i][i]
-		}
+func square(op string, matrix *tensor.Tensor[float64]) error {
+	if matrix.Rank() != 2 || matrix.Dim(0) != matrix.Dim(1) {
+		return &tensor.ShapeError{Op: op, Shapes: [][]int{matrix.Shape()}, Reason: "is not a square matrix"}
 	}
-	
-	return trace
+	return nil
 }
 
-func (ta *TensorAlgebra) Determinant(matrix [][]float64) float64 {
-	n := len(matrix)
-	if n == 0 {
-		return 0.0
-	}
-	
-	if n == 1 {
-		return matrix[0][0]
+func (ta *TensorAlgebra) Trace(matrix *tensor.Tensor[float64]) (float64, error) {
+	if err := square("trace", matrix); err != nil {
+		return 0, err
 	}
 	
-	if n == 2 {
-		return matrix[0][0]*matrix[1][1] - matrix[0][1]*matrix[1][0]
-	}
-	
-	det := 0.0
-	for j := 0; j < n; j++ {
-		minor := ta.getMinor(matrix, 0, j)
-		cofactor := math.Pow(-1, float64(j)) * matrix[0][j] * ta.Determinant(minor)
-		det += cofactor
+	trace := 0.0
+	for i := 0; i < matrix.Dim(0); i++ {
+		value, _ := matrix.At(i, i)
+		trace += value
 	}
 	
-	return det
+	return trace, nil
 }
 
-func (ta *TensorAlgebra) getMinor(matrix [][]float64, row, col int) [][]float64 {
-	n := len(matrix)
-	minor := make([][]float64, n-1)
-	
-	minorRow := 0
-	for i := 0; i < n; i++ {
-		if i == row {
-			continue
-		}
-		
-		minor[minorRow] = make([]float64, n-1)
-		minorCol := 0
-		
-		for j := 0; j < n; j++ {
-			if j == col {
-				continue
-			}
-			minor[minorRow][minorCol] = matrix[i][j]
-			minorCol++
-		}
-		minorRow++
-	}
-	
-	return minor
+// Determinant is computed from an LU factorization with partial pivoting.
+func (ta *TensorAlgebra) Determinant(matrix *tensor.Tensor[float64]) (float64, error) {
+	return linalg.Det(matrix)
 }
 
-func (ta *TensorAlgebra) Transpose(matrix [][]float64) [][]float64 {
-	if len(matrix) == 0 {
-		return [][]float64{}
-	}
-	
-	rows := len(matrix)
-	cols := len(matrix[0])
-	
-	result := make([][]float64, cols)
-	for i := range result {
-		result[i] = make([]float64, rows)
-		for j := range result[i] {
-			result[i][j] = matrix[j][i]
-		}
+func (ta *TensorAlgebra) Transpose(matrix *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	if matrix.Rank() != 2 {
+		return nil, &tensor.ShapeError{Op: "transpose", Shapes: [][]int{matrix.Shape()}, Reason: "is not a matrix"}
 	}
 	
-	return result
+	return matrix.Transpose()
 }
 
-func (ta *TensorAlgebra) MatrixMultiply(a, b [][]float64) [][]float64 {
-	if len(a) == 0 || len(b) == 0 || len(a[0]) != len(b) {
-		return [][]float64{}
-	}
-	
-	rows := len(a)
-	cols := len(b[0])
-	common := len(a[0])
-	
-	result := make([][]float64, rows)
-	for i := range result {
-		result[i] = make([]float64, cols)
-		for j := range result[i] {
-			for k := 0; k < common; k++ {
-				result[i][j] += a[i][k] * b[k][j]
-			}
-		}
-	}
-	
-	return result
+func (ta *TensorAlgebra) MatrixMultiply(a, b *tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.MatMul(a, b)
 }
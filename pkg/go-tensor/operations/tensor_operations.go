@@ -1,6 +1,11 @@
 package operations
 
-import "math"
+import (
+	"fmt"
+	"math"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
 
 type TensorOperator struct {
 	Operations map[string]Operation
@@ -8,7 +13,7 @@ type TensorOperator struct {
 
 type Operation struct {
 	Name     string
-	Function func([][]float64) []float64
+	Function func([]*tensor.Tensor[float64]) (*tensor.Tensor[float64], error)
 	Arity    int
 }
 
@@ -53,130 +58,58 @@ func (to *TensorOperator) registerOperations() {
 	}
 }
 
-func (to *TensorOperator) addOperation(tensors [][]float64) []float64 {
-	if len(tensors) < 2 {
-		return []float64{}
-	}
-	
-	tensor1 := tensors[0]
-	tensor2 := tensors[1]
-	
-	minLen := len(tensor1)
-	if len(tensor2) < minLen {
-		minLen = len(tensor2)
-	}
-	
-	result := make([]float64, minLen)
-	for i := 0; i < minLen; i++ {
-		result[i] = tensor1[i] + tensor2[i]
-	}
-	
-	return result
+func (to *TensorOperator) addOperation(tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Add(tensors[0], tensors[1])
 }
 
-func (to *TensorOperator) multiplyOperation(tensors [][]float64) []float64 {
-	if len(tensors) < 2 {
-		return []float64{}
-	}
-	
-	tensor1 := tensors[0]
-	tensor2 := tensors[1]
-	
-	minLen := len(tensor1)
-	if len(tensor2) < minLen {
-		minLen = len(tensor2)
-	}
-	
-	result := make([]float64, minLen)
-	for i := 0; i < minLen; i++ {
-		result[i] = tensor1[i] * tensor2[i]
-	}
-	
-	return result
+func (to *TensorOperator) multiplyOperation(tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Mul(tensors[0], tensors[1])
 }
 
-func (to *TensorOperator) contractOperation(tensors [][]float64) []float64 {
-	if len(tensors) < 2 {
-		return []float64{}
-	}
-	
-	tensor1 := tensors[0]
-	tensor2 := tensors[1]
-	
-	minLen := len(tensor1)
-	if len(tensor2) < minLen {
-		minLen = len(tensor2)
-	}
-	
-	contraction := 0.0
-	for i := 0; i < minLen; i++ {
-		contraction += tensor1[i] * tensor2[i]
+func (to *TensorOperator) contractOperation(tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	contraction, err := tensor.Dot(tensors[0], tensors[1])
+	if err != nil {
+		return nil, err
 	}
 	
-	return []float64{contraction}
+	return tensor.Scalar(contraction), nil
 }
 
-func (to *TensorOperator) outerProductOperation(tensors [][]float64) []float64 {
-	if len(tensors) < 2 {
-		return []float64{}
-	}
-	
-	tensor1 := tensors[0]
-	tensor2 := tensors[1]
-	
-	result := make([]float64, len(tensor1)*len(tensor2))
-	
-	for i, val1 := range tensor1 {
-		for j, val2 := range tensor2 {
-			result[i*len(tensor2)+j] = val1 * val2
-		}
-	}
-	
-	return result
+func (to *TensorOperator) outerProductOperation(tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Outer(tensors[0], tensors[1]), nil
 }
 
-func (to *TensorOperator) transformOperation(tensors [][]float64) []float64 {
-	if len(tensors) < 1 {
-		return []float64{}
+func (to *TensorOperator) transformOperation(tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Map(tensors[0], math.Tanh), nil
+}
+
+func (to *TensorOperator) Apply(operationName string, tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	operation, exists := to.Operations[operationName]
+	if !exists {
+		return nil, fmt.Errorf("operations: unknown operation %q", operationName)
 	}
-	
-	tensor := tensors[0]
-	result := make([]float64, len(tensor))
-	
-	for i, val := range tensor {
-		result[i] = math.Tanh(val)
+	if len(tensors) < operation.Arity {
+		return nil, fmt.Errorf("operations: %s needs %d tensors, got %d", operationName, operation.Arity, len(tensors))
 	}
 	
-	return result
+	return operation.Function(tensors)
 }
 
-func (to *TensorOperator) Apply(operationName string, tensors [][]float64) []float64 {
-	if operation, exists := to.Operations[operationName]; exists {
-		if len(tensors) >= operation.Arity {
-			return operation.Function(tensors)
-		}
-	}
-	return []float64{}
+// Contract evaluates an Einstein-notation contraction such as "ij,jk->ik";
+// see tensor.Einsum.
+func (to *TensorOperator) Contract(spec string, tensors []*tensor.Tensor[float64]) (*tensor.Tensor[float64], error) {
+	return tensor.Einsum(spec, tensors...)
 }
 
-func (to *TensorOperator) ComputeNorm(tensor []float64) float64 {
-	sum := 0.0
-	for _, val := range tensor {
-		sum += val * val
-	}
-	return math.Sqrt(sum)
+func (to *TensorOperator) ComputeNorm(t *tensor.Tensor[float64]) float64 {
+	return tensor.Norm(t)
 }
 
-func (to *TensorOperator) Normalize(tensor []float64) []float64 {
-	norm := to.ComputeNorm(tensor)
+func (to *TensorOperator) Normalize(t *tensor.Tensor[float64]) *tensor.Tensor[float64] {
+	norm := to.ComputeNorm(t)
 	if norm == 0 {
-		return tensor
-	}
-	
-	result := make([]float64, len(tensor))
-	for i, val := range tensor {
-		result[i] = val / norm
+		return t
 	}
 	
-	return result
+	return tensor.Scale(t, 1/norm)
 }
@@ -0,0 +1,115 @@
+// Package sparse stores matrices that are mostly zeros, such as the
+// couplings of a hierarchy where each entity interacts only with its
+// mentor. COO lists entries as (row, column, value) triples and is the
+// format to build a matrix in; CSR groups them by row and is the format to
+// multiply with.
+package sparse
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// COO is a sparse matrix in coordinate format. Entries may be added in any
+// order, and entries at the same position add up.
+type COO[T tensor.Number] struct {
+	rows, cols int
+	row, col   []int
+	value      []T
+}
+
+func NewCOO[T tensor.Number](rows, cols int) (*COO[T], error) {
+	if rows < 0 || cols < 0 {
+		return nil, &tensor.ShapeError{Op: "sparse", Shapes: [][]int{{rows, cols}}, Reason: "has a negative dimension"}
+	}
+	return &COO[T]{rows: rows, cols: cols}, nil
+}
+
+// COOFromDense lists the nonzero entries of a matrix.
+func COOFromDense[T tensor.Number](a *tensor.Tensor[T]) (*COO[T], error) {
+	values, err := a.Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &COO[T]{rows: a.Dim(0), cols: a.Dim(1)}
+	for i, row := range values {
+		for j, value := range row {
+			if value != 0 {
+				m.add(i, j, value)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *COO[T]) Shape() []int {
+	return []int{m.rows, m.cols}
+}
+
+// NNZ is the number of stored entries, counting repeated positions
+// separately.
+func (m *COO[T]) NNZ() int {
+	return len(m.value)
+}
+
+// Add adds value to the entry at (i, j).
+func (m *COO[T]) Add(i, j int, value T) error {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return &tensor.ShapeError{Op: "sparse add", Shapes: [][]int{m.Shape()}, Reason: fmt.Sprintf("has no entry (%d, %d)", i, j)}
+	}
+	m.add(i, j, value)
+	return nil
+}
+
+func (m *COO[T]) add(i, j int, value T) {
+	m.row = append(m.row, i)
+	m.col = append(m.col, j)
+	m.value = append(m.value, value)
+}
+
+// Do calls f with each stored entry in the order they were added.
+func (m *COO[T]) Do(f func(i, j int, value T)) {
+	for k, value := range m.value {
+		f(m.row[k], m.col[k], value)
+	}
+}
+
+// ToCSR sorts the entries by row and column and sums those at the same
+// position.
+func (m *COO[T]) ToCSR() *CSR[T] {
+	order := make([]int, len(m.value))
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(a, b int) bool {
+		p, q := order[a], order[b]
+		if m.row[p] != m.row[q] {
+			return m.row[p] < m.row[q]
+		}
+		return m.col[p] < m.col[q]
+	})
+
+	c := &CSR[T]{rows: m.rows, cols: m.cols, indptr: make([]int, m.rows+1)}
+	for n, k := range order {
+		if n > 0 {
+			if previous := order[n-1]; m.row[previous] == m.row[k] && m.col[previous] == m.col[k] {
+				c.value[len(c.value)-1] += m.value[k]
+				continue
+			}
+		}
+		c.index = append(c.index, m.col[k])
+		c.value = append(c.value, m.value[k])
+		c.indptr[m.row[k]+1]++
+	}
+	for i := 0; i < m.rows; i++ {
+		c.indptr[i+1] += c.indptr[i]
+	}
+	return c
+}
+
+func (m *COO[T]) ToDense() *tensor.Tensor[T] {
+	return m.ToCSR().ToDense()
+}
@@ -0,0 +1,144 @@
+package sparse
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+// CSR is a sparse matrix in compressed sparse row format: the entries of
+// row i are index[indptr[i]:indptr[i+1]] and value[indptr[i]:indptr[i+1]],
+// in ascending column order.
+type CSR[T tensor.Number] struct {
+	rows, cols int
+	indptr     []int
+	index      []int
+	value      []T
+}
+
+// CSRFromDense compresses the nonzero entries of a matrix.
+func CSRFromDense[T tensor.Number](a *tensor.Tensor[T]) (*CSR[T], error) {
+	m, err := COOFromDense(a)
+	if err != nil {
+		return nil, err
+	}
+	return m.ToCSR(), nil
+}
+
+func (m *CSR[T]) Shape() []int {
+	return []int{m.rows, m.cols}
+}
+
+func (m *CSR[T]) NNZ() int {
+	return len(m.value)
+}
+
+// At is the entry at (i, j), which is zero unless stored.
+func (m *CSR[T]) At(i, j int) (T, error) {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return 0, &tensor.ShapeError{Op: "sparse at", Shapes: [][]int{m.Shape()}, Reason: fmt.Sprintf("has no entry (%d, %d)", i, j)}
+	}
+	columns := m.index[m.indptr[i]:m.indptr[i+1]]
+	if k := sort.SearchInts(columns, j); k < len(columns) && columns[k] == j {
+		return m.value[m.indptr[i]+k], nil
+	}
+	return 0, nil
+}
+
+// Row is the columns and values of the entries stored in row i. They share
+// the matrix's storage and must not be modified.
+func (m *CSR[T]) Row(i int) ([]int, []T) {
+	return m.index[m.indptr[i]:m.indptr[i+1]], m.value[m.indptr[i]:m.indptr[i+1]]
+}
+
+// MulVec is the product of the matrix with a vector x.
+func (m *CSR[T]) MulVec(x *tensor.Tensor[T]) (*tensor.Tensor[T], error) {
+	if x.Rank() != 1 || x.Dim(0) != m.cols {
+		return nil, &tensor.ShapeError{Op: "spmv", Shapes: [][]int{m.Shape(), x.Shape()}, Reason: "cannot be multiplied"}
+	}
+
+	xs := x.Values()
+	out := make([]T, m.rows)
+	for i := range out {
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			out[i] += m.value[k] * xs[m.index[k]]
+		}
+	}
+	return tensor.Vector(out), nil
+}
+
+// MatMul is the product of the matrix with a dense matrix b. A vector b is
+// treated as a column, as in tensor.MatMul.
+func (m *CSR[T]) MatMul(b *tensor.Tensor[T]) (*tensor.Tensor[T], error) {
+	if b.Rank() == 1 {
+		return m.MulVec(b)
+	}
+	if b.Rank() != 2 || b.Dim(0) != m.cols {
+		return nil, &tensor.ShapeError{Op: "sparse matmul", Shapes: [][]int{m.Shape(), b.Shape()}, Reason: "cannot be multiplied"}
+	}
+
+	n := b.Dim(1)
+	bs := b.Values()
+	out := make([]T, m.rows*n)
+	for i := 0; i < m.rows; i++ {
+		row := out[i*n : (i+1)*n]
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			u := m.value[k]
+			for j, v := range bs[m.index[k]*n : (m.index[k]+1)*n] {
+				row[j] += u * v
+			}
+		}
+	}
+	return tensor.FromSlice(out, m.rows, n)
+}
+
+// Transpose is the transposed matrix, also in CSR format.
+func (m *CSR[T]) Transpose() *CSR[T] {
+	t := &CSR[T]{
+		rows:   m.cols,
+		cols:   m.rows,
+		indptr: make([]int, m.cols+1),
+		index:  make([]int, len(m.index)),
+		value:  make([]T, len(m.value)),
+	}
+	for _, j := range m.index {
+		t.indptr[j+1]++
+	}
+	for j := 0; j < m.cols; j++ {
+		t.indptr[j+1] += t.indptr[j]
+	}
+
+	// Walking rows in order fills each column of the transpose in order.
+	next := append([]int{}, t.indptr[:m.cols]...)
+	for i := 0; i < m.rows; i++ {
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			j := m.index[k]
+			t.index[next[j]] = i
+			t.value[next[j]] = m.value[k]
+			next[j]++
+		}
+	}
+	return t
+}
+
+func (m *CSR[T]) ToCOO() *COO[T] {
+	c := &COO[T]{rows: m.rows, cols: m.cols}
+	for i := 0; i < m.rows; i++ {
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			c.add(i, m.index[k], m.value[k])
+		}
+	}
+	return c
+}
+
+func (m *CSR[T]) ToDense() *tensor.Tensor[T] {
+	dense := make([]T, m.rows*m.cols)
+	for i := 0; i < m.rows; i++ {
+		for k := m.indptr[i]; k < m.indptr[i+1]; k++ {
+			dense[i*m.cols+m.index[k]] = m.value[k]
+		}
+	}
+	out, _ := tensor.FromSlice(dense, m.rows, m.cols)
+	return out
+}
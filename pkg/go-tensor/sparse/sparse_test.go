@@ -0,0 +1,110 @@
+package sparse
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ykashou/go-elder/pkg/go-tensor/tensor"
+)
+
+func example(t *testing.T) (*COO[float64], *tensor.Tensor[float64]) {
+	t.Helper()
+	m, err := NewCOO[float64](3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []struct {
+		i, j  int
+		value float64
+	}{{2, 1, 5}, {0, 3, 1}, {0, 0, 2}, {2, 1, 1}, {1, 2, -3}} {
+		if err := m.Add(entry.i, entry.j, entry.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dense, _ := tensor.FromRows([][]float64{
+		{2, 0, 0, 1},
+		{0, 0, -3, 0},
+		{0, 6, 0, 0},
+	})
+	return m, dense
+}
+
+func assertEqual(t *testing.T, name string, got, want *tensor.Tensor[float64]) {
+	t.Helper()
+	if got.String() != want.String() {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	coo, dense := example(t)
+	csr := coo.ToCSR()
+	if csr.NNZ() != 4 {
+		t.Errorf("CSR holds %d entries, want 4 after summing duplicates", csr.NNZ())
+	}
+	assertEqual(t, "COO dense", coo.ToDense(), dense)
+	assertEqual(t, "CSR dense", csr.ToDense(), dense)
+	assertEqual(t, "CSR to COO dense", csr.ToCOO().ToDense(), dense)
+
+	fromDense, err := CSRFromDense(dense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "CSRFromDense", fromDense.ToDense(), dense)
+
+	transposed, _ := dense.Transpose()
+	assertEqual(t, "transpose", csr.Transpose().ToDense(), transposed)
+
+	if value, _ := csr.At(2, 1); value != 6 {
+		t.Errorf("At(2, 1) = %g, want 6", value)
+	}
+	if value, _ := csr.At(1, 1); value != 0 {
+		t.Errorf("At(1, 1) = %g, want 0", value)
+	}
+}
+
+func TestProductsMatchDense(t *testing.T) {
+	coo, dense := example(t)
+	csr := coo.ToCSR()
+
+	x := tensor.Vector([]float64{1, 2, 3, 4})
+	got, err := csr.MulVec(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := tensor.MatMul(dense, x)
+	assertEqual(t, "MulVec", got, want)
+
+	b, _ := tensor.FromRows([][]float64{{1, 2}, {3, 4}, {5, 6}, {7, 8}})
+	got, err = csr.MatMul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = tensor.MatMul(dense, b)
+	assertEqual(t, "MatMul", got, want)
+
+	// A transposed view is not contiguous.
+	bt, _ := tensor.FromRows([][]float64{{1, 3, 5, 7}, {2, 4, 6, 8}})
+	view, _ := bt.Transpose()
+	got, _ = csr.MatMul(view)
+	assertEqual(t, "MatMul of a view", got, want)
+
+	if _, err := csr.MulVec(tensor.Vector([]float64{1, 2, 3})); err == nil {
+		t.Error("MulVec accepted a vector of the wrong length")
+	}
+}
+
+func TestShapeErrors(t *testing.T) {
+	var shapeError *tensor.ShapeError
+	if _, err := NewCOO[float64](-1, 2); !errors.As(err, &shapeError) {
+		t.Errorf("NewCOO(-1, 2) error = %v, want a ShapeError", err)
+	}
+
+	m, _ := NewCOO[complex128](2, 2)
+	if err := m.Add(2, 0, 1); !errors.As(err, &shapeError) {
+		t.Errorf("Add(2, 0) error = %v, want a ShapeError", err)
+	}
+	if _, err := COOFromDense(tensor.Vector([]float64{1, 2})); err == nil {
+		t.Error("COOFromDense accepted a vector")
+	}
+}
@@ -0,0 +1,298 @@
+package tensor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Einsum evaluates a contraction in Einstein notation, as NumPy's einsum
+// does: "ij,jk->ik" multiplies matrices, "ii->" takes a trace, "i,j->ij" is
+// an outer product and "bij,bjk->bik" multiplies batches. Each operand's
+// subscripts are one letter per axis; labels shared between operands are
+// multiplied along, and labels missing from the output are summed over.
+// Without "->" the output is every label used once, in alphabetical order.
+//
+// An operand whose subscripts start with '*' is conjugated first, so
+// "*i,i->" is the Hermitian inner product of complex vectors.
+//
+// With more than two operands the contractions are done in pairs, choosing
+// at each step the pair that costs the fewest multiplications.
+func Einsum[T Number](spec string, operands ...*Tensor[T]) (*Tensor[T], error) {
+	expr, err := parseEinsum(spec, len(operands))
+	if err != nil {
+		return nil, err
+	}
+	shapes := make([][]int, len(operands))
+	for i, operand := range operands {
+		shapes[i] = operand.shape
+	}
+	dims, err := expr.dimensions(spec, shapes)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]einsumTerm[T], len(operands))
+	for i, operand := range operands {
+		if expr.conjugate[i] {
+			operand = Conj(operand)
+		}
+		terms[i] = einsumTerm[T]{operand, expr.inputs[i]}
+	}
+
+	// Sum out labels no other operand or the output needs, so that pairs are
+	// contracted over as few labels as possible.
+	for i := range terms {
+		keep := keptLabels(expr.output, labelsOf(terms), i, -1)
+		if keep != terms[i].labels {
+			terms[i] = contract(dims, keep, terms[i])
+		}
+	}
+
+	for _, pair := range planContraction(dims, expr.output, labelsOf(terms)) {
+		i, j := pair[0], pair[1]
+		keep := keptLabels(expr.output, labelsOf(terms), i, j)
+		combined := contract(dims, keep, terms[i], terms[j])
+		terms = append(terms[:j], terms[j+1:]...)
+		terms[i] = combined
+	}
+
+	return contract(dims, expr.output, terms[0]).tensor, nil
+}
+
+// EinsumPath is the order in which Einsum would contract operands of the
+// given shapes: each step replaces the pair at the two positions with
+// their contraction, placed at the first position. It also returns the
+// number of multiplications the pairs take.
+func EinsumPath(spec string, shapes ...[]int) ([][2]int, int, error) {
+	expr, err := parseEinsum(spec, len(shapes))
+	if err != nil {
+		return nil, 0, err
+	}
+	dims, err := expr.dimensions(spec, shapes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	labels := make([]string, len(shapes))
+	for i := range labels {
+		labels[i] = keptLabels(expr.output, expr.inputs, i, -1)
+	}
+
+	path := planContraction(dims, expr.output, labels)
+	cost := 0
+	for _, pair := range path {
+		i, j := pair[0], pair[1]
+		cost += size(labelDims(dims, union(labels[i], labels[j])))
+		labels[i] = keptLabels(expr.output, labels, i, j)
+		labels = append(labels[:j], labels[j+1:]...)
+	}
+	return path, cost, nil
+}
+
+type einsumExpr struct {
+	inputs    []string
+	conjugate []bool
+	output    string
+}
+
+type einsumTerm[T Number] struct {
+	tensor *Tensor[T]
+	labels string
+}
+
+func parseEinsum(spec string, operands int) (*einsumExpr, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("tensor: einsum %q: %s", spec, reason)
+	}
+
+	compact := strings.ReplaceAll(spec, " ", "")
+	lhs, output, explicit := strings.Cut(compact, "->")
+	expr := &einsumExpr{inputs: strings.Split(lhs, ",")}
+	if len(expr.inputs) != operands {
+		return nil, invalid(fmt.Sprintf("names %d operands but %d were given", len(expr.inputs), operands))
+	}
+
+	counts := make(map[rune]int)
+	expr.conjugate = make([]bool, operands)
+	for i, input := range expr.inputs {
+		if strings.HasPrefix(input, "*") {
+			expr.conjugate[i], input = true, input[1:]
+			expr.inputs[i] = input
+		}
+		for _, label := range input {
+			if !isLabel(label) {
+				return nil, invalid(fmt.Sprintf("subscript %q is not a letter", label))
+			}
+			counts[label]++
+		}
+	}
+
+	if !explicit {
+		var once []rune
+		for label, count := range counts {
+			if count == 1 {
+				once = append(once, label)
+			}
+		}
+		sort.Slice(once, func(i, j int) bool { return once[i] < once[j] })
+		output = string(once)
+	}
+	seen := make(map[rune]bool)
+	for _, label := range output {
+		if counts[label] == 0 {
+			return nil, invalid(fmt.Sprintf("output subscript %q is not an input subscript", label))
+		}
+		if seen[label] {
+			return nil, invalid(fmt.Sprintf("output subscript %q repeats", label))
+		}
+		seen[label] = true
+	}
+	expr.output = output
+	return expr, nil
+}
+
+func isLabel(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// dimensions is the length of each label, which must agree wherever the
+// label appears.
+func (expr *einsumExpr) dimensions(spec string, shapes [][]int) (map[rune]int, error) {
+	dims := make(map[rune]int)
+	for i, input := range expr.inputs {
+		if len(input) != len(shapes[i]) {
+			return nil, shapeError("einsum "+spec, fmt.Sprintf("operand %d has %d subscripts for %d axes", i, len(input), len(shapes[i])), shapes...)
+		}
+		for axis, label := range input {
+			if n, ok := dims[label]; ok && n != shapes[i][axis] {
+				return nil, shapeError("einsum "+spec, fmt.Sprintf("subscript %q has lengths %d and %d", label, n, shapes[i][axis]), shapes...)
+			}
+			dims[label] = shapes[i][axis]
+		}
+	}
+	return dims, nil
+}
+
+// keptLabels is the labels of term i, combined with term j unless j is
+// negative, that the output or the other terms still use.
+func keptLabels(output string, labels []string, i, j int) string {
+	own := labels[i]
+	if j >= 0 {
+		own = union(own, labels[j])
+	}
+	var kept []rune
+	for _, label := range own {
+		if containsRune(kept, label) {
+			continue
+		}
+		used := strings.ContainsRune(output, label)
+		for k, other := range labels {
+			if k != i && k != j && strings.ContainsRune(other, label) {
+				used = true
+			}
+		}
+		if used {
+			kept = append(kept, label)
+		}
+	}
+	return string(kept)
+}
+
+func labelsOf[T Number](terms []einsumTerm[T]) []string {
+	labels := make([]string, len(terms))
+	for i, term := range terms {
+		labels[i] = term.labels
+	}
+	return labels
+}
+
+// planContraction picks pairs of terms to contract greedily: the pair
+// costing the fewest multiplications first, breaking ties by the smaller
+// result.
+func planContraction(dims map[rune]int, output string, labels []string) [][2]int {
+	labels = append([]string{}, labels...)
+	var path [][2]int
+	for len(labels) > 1 {
+		best, bestCost, bestSize := [2]int{}, -1, -1
+		for i := 0; i < len(labels); i++ {
+			for j := i + 1; j < len(labels); j++ {
+				cost := size(labelDims(dims, union(labels[i], labels[j])))
+				result := size(labelDims(dims, keptLabels(output, labels, i, j)))
+				if bestCost < 0 || cost < bestCost || (cost == bestCost && result < bestSize) {
+					best, bestCost, bestSize = [2]int{i, j}, cost, result
+				}
+			}
+		}
+		path = append(path, best)
+		labels[best[0]] = keptLabels(output, labels, best[0], best[1])
+		labels = append(labels[:best[1]], labels[best[1]+1:]...)
+	}
+	return path
+}
+
+// contract multiplies terms elementwise over every combination of their
+// labels and sums the products into a tensor over keep, in that order.
+func contract[T Number](dims map[rune]int, keep string, terms ...einsumTerm[T]) einsumTerm[T] {
+	all := keep
+	for _, term := range terms {
+		all = union(all, term.labels)
+	}
+	shape := labelDims(dims, all)
+
+	out := Zeros[T](labelDims(dims, keep)...)
+	strides := [][]int{labelStrides(all, keep, out.strides)}
+	starts := []int{0}
+	for _, term := range terms {
+		strides = append(strides, labelStrides(all, term.labels, term.tensor.strides))
+		starts = append(starts, term.tensor.offset)
+	}
+
+	walk(shape, strides, starts, func(offsets []int) {
+		product := terms[0].tensor.data[offsets[1]]
+		for k := 1; k < len(terms); k++ {
+			product *= terms[k].tensor.data[offsets[k+1]]
+		}
+		out.data[offsets[0]] += product
+	})
+	return einsumTerm[T]{out, keep}
+}
+
+// labelStrides maps the strides of a tensor whose axes carry labels onto
+// the axes all: zero where the tensor lacks the label, and summed where it
+// repeats, which walks the diagonal.
+func labelStrides(all, labels string, strides []int) []int {
+	mapped := make([]int, len(all))
+	for axis, label := range []rune(labels) {
+		mapped[strings.IndexRune(all, label)] += strides[axis]
+	}
+	return mapped
+}
+
+func labelDims(dims map[rune]int, labels string) []int {
+	shape := make([]int, 0, len(labels))
+	for _, label := range labels {
+		shape = append(shape, dims[label])
+	}
+	return shape
+}
+
+// union is a followed by the labels of b not in a, each once.
+func union(a, b string) string {
+	var labels []rune
+	for _, label := range a + b {
+		if !containsRune(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return string(labels)
+}
+
+func containsRune(labels []rune, r rune) bool {
+	for _, label := range labels {
+		if label == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+package tensor
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func randomTensor[T Number](rng *rand.Rand, shape ...int) *Tensor[T] {
+	t := Zeros[T](shape...)
+	for i := range t.data {
+		var value any
+		switch any(t.data[i]).(type) {
+		case complex128:
+			value = complex(2*rng.Float64()-1, 2*rng.Float64()-1)
+		default:
+			value = 2*rng.Float64() - 1
+		}
+		t.data[i] = value.(T)
+	}
+	return t
+}
+
+// naiveEinsum evaluates spec by summing the product of every operand over
+// all combinations of every label, the definition Einsum must agree with.
+func naiveEinsum[T Number](t *testing.T, spec string, operands ...*Tensor[T]) *Tensor[T] {
+	t.Helper()
+	lhs, output, _ := strings.Cut(spec, "->")
+	inputs := strings.Split(lhs, ",")
+	operands = append([]*Tensor[T]{}, operands...)
+
+	dims := make(map[rune]int)
+	for i, input := range inputs {
+		if strings.HasPrefix(input, "*") {
+			operands[i], inputs[i] = Conj(operands[i]), input[1:]
+		}
+		for axis, label := range inputs[i] {
+			dims[label] = operands[i].Dim(axis)
+		}
+	}
+	labels := make([]rune, 0, len(dims))
+	for label := range dims {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+	out := Zeros[T](labelDims(dims, output)...)
+	index := make(map[rune]int)
+	var visit func(k int)
+	visit = func(k int) {
+		if k == len(labels) {
+			product := T(1)
+			for i, input := range inputs {
+				at := make([]int, 0, len(input))
+				for _, label := range input {
+					at = append(at, index[label])
+				}
+				value, err := operands[i].At(at...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				product *= value
+			}
+			at := make([]int, 0, len(output))
+			for _, label := range output {
+				at = append(at, index[label])
+			}
+			current, _ := out.At(at...)
+			out.Set(current+product, at...)
+			return
+		}
+		for i := 0; i < dims[labels[k]]; i++ {
+			index[labels[k]] = i
+			visit(k + 1)
+		}
+	}
+	visit(0)
+	return out
+}
+
+func assertNear[T Number](t *testing.T, got, want *Tensor[T]) {
+	t.Helper()
+	if !reflect.DeepEqual(got.Shape(), want.Shape()) {
+		t.Fatalf("shape %v, want %v", got.Shape(), want.Shape())
+	}
+	g, w := got.Values(), want.Values()
+	for i := range g {
+		if Abs(g[i]-w[i]) > 1e-12*(1+Abs(w[i])) {
+			t.Fatalf("element %d is %v, want %v", i, g[i], w[i])
+		}
+	}
+}
+
+// einsumError calls Einsum and EinsumPath expecting both to fail, and fails
+// the test rather than the run if either panics.
+func einsumError(t *testing.T, spec string, operands ...*Tensor[float64]) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("einsum %q panicked: %v", spec, r)
+		}
+	}()
+
+	if _, err := Einsum(spec, operands...); err == nil {
+		t.Errorf("einsum %q: expected an error", spec)
+	}
+	shapes := make([][]int, len(operands))
+	for i, operand := range operands {
+		shapes[i] = operand.Shape()
+	}
+	if _, _, err := EinsumPath(spec, shapes...); err == nil {
+		t.Errorf("einsum path %q: expected an error", spec)
+	}
+}
+
+func TestEinsumErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		operands []*Tensor[float64]
+	}{
+		{"too few operands", "ij,jk->ik", []*Tensor[float64]{arange(2, 2)}},
+		{"too many operands", "ij->ij", []*Tensor[float64]{arange(2, 2), arange(2, 2)}},
+		{"digit subscript", "i1->i", []*Tensor[float64]{arange(2, 2)}},
+		{"misplaced star", "i*->i", []*Tensor[float64]{arange(2, 2)}},
+		{"double star", "**i->i", []*Tensor[float64]{arange(2)}},
+		{"unknown output", "ij->ik", []*Tensor[float64]{arange(2, 2)}},
+		{"repeated output", "ij->ii", []*Tensor[float64]{arange(2, 2)}},
+		{"second arrow", "i->i->i", []*Tensor[float64]{arange(2)}},
+		{"too few subscripts", "i->i", []*Tensor[float64]{arange(2, 2)}},
+		{"too many subscripts", "ijk->i", []*Tensor[float64]{arange(2, 2)}},
+		{"scalar with subscripts", "i->", []*Tensor[float64]{Scalar(1.0)}},
+		{"length mismatch", "ij,jk->ik", []*Tensor[float64]{arange(2, 3), arange(2, 3)}},
+		{"non-square trace", "ii->", []*Tensor[float64]{arange(2, 3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			einsumError(t, tt.spec, tt.operands...)
+		})
+	}
+}
+
+func TestEinsumRepeatedIndices(t *testing.T) {
+	square := arange(3, 3)
+	cube := arange(2, 3, 3)
+
+	tests := []struct {
+		name     string
+		spec     string
+		operands []*Tensor[float64]
+		shape    []int
+		values   []float64
+	}{
+		{"trace", "ii->", []*Tensor[float64]{square}, []int{}, []float64{12}},
+		{"diagonal", "ii->i", []*Tensor[float64]{square}, []int{3}, []float64{0, 4, 8}},
+		{"batched trace", "bii->b", []*Tensor[float64]{cube}, []int{2}, []float64{12, 39}},
+		{"batched diagonal", "bii->bi", []*Tensor[float64]{cube}, []int{2, 3}, []float64{0, 4, 8, 9, 13, 17}},
+		// Σᵢ a[i,i]·b[i] weighs the diagonal.
+		{"diagonal against vector", "ii,i->", []*Tensor[float64]{square, arange(3)}, []int{}, []float64{20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Einsum(tt.spec, tt.operands...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+			assertNear(t, got, naiveEinsum(t, tt.spec, tt.operands...))
+		})
+	}
+}
+
+func TestEinsumImplicitOutput(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	a, b := randomTensor[float64](rng, 2, 3), randomTensor[float64](rng, 3, 4)
+	v := randomTensor[float64](rng, 3)
+
+	tests := []struct {
+		name             string
+		implicit, output string
+		operands         []*Tensor[float64]
+	}{
+		{"matrix product", "ij,jk", "ij,jk->ik", []*Tensor[float64]{a, b}},
+		{"alphabetical order transposes", "ji", "ji->ij", []*Tensor[float64]{a}},
+		{"trace", "ii", "ii->", []*Tensor[float64]{arange(3, 3)}},
+		{"inner product", "i,i", "i,i->", []*Tensor[float64]{v, v}},
+		{"outer product", "j,i", "j,i->ij", []*Tensor[float64]{v, v}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Einsum(tt.implicit, tt.operands...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := Einsum(tt.output, tt.operands...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertNear(t, got, want)
+		})
+	}
+}
+
+func TestEinsumConjugate(t *testing.T) {
+	a := Vector([]complex128{1 + 2i, 3 - 1i})
+	b := Vector([]complex128{2 - 1i, 1i})
+
+	// ⟨a, b⟩ = Σ conj(aᵢ)·bᵢ = (1-2i)(2-i) + (3+i)i = -1 - 2i.
+	got, err := Einsum("*i,i->", a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := got.Item(); value != -1-2i {
+		t.Errorf("Hermitian inner product %v, want -1-2i", value)
+	}
+
+	// ⟨a, a⟩ is the squared norm, real and positive.
+	got, _ = Einsum("*i,i", a, a)
+	if value, _ := got.Item(); value != 15 {
+		t.Errorf("squared norm %v, want 15", value)
+	}
+
+	// Without the star no operand is conjugated.
+	got, _ = Einsum("i,i->", a, b)
+	if value, _ := got.Item(); value != 5+6i {
+		t.Errorf("bilinear product %v, want 5+6i", value)
+	}
+
+	// Conjugation leaves the operand itself alone.
+	if value, _ := a.At(0); value != 1+2i {
+		t.Errorf("operand was modified: %v", value)
+	}
+
+	// A conjugated matrix: Aᴴ·B for random complex matrices.
+	rng := rand.New(rand.NewSource(2))
+	m, n := randomTensor[complex128](rng, 3, 2), randomTensor[complex128](rng, 3, 4)
+	got, err = Einsum("*ij,ik->jk", m, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mT, _ := m.Transpose()
+	want, _ := MatMul(Conj(mT), n)
+	assertNear(t, got, want)
+}
+
+func TestEinsumMatchesNaiveSum(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	tests := []struct {
+		spec   string
+		shapes [][]int
+	}{
+		{"ij,jk,kl->il", [][]int{{2, 3}, {3, 4}, {4, 2}}},
+		{"ab,bc,cd,de->ae", [][]int{{2, 5}, {5, 1}, {1, 4}, {4, 3}}},
+		{"ijk,jl,kl->i", [][]int{{2, 3, 4}, {3, 5}, {4, 5}}},
+		{"i,i,i->", [][]int{{4}, {4}, {4}}},
+		// b appears in all three operands and must survive until the last.
+		{"ab,b,cab->c", [][]int{{2, 3}, {3}, {4, 2, 3}}},
+		{"ij,jk,k->i", [][]int{{5, 6}, {6, 7}, {7}}},
+		{"bij,bjk,bkl->bil", [][]int{{2, 2, 3}, {2, 3, 2}, {2, 2, 4}}},
+		{"ii,ij,j->", [][]int{{3, 3}, {3, 2}, {2}}},
+		{"ij,kl,jk", [][]int{{2, 3}, {4, 2}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			reals := make([]*Tensor[float64], len(tt.shapes))
+			complexOperands := make([]*Tensor[complex128], len(tt.shapes))
+			for i, shape := range tt.shapes {
+				reals[i] = randomTensor[float64](rng, shape...)
+				complexOperands[i] = randomTensor[complex128](rng, shape...)
+			}
+
+			got, err := Einsum(tt.spec, reals...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			explicit := tt.spec
+			if !strings.Contains(explicit, "->") {
+				explicit += "->" + explicitOutput(tt.spec)
+			}
+			assertNear(t, got, naiveEinsum(t, explicit, reals...))
+
+			conjugated := "*" + explicit
+			gotComplex, err := Einsum(conjugated, complexOperands...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertNear(t, gotComplex, naiveEinsum(t, conjugated, complexOperands...))
+
+			path, _, err := EinsumPath(tt.spec, tt.shapes...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(path) != len(tt.shapes)-1 {
+				t.Errorf("path %v has %d steps for %d operands", path, len(path), len(tt.shapes))
+			}
+		})
+	}
+}
+
+// explicitOutput is the output Einsum infers for an implicit spec: every
+// label used once, alphabetically.
+func explicitOutput(spec string) string {
+	counts := make(map[rune]int)
+	for _, label := range strings.ReplaceAll(spec, ",", "") {
+		counts[label]++
+	}
+	var once []rune
+	for label, count := range counts {
+		if count == 1 {
+			once = append(once, label)
+		}
+	}
+	sort.Slice(once, func(i, j int) bool { return once[i] < once[j] })
+	return string(once)
+}
+
+func TestEinsumPathIsGreedy(t *testing.T) {
+	// Contracting the matrix with the vector first costs 10·10 rather than
+	// 10·10·10 multiplications, and leaves another matrix-vector product.
+	path, cost, err := EinsumPath("ij,jk,k->i", []int{10, 10}, []int{10, 10}, []int{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{1, 2}, {0, 1}}; !reflect.DeepEqual(path, want) {
+		t.Errorf("path %v, want %v", path, want)
+	}
+	if cost != 200 {
+		t.Errorf("cost %d, want 200", cost)
+	}
+}
@@ -0,0 +1,206 @@
+package tensor
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// Zip applies f elementwise to a and b broadcast to a common shape.
+func Zip[T Number](a, b *Tensor[T], f func(x, y T) T) (*Tensor[T], error) {
+	return zip("zip", a, b, f)
+}
+
+func zip[T Number](op string, a, b *Tensor[T], f func(x, y T) T) (*Tensor[T], error) {
+	shape, err := BroadcastShapes(a.shape, b.shape)
+	if err != nil {
+		return nil, shapeError(op, "do not broadcast together", a.shape, b.shape)
+	}
+	x, _ := a.BroadcastTo(shape...)
+	y, _ := b.BroadcastTo(shape...)
+
+	out := Zeros[T](shape...)
+	i := 0
+	walk(shape, [][]int{x.strides, y.strides}, []int{x.offset, y.offset}, func(offsets []int) {
+		out.data[i] = f(x.data[offsets[0]], y.data[offsets[1]])
+		i++
+	})
+	return out, nil
+}
+
+// Map applies f to every element of t.
+func Map[T Number](t *Tensor[T], f func(x T) T) *Tensor[T] {
+	out := Zeros[T](t.shape...)
+	i := 0
+	walk(t.shape, [][]int{t.strides}, []int{t.offset}, func(offsets []int) {
+		out.data[i] = f(t.data[offsets[0]])
+		i++
+	})
+	return out
+}
+
+func Add[T Number](a, b *Tensor[T]) (*Tensor[T], error) {
+	return zip("add", a, b, func(x, y T) T { return x + y })
+}
+
+func Sub[T Number](a, b *Tensor[T]) (*Tensor[T], error) {
+	return zip("sub", a, b, func(x, y T) T { return x - y })
+}
+
+func Mul[T Number](a, b *Tensor[T]) (*Tensor[T], error) {
+	return zip("mul", a, b, func(x, y T) T { return x * y })
+}
+
+func Div[T Number](a, b *Tensor[T]) (*Tensor[T], error) {
+	return zip("div", a, b, func(x, y T) T { return x / y })
+}
+
+func Scale[T Number](t *Tensor[T], c T) *Tensor[T] {
+	return Map(t, func(x T) T { return x * c })
+}
+
+func Sum[T Number](t *Tensor[T]) T {
+	var total T
+	walk(t.shape, [][]int{t.strides}, []int{t.offset}, func(offsets []int) {
+		total += t.data[offsets[0]]
+	})
+	return total
+}
+
+// Conj is the complex conjugate of t; real tensors are returned as they are.
+func Conj[T Number](t *Tensor[T]) *Tensor[T] {
+	if _, ok := any(t.data).([]complex128); !ok {
+		return t
+	}
+	return Map(t, func(x T) T { return any(cmplx.Conj(any(x).(complex128))).(T) })
+}
+
+// Abs is the magnitude of x.
+func Abs[T Number](x T) float64 {
+	switch v := any(x).(type) {
+	case complex128:
+		return cmplx.Abs(v)
+	case float64:
+		return math.Abs(v)
+	}
+	return 0
+}
+
+// Norm is the Frobenius norm of t: the root of the sum of its elements'
+// squared magnitudes.
+func Norm[T Number](t *Tensor[T]) float64 {
+	total := 0.0
+	walk(t.shape, [][]int{t.strides}, []int{t.offset}, func(offsets []int) {
+		m := Abs(t.data[offsets[0]])
+		total += m * m
+	})
+	return math.Sqrt(total)
+}
+
+// Dot is the sum of the elementwise products of a and b, which must have
+// the same shape. Complex elements are not conjugated.
+func Dot[T Number](a, b *Tensor[T]) (T, error) {
+	var total T
+	if !sameShape(a.shape, b.shape) {
+		return total, shapeError("dot", "differ", a.shape, b.shape)
+	}
+	walk(a.shape, [][]int{a.strides, b.strides}, []int{a.offset, b.offset}, func(offsets []int) {
+		total += a.data[offsets[0]] * b.data[offsets[1]]
+	})
+	return total, nil
+}
+
+// Outer is the outer product of a and b, whose shape is a's followed by b's.
+func Outer[T Number](a, b *Tensor[T]) *Tensor[T] {
+	x, y := a.Values(), b.Values()
+	out := Zeros[T](append(a.Shape(), b.shape...)...)
+	for i, u := range x {
+		row := out.data[i*len(y) : (i+1)*len(y)]
+		for j, v := range y {
+			row[j] = u * v
+		}
+	}
+	return out
+}
+
+// MatMul is the matrix product of an m×k matrix a and a k×n matrix b. A
+// vector b is treated as a column, giving a vector of length m.
+func MatMul[T Number](a, b *Tensor[T]) (*Tensor[T], error) {
+	if len(a.shape) != 2 || len(b.shape) < 1 || len(b.shape) > 2 || a.shape[1] != b.shape[0] {
+		return nil, shapeError("matmul", "cannot be multiplied", a.shape, b.shape)
+	}
+	m, k := a.shape[0], a.shape[1]
+	n := 1
+	if len(b.shape) == 2 {
+		n = b.shape[1]
+	}
+
+	x, y := a.Contiguous(), b.Contiguous()
+	xs, ys := x.data[x.offset:], y.data[y.offset:]
+	out := Zeros[T](m, n)
+	for i := 0; i < m; i++ {
+		row := out.data[i*n : (i+1)*n]
+		for p := 0; p < k; p++ {
+			u := xs[i*k+p]
+			if u == 0 {
+				continue
+			}
+			for j, v := range ys[p*n : (p+1)*n] {
+				row[j] += u * v
+			}
+		}
+	}
+
+	if len(b.shape) == 1 {
+		out.shape, out.strides = []int{m}, []int{1}
+	}
+	return out, nil
+}
+
+// Tensordot contracts axis axisA of a with axis axisB of b, which must
+// have the same length. The result's axes are a's remaining axes followed
+// by b's.
+func Tensordot[T Number](a, b *Tensor[T], axisA, axisB int) (*Tensor[T], error) {
+	if axisA < 0 || axisA >= len(a.shape) || axisB < 0 || axisB >= len(b.shape) {
+		return nil, shapeError("tensordot", fmt.Sprintf("have no axes %d and %d", axisA, axisB), a.shape, b.shape)
+	}
+	if a.shape[axisA] != b.shape[axisB] {
+		return nil, shapeError("tensordot", fmt.Sprintf("axes %d and %d differ in length", axisA, axisB), a.shape, b.shape)
+	}
+
+	// Move the contracted axes inwards and multiply as matrices.
+	x, _ := a.Transpose(moveAxis(len(a.shape), axisA, len(a.shape)-1)...)
+	y, _ := b.Transpose(moveAxis(len(b.shape), axisB, 0)...)
+	k := a.shape[axisA]
+	xm, _ := x.Reshape(size(x.shape[:len(x.shape)-1]), k)
+	ym, _ := y.Reshape(k, size(y.shape[1:]))
+	product, err := MatMul(xm, ym)
+	if err != nil {
+		return nil, err
+	}
+	return product.Reshape(append(x.Shape()[:len(x.shape)-1], y.shape[1:]...)...)
+}
+
+// moveAxis is the permutation of rank axes that moves axis to position to.
+func moveAxis(rank, axis, to int) []int {
+	axes := make([]int, 0, rank)
+	for i := 0; i < rank; i++ {
+		if i != axis {
+			axes = append(axes, i)
+		}
+	}
+	axes = append(axes[:to], append([]int{axis}, axes[to:]...)...)
+	return axes
+}
+
+func sameShape(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,285 @@
+// Package tensor is an N-dimensional array of real or complex numbers that
+// the other go-tensor packages are built on. A tensor is a view of a
+// buffer: its shape, the distance in the buffer between neighbours along
+// each axis, and where its first element sits. Reshaping, transposing,
+// slicing and broadcasting make new views of the same buffer without
+// copying it, so writes through one view are seen by the others.
+package tensor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number is the element type of a tensor.
+type Number interface {
+	float64 | complex128
+}
+
+// Tensor is a view of a buffer of numbers: element (i, j, …) sits at offset
+// + i·strides[0] + j·strides[1] + … in data. Tensors made by the
+// constructors are contiguous and row-major; views made from them share
+// their buffer. The zero value is not usable.
+type Tensor[T Number] struct {
+	data    []T
+	shape   []int
+	strides []int
+	offset  int
+}
+
+// ShapeError reports shapes an operation cannot work with.
+type ShapeError struct {
+	Op     string
+	Shapes [][]int
+	Reason string
+}
+
+func (e *ShapeError) Error() string {
+	shapes := make([]string, len(e.Shapes))
+	for i, shape := range e.Shapes {
+		shapes[i] = fmt.Sprint(shape)
+	}
+	return fmt.Sprintf("tensor: %s %s: %s", e.Op, strings.Join(shapes, " and "), e.Reason)
+}
+
+func shapeError(op, reason string, shapes ...[]int) error {
+	return &ShapeError{Op: op, Shapes: shapes, Reason: reason}
+}
+
+// Zeros is a contiguous tensor of zeros. It panics on a negative
+// dimension, as make does on a negative length.
+func Zeros[T Number](shape ...int) *Tensor[T] {
+	for _, n := range shape {
+		if n < 0 {
+			panic(fmt.Sprintf("tensor: negative dimension in shape %v", shape))
+		}
+	}
+	return &Tensor[T]{
+		data:    make([]T, size(shape)),
+		shape:   append([]int{}, shape...),
+		strides: rowMajor(shape),
+	}
+}
+
+// FromSlice is a contiguous tensor of the given shape over data, which it
+// uses without copying. Without a shape data forms a vector.
+func FromSlice[T Number](data []T, shape ...int) (*Tensor[T], error) {
+	if shape == nil {
+		shape = []int{len(data)}
+	}
+	for _, n := range shape {
+		if n < 0 {
+			return nil, shapeError("from slice", "negative dimension", shape)
+		}
+	}
+	if size(shape) != len(data) {
+		return nil, shapeError("from slice", fmt.Sprintf("does not hold %d values", len(data)), shape)
+	}
+	return &Tensor[T]{data: data, shape: append([]int{}, shape...), strides: rowMajor(shape)}, nil
+}
+
+// Vector is a vector over data, which it uses without copying.
+func Vector[T Number](data []T) *Tensor[T] {
+	t, _ := FromSlice(data)
+	return t
+}
+
+// Scalar is a tensor of rank zero holding value.
+func Scalar[T Number](value T) *Tensor[T] {
+	return &Tensor[T]{data: []T{value}, shape: []int{}, strides: []int{}}
+}
+
+// FromRows is a matrix holding a copy of rows, which must all have the same
+// length.
+func FromRows[T Number](rows [][]T) (*Tensor[T], error) {
+	cols := 0
+	if len(rows) > 0 {
+		cols = len(rows[0])
+	}
+	t := Zeros[T](len(rows), cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, shapeError("from rows", fmt.Sprintf("row %d has %d values, not %d", i, len(row), cols), []int{len(rows), cols})
+		}
+		copy(t.data[i*cols:], row)
+	}
+	return t, nil
+}
+
+func size(shape []int) int {
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	return n
+}
+
+func rowMajor(shape []int) []int {
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return strides
+}
+
+func (t *Tensor[T]) Shape() []int {
+	return append([]int{}, t.shape...)
+}
+
+// Strides are the distances in the buffer between neighbouring elements
+// along each axis. Broadcast axes have stride zero.
+func (t *Tensor[T]) Strides() []int {
+	return append([]int{}, t.strides...)
+}
+
+func (t *Tensor[T]) Rank() int {
+	return len(t.shape)
+}
+
+func (t *Tensor[T]) Size() int {
+	return size(t.shape)
+}
+
+// Dim is the length of axis, which counts from the end when negative.
+func (t *Tensor[T]) Dim(axis int) int {
+	if axis < 0 {
+		axis += len(t.shape)
+	}
+	return t.shape[axis]
+}
+
+// IsContiguous reports whether t's elements fill its buffer in row-major
+// order with nothing in between.
+func (t *Tensor[T]) IsContiguous() bool {
+	expected := rowMajor(t.shape)
+	for i, n := range t.shape {
+		if n > 1 && t.strides[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tensor[T]) index(op string, index []int) (int, error) {
+	if len(index) != len(t.shape) {
+		return 0, shapeError(op, fmt.Sprintf("needs %d indices, got %d", len(t.shape), len(index)), t.shape)
+	}
+	offset := t.offset
+	for axis, i := range index {
+		if i < 0 || i >= t.shape[axis] {
+			return 0, shapeError(op, fmt.Sprintf("index %d out of range on axis %d", i, axis), t.shape)
+		}
+		offset += i * t.strides[axis]
+	}
+	return offset, nil
+}
+
+func (t *Tensor[T]) At(index ...int) (T, error) {
+	offset, err := t.index("at", index)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return t.data[offset], nil
+}
+
+func (t *Tensor[T]) Set(value T, index ...int) error {
+	offset, err := t.index("set", index)
+	if err != nil {
+		return err
+	}
+	t.data[offset] = value
+	return nil
+}
+
+// Item is the value of a tensor with a single element.
+func (t *Tensor[T]) Item() (T, error) {
+	if t.Size() != 1 {
+		var zero T
+		return zero, shapeError("item", "does not hold a single value", t.shape)
+	}
+	return t.data[t.offset], nil
+}
+
+// Values copies t's elements in row-major order.
+func (t *Tensor[T]) Values() []T {
+	values := make([]T, 0, t.Size())
+	walk(t.shape, [][]int{t.strides}, []int{t.offset}, func(offsets []int) {
+		values = append(values, t.data[offsets[0]])
+	})
+	return values
+}
+
+// Rows copies a matrix into a slice per row.
+func (t *Tensor[T]) Rows() ([][]T, error) {
+	if len(t.shape) != 2 {
+		return nil, shapeError("rows", "is not a matrix", t.shape)
+	}
+	values := t.Values()
+	rows := make([][]T, t.shape[0])
+	for i := range rows {
+		rows[i] = values[i*t.shape[1] : (i+1)*t.shape[1] : (i+1)*t.shape[1]]
+	}
+	return rows, nil
+}
+
+// Clone copies t into a new contiguous buffer.
+func (t *Tensor[T]) Clone() *Tensor[T] {
+	c, _ := FromSlice(t.Values(), t.shape...)
+	return c
+}
+
+// Contiguous is t when it is already contiguous and a contiguous copy
+// otherwise.
+func (t *Tensor[T]) Contiguous() *Tensor[T] {
+	if t.IsContiguous() {
+		return t
+	}
+	return t.Clone()
+}
+
+// CopyFrom copies src, which must broadcast to t's shape, into t.
+func (t *Tensor[T]) CopyFrom(src *Tensor[T]) error {
+	view, err := src.BroadcastTo(t.shape...)
+	if err != nil {
+		return shapeError("copy", "source does not broadcast to destination", src.shape, t.shape)
+	}
+	walk(t.shape, [][]int{t.strides, view.strides}, []int{t.offset, view.offset}, func(offsets []int) {
+		t.data[offsets[0]] = view.data[offsets[1]]
+	})
+	return nil
+}
+
+func (t *Tensor[T]) String() string {
+	return fmt.Sprintf("tensor%v%v", t.shape, t.Values())
+}
+
+// walk calls body with the buffer offset of each element of tensors sharing
+// shape, in row-major order; strides and starts are each tensor's strides
+// and offset. The offsets slice is reused between calls.
+func walk(shape []int, strides [][]int, starts []int, body func(offsets []int)) {
+	n := size(shape)
+	if n == 0 {
+		return
+	}
+	offsets := append([]int{}, starts...)
+	position := make([]int, len(shape))
+	for count := 0; count < n; count++ {
+		body(offsets)
+		for axis := len(shape) - 1; axis >= 0; axis-- {
+			position[axis]++
+			for k := range offsets {
+				offsets[k] += strides[k][axis]
+			}
+			if position[axis] < shape[axis] {
+				break
+			}
+			for k := range offsets {
+				offsets[k] -= strides[k][axis] * shape[axis]
+			}
+			position[axis] = 0
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package tensor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// arange is a contiguous tensor of the given shape holding 0, 1, 2, … in
+// row-major order.
+func arange(shape ...int) *Tensor[float64] {
+	values := make([]float64, size(shape))
+	for i := range values {
+		values[i] = float64(i)
+	}
+	t, err := FromSlice(values, shape...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// assertTensor checks t's shape and its values in row-major order.
+func assertTensor(t *testing.T, got *Tensor[float64], shape []int, values []float64) {
+	t.Helper()
+	if !reflect.DeepEqual(got.Shape(), shape) {
+		t.Fatalf("shape %v, want %v", got.Shape(), shape)
+	}
+	if !reflect.DeepEqual(got.Values(), values) {
+		t.Fatalf("values %v, want %v", got.Values(), values)
+	}
+}
+
+func assertShapeError(t *testing.T, err error) {
+	t.Helper()
+	var shapeErr *ShapeError
+	if !errors.As(err, &shapeErr) {
+		t.Fatalf("expected a ShapeError, got %v", err)
+	}
+}
+
+func TestReshape(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *Tensor[float64]
+		shape  []int
+		want   []int
+		ok     bool
+	}{
+		{"matrix to vector", arange(2, 3), []int{6}, []int{6}, true},
+		{"free leading", arange(2, 3, 4), []int{-1, 4}, []int{6, 4}, true},
+		{"free trailing", arange(2, 3, 4), []int{2, -1}, []int{2, 12}, true},
+		{"free middle", arange(24), []int{2, -1, 3}, []int{2, 4, 3}, true},
+		{"to scalar", arange(1), []int{}, []int{}, true},
+		{"wrong size", arange(2, 3), []int{4, 2}, nil, false},
+		{"free does not divide", arange(2, 3), []int{4, -1}, nil, false},
+		{"two free", arange(2, 3), []int{-1, -1}, nil, false},
+		{"negative", arange(2, 3), []int{-2, -3}, nil, false},
+		{"free beside zero", arange(2, 3), []int{0, -1}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Reshape(tt.shape...)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.want, tt.source.Values())
+		})
+	}
+}
+
+func TestReshapeSharesContiguousBuffer(t *testing.T) {
+	source := arange(2, 3)
+	view, _ := source.Reshape(3, 2)
+	view.Set(42, 0, 1)
+	if value, _ := source.At(0, 1); value != 42 {
+		t.Errorf("reshape of a contiguous tensor copied its buffer")
+	}
+
+	// A transposed view is not contiguous, so reshaping it copies.
+	transposed, _ := source.Transpose()
+	flat, _ := transposed.Reshape(-1)
+	assertTensor(t, flat, []int{6}, []float64{0, 3, 42, 4, 2, 5})
+	flat.Set(-1, 0)
+	if value, _ := source.At(0, 0); value != 0 {
+		t.Errorf("reshape of a strided view wrote through to its source")
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *Tensor[float64]
+		axes   []int
+		shape  []int
+		values []float64
+		ok     bool
+	}{
+		{"matrix", arange(2, 3), nil, []int{3, 2}, []float64{0, 3, 1, 4, 2, 5}, true},
+		{"identity", arange(2, 3), []int{0, 1}, []int{2, 3}, []float64{0, 1, 2, 3, 4, 5}, true},
+		{"reverse rank 3", arange(2, 1, 3), nil, []int{3, 1, 2}, []float64{0, 3, 1, 4, 2, 5}, true},
+		{"rotate rank 3", arange(2, 2, 2), []int{1, 2, 0}, []int{2, 2, 2}, []float64{0, 4, 1, 5, 2, 6, 3, 7}, true},
+		{"vector", arange(3), nil, []int{3}, []float64{0, 1, 2}, true},
+		{"too few axes", arange(2, 3), []int{0}, nil, nil, false},
+		{"repeated axis", arange(2, 3), []int{0, 0}, nil, nil, false},
+		{"axis out of range", arange(2, 3), []int{0, 2}, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Transpose(tt.axes...)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+			if len(tt.axes) == 0 && got.Rank() == 2 && got.IsContiguous() {
+				t.Errorf("a transposed matrix should be a strided view")
+			}
+		})
+	}
+}
+
+func TestSliceStep(t *testing.T) {
+	tests := []struct {
+		name                   string
+		source                 *Tensor[float64]
+		axis, start, end, step int
+		shape                  []int
+		values                 []float64
+		ok                     bool
+	}{
+		{"every other", arange(7), 0, 0, 7, 2, []int{4}, []float64{0, 2, 4, 6}, true},
+		{"offset step", arange(7), 0, 1, 7, 3, []int{2}, []float64{1, 4}, true},
+		{"rows", arange(4, 2), 0, 1, 4, 2, []int{2, 2}, []float64{2, 3, 6, 7}, true},
+		{"columns", arange(2, 5), 1, 1, 5, 2, []int{2, 2}, []float64{1, 3, 6, 8}, true},
+		{"empty", arange(4), 0, 2, 2, 1, []int{0}, []float64{}, true},
+		{"no such axis", arange(4), 1, 0, 1, 1, nil, nil, false},
+		{"end past length", arange(4), 0, 0, 5, 1, nil, nil, false},
+		{"start after end", arange(4), 0, 3, 2, 1, nil, nil, false},
+		{"zero step", arange(4), 0, 0, 4, 0, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.SliceStep(tt.axis, tt.start, tt.end, tt.step)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+		})
+	}
+}
+
+func TestSliceOfSlice(t *testing.T) {
+	// Slicing a strided view composes its offset and stride with t's.
+	columns, _ := arange(3, 6).SliceStep(1, 1, 6, 2)
+	inner, _ := columns.SliceStep(1, 1, 3, 1)
+	rows, _ := inner.SliceStep(0, 0, 3, 2)
+	assertTensor(t, rows, []int{2, 2}, []float64{3, 5, 15, 17})
+
+	rows.Set(-1, 1, 1)
+	if value, _ := columns.At(2, 2); value != -1 {
+		t.Errorf("slices should share their source's buffer")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      *Tensor[float64]
+		axis, index int
+		shape       []int
+		values      []float64
+		ok          bool
+	}{
+		{"row", arange(3, 2), 0, 1, []int{2}, []float64{2, 3}, true},
+		{"column", arange(3, 2), 1, 1, []int{3}, []float64{1, 3, 5}, true},
+		{"middle axis", arange(2, 3, 2), 1, 2, []int{2, 2}, []float64{4, 5, 10, 11}, true},
+		{"vector to scalar", arange(3), 0, 2, []int{}, []float64{2}, true},
+		{"no such axis", arange(3), 1, 0, nil, nil, false},
+		{"index out of range", arange(3, 2), 1, 2, nil, nil, false},
+		{"negative index", arange(3, 2), 0, -1, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Select(tt.axis, tt.index)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+		})
+	}
+}
+
+func TestBroadcastTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *Tensor[float64]
+		shape  []int
+		values []float64
+		ok     bool
+	}{
+		{"row to matrix", arange(1, 3), []int{2, 3}, []float64{0, 1, 2, 0, 1, 2}, true},
+		{"column to matrix", arange(2, 1), []int{2, 3}, []float64{0, 0, 0, 1, 1, 1}, true},
+		{"vector gains axes", arange(2), []int{2, 1, 2}, []float64{0, 1, 0, 1}, true},
+		{"scalar", Scalar(0.0), []int{2}, []float64{0, 0}, true},
+		{"same shape", arange(2, 2), []int{2, 2}, []float64{0, 1, 2, 3}, true},
+		{"fewer axes", arange(2, 3), []int{3}, nil, false},
+		{"length mismatch", arange(2, 3), []int{2, 4}, nil, false},
+		{"cannot shrink", arange(2, 1), []int{1, 1}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.BroadcastTo(tt.shape...)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+		})
+	}
+}
+
+func TestBroadcastShapes(t *testing.T) {
+	tests := []struct {
+		name   string
+		shapes [][]int
+		want   []int
+		ok     bool
+	}{
+		{"none", nil, []int{}, true},
+		{"equal", [][]int{{2, 3}, {2, 3}}, []int{2, 3}, true},
+		{"ones stretch", [][]int{{3, 1}, {1, 4}}, []int{3, 4}, true},
+		{"leading axes", [][]int{{4}, {2, 3, 4}}, []int{2, 3, 4}, true},
+		{"scalar", [][]int{{}, {2, 2}}, []int{2, 2}, true},
+		{"three shapes", [][]int{{5, 1, 1}, {1, 3, 1}, {2}}, []int{5, 3, 2}, true},
+		{"mismatch", [][]int{{2, 3}, {3, 2}}, nil, false},
+		{"trailing mismatch", [][]int{{3}, {4}}, nil, false},
+		{"third conflicts", [][]int{{2, 1}, {1, 3}, {4, 3}}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BroadcastShapes(tt.shapes...)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatMulOnViews(t *testing.T) {
+	a := arange(2, 3)      // [[0 1 2] [3 4 5]]
+	aT, _ := a.Transpose() // 3×2, strided
+	wide := arange(3, 4)   // [[0 1 2 3] [4 5 6 7] [8 9 10 11]]
+	odd, _ := wide.SliceStep(1, 1, 4, 2)
+	row, _ := wide.Select(0, 1)    // [4 5 6 7], contiguous
+	column, _ := wide.Select(1, 2) // [2 6 10], strided
+	outer, _ := wide.SliceStep(0, 0, 3, 2)
+
+	tests := []struct {
+		name   string
+		a, b   *Tensor[float64]
+		shape  []int
+		values []float64
+	}{
+		// AᵀA for the transposed view.
+		{"transposed left", aT, a, []int{3, 3}, []float64{9, 12, 15, 12, 17, 22, 15, 22, 29}},
+		// AAᵀ for the transposed view on the right.
+		{"transposed right", a, aT, []int{2, 2}, []float64{5, 14, 14, 50}},
+		// A times columns 1 and 3 of wide.
+		{"sliced right", a, odd, []int{2, 2}, []float64{23, 29, 68, 92}},
+		{"strided vector", a, column, []int{2}, []float64{26, 80}},
+		{"sliced left", odd, arange(2, 1), []int{3, 1}, []float64{3, 7, 11}},
+		// Rows 0 and 2 of wide times its row 1.
+		{"sliced rows", outer, row, []int{2}, []float64{38, 214}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatMul(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+			// The same product of contiguous copies.
+			want, _ := MatMul(tt.a.Clone(), tt.b.Clone())
+			assertTensor(t, got, want.Shape(), want.Values())
+		})
+	}
+
+	if _, err := MatMul(a, a); err == nil {
+		t.Error("expected an error multiplying 2×3 by 2×3")
+	}
+	if _, err := MatMul(arange(3), a); err == nil {
+		t.Error("expected an error for a vector on the left")
+	}
+}
+
+func TestTensordot(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         *Tensor[float64]
+		axisA, axisB int
+		shape        []int
+		values       []float64
+		ok           bool
+	}{
+		{"matrix product", arange(2, 3), arange(3, 2), 1, 0, []int{2, 2}, []float64{10, 13, 28, 40}, true},
+		{"inner product", arange(3), arange(3), 0, 0, []int{}, []float64{5}, true},
+		// Σᵢ a[i,j]·b[i,k]: AᵀB without materialising Aᵀ.
+		{"leading axes", arange(3, 2), arange(3, 2), 0, 0, []int{2, 2}, []float64{20, 26, 26, 35}, true},
+		// Σⱼ a[i,j,k]·b[j] for a of shape 2×3×2.
+		{"middle axis", arange(2, 3, 2), arange(3), 1, 0, []int{2, 2}, []float64{10, 13, 28, 31}, true},
+		{"vector and rank 3", arange(2), arange(3, 2, 2), 0, 1, []int{3, 2}, []float64{2, 3, 6, 7, 10, 11}, true},
+		{"length mismatch", arange(2, 3), arange(2, 3), 1, 0, nil, nil, false},
+		{"no such axis", arange(2, 3), arange(3), 2, 0, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tensordot(tt.a, tt.b, tt.axisA, tt.axisB)
+			if !tt.ok {
+				assertShapeError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTensor(t, got, tt.shape, tt.values)
+		})
+	}
+}
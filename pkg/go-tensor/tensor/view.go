@@ -0,0 +1,146 @@
+package tensor
+
+import "fmt"
+
+// Reshape views t with a new shape holding as many elements; one dimension
+// may be -1 to take whatever length is left. It shares t's buffer when t is
+// contiguous and copies it otherwise.
+func (t *Tensor[T]) Reshape(shape ...int) (*Tensor[T], error) {
+	shape = append([]int{}, shape...)
+	known, free := 1, -1
+	for i, n := range shape {
+		switch {
+		case n == -1 && free < 0:
+			free = i
+		case n < 0:
+			return nil, shapeError("reshape", fmt.Sprintf("cannot become %v", shape), t.shape)
+		default:
+			known *= n
+		}
+	}
+	if free >= 0 {
+		if known == 0 || t.Size()%known != 0 {
+			return nil, shapeError("reshape", fmt.Sprintf("cannot become %v", shape), t.shape)
+		}
+		shape[free] = t.Size() / known
+	}
+	if size(shape) != t.Size() {
+		return nil, shapeError("reshape", fmt.Sprintf("cannot become %v", shape), t.shape)
+	}
+
+	c := t.Contiguous()
+	return &Tensor[T]{data: c.data, shape: shape, strides: rowMajor(shape), offset: c.offset}, nil
+}
+
+// Transpose permutes t's axes: axis i of the result is axes[i] of t.
+// Without axes their order is reversed, which transposes a matrix.
+func (t *Tensor[T]) Transpose(axes ...int) (*Tensor[T], error) {
+	rank := len(t.shape)
+	if len(axes) == 0 {
+		axes = make([]int, rank)
+		for i := range axes {
+			axes[i] = rank - 1 - i
+		}
+	}
+	if len(axes) != rank {
+		return nil, shapeError("transpose", fmt.Sprintf("axes %v are not a permutation of %d axes", axes, rank), t.shape)
+	}
+
+	seen := make([]bool, rank)
+	shape, strides := make([]int, rank), make([]int, rank)
+	for i, axis := range axes {
+		if axis < 0 || axis >= rank || seen[axis] {
+			return nil, shapeError("transpose", fmt.Sprintf("axes %v are not a permutation of %d axes", axes, rank), t.shape)
+		}
+		seen[axis] = true
+		shape[i], strides[i] = t.shape[axis], t.strides[axis]
+	}
+	return &Tensor[T]{data: t.data, shape: shape, strides: strides, offset: t.offset}, nil
+}
+
+// Slice views the elements start up to end of axis.
+func (t *Tensor[T]) Slice(axis, start, end int) (*Tensor[T], error) {
+	return t.SliceStep(axis, start, end, 1)
+}
+
+// SliceStep views every step-th element from start up to end of axis.
+func (t *Tensor[T]) SliceStep(axis, start, end, step int) (*Tensor[T], error) {
+	if axis < 0 || axis >= len(t.shape) {
+		return nil, shapeError("slice", fmt.Sprintf("has no axis %d", axis), t.shape)
+	}
+	if start < 0 || end > t.shape[axis] || start > end || step < 1 {
+		return nil, shapeError("slice", fmt.Sprintf("cannot slice [%d:%d:%d] of axis %d", start, end, step, axis), t.shape)
+	}
+
+	shape, strides := t.Shape(), t.Strides()
+	shape[axis] = (end - start + step - 1) / step
+	strides[axis] *= step
+	return &Tensor[T]{data: t.data, shape: shape, strides: strides, offset: t.offset + start*t.strides[axis]}, nil
+}
+
+// Select views the elements at index along axis, dropping the axis.
+func (t *Tensor[T]) Select(axis, index int) (*Tensor[T], error) {
+	if axis < 0 || axis >= len(t.shape) {
+		return nil, shapeError("select", fmt.Sprintf("has no axis %d", axis), t.shape)
+	}
+	if index < 0 || index >= t.shape[axis] {
+		return nil, shapeError("select", fmt.Sprintf("index %d out of range on axis %d", index, axis), t.shape)
+	}
+
+	shape := append(append([]int{}, t.shape[:axis]...), t.shape[axis+1:]...)
+	strides := append(append([]int{}, t.strides[:axis]...), t.strides[axis+1:]...)
+	return &Tensor[T]{data: t.data, shape: shape, strides: strides, offset: t.offset + index*t.strides[axis]}, nil
+}
+
+// BroadcastTo views t with shape by repeating it along axes where it has
+// length one or that it lacks, as NumPy does. Elements of the view alias
+// one another, so it should only be read.
+func (t *Tensor[T]) BroadcastTo(shape ...int) (*Tensor[T], error) {
+	if len(shape) < len(t.shape) {
+		return nil, shapeError("broadcast", fmt.Sprintf("cannot broadcast to %v", shape), t.shape)
+	}
+
+	lead := len(shape) - len(t.shape)
+	strides := make([]int, len(shape))
+	for i := range t.shape {
+		switch t.shape[i] {
+		case shape[lead+i]:
+			strides[lead+i] = t.strides[i]
+		case 1:
+			strides[lead+i] = 0
+		default:
+			return nil, shapeError("broadcast", fmt.Sprintf("cannot broadcast to %v", shape), t.shape)
+		}
+	}
+	return &Tensor[T]{data: t.data, shape: append([]int{}, shape...), strides: strides, offset: t.offset}, nil
+}
+
+// BroadcastShapes is the shape that tensors of the given shapes broadcast
+// to: aligned at their last axes, each axis is the length the shapes agree
+// on, ignoring those of length one or missing the axis.
+func BroadcastShapes(shapes ...[]int) ([]int, error) {
+	rank := 0
+	for _, shape := range shapes {
+		if len(shape) > rank {
+			rank = len(shape)
+		}
+	}
+
+	result := make([]int, rank)
+	for i := range result {
+		result[i] = 1
+	}
+	for _, shape := range shapes {
+		lead := rank - len(shape)
+		for i, n := range shape {
+			switch {
+			case n == result[lead+i] || n == 1:
+			case result[lead+i] == 1:
+				result[lead+i] = n
+			default:
+				return nil, shapeError("broadcast", "do not broadcast together", shapes...)
+			}
+		}
+	}
+	return result, nil
+}